@@ -41,7 +41,7 @@ func (s *Service) contactHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Send contact email
-	go func() {
+	s.background.Go(func() {
 		contactEmail := &email.Email{
 			Subject: contactRequest.Subject,
 			Recipients: []*email.Recipient{&email.Recipient{
@@ -60,7 +60,7 @@ func (s *Service) contactHandler(w http.ResponseWriter, r *http.Request) {
 			logger.ERROR.Printf("Send email error: %s", err)
 			return
 		}
-	}()
+	})
 
 	// 204 no content response
 	response.NoContent(w)
@@ -162,7 +162,7 @@ func (s *Service) inviteUserCommon(db *gorm.DB, invitedByUser *User, invitationR
 	invitation.InvitedByUser = invitedByUser
 
 	// Send invitation email
-	go func() {
+	s.background.Go(func() {
 		invitationEmail := s.emailFactory.NewInvitationEmail(invitation)
 
 		// Try to send the invitation email
@@ -178,7 +178,7 @@ func (s *Service) inviteUserCommon(db *gorm.DB, invitedByUser *User, invitationR
 			EmailSentAt: util.TimeOrNull(&now),
 			Model:       gorm.Model{UpdatedAt: time.Now()},
 		})
-	}()
+	})
 
 	return invitation, nil
 }
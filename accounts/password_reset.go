@@ -105,7 +105,7 @@ func (s *Service) createPasswordReset(user *User) (*PasswordReset, error) {
 	}
 
 	// Send password reset email
-	go func() {
+	s.background.Go(func() {
 		passwordResetEmail := s.emailFactory.NewPasswordResetEmail(passwordReset)
 
 		// Try to send the password reset email
@@ -121,7 +121,7 @@ func (s *Service) createPasswordReset(user *User) (*PasswordReset, error) {
 			EmailSentAt: util.TimeOrNull(&now),
 			Model:       gorm.Model{UpdatedAt: now},
 		})
-	}()
+	})
 
 	return passwordReset, nil
 }
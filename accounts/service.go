@@ -1,10 +1,13 @@
 package accounts
 
 import (
+	"context"
+
 	slack "github.com/RichardKnop/go-slack"
 	"github.com/RichardKnop/pinglist-api/config"
 	"github.com/RichardKnop/pinglist-api/email"
 	"github.com/RichardKnop/pinglist-api/oauth"
+	"github.com/RichardKnop/pinglist-api/util"
 	"github.com/jinzhu/gorm"
 )
 
@@ -15,12 +18,13 @@ type Service struct {
 	oauthService oauth.ServiceInterface
 	emailService email.ServiceInterface
 	emailFactory EmailFactoryInterface
+	background   util.Background
 }
 
 // NewService starts a new Service instance
 func NewService(cnf *config.Config, db *gorm.DB, oauthService oauth.ServiceInterface, emailService email.ServiceInterface, emailFactory EmailFactoryInterface) *Service {
 	if emailService == nil {
-		emailService = email.NewService(cnf)
+		emailService = email.NewServiceFromConfig(cnf)
 	}
 	if emailFactory == nil {
 		emailFactory = NewEmailFactory(cnf)
@@ -51,3 +55,8 @@ func (s *Service) GetSlackAdapter(user *User) slack.AdapterInterface {
 	}
 	return slack.NewAdapter(cnf)
 }
+
+// WaitForNotifications waits for emails still being sent in the background
+func (s *Service) WaitForNotifications(ctx context.Context) error {
+	return s.background.Wait(ctx)
+}
@@ -1,6 +1,7 @@
 package accounts
 
 import (
+	"context"
 	"net/http"
 
 	slack "github.com/RichardKnop/go-slack"
@@ -39,6 +40,7 @@ type ServiceInterface interface {
 	ConfirmInvitation(invitation *Invitation, password string) error
 	GetAccountFromQueryString(r *http.Request) (*Account, error)
 	GetUserFromQueryString(r *http.Request) (*User, error)
+	WaitForNotifications(ctx context.Context) error
 
 	// Needed for the newRoutes to be able to register handlers
 	createUserHandler(w http.ResponseWriter, r *http.Request)
@@ -1,6 +1,7 @@
 package accounts
 
 import (
+	"context"
 	"net/http"
 
 	slack "github.com/RichardKnop/go-slack"
@@ -575,6 +576,20 @@ func (_m *ServiceMock) GetUserFromQueryString(r *http.Request) (*User, error) {
 	return r0, r1
 }
 
+// WaitForNotifications ...
+func (_m *ServiceMock) WaitForNotifications(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 func (_m *ServiceMock) createUserHandler(w http.ResponseWriter, r *http.Request) {
 	_m.Called(w, r)
 }
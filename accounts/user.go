@@ -130,7 +130,7 @@ func (s *Service) CreateUser(account *Account, userRequest *UserRequest) (*User,
 	}
 
 	// Send confirmation email
-	go func() {
+	s.background.Go(func() {
 		confirmationEmail := s.emailFactory.NewConfirmationEmail(confirmation)
 
 		// Try to send the confirmation email
@@ -146,7 +146,7 @@ func (s *Service) CreateUser(account *Account, userRequest *UserRequest) (*User,
 			EmailSentAt: util.TimeOrNull(&now),
 			Model:       gorm.Model{UpdatedAt: now},
 		})
-	}()
+	})
 
 	return user, nil
 }
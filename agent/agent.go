@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+// DefaultWorkers is used when number of workers is not configured
+const DefaultWorkers = 20
+
+// Agent runs in a remote region, it leases due checks of its region from
+// the API, makes the requests locally and posts the results back
+type Agent struct {
+	client      *Client
+	checkClient *http.Client
+	slots       chan struct{}
+	running     util.Background
+}
+
+// New starts a new Agent instance
+func New(client *Client, workers int, checkClient *http.Client) *Agent {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if checkClient == nil {
+		checkClient = &http.Client{
+			Timeout: alarms.AlarmCheckTimeout,
+		}
+	}
+	return &Agent{
+		client:      client,
+		checkClient: checkClient,
+		slots:       make(chan struct{}, workers),
+	}
+}
+
+// Run leases checks every interval until the context is done, then it waits
+// for running checks to finish and post their results
+func (a *Agent) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.leaseChecks(ctx)
+		case <-ctx.Done():
+			a.running.Wait(context.Background())
+			return
+		}
+	}
+}
+
+// leaseChecks leases as many checks as there are free workers and starts them
+func (a *Agent) leaseChecks(ctx context.Context) {
+	free := cap(a.slots) - len(a.slots)
+	if free < 1 {
+		return
+	}
+
+	leases, err := a.client.LeaseChecks(ctx, free)
+	if err != nil {
+		logger.ERROR.Printf("Lease checks error: %s", err)
+		return
+	}
+
+	for _, lease := range leases {
+		lease := lease
+		a.slots <- struct{}{}
+		a.running.Go(func() {
+			defer func() { <-a.slots }()
+			a.check(lease)
+		})
+	}
+}
+
+// check makes the request of a leased check and posts the result, leased
+// checks are finished even when the agent is shutting down
+func (a *Agent) check(lease *alarms.CheckLeaseResponse) {
+	ctx := context.Background()
+
+	result, err := alarms.RunCheck(ctx, a.checkClient, lease.EndpointURL)
+	if err != nil {
+		logger.ERROR.Printf("Alarm #%d check error: %s", lease.AlarmID, err)
+		return
+	}
+
+	resultRequest := alarms.NewCheckResultRequest(lease, result)
+	if err := a.client.SubmitResult(ctx, resultRequest); err != nil {
+		logger.ERROR.Printf("Alarm #%d result not submitted: %s", lease.AlarmID, err)
+		return
+	}
+
+	logger.INFO.Printf("Alarm #%d checked successfully", lease.AlarmID)
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms"
+	"github.com/RichardKnop/pinglist-api/oauth"
+)
+
+var (
+	// ErrLeaseExpired ...
+	ErrLeaseExpired = errors.New("Check lease has expired")
+
+	// tokenExpiryMargin renews access tokens a bit before they expire
+	tokenExpiryMargin = 30 * time.Second
+)
+
+// Client talks to the API on behalf of a region agent, it authenticates with
+// the client credentials grant and renews the access token when needed
+type Client struct {
+	apiURL     string
+	clientID   string
+	secret     string
+	httpClient *http.Client
+	mutex      sync.Mutex
+	token      string
+	expiresAt  time.Time
+}
+
+// leasesResponse is the list of leased checks as returned by the API
+type leasesResponse struct {
+	Embedded struct {
+		CheckLeases []*alarms.CheckLeaseResponse `json:"check_leases"`
+	} `json:"_embedded"`
+}
+
+// errorResponse is an error as returned by the API
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewClient starts a new Client instance
+func NewClient(apiURL, clientID, secret string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		clientID:   clientID,
+		secret:     secret,
+		httpClient: httpClient,
+	}
+}
+
+// LeaseChecks leases up to limit due checks of the agent's region
+func (c *Client) LeaseChecks(ctx context.Context, limit int) ([]*alarms.CheckLeaseResponse, error) {
+	leases := new(leasesResponse)
+	err := c.call(ctx, "/v1/agent/leases", &alarms.CheckLeaseRequest{Limit: limit}, leases)
+	if err != nil {
+		return nil, err
+	}
+	return leases.Embedded.CheckLeases, nil
+}
+
+// SubmitResult posts a result of a leased check
+func (c *Client) SubmitResult(ctx context.Context, resultRequest *alarms.CheckResultRequest) error {
+	return c.call(ctx, "/v1/agent/results", resultRequest, nil)
+}
+
+// call posts the JSON request to the API and decodes the response into out,
+// a rejected access token is renewed and the call retried once
+func (c *Client) call(ctx context.Context, path string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest("POST", c.apiURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			resp.Body.Close()
+			c.resetAccessToken(token)
+			continue
+		}
+
+		return decodeResponse(resp, out)
+	}
+}
+
+// accessToken returns the current access token or obtains a new one
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest(
+		"POST",
+		c.apiURL+"/v1/oauth/tokens",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.clientID, c.secret)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	tokenResponse := new(oauth.AccessTokenResponse)
+	if err := decodeResponse(resp, tokenResponse); err != nil {
+		return "", err
+	}
+
+	c.token = tokenResponse.AccessToken
+	c.expiresAt = time.Now().Add(
+		time.Duration(tokenResponse.ExpiresIn)*time.Second - tokenExpiryMargin,
+	)
+	return c.token, nil
+}
+
+// resetAccessToken forgets the token unless it has been renewed already
+func (c *Client) resetAccessToken(token string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// decodeResponse closes the response body after decoding it into out,
+// error responses are turned into errors
+func decodeResponse(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		io.Copy(ioutil.Discard, resp.Body)
+		return ErrLeaseExpired
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errResponse := new(errorResponse)
+		json.NewDecoder(resp.Body).Decode(errResponse)
+		return fmt.Errorf("API error %d: %s", resp.StatusCode, errResponse.Error)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
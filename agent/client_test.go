@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/RichardKnop/pinglist-api/alarms"
+	"github.com/RichardKnop/pinglist-api/oauth"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPI serves the agent endpoints, it hands out numbered access tokens
+// and only accepts the latest one
+type fakeAPI struct {
+	mutex   sync.Mutex
+	tokens  int
+	results []*alarms.CheckResultRequest
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r.URL.Path == "/v1/oauth/tokens" {
+		clientID, secret, ok := r.BasicAuth()
+		if !ok || clientID != "agent_client" || secret != "agent_secret" ||
+			r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.tokens++
+		json.NewEncoder(w).Encode(&oauth.AccessTokenResponse{
+			AccessToken: f.currentToken(),
+			ExpiresIn:   3600,
+			TokenType:   "Bearer",
+		})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+f.currentToken() {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/agent/leases":
+		leaseRequest := new(alarms.CheckLeaseRequest)
+		json.NewDecoder(r.Body).Decode(leaseRequest)
+		response := new(leasesResponse)
+		for i := 0; i < leaseRequest.Limit; i++ {
+			response.Embedded.CheckLeases = append(
+				response.Embedded.CheckLeases,
+				&alarms.CheckLeaseResponse{
+					AlarmID:     uint(i + 1),
+					EndpointURL: "http://foo",
+					Watermark:   "2016-03-07T09:00:00Z",
+				},
+			)
+		}
+		json.NewEncoder(w).Encode(response)
+	case "/v1/agent/results":
+		resultRequest := new(alarms.CheckResultRequest)
+		json.NewDecoder(r.Body).Decode(resultRequest)
+		for _, result := range f.results {
+			if result.AlarmID == resultRequest.AlarmID &&
+				result.Watermark == resultRequest.Watermark {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(&errorResponse{Error: "Check already triggered"})
+				return
+			}
+		}
+		f.results = append(f.results, resultRequest)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&errorResponse{Error: "Not found"})
+	}
+}
+
+func (f *fakeAPI) currentToken() string {
+	return string(rune('a' + f.tokens))
+}
+
+func TestClientLeaseAndSubmit(t *testing.T) {
+	api := new(fakeAPI)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", "agent_client", "agent_secret", nil)
+	ctx := context.Background()
+
+	leases, err := client.LeaseChecks(ctx, 2)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(leases)) {
+		assert.Equal(t, uint(1), leases[0].AlarmID)
+		assert.Equal(t, "http://foo", leases[0].EndpointURL)
+		assert.Equal(t, "2016-03-07T09:00:00Z", leases[0].Watermark)
+	}
+
+	// The token is reused
+	resultRequest := &alarms.CheckResultRequest{
+		AlarmID:    1,
+		Watermark:  "2016-03-07T09:00:00Z",
+		StartedAt:  "2016-03-07T09:00:01Z",
+		StatusCode: 200,
+	}
+	assert.NoError(t, client.SubmitResult(ctx, resultRequest))
+	assert.Equal(t, 1, api.tokens)
+	if assert.Equal(t, 1, len(api.results)) {
+		assert.Equal(t, 200, api.results[0].StatusCode)
+	}
+
+	// A duplicate result means the lease has expired
+	assert.Equal(t, ErrLeaseExpired, client.SubmitResult(ctx, resultRequest))
+}
+
+func TestClientRenewsRejectedToken(t *testing.T) {
+	api := new(fakeAPI)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	client := NewClient(server.URL, "agent_client", "agent_secret", nil)
+	ctx := context.Background()
+
+	_, err := client.LeaseChecks(ctx, 1)
+	assert.NoError(t, err)
+
+	// The API revokes the token, the client obtains a new one and retries
+	api.mutex.Lock()
+	api.tokens++
+	api.mutex.Unlock()
+
+	leases, err := client.LeaseChecks(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(leases))
+	assert.Equal(t, 3, api.tokens)
+}
+
+func TestClientBadCredentials(t *testing.T) {
+	server := httptest.NewServer(new(fakeAPI))
+	defer server.Close()
+
+	client := NewClient(server.URL, "agent_client", "bogus", nil)
+
+	_, err := client.LeaseChecks(context.Background(), 1)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "API error 401")
+	}
+}
@@ -0,0 +1,93 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrAcknowledgeIncidentPermission ...
+	ErrAcknowledgeIncidentPermission = errors.New("Need permission to acknowledge incident")
+)
+
+// Handles calls to acknowledge an alarm incident
+// (POST /v1/alarms/{id:[0-9]+}/incidents/{incident_id:[0-9]+}/acknowledgement)
+func (s *Service) acknowledgeIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the ids from request URI and type assert them
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	incidentID, err := strconv.Atoi(vars["incident_id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkAcknowledgeIncidentPermissions(authenticatedUser, alarm); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Fetch the incident
+	incident, err := s.findIncidentByID(alarm, uint(incidentID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Acknowledge the incident
+	if err := s.acknowledgeIncident(incident); err != nil {
+		logger.ERROR.Printf("Acknowledge incident error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	incidentResponse, err := NewIncidentResponse(incident)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, incidentResponse, http.StatusOK)
+}
+
+func checkAcknowledgeIncidentPermissions(authenticatedUser *accounts.User, alarm *Alarm) error {
+	// Superusers can acknowledge any incidents
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can acknowledge incidents of their own alarms
+	if authenticatedUser.ID == alarm.User.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrAcknowledgeIncidentPermission
+}
@@ -0,0 +1,177 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestAcknowledgeIncidentRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.acknowledgeIncidentHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestAcknowledgeIncidentWithoutPermission() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/incidents/%d/acknowledgement",
+			suite.alarms[0].ID,
+			suite.incidents[3].ID,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "acknowledge_incident", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrAcknowledgeIncidentPermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestAcknowledgeIncidentNotFound() {
+	// Prepare a request for an incident of a different alarm
+	r, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/incidents/%d/acknowledgement",
+			suite.alarms[1].ID,
+			suite.incidents[3].ID,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 404, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrIncidentNotFound.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestAcknowledgeIncident() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/incidents/%d/acknowledgement",
+			suite.alarms[0].ID,
+			suite.incidents[3].ID,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Fetch the updated incident
+	incident := new(Incident)
+	assert.NoError(suite.T(), suite.db.First(incident, suite.incidents[3].ID).Error)
+
+	// Check that the incident has been acknowledged
+	assert.True(suite.T(), incident.AcknowledgedAt.Valid)
+	assert.False(suite.T(), incident.ResolvedAt.Valid)
+
+	// Check the response body
+	expected, err := NewIncidentResponse(incident)
+	assert.NoError(suite.T(), err, "Creating response object failed")
+	expectedJSON, err := json.Marshal(expected)
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the incident",
+		)
+	}
+
+	// Acknowledging again keeps the original time
+	w = httptest.NewRecorder()
+	suite.mockUserAuth(suite.users[1])
+	suite.router.ServeHTTP(w, r)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(
+		suite.T(),
+		string(expectedJSON),
+		strings.TrimRight(w.Body.String(), "\n"),
+	)
+}
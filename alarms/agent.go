@@ -0,0 +1,159 @@
+package alarms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+const (
+	// DefaultCheckLeaseLimit is used when an agent does not ask for a limit
+	DefaultCheckLeaseLimit = 10
+	// MaxCheckLeaseLimit caps how many checks an agent can lease at once
+	MaxCheckLeaseLimit = 100
+)
+
+var (
+	// ErrAgentRegionNotFound ...
+	ErrAgentRegionNotFound = errors.New("Account is not an agent of any region")
+	// ErrAgentAlarmNotFound ...
+	ErrAgentAlarmNotFound = errors.New("Alarm not found in the agent's region")
+	// ErrInvalidLeaseWatermark ...
+	ErrInvalidLeaseWatermark = errors.New("Invalid lease watermark")
+	// ErrInvalidCheckStartedAt ...
+	ErrInvalidCheckStartedAt = errors.New("Invalid check start time")
+)
+
+// findAgentRegion returns the region the account runs an agent of
+func (s *Service) findAgentRegion(account *accounts.Account) (*Region, error) {
+	region := new(Region)
+	notFound := s.db.Where("agent_account_id = ?", account.ID).
+		First(region).RecordNotFound()
+	if notFound {
+		return nil, ErrAgentRegionNotFound
+	}
+	return region, nil
+}
+
+// leaseAgentChecks claims due alarms of the region for its agent, the claim
+// watermark of each alarm identifies the lease when the result is posted
+func (s *Service) leaseAgentChecks(region *Region, limit int, now time.Time) ([]*Alarm, error) {
+	if limit <= 0 {
+		limit = DefaultCheckLeaseLimit
+	}
+	if limit > MaxCheckLeaseLimit {
+		limit = MaxCheckLeaseLimit
+	}
+
+	alarmIDs, err := s.claimDueAlarms(region.ID, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	alarms := make([]*Alarm, 0, len(alarmIDs))
+	if len(alarmIDs) == 0 {
+		return alarms, nil
+	}
+	err = s.db.Where("id IN (?)", alarmIDs).Order("id").Find(&alarms).Error
+	if err != nil {
+		return nil, err
+	}
+	return alarms, nil
+}
+
+// submitAgentCheckResult processes a check result posted by a region agent
+// the same way as checks made by the scheduler, a result of an expired lease
+// is rejected as the alarm has been claimed again since
+func (s *Service) submitAgentCheckResult(region *Region, resultRequest *CheckResultRequest) error {
+	watermark, err := util.ParseTimestamp(resultRequest.Watermark)
+	if err != nil {
+		return ErrInvalidLeaseWatermark
+	}
+	startedAt, err := util.ParseTimestamp(resultRequest.StartedAt)
+	if err != nil {
+		return ErrInvalidCheckStartedAt
+	}
+
+	// Fetch the alarm, agents can only check alarms of their region
+	alarm, err := s.FindAlarmByID(resultRequest.AlarmID)
+	if err != nil || alarm.RegionID.String != region.ID {
+		return ErrAgentAlarmNotFound
+	}
+
+	// Claim the check, duplicate results of the same lease get rejected
+	if err := s.claimAlarmCheck(alarm, watermark); err != nil {
+		return err
+	}
+
+	return s.processCheckResult(alarm, newAgentCheckResult(resultRequest, startedAt))
+}
+
+// NewCheckResultRequest creates a request an agent posts the result of
+// a leased check with
+func NewCheckResultRequest(lease *CheckLeaseResponse, result *CheckResult) *CheckResultRequest {
+	resultRequest := &CheckResultRequest{
+		AlarmID:      lease.AlarmID,
+		Watermark:    lease.Watermark,
+		StartedAt:    result.StartedAt.UTC().Format(time.RFC3339Nano),
+		ResponseTime: result.ResponseTime.Nanoseconds(),
+		ResponseSize: result.ResponseSize,
+		Timeout:      result.Timeout,
+		ErrorMessage: result.ErrorMessage,
+	}
+	if result.Response != nil {
+		resultRequest.StatusCode = result.Response.StatusCode
+		resultRequest.Headers = result.Response.Header
+	}
+	if result.Timings != nil {
+		resultRequest.Timings = &CheckTimingsRequest{
+			DNSLookup:    result.Timings.DNSLookup,
+			Connect:      result.Timings.Connect,
+			TLSHandshake: result.Timings.TLSHandshake,
+			FirstByte:    result.Timings.FirstByte,
+			Transfer:     result.Timings.Transfer,
+		}
+	}
+	return resultRequest
+}
+
+// newAgentCheckResult creates a CheckResult from a result posted by an agent,
+// the response only carries the status and headers which incidents store
+func newAgentCheckResult(resultRequest *CheckResultRequest, startedAt time.Time) *CheckResult {
+	result := &CheckResult{
+		StartedAt:    startedAt,
+		ResponseTime: time.Duration(resultRequest.ResponseTime),
+		ResponseSize: resultRequest.ResponseSize,
+		Timeout:      resultRequest.Timeout,
+		ErrorMessage: resultRequest.ErrorMessage,
+	}
+	if resultRequest.StatusCode > 0 {
+		result.Response = &http.Response{
+			Status: fmt.Sprintf(
+				"%d %s",
+				resultRequest.StatusCode,
+				http.StatusText(resultRequest.StatusCode),
+			),
+			StatusCode: resultRequest.StatusCode,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header(resultRequest.Headers),
+			Body:       http.NoBody,
+		}
+	}
+	if resultRequest.Timings != nil {
+		result.Timings = &metrics.Timings{
+			DNSLookup:    resultRequest.Timings.DNSLookup,
+			Connect:      resultRequest.Timings.Connect,
+			TLSHandshake: resultRequest.Timings.TLSHandshake,
+			FirstByte:    resultRequest.Timings.FirstByte,
+			Transfer:     resultRequest.Timings.Transfer,
+		}
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package alarms
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgentCheckResultRoundTrip(t *testing.T) {
+	var (
+		startedAt = time.Date(2016, time.March, 7, 9, 0, 0, 123456000, time.UTC)
+		lease     = &CheckLeaseResponse{
+			AlarmID:   1,
+			Watermark: "2016-03-07T09:00:00.123456Z",
+		}
+		timings = &metrics.Timings{DNSLookup: 1, Connect: 2, TLSHandshake: 3, FirstByte: 4, Transfer: 5}
+	)
+
+	// A response measured by the agent
+	resultRequest := NewCheckResultRequest(lease, &CheckResult{
+		StartedAt:    startedAt,
+		ResponseTime: 50 * time.Millisecond,
+		Response: &http.Response{
+			StatusCode: 503,
+			Header:     http.Header{"Retry-After": {"120"}},
+		},
+		ResponseSize: 12,
+		Timings:      timings,
+	})
+	assert.Equal(t, uint(1), resultRequest.AlarmID)
+	assert.Equal(t, lease.Watermark, resultRequest.Watermark)
+	assert.Equal(t, "2016-03-07T09:00:00.123456Z", resultRequest.StartedAt)
+	assert.Equal(t, 503, resultRequest.StatusCode)
+
+	// Turned back into a result on the API side
+	result := newAgentCheckResult(resultRequest, startedAt)
+	assert.Equal(t, startedAt, result.StartedAt)
+	assert.Equal(t, 50*time.Millisecond, result.ResponseTime)
+	assert.Equal(t, int64(12), result.ResponseSize)
+	assert.Equal(t, timings, result.Timings)
+	assert.False(t, result.Timeout)
+	assert.Equal(t, "", result.ErrorMessage)
+	if assert.NotNil(t, result.Response) {
+		assert.Equal(t, 503, result.Response.StatusCode)
+		dump, err := httputil.DumpResponse(result.Response, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1 503 Service Unavailable\r\nRetry-After: 120\r\nContent-Length: 0\r\n\r\n", string(dump))
+	}
+
+	// A request which failed has no response
+	resultRequest = NewCheckResultRequest(lease, &CheckResult{
+		StartedAt:    startedAt,
+		ResponseTime: 10 * time.Second,
+		Timeout:      true,
+		ErrorMessage: "net/http: request canceled",
+	})
+	result = newAgentCheckResult(resultRequest, startedAt)
+	assert.Nil(t, result.Response)
+	assert.Nil(t, result.Timings)
+	assert.True(t, result.Timeout)
+	assert.Equal(t, "net/http: request canceled", result.ErrorMessage)
+}
@@ -78,7 +78,7 @@ func (s *Service) FindAlarmByID(alarmID uint) (*Alarm, error) {
 	// Fetch the alarm from the database
 	alarm := new(Alarm)
 	notFound := s.db.Preload("User.OauthUser").Preload("Incidents", "resolved_at IS NULL").
-		Preload("Region").First(alarm, alarmID).RecordNotFound()
+		Preload("Region").Preload("Tags").First(alarm, alarmID).RecordNotFound()
 
 	// Not found
 	if notFound {
@@ -112,6 +112,22 @@ func (s *Service) createAlarm(user *accounts.User, alarmRequest *AlarmRequest) (
 		return nil, ErrMaxResponseTimeTooBig
 	}
 
+	// Alarms created without an SLO target get the default one
+	sloTarget, err := getSLOTarget(alarmRequest.SLOTarget, DefaultSLOTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	// Alarms created without anomaly detection settings get the default ones
+	anomalySensitivity, err := getAnomalySensitivity(alarmRequest.AnomalySensitivity, DefaultAnomalySensitivity)
+	if err != nil {
+		return nil, err
+	}
+	anomalyPeriod, err := getAnomalyPeriod(alarmRequest.AnomalyPeriod, DefaultAnomalyPeriod)
+	if err != nil {
+		return nil, err
+	}
+
 	// Fetch the region from the database
 	region, err := s.findRegionByID(alarmRequest.Region)
 	if err != nil {
@@ -124,11 +140,32 @@ func (s *Service) createAlarm(user *accounts.User, alarmRequest *AlarmRequest) (
 		return nil, err
 	}
 
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Fetch or create tags
+	tags, err := findOrCreateTags(tx, alarmRequest.Tags)
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
 	// Create a new alarm object
 	alarm := NewAlarm(user, region, alarmState, alarmRequest)
+	alarm.SLOTarget = sloTarget
+	alarm.AnomalySensitivity = anomalySensitivity
+	alarm.AnomalyPeriod = anomalyPeriod
+	alarm.Tags = tags
 
 	// Save the alarm to the database
-	if err := s.db.Create(alarm).Error; err != nil {
+	if err := tx.Create(alarm).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
 		return nil, err
 	}
 
@@ -164,15 +201,41 @@ func (s *Service) updateAlarm(alarm *Alarm, alarmRequest *AlarmRequest) error {
 		return ErrMaxResponseTimeTooBig
 	}
 
+	// Keep the current SLO target unless a new one is requested
+	sloTarget, err := getSLOTarget(alarmRequest.SLOTarget, alarm.SLOTarget)
+	if err != nil {
+		return err
+	}
+
+	// Keep the current anomaly detection settings unless new ones are requested
+	anomalySensitivity, err := getAnomalySensitivity(alarmRequest.AnomalySensitivity, alarm.AnomalySensitivity)
+	if err != nil {
+		return err
+	}
+	anomalyPeriod, err := getAnomalyPeriod(alarmRequest.AnomalyPeriod, alarm.AnomalyPeriod)
+	if err != nil {
+		return err
+	}
+
 	// Fetch the region from the database
 	region, err := s.findRegionByID(alarmRequest.Region)
 	if err != nil {
 		return err
 	}
 
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Fetch or create tags
+	tags, err := findOrCreateTags(tx, alarmRequest.Tags)
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
 	// Update the alarm (need to use map here because active field might be
 	// changing to false which would not work with struct)
-	if err := s.db.Model(alarm).UpdateColumns(map[string]interface{}{
+	updates := map[string]interface{}{
 		"region_id":                region.ID,
 		"endpoint_url":             alarmRequest.EndpointURL,
 		"expected_http_code":       alarmRequest.ExpectedHTTPCode,
@@ -182,13 +245,43 @@ func (s *Service) updateAlarm(alarm *Alarm, alarmRequest *AlarmRequest) error {
 		"push_notification_alerts": alarmRequest.PushNotificationAlerts,
 		"slack_alerts":             alarmRequest.SlackAlerts,
 		"active":                   alarmRequest.Active,
+		"slo_target":               sloTarget,
+		"anomaly_detection":        alarmRequest.AnomalyDetection,
+		"anomaly_sensitivity":      anomalySensitivity,
+		"anomaly_period":           anomalyPeriod,
 		"updated_at":               time.Now(),
-	}).Error; err != nil {
+	}
+
+	// Reschedule the next check when the interval changes
+	if alarm.NextCheckAt.Valid && alarmRequest.Interval != alarm.Interval {
+		updates["next_check_at"] = nextCheckAt(
+			alarm.ID,
+			alarmRequest.Interval,
+			time.Now(),
+			s.maxJitter(),
+		)
+	}
+
+	if err := tx.Model(alarm).UpdateColumns(updates).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Replace tags
+	if err := tx.Model(alarm).Association("Tags").Replace(tags).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
 		return err
 	}
 
-	// Make sure the alarm region is up-to-date
+	// Make sure the alarm region and tags are up-to-date
 	alarm.Region = region
+	alarm.Tags = tags
 
 	return nil
 }
@@ -218,7 +311,7 @@ func (s *Service) findPaginatedAlarms(offset, limit int, orderBy string, user *a
 
 	// Retrieve paginated results from the database
 	err := alarmsQuery.Offset(offset).Limit(limit).Order(orderBy).
-		Preload("User").Preload("Incidents", "resolved_at IS NULL").
+		Preload("User").Preload("Incidents", "resolved_at IS NULL").Preload("Tags").
 		Find(&alarms).Error
 	if err != nil {
 		return alarms, err
@@ -1,16 +1,13 @@
 package alarms
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"net"
-	"net/http"
-	"net/http/httptest"
-	"net/http/httputil"
-	"net/url"
+	"strings"
 	"time"
 
 	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/metrics"
 	"github.com/RichardKnop/pinglist-api/util"
 	"github.com/jinzhu/gorm"
 )
@@ -19,129 +16,226 @@ var (
 	// ErrCheckAlreadyTriggered ...
 	ErrCheckAlreadyTriggered = errors.New("Alarm check has already been trigerred")
 
+	// ErrRemoteRegionAlarm ...
+	ErrRemoteRegionAlarm = errors.New("Alarm is checked by an agent of its region")
+
 	// AlarmCheckTimeout defines how long to wait before considering alarm check timed out
 	AlarmCheckTimeout = 10 * time.Second
+
+	// MaxResponseBodySize limits how much of a response body is read
+	MaxResponseBodySize int64 = 10 << 20 // 10MB
 )
 
-// GetAlarmsToCheck returns IDs of alarms that should be checked
+// TruncateWatermark truncates the time to the microsecond precision postgres
+// stores timestamps with, a claim watermark has to be compared with exactly
+// the value which got stored, otherwise rounding can make it look later
+func TruncateWatermark(t time.Time) time.Time {
+	return t.Truncate(time.Microsecond)
+}
+
+// GetAlarmsToCheck claims and returns IDs of alarms of the local region
+// whose next check time has come, alarms of other regions are leased by
+// their region agents
 func (s *Service) GetAlarmsToCheck(now time.Time) ([]uint, error) {
+	return s.claimDueAlarms(s.cnf.AWS.Region, now, 0)
+}
+
+// claimDueAlarms claims and returns IDs of alarms of the region whose next
+// check time has come, zero limit claims all of them. Due alarms are claimed
+// atomically by moving their watermark to now and their next check time to
+// their next slot, rows locked by another scheduler are skipped, so multiple
+// schedulers or agents never get the same alarm. Alarms claimed by a scheduler
+// or an agent which dies before checking them become due again at their next
+// slot
+func (s *Service) claimDueAlarms(regionID string, now time.Time, limit int) ([]uint, error) {
+	now = TruncateWatermark(now)
 	var alarmIDs []uint
-	query := `SELECT t.id FROM (
-		SELECT
-			a.id,
-			a.user_id,
-			COALESCE(GREATEST(p.max_alarms, p2.max_alarms), ?) AS max_alarms,
-			(watermark IS NULL OR watermark + interval '1 second' * a.interval < ?) AS ready_for_check,
-			DENSE_RANK() OVER (PARTITION BY COALESCE(CAST(s.id AS TEXT), ou.username) ORDER BY a.id ASC) AS rank
-		FROM alarm_alarms a
-			INNER JOIN account_users u ON u.id = a.user_id
-			INNER JOIN oauth_users ou ON ou.id = u.oauth_user_id
-			LEFT JOIN subscription_customers c ON c.user_id = a.user_id
-			LEFT JOIN subscription_subscriptions s ON s.customer_id = c.id AND s.period_end > ?
-			LEFT JOIN subscription_plans p ON p.id = s.plan_id
-			LEFT JOIN team_team_members tm ON tm.user_id = u.id
-			LEFT JOIN team_teams t ON t.id = tm.team_id
-			LEFT JOIN subscription_customers c2 ON c2.user_id = t.owner_id
-			LEFT JOIN subscription_subscriptions s2 ON s2.customer_id = c2.id AND s2.period_end > ?
-			LEFT JOIN subscription_plans p2 ON p2.id = s2.plan_id
+	query := `WITH due AS (
+		SELECT t.id FROM (
+			SELECT
+				a.id,
+				a.user_id,
+				a.region_id,
+				COALESCE(GREATEST(p.max_alarms, p2.max_alarms), ?) AS max_alarms,
+				(a.next_check_at IS NULL OR a.next_check_at <= ?) AS ready_for_check,
+				DENSE_RANK() OVER (PARTITION BY COALESCE(CAST(s.id AS TEXT), ou.username) ORDER BY a.id ASC) AS rank
+			FROM alarm_alarms a
+				INNER JOIN account_users u ON u.id = a.user_id
+				INNER JOIN oauth_users ou ON ou.id = u.oauth_user_id
+				LEFT JOIN subscription_customers c ON c.user_id = a.user_id
+				LEFT JOIN subscription_subscriptions s ON s.customer_id = c.id AND s.period_end > ?
+				LEFT JOIN subscription_plans p ON p.id = s.plan_id
+				LEFT JOIN team_team_members tm ON tm.user_id = u.id
+				LEFT JOIN team_teams t ON t.id = tm.team_id
+				LEFT JOIN subscription_customers c2 ON c2.user_id = t.owner_id
+				LEFT JOIN subscription_subscriptions s2 ON s2.customer_id = c2.id AND s2.period_end > ?
+				LEFT JOIN subscription_plans p2 ON p2.id = s2.plan_id
+			WHERE
+				a.deleted_at IS NULL
+				AND active = true
+			ORDER BY s.id, a.user_id, a.id, rank
+		) t WHERE
+			rank <= max_alarms
+			AND ready_for_check = true
+			AND region_id = ?
+	), claimable AS (
+		SELECT l.id FROM alarm_alarms l
 		WHERE
-			a.deleted_at IS NULL
-			AND active = true
-		ORDER BY s.id, a.user_id, a.id, rank
-	) t WHERE
-		rank <= max_alarms
-		AND ready_for_check = true;`
-	rows, err := s.db.Raw(query, FreeTierMaxAlarms, now, now, now).Rows() // (*sql.Rows, error)
+			l.id IN (SELECT id FROM due)
+			AND (l.next_check_at IS NULL OR l.next_check_at <= ?)
+		ORDER BY l.id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	), claimed AS (
+		UPDATE alarm_alarms a SET
+			watermark = ?,
+			next_check_at = CAST(? AS timestamp with time zone) + interval '1 second' * a.interval
+		FROM claimable
+		WHERE a.id = claimable.id
+		RETURNING a.id, a.interval AS alarm_interval
+	)
+	SELECT id, alarm_interval FROM claimed ORDER BY id;`
+
+	// NULL limit means no limit
+	var limitParam interface{}
+	if limit > 0 {
+		limitParam = limit
+	}
+
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	rows, err := tx.Raw(
+		query,
+		FreeTierMaxAlarms,
+		now,
+		now,
+		now,
+		regionID,
+		now,
+		limitParam,
+		now,
+		now,
+	).Rows() // (*sql.Rows, error)
 	if err != nil {
+		tx.Rollback() // rollback the transaction
 		return alarmIDs, err
 	}
-	defer rows.Close()
+	var (
+		values []string
+		args   []interface{}
+	)
 	for rows.Next() {
-		var alarmID uint
-		if err := rows.Scan(&alarmID); err != nil {
-			return alarmIDs, err
+		var alarmID, interval uint
+		if err := rows.Scan(&alarmID, &interval); err != nil {
+			rows.Close()
+			tx.Rollback() // rollback the transaction
+			return nil, err
 		}
 		alarmIDs = append(alarmIDs, alarmID)
+		values = append(values, "(CAST(? AS INTEGER), CAST(? AS timestamp with time zone))")
+		args = append(args, alarmID, nextCheckAt(alarmID, interval, now, s.maxJitter()))
 	}
+	rows.Close()
+
+	// The claim leases alarms for an interval, move the next check times to
+	// the slots the checks would schedule so they don't depend on whether
+	// the alarm was claimed here or by the check
+	if len(values) > 0 {
+		err := tx.Exec(
+			`UPDATE alarm_alarms a SET next_check_at = v.next_check_at
+			FROM (VALUES `+strings.Join(values, ", ")+`) AS v(id, next_check_at)
+			WHERE a.id = v.id`,
+			args...,
+		).Error
+		if err != nil {
+			tx.Rollback() // rollback the transaction
+			return nil, err
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
 	return alarmIDs, nil
 }
 
-// CheckAlarm performs an alarm check
-func (s *Service) CheckAlarm(alarmID uint, watermark time.Time) error {
+// CheckAlarm performs an alarm check, a cancelled context abandons the
+// check without opening an incident
+func (s *Service) CheckAlarm(ctx context.Context, alarmID uint, watermark time.Time) error {
 	// Fetch the alarm
 	alarm, err := s.FindAlarmByID(alarmID)
 	if err != nil {
 		return err
 	}
 
-	// Idempotency check
-	if alarm.Watermark.Time.After(watermark) {
-		return ErrCheckAlreadyTriggered
-	}
-
-	// Start with the default request URL
-	var requestURL = alarm.EndpointURL
-
-	// If we are going to proxy the request through a remote server
+	// Alarms of other regions are checked by their region agents
 	if alarm.Region.ID != s.cnf.AWS.Region {
-		// Parse the proxy URL
-		proxyURL, err := url.ParseRequestURI(alarm.Region.ProxyURL.String)
-		if err != nil {
-			return err
-		}
-
-		// Create a proxy server
-		frontendProxy := httptest.NewServer(httputil.NewSingleHostReverseProxy(proxyURL))
-		defer frontendProxy.Close()
+		return ErrRemoteRegionAlarm
+	}
 
-		// Set the request URL to use the proxy server
-		requestURL = fmt.Sprintf(
-			"%s?request_url=%s",
-			frontendProxy.URL,
-			alarm.EndpointURL,
-		)
+	// Claim the check, only one of concurrent checks with the same watermark
+	// gets through, even when they run in different schedulers
+	if err := s.claimAlarmCheck(alarm, watermark); err != nil {
+		return err
 	}
 
-	// Prepare a request
-	req, err := http.NewRequest("GET", requestURL, nil)
+	// Wait for a free slot of the target host
+	release, err := s.hostLimiter.acquire(ctx, getHost(alarm.EndpointURL))
 	if err != nil {
 		return err
 	}
+	defer release()
 
-	// Update the watermark
-	newWatermark := gorm.NowFunc()
-	err = s.db.Model(alarm).UpdateColumns(Alarm{
-		Watermark: util.TimeOrNull(&newWatermark),
-		Model:     gorm.Model{UpdatedAt: newWatermark},
-	}).Error
+	// Make the request
+	result, err := RunCheck(ctx, s.client, alarm.EndpointURL)
 	if err != nil {
 		return err
 	}
 
-	// Make the request
-	start := gorm.NowFunc()
-	resp, err := s.client.Do(req)
-	if resp != nil {
-		defer resp.Body.Close()
+	// Cancelled checks are not the endpoint's fault
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-	elapsed := time.Since(start)
 
+	// Free the host slot before handling incidents
+	release()
+
+	return s.processCheckResult(alarm, result)
+}
+
+// processCheckResult opens or resolves incidents based on the check result
+// and logs the metrics, results measured by region agents take the same path
+func (s *Service) processCheckResult(alarm *Alarm, result *CheckResult) error {
 	var (
 		incidentType string
-		errMsg       string
+		statusCode   int
 	)
-	if e, ok := err.(net.Error); ok && e.Timeout() {
+	if result.Response != nil {
+		statusCode = result.Response.StatusCode
+	}
+	if result.Timeout {
 		// The response timed out
 		incidentType = incidenttypes.Timeout
-		errMsg = err.Error()
-	} else if err != nil {
+	} else if result.ErrorMessage != "" {
 		// The request failed due to any other error
 		incidentType = incidenttypes.Other
-		errMsg = err.Error()
-	} else if resp.StatusCode != int(alarm.ExpectedHTTPCode) {
+	} else if statusCode != int(alarm.ExpectedHTTPCode) {
 		// The request returned a response with a bad status code
 		incidentType = incidenttypes.BadCode
-	} else if uint(elapsed.Nanoseconds()/1000000) > alarm.MaxResponseTime {
+	} else if alarm.AnomalyDetection {
+		// The response has been unusually slow compared to the baseline
+		slow, err := s.isSlowAnomaly(alarm, result.StartedAt, result.ResponseTime)
+		if err != nil {
+			return err
+		}
+		if slow {
+			incidentType = incidenttypes.Slow
+		}
+	} else if uint(result.ResponseTime.Nanoseconds()/1000000) > alarm.MaxResponseTime {
 		// The response was too slow
 		incidentType = incidenttypes.Slow
 	}
@@ -151,9 +245,9 @@ func (s *Service) CheckAlarm(alarmID uint, watermark time.Time) error {
 		if err := s.openIncident(
 			alarm,
 			incidentType,
-			resp,
-			elapsed.Nanoseconds(),
-			errMsg,
+			result.Response,
+			result.ResponseTime.Nanoseconds(),
+			result.ErrorMessage,
 		); err != nil {
 			return err
 		}
@@ -164,6 +258,62 @@ func (s *Service) CheckAlarm(alarmID uint, watermark time.Time) error {
 		}
 	}
 
-	// Log the response time metric
-	return s.metricsService.LogResponseTime(start, alarm.ID, elapsed.Nanoseconds())
+	// Log the check result so there is a record of every check
+	outcome := metrics.CheckOutcomeSuccess
+	if incidentType != "" {
+		outcome = incidentType
+	}
+	err := s.metricsService.LogCheckResult(
+		result.StartedAt,
+		alarm.ID,
+		alarm.Region.ID,
+		statusCode,
+		result.ResponseSize,
+		outcome,
+		result.ErrorMessage,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Log the response time metric, slow responses are not counted as failures
+	failed := incidentType != "" && incidentType != incidenttypes.Slow
+	return s.metricsService.LogResponseTime(
+		result.StartedAt,
+		alarm.ID,
+		result.ResponseTime.Nanoseconds(),
+		failed,
+		result.Timings,
+	)
+}
+
+// claimAlarmCheck moves the watermark of the alarm to now unless it has
+// moved past the watermark already and schedules the next check. The
+// comparison and the update happen in a single statement so concurrent
+// checks cannot both claim the alarm
+func (s *Service) claimAlarmCheck(alarm *Alarm, watermark time.Time) error {
+	newWatermark := TruncateWatermark(gorm.NowFunc())
+	newNextCheckAt := nextCheckAt(
+		alarm.ID,
+		alarm.Interval,
+		newWatermark,
+		s.maxJitter(),
+	)
+	result := s.db.Model(new(Alarm)).
+		Where("id = ? AND (watermark IS NULL OR watermark <= ?)", alarm.ID, watermark).
+		UpdateColumns(Alarm{
+			Watermark:   util.TimeOrNull(&newWatermark),
+			NextCheckAt: util.TimeOrNull(&newNextCheckAt),
+			Model:       gorm.Model{UpdatedAt: newWatermark},
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCheckAlreadyTriggered
+	}
+	alarm.Watermark = util.TimeOrNull(&newWatermark)
+	alarm.NextCheckAt = util.TimeOrNull(&newNextCheckAt)
+	alarm.UpdatedAt = newWatermark
+	return nil
 }
@@ -1,6 +1,7 @@
 package alarms
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,8 @@ import (
 	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
 	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
 	"github.com/RichardKnop/pinglist-api/alarms/regions"
+	"github.com/RichardKnop/pinglist-api/jobs"
+	"github.com/RichardKnop/pinglist-api/metrics"
 	"github.com/RichardKnop/pinglist-api/notifications"
 	"github.com/RichardKnop/pinglist-api/subscriptions"
 	"github.com/RichardKnop/pinglist-api/teams"
@@ -40,12 +43,12 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckNoActiveAlarms() {
 
 func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveAlarmNotReadyForCheck() {
 	var (
-		alarmIDs  []uint
-		testAlarm *Alarm
-		err       error
-		testUser  = suite.users[1]
-		watermark time.Time
-		interval  = uint(60)
+		alarmIDs    []uint
+		testAlarm   *Alarm
+		err         error
+		testUser    = suite.users[1]
+		nextCheckAt time.Time
+		interval    = uint(60)
 	)
 
 	// Deactivate all alarms
@@ -53,13 +56,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveAlarmNotReadyForCheck()
 	assert.NoError(suite.T(), err, "Deactivating alarms failed")
 
 	// Insert an active test alarm not yet ready to be checked
-	watermark = time.Now().Add(-time.Duration(interval-1) * time.Second)
+	nextCheckAt = time.Now().Add(time.Second)
 	testAlarm = &Alarm{
 		User:             testUser,
 		Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 		AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 		EndpointURL:      "http://foo",
-		Watermark:        util.TimeOrNull(&watermark),
+		NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 		ExpectedHTTPCode: 200,
 		MaxResponseTime:  1000,
 		Interval:         interval,
@@ -80,12 +83,12 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveAlarmNotReadyForCheck()
 
 func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveAlarmReadyForCheck() {
 	var (
-		alarmIDs  []uint
-		testAlarm *Alarm
-		err       error
-		testUser  = suite.users[1]
-		watermark time.Time
-		interval  = uint(60)
+		alarmIDs    []uint
+		testAlarm   *Alarm
+		err         error
+		testUser    = suite.users[1]
+		nextCheckAt time.Time
+		interval    = uint(60)
 	)
 
 	// Deactivate all alarms
@@ -93,13 +96,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveAlarmReadyForCheck() {
 	assert.NoError(suite.T(), err, "Deactivating alarms failed")
 
 	// Insert an active test alarm not yet ready to be checked
-	watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+	nextCheckAt = time.Now().Add(-time.Second)
 	testAlarm = &Alarm{
 		User:             testUser,
 		Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 		AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 		EndpointURL:      "http://foo",
-		Watermark:        util.TimeOrNull(&watermark),
+		NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 		ExpectedHTTPCode: 200,
 		MaxResponseTime:  1000,
 		Interval:         interval,
@@ -120,12 +123,12 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveAlarmReadyForCheck() {
 
 func (suite *AlarmsTestSuite) TestGetAlarmsToCheckOnlyFirstActiveRelevant() {
 	var (
-		alarmIDs  []uint
-		testAlarm *Alarm
-		err       error
-		testUser  = suite.users[1]
-		watermark time.Time
-		interval  = uint(60)
+		alarmIDs    []uint
+		testAlarm   *Alarm
+		err         error
+		testUser    = suite.users[1]
+		nextCheckAt time.Time
+		interval    = uint(60)
 	)
 
 	// Deactivate all alarms
@@ -133,13 +136,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckOnlyFirstActiveRelevant() {
 	assert.NoError(suite.T(), err, "Deactivating alarms failed")
 
 	// Insert an active test alarm not yet ready to be checked
-	watermark = time.Now().Add(-time.Duration(interval-1) * time.Second)
+	nextCheckAt = time.Now().Add(time.Second)
 	testAlarm = &Alarm{
 		User:             testUser,
 		Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 		AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 		EndpointURL:      "http://foo",
-		Watermark:        util.TimeOrNull(&watermark),
+		NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 		ExpectedHTTPCode: 200,
 		MaxResponseTime:  1000,
 		Interval:         interval,
@@ -149,13 +152,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckOnlyFirstActiveRelevant() {
 	assert.NoError(suite.T(), err, "Inserting test alarm failed")
 
 	// Insert an active test alarm ready for check
-	watermark = time.Now().Add(-time.Duration(interval+100) * time.Second)
+	nextCheckAt = time.Now().Add(-100 * time.Second)
 	testAlarm = &Alarm{
 		User:             testUser,
 		Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 		AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 		EndpointURL:      "http://bar",
-		Watermark:        util.TimeOrNull(&watermark),
+		NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 		ExpectedHTTPCode: 200,
 		MaxResponseTime:  1000,
 		Interval:         interval,
@@ -183,7 +186,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckMultipleAlarms() {
 			suite.users[1],
 			suite.users[2],
 		}
-		watermark        time.Time
+		nextCheckAt      time.Time
 		interval         = uint(60)
 		expectedAlarmIDs []uint
 	)
@@ -194,13 +197,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckMultipleAlarms() {
 
 	// Insert an active test alarm ready for check, one for each test user
 	for _, testUser := range testUsers {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -230,7 +233,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckFreeTierMaxAlarmsLimit() {
 		testAlarm        *Alarm
 		err              error
 		testUser         = suite.users[1]
-		watermark        time.Time
+		nextCheckAt      time.Time
 		interval         = uint(60)
 		expectedAlarmIDs []uint
 	)
@@ -241,13 +244,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckFreeTierMaxAlarmsLimit() {
 
 	// Insert multiple active test alarms ready for check, user in free tier
 	for i := 0; i < 2; i++ {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -277,7 +280,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckExpiredUserSubscription() {
 		alarmIDs         []uint
 		testAlarm        *Alarm
 		err              error
-		watermark        time.Time
+		nextCheckAt      time.Time
 		testUser         = suite.users[1]
 		testPlan         *subscriptions.Plan
 		testCustomer     *subscriptions.Customer
@@ -315,13 +318,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckExpiredUserSubscription() {
 
 	// Insert multiple active test alarms ready for check
 	for i := 0; i < 2; i++ {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -351,7 +354,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveUserSubscription() {
 		alarmIDs         []uint
 		testAlarm        *Alarm
 		err              error
-		watermark        time.Time
+		nextCheckAt      time.Time
 		testUser         = suite.users[1]
 		testPlan         *subscriptions.Plan
 		testCustomer     *subscriptions.Customer
@@ -391,13 +394,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveUserSubscription() {
 
 	// Insert multiple active test alarms ready for check
 	for i := 0; i < 2; i++ {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -426,7 +429,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckExpiredTeamSubscription() {
 		alarmIDs         []uint
 		testAlarm        *Alarm
 		err              error
-		watermark        time.Time
+		nextCheckAt      time.Time
 		testUser         = suite.users[1]
 		testTeamOwner    = suite.users[0]
 		testTeam         *teams.Team
@@ -475,13 +478,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckExpiredTeamSubscription() {
 
 	// Insert multiple active test alarms ready for check
 	for i := 0; i < 2; i++ {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -511,7 +514,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveTeamSubscription() {
 		alarmIDs         []uint
 		testAlarm        *Alarm
 		err              error
-		watermark        time.Time
+		nextCheckAt      time.Time
 		testUser         = suite.users[1]
 		testTeamOwner    = suite.users[0]
 		testTeam         *teams.Team
@@ -560,13 +563,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckActiveTeamSubscription() {
 
 	// Insert multiple active test alarms ready for check
 	for i := 0; i < 2; i++ {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -595,7 +598,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckExpiredUserSubscriptionExpired
 		alarmIDs         []uint
 		testAlarm        *Alarm
 		err              error
-		watermark        time.Time
+		nextCheckAt      time.Time
 		testUser         = suite.users[1]
 		testTeamOwner    = suite.users[0]
 		testTeam         *teams.Team
@@ -666,13 +669,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckExpiredUserSubscriptionExpired
 
 	// Insert multiple active test alarms ready for check
 	for i := 0; i < 2; i++ {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -702,7 +705,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckUserSubscriptionGreaterThanTea
 		alarmIDs         []uint
 		testAlarm        *Alarm
 		err              error
-		watermark        time.Time
+		nextCheckAt      time.Time
 		testUser         = suite.users[1]
 		testTeamOwner    = suite.users[0]
 		testTeam         *teams.Team
@@ -773,13 +776,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckUserSubscriptionGreaterThanTea
 
 	// Insert multiple active test alarms ready for check
 	for i := 0; i < 3; i++ {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -808,7 +811,7 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckTeamSubscriptionGreaterThanUse
 		alarmIDs         []uint
 		testAlarm        *Alarm
 		err              error
-		watermark        time.Time
+		nextCheckAt      time.Time
 		testUser         = suite.users[1]
 		testTeamOwner    = suite.users[0]
 		testTeam         *teams.Team
@@ -879,13 +882,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckTeamSubscriptionGreaterThanUse
 
 	// Insert multiple active test alarms ready for check
 	for i := 0; i < 3; i++ {
-		watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+		nextCheckAt = time.Now().Add(-time.Second)
 		testAlarm = &Alarm{
 			User:             testUser,
 			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 			EndpointURL:      "http://foo",
-			Watermark:        util.TimeOrNull(&watermark),
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 			ExpectedHTTPCode: 200,
 			MaxResponseTime:  1000,
 			Interval:         interval,
@@ -911,11 +914,11 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckTeamSubscriptionGreaterThanUse
 
 func (suite *AlarmsTestSuite) TestGetAlarmsToCheckMixedUseCase() {
 	var (
-		alarmIDs  []uint
-		testAlarm *Alarm
-		err       error
-		watermark time.Time
-		testUsers = []*accounts.User{
+		alarmIDs    []uint
+		testAlarm   *Alarm
+		err         error
+		nextCheckAt time.Time
+		testUsers   = []*accounts.User{
 			suite.users[1],
 			suite.users[2],
 		}
@@ -958,13 +961,13 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckMixedUseCase() {
 	// Insert multiple active test alarms ready for check for both users
 	for i, testUser := range testUsers {
 		for j := 0; i < 4; i++ {
-			watermark = time.Now().Add(-time.Duration(interval+1) * time.Second)
+			nextCheckAt = time.Now().Add(-time.Second)
 			testAlarm = &Alarm{
 				User:             testUser,
 				Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
 				AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
 				EndpointURL:      "http://foo",
-				Watermark:        util.TimeOrNull(&watermark),
+				NextCheckAt:      util.TimeOrNull(&nextCheckAt),
 				ExpectedHTTPCode: 200,
 				MaxResponseTime:  1000,
 				Interval:         interval,
@@ -996,6 +999,255 @@ func (suite *AlarmsTestSuite) TestGetAlarmsToCheckMixedUseCase() {
 	}
 }
 
+func (suite *AlarmsTestSuite) TestGetAlarmsToCheckClaimsAlarms() {
+	var (
+		alarmIDs  []uint
+		testAlarm *Alarm
+		err       error
+		testUsers = []*accounts.User{
+			suite.users[1],
+			suite.users[2],
+		}
+		dueAt            time.Time
+		interval         = uint(60)
+		expectedAlarmIDs []uint
+		now              = time.Now().UTC()
+	)
+
+	// Deactivate all alarms
+	err = suite.service.db.Model(new(Alarm)).UpdateColumn("active", false).Error
+	assert.NoError(suite.T(), err, "Deactivating alarms failed")
+
+	// Insert an active test alarm ready for check, one for each test user
+	for _, testUser := range testUsers {
+		dueAt = now.Add(-time.Second)
+		testAlarm = &Alarm{
+			User:             testUser,
+			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
+			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
+			EndpointURL:      "http://foo",
+			NextCheckAt:      util.TimeOrNull(&dueAt),
+			ExpectedHTTPCode: 200,
+			MaxResponseTime:  1000,
+			Interval:         interval,
+			Active:           true,
+		}
+		err = suite.db.Create(testAlarm).Error
+		assert.NoError(suite.T(), err, "Inserting test alarm failed")
+		expectedAlarmIDs = append(expectedAlarmIDs, testAlarm.ID)
+	}
+
+	// The first scheduler claims both alarms
+	alarmIDs, err = suite.service.GetAlarmsToCheck(now)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), expectedAlarmIDs, alarmIDs)
+
+	// Watermarks moved to the claim time, next checks scheduled at the
+	// alarm's slot of the next interval the same way checks schedule them
+	for _, alarmID := range expectedAlarmIDs {
+		alarm, err := suite.service.FindAlarmByID(alarmID)
+		if assert.NoError(suite.T(), err) {
+			assert.Equal(suite.T(), util.FormatTime(now), util.FormatTime(alarm.Watermark.Time))
+			assert.Equal(
+				suite.T(),
+				util.FormatTime(nextCheckAt(alarmID, interval, now, 0)),
+				util.FormatTime(alarm.NextCheckAt.Time),
+			)
+		}
+	}
+
+	// Another scheduler ticking at the same time gets nothing
+	alarmIDs, err = suite.service.GetAlarmsToCheck(now)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 0, len(alarmIDs))
+
+	// If the first scheduler dies before checking the alarms,
+	// they are claimed again once their interval passes
+	alarmIDs, err = suite.service.GetAlarmsToCheck(
+		now.Add(time.Duration(interval+1) * time.Second),
+	)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), expectedAlarmIDs, alarmIDs)
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmsToCheckWatermarkPrecision() {
+	var (
+		alarmIDs    []uint
+		testAlarm   *Alarm
+		err         error
+		nextCheckAt time.Time
+		// Postgres keeps microseconds, the nanoseconds would round the
+		// stored watermark up past the one the check job claims with
+		now = time.Date(2016, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	)
+
+	// Deactivate all alarms
+	err = suite.service.db.Model(new(Alarm)).UpdateColumn("active", false).Error
+	assert.NoError(suite.T(), err, "Deactivating alarms failed")
+
+	// Insert an active test alarm ready for check
+	nextCheckAt = now.Add(-time.Second)
+	testAlarm = &Alarm{
+		User:             suite.users[1],
+		Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
+		AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
+		EndpointURL:      "http://foo",
+		NextCheckAt:      util.TimeOrNull(&nextCheckAt),
+		ExpectedHTTPCode: 200,
+		MaxResponseTime:  1000,
+		Interval:         60,
+		Active:           true,
+	}
+	err = suite.db.Create(testAlarm).Error
+	assert.NoError(suite.T(), err, "Inserting test alarm failed")
+
+	// The scheduler claims the alarm
+	alarmIDs, err = suite.service.GetAlarmsToCheck(now)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []uint{testAlarm.ID}, alarmIDs)
+
+	alarm, err := suite.service.FindAlarmByID(testAlarm.ID)
+	if assert.NoError(suite.T(), err) {
+		assert.True(suite.T(), TruncateWatermark(now).Equal(alarm.Watermark.Time))
+	}
+
+	// The check job claims the check with the watermark it was queued with
+	assert.NoError(suite.T(), suite.service.claimAlarmCheck(alarm, TruncateWatermark(now)))
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmsToCheckSkipsLockedAlarms() {
+	var (
+		alarmIDs  []uint
+		testAlarm *Alarm
+		err       error
+		testUsers = []*accounts.User{
+			suite.users[1],
+			suite.users[2],
+		}
+		nextCheckAt   time.Time
+		interval      = uint(60)
+		testAlarmIDs  []uint
+		now           = time.Now().UTC()
+		lockedAlarmID uint
+	)
+
+	// Deactivate all alarms
+	err = suite.service.db.Model(new(Alarm)).UpdateColumn("active", false).Error
+	assert.NoError(suite.T(), err, "Deactivating alarms failed")
+
+	// Insert an active test alarm ready for check, one for each test user
+	for _, testUser := range testUsers {
+		nextCheckAt = now.Add(-time.Second)
+		testAlarm = &Alarm{
+			User:             testUser,
+			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
+			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
+			EndpointURL:      "http://foo",
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
+			ExpectedHTTPCode: 200,
+			MaxResponseTime:  1000,
+			Interval:         interval,
+			Active:           true,
+		}
+		err = suite.db.Create(testAlarm).Error
+		assert.NoError(suite.T(), err, "Inserting test alarm failed")
+		testAlarmIDs = append(testAlarmIDs, testAlarm.ID)
+	}
+
+	// Another scheduler is in the middle of claiming the first alarm
+	tx := suite.db.Begin()
+	err = tx.Raw(
+		"SELECT id FROM alarm_alarms WHERE id = ? FOR UPDATE",
+		testAlarmIDs[0],
+	).Row().Scan(&lockedAlarmID)
+	assert.NoError(suite.T(), err, "Locking test alarm failed")
+
+	// The locked alarm is skipped without waiting for the lock
+	alarmIDs, err = suite.service.GetAlarmsToCheck(now)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []uint{testAlarmIDs[1]}, alarmIDs)
+
+	// The other scheduler crashes, its locks are released
+	tx.Rollback()
+
+	// Now the first alarm can be claimed
+	alarmIDs, err = suite.service.GetAlarmsToCheck(now)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []uint{testAlarmIDs[0]}, alarmIDs)
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmsToCheckConcurrentSchedulers() {
+	var (
+		testAlarm *Alarm
+		err       error
+		testUsers = []*accounts.User{
+			suite.users[1],
+			suite.users[2],
+		}
+		nextCheckAt      time.Time
+		interval         = uint(60)
+		expectedAlarmIDs []uint
+		now              = time.Now().UTC()
+	)
+
+	// Deactivate all alarms
+	err = suite.service.db.Model(new(Alarm)).UpdateColumn("active", false).Error
+	assert.NoError(suite.T(), err, "Deactivating alarms failed")
+
+	// Insert an active test alarm ready for check, one for each test user
+	for _, testUser := range testUsers {
+		nextCheckAt = now.Add(-time.Second)
+		testAlarm = &Alarm{
+			User:             testUser,
+			Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
+			AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
+			EndpointURL:      "http://foo",
+			NextCheckAt:      util.TimeOrNull(&nextCheckAt),
+			ExpectedHTTPCode: 200,
+			MaxResponseTime:  1000,
+			Interval:         interval,
+			Active:           true,
+		}
+		err = suite.db.Create(testAlarm).Error
+		assert.NoError(suite.T(), err, "Inserting test alarm failed")
+		expectedAlarmIDs = append(expectedAlarmIDs, testAlarm.ID)
+	}
+
+	// Several schedulers tick at the same time
+	var (
+		concurrency = 4
+		idsChan     = make(chan []uint)
+		errChan     = make(chan error)
+	)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			alarmIDs, err := suite.service.GetAlarmsToCheck(now)
+			if err != nil {
+				errChan <- err
+				return
+			}
+			idsChan <- alarmIDs
+		}()
+	}
+
+	// Each alarm should have been claimed exactly once
+	claims := make(map[uint]int)
+	for i := 0; i < concurrency; i++ {
+		select {
+		case alarmIDs := <-idsChan:
+			for _, alarmID := range alarmIDs {
+				claims[alarmID]++
+			}
+		case err := <-errChan:
+			assert.NoError(suite.T(), err)
+		}
+	}
+	assert.Equal(suite.T(), len(expectedAlarmIDs), len(claims))
+	for _, alarmID := range expectedAlarmIDs {
+		assert.Equal(suite.T(), 1, claims[alarmID], fmt.Sprintf("Alarm #%d claims", alarmID))
+	}
+}
+
 func (suite *AlarmsTestSuite) TestAlarmCheck() {
 	var (
 		testAlarm, alarm *Alarm
@@ -1035,8 +1287,9 @@ func (suite *AlarmsTestSuite) TestAlarmCheck() {
 	gorm.NowFunc = func() time.Time {
 		return start
 	}
+	suite.mockLogCheckResult(start, alarm.ID, 200, metrics.CheckOutcomeSuccess, nil)
 	suite.mockLogResponseTime(start, alarm.ID, nil)
-	err = suite.service.CheckAlarm(alarm.ID, alarm.Watermark.Time)
+	err = suite.service.CheckAlarm(context.Background(), alarm.ID, alarm.Watermark.Time)
 
 	// Check that the mock object expectations were met
 	suite.assertMockExpectations()
@@ -1056,6 +1309,13 @@ func (suite *AlarmsTestSuite) TestAlarmCheck() {
 		util.FormatTime(alarm.Watermark.Time),
 	)
 
+	// Next check scheduled at the alarm's slot of the next interval
+	assert.Equal(
+		suite.T(),
+		util.FormatTime(nextCheckAt(alarm.ID, alarm.Interval, start, 0)),
+		util.FormatTime(alarm.NextCheckAt.Time),
+	)
+
 	// Status OK
 	assert.Equal(suite.T(), alarmstates.OK, alarm.AlarmStateID.String)
 
@@ -1105,8 +1365,9 @@ func (suite *AlarmsTestSuite) TestAlarmCheck() {
 		nil,
 	)
 	suite.mockNewIncidentSlackMessage(suite.users[1])
+	suite.mockLogCheckResult(start, alarm.ID, 200, incidenttypes.Slow, nil)
 	suite.mockLogResponseTime(start, alarm.ID, nil)
-	err = suite.service.CheckAlarm(alarm.ID, alarm.Watermark.Time)
+	err = suite.service.CheckAlarm(context.Background(), alarm.ID, alarm.Watermark.Time)
 	assert.NoError(
 		suite.T(),
 		suite.db.Model(alarm).UpdateColumn("max_response_time", 1000).Error,
@@ -1186,8 +1447,9 @@ func (suite *AlarmsTestSuite) TestAlarmCheck() {
 		nil,
 	)
 	suite.mockNewIncidentSlackMessage(suite.users[1])
+	suite.mockLogCheckResult(start, alarm.ID, 0, incidenttypes.Timeout, nil)
 	suite.mockLogResponseTime(start, alarm.ID, nil)
-	err = suite.service.CheckAlarm(alarm.ID, alarm.Watermark.Time)
+	err = suite.service.CheckAlarm(context.Background(), alarm.ID, alarm.Watermark.Time)
 
 	// Sleep for the email and push notification goroutines to finish
 	time.Sleep(15 * time.Millisecond)
@@ -1262,8 +1524,9 @@ func (suite *AlarmsTestSuite) TestAlarmCheck() {
 		nil,
 	)
 	suite.mockNewIncidentSlackMessage(suite.users[1])
+	suite.mockLogCheckResult(start, alarm.ID, 500, incidenttypes.BadCode, nil)
 	suite.mockLogResponseTime(start, alarm.ID, nil)
-	err = suite.service.CheckAlarm(alarm.ID, alarm.Watermark.Time)
+	err = suite.service.CheckAlarm(context.Background(), alarm.ID, alarm.Watermark.Time)
 
 	// Sleep for the email and push notification goroutines to finish
 	time.Sleep(15 * time.Millisecond)
@@ -1338,8 +1601,9 @@ func (suite *AlarmsTestSuite) TestAlarmCheck() {
 		nil,
 	)
 	suite.mockIncidentsResolvedSlackMessage(suite.users[1])
+	suite.mockLogCheckResult(start, alarm.ID, 200, metrics.CheckOutcomeSuccess, nil)
 	suite.mockLogResponseTime(start, alarm.ID, nil)
-	err = suite.service.CheckAlarm(alarm.ID, alarm.Watermark.Time)
+	err = suite.service.CheckAlarm(context.Background(), alarm.ID, alarm.Watermark.Time)
 
 	// Sleep for the email & push notification goroutines to finish
 	time.Sleep(15 * time.Millisecond)
@@ -1407,7 +1671,8 @@ func (suite *AlarmsTestSuite) TestAlarmCheckIdempotency() {
 		return start
 	}
 
-	// Just one response time metric will be logged
+	// Just one check result and response time metric will be logged
+	suite.mockLogCheckResult(start, testAlarm.ID, 200, metrics.CheckOutcomeSuccess, nil)
 	suite.mockLogResponseTime(start, testAlarm.ID, nil)
 
 	// Trigger multiple parallel alarm checks with the same watermark
@@ -1468,6 +1733,231 @@ func (suite *AlarmsTestSuite) TestAlarmCheckIdempotency() {
 	suite.assertMockExpectations()
 }
 
+func (suite *AlarmsTestSuite) TestAlarmCheckCancelled() {
+	var (
+		testAlarm, alarm *Alarm
+		err              error
+		server           *httptest.Server
+		client           *http.Client
+	)
+
+	// Insert a test alarm
+	testAlarm = &Alarm{
+		User:             suite.users[1],
+		Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
+		AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
+		EndpointURL:      "http://foobar",
+		ExpectedHTTPCode: 200,
+		MaxResponseTime:  1000,
+		Interval:         60,
+		EmailAlerts:      true,
+		Active:           true,
+	}
+	err = suite.db.Create(testAlarm).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// Prepare test server and client
+	server, client = testServer(&http.Response{StatusCode: 500})
+	defer server.Close()
+	suite.service.client = client
+
+	// The scheduler is shutting down
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// No metrics should be logged and no incident opened
+	err = suite.service.CheckAlarm(ctx, testAlarm.ID, testAlarm.Watermark.Time)
+	assert.Equal(suite.T(), context.Canceled, err)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Fetch the updated alarm
+	alarm = new(Alarm)
+	assert.False(suite.T(), suite.service.db.Preload("Incidents").
+		First(alarm, testAlarm.ID).RecordNotFound())
+
+	// State unchanged and 0 incidents
+	assert.Equal(suite.T(), alarmstates.InsufficientData, alarm.AlarmStateID.String)
+	assert.Equal(suite.T(), 0, len(alarm.Incidents))
+}
+
 func (suite *AlarmsTestSuite) alarmCheckWrapper(alarmID uint, watermark time.Time, errChan chan error) {
-	errChan <- suite.service.CheckAlarm(alarmID, watermark)
+	errChan <- suite.service.CheckAlarm(context.Background(), alarmID, watermark)
+}
+
+func (suite *AlarmsTestSuite) TestIsSlowAnomaly() {
+	var (
+		testAlarm *Alarm
+		slow      bool
+		err       error
+		start     = time.Now().UTC().Truncate(time.Hour)
+	)
+
+	// Insert a test alarm with anomaly detection and fresh baselines
+	testAlarm = &Alarm{
+		User:               suite.users[1],
+		Region:             &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
+		AlarmState:         &AlarmState{ID: alarmstates.OK},
+		EndpointURL:        "http://foobar",
+		ExpectedHTTPCode:   200,
+		MaxResponseTime:    1000,
+		Interval:           30,
+		Active:             true,
+		AnomalyDetection:   true,
+		AnomalySensitivity: 3,
+		AnomalyPeriod:      60,
+		BaselineUpdatedAt:  util.TimeOrNull(&start),
+	}
+	err = suite.db.Create(testAlarm).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+	baseline := &Baseline{
+		AlarmID:    util.PositiveIntOrNull(int64(testAlarm.ID)),
+		HourOfWeek: hourOfWeek(start),
+		Median:     100,
+		MAD:        5,
+		Samples:    20,
+	}
+	err = suite.db.Create(baseline).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// Within the threshold of 130ms
+	slow, err = suite.service.isSlowAnomaly(testAlarm, start, 120*time.Millisecond)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), slow)
+	assert.False(suite.T(), testAlarm.AnomalyStartedAt.Valid)
+
+	// Anomalous but not for the whole period yet
+	slow, err = suite.service.isSlowAnomaly(testAlarm, start, 200*time.Millisecond)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), slow)
+	assert.True(suite.T(), testAlarm.AnomalyStartedAt.Valid)
+	slow, err = suite.service.isSlowAnomaly(testAlarm, start.Add(30*time.Second), 200*time.Millisecond)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), slow)
+
+	// Anomalous for the whole period
+	slow, err = suite.service.isSlowAnomaly(testAlarm, start.Add(60*time.Second), 200*time.Millisecond)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), slow)
+
+	// The streak should be persisted
+	alarm, err := suite.service.FindAlarmByID(testAlarm.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), util.FormatTime(start), util.FormatTime(alarm.AnomalyStartedAt.Time))
+
+	// Back to normal resets the streak
+	slow, err = suite.service.isSlowAnomaly(testAlarm, start.Add(90*time.Second), 100*time.Millisecond)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), slow)
+	alarm, err = suite.service.FindAlarmByID(testAlarm.ID)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), alarm.AnomalyStartedAt.Valid)
+
+	// Hours without a baseline fall back to the max response time
+	err = suite.db.Unscoped().Delete(baseline).Error
+	assert.NoError(suite.T(), err)
+	slow, err = suite.service.isSlowAnomaly(testAlarm, start, 900*time.Millisecond)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), slow)
+	slow, err = suite.service.isSlowAnomaly(testAlarm, start, 1001*time.Millisecond)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), slow)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+}
+
+func (suite *AlarmsTestSuite) TestScheduleBaselineRefresh() {
+	jobsServiceMock := new(jobs.ServiceMock)
+	suite.service.jobsService = jobsServiceMock
+	defer func() { suite.service.jobsService = nil }()
+
+	var (
+		now     = time.Now().UTC()
+		alarm   = suite.alarms[0]
+		options = baselineJobOptions
+	)
+	options.Key = fmt.Sprintf(
+		"%s:%d:%d",
+		BaselineJob,
+		alarm.ID,
+		now.Truncate(BaselineRefreshInterval).Unix(),
+	)
+
+	// Stale baselines are refreshed by a job, only one job is queued per
+	// refresh interval
+	jobsServiceMock.On(
+		"Enqueue",
+		BaselineJob,
+		&baselinePayload{AlarmID: alarm.ID},
+		&options,
+	).Return(new(jobs.Job), nil).Once()
+	jobsServiceMock.On(
+		"Enqueue",
+		BaselineJob,
+		&baselinePayload{AlarmID: alarm.ID},
+		&options,
+	).Return(nil, jobs.ErrDuplicateJob).Once()
+	suite.service.scheduleBaselineRefresh(alarm, now)
+	suite.service.scheduleBaselineRefresh(alarm, now)
+
+	// Fresh baselines are not refreshed
+	alarm.BaselineUpdatedAt = util.TimeOrNull(&now)
+	defer func() { alarm.BaselineUpdatedAt = util.TimeOrNull(nil) }()
+	suite.service.scheduleBaselineRefresh(alarm, now)
+
+	jobsServiceMock.AssertExpectations(suite.T())
+}
+
+func (suite *AlarmsTestSuite) TestRefreshBaselinesSkipsIncidents() {
+	// A week ago, all samples fall into the same hour of the week
+	hour := time.Now().UTC().Add(-7 * 24 * time.Hour).Truncate(time.Hour)
+	responseTimes := make([]*metrics.ResponseTime, 0)
+	for i := 0; i < 12; i++ {
+		responseTimes = append(responseTimes, &metrics.ResponseTime{
+			ReferenceID: suite.alarms[0].ID,
+			Timestamp:   hour.Add(time.Duration(i) * time.Minute),
+			Value:       int64(100) * int64(time.Millisecond),
+		})
+	}
+	// Slow checks which opened an incident
+	for i := 40; i < 45; i++ {
+		responseTimes = append(responseTimes, &metrics.ResponseTime{
+			ReferenceID: suite.alarms[0].ID,
+			Timestamp:   hour.Add(time.Duration(i) * time.Minute),
+			Value:       int64(5 * time.Second),
+		})
+	}
+
+	// Insert the incident, it was opened after the anomaly period
+	resolvedAt := hour.Add(50 * time.Minute)
+	incident := &Incident{
+		Alarm:        suite.alarms[0],
+		IncidentType: &IncidentType{ID: incidenttypes.Slow},
+		ResolvedAt:   util.TimeOrNull(&resolvedAt),
+	}
+	err := suite.db.Create(incident).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+	err = suite.db.Model(incident).UpdateColumn("created_at", hour.Add(45*time.Minute)).Error
+	assert.NoError(suite.T(), err, "Updating created_at failed")
+
+	// Mock the metrics history
+	suite.mockIterateBaselineResponseTimes(int(suite.alarms[0].ID), responseTimes, nil)
+
+	// Refresh the baselines
+	alarm, err := suite.service.FindAlarmByID(suite.alarms[0].ID)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.service.refreshBaselines(alarm, time.Now()))
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// The slow checks are not part of the baseline
+	baselines, err := suite.service.findBaselines(alarm)
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 1, len(baselines)) {
+		assert.Equal(suite.T(), uint(12), baselines[0].Samples)
+		assert.Equal(suite.T(), 100.0, baselines[0].Median)
+	}
 }
@@ -5,9 +5,10 @@ import (
 	"time"
 
 	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/notifications"
 )
 
-func (s *Service) sendNewIncidentPushNotification(alarm *Alarm, incident *Incident) {
+func (s *Service) sendNewIncidentPushNotification(alarm *Alarm, incident *Incident) error {
 	now := time.Now()
 
 	// Find SNS endpoint
@@ -15,9 +16,13 @@ func (s *Service) sendNewIncidentPushNotification(alarm *Alarm, incident *Incide
 		uint(alarm.UserID.Int64),
 		s.cnf.AWS.APNSPlatformApplicationARN,
 	)
+	if err == notifications.ErrEndpointNotFound {
+		// The user has no device registered, retrying would not help
+		return nil
+	}
 	if err != nil {
 		logger.ERROR.Printf("Find endpoint by user ID and application ARN error: %s", err.Error())
-		return
+		return err
 	}
 
 	// Send push notification
@@ -29,9 +34,10 @@ func (s *Service) sendNewIncidentPushNotification(alarm *Alarm, incident *Incide
 		),
 		map[string]interface{}{},
 	)
+	countNotification(pushChannel, err)
 	if err != nil {
 		logger.ERROR.Printf("Publish message error: %s", err.Error())
-		return
+		return err
 	}
 
 	// Increment push notifications counter
@@ -42,9 +48,11 @@ func (s *Service) sendNewIncidentPushNotification(alarm *Alarm, incident *Incide
 	); err != nil {
 		logger.ERROR.Printf("Increment push notifications counter error: %s", err)
 	}
+
+	return nil
 }
 
-func (s *Service) sendNewIncidentEmail(incident *Incident) {
+func (s *Service) sendNewIncidentEmail(incident *Incident) error {
 	now := time.Now()
 
 	// Get alarm limits
@@ -58,7 +66,7 @@ func (s *Service) sendNewIncidentEmail(incident *Incident) {
 	)
 	if err != nil {
 		logger.ERROR.Printf("Find notification counter error: %s", err.Error())
-		return
+		return err
 	}
 	if !alarmLimits.unlimitedEmails && notificationCounter.Email > alarmLimits.maxEmailsPerInterval {
 		logger.ERROR.Printf(
@@ -66,15 +74,17 @@ func (s *Service) sendNewIncidentEmail(incident *Incident) {
 			notificationCounter.Email,
 			alarmLimits.maxEmailsPerInterval,
 		)
-		return
+		return nil
 	}
 
 	newIncidentEmail := s.emailFactory.NewIncidentEmail(incident)
 
 	// Send the email
-	if err := s.emailService.Send(newIncidentEmail); err != nil {
+	err = s.emailService.Send(newIncidentEmail)
+	countNotification(emailChannel, err)
+	if err != nil {
 		logger.ERROR.Printf("Send email error: %s", err)
-		return
+		return err
 	}
 
 	// Increment email notifications counter
@@ -85,29 +95,33 @@ func (s *Service) sendNewIncidentEmail(incident *Incident) {
 	); err != nil {
 		logger.ERROR.Printf("Increment email notifications counter error: %s", err)
 	}
+
+	return nil
 }
 
-func (s *Service) sendNewIncidentSlackMessage(alarm *Alarm, incident *Incident) {
+func (s *Service) sendNewIncidentSlackMessage(alarm *Alarm, incident *Incident) error {
 	now := time.Now()
 
 	// Get alarm limits
 	alarmLimits := s.getAlarmLimits(alarm.User)
 
 	if !alarmLimits.slackAlerts {
-		return
+		return nil
 	}
 
 	newIncidentMessage := s.slackFactory.NewIncidentMessage(incident)
 
 	// Send slack message
-	if err := s.GetAccountsService().GetSlackAdapter(alarm.User).SendMessage(
+	err := s.GetAccountsService().GetSlackAdapter(alarm.User).SendMessage(
 		alarm.User.SlackChannel.String,
 		s.cnf.Slack.Username,
 		newIncidentMessage,
 		s.cnf.Slack.Emoji,
-	); err != nil {
+	)
+	countNotification(slackChannel, err)
+	if err != nil {
 		logger.ERROR.Printf("Send slack message error: %s", err)
-		return
+		return err
 	}
 
 	// Increment slack notifications counter
@@ -118,9 +132,11 @@ func (s *Service) sendNewIncidentSlackMessage(alarm *Alarm, incident *Incident)
 	); err != nil {
 		logger.ERROR.Printf("Increment slack notifications counter error: %s", err)
 	}
+
+	return nil
 }
 
-func (s *Service) sendIncidentsResolvedPushNotification(alarm *Alarm) {
+func (s *Service) sendIncidentsResolvedPushNotification(alarm *Alarm) error {
 	now := time.Now()
 
 	// Find SNS endpoint
@@ -128,9 +144,13 @@ func (s *Service) sendIncidentsResolvedPushNotification(alarm *Alarm) {
 		alarm.User.ID,
 		s.cnf.AWS.APNSPlatformApplicationARN,
 	)
+	if err == notifications.ErrEndpointNotFound {
+		// The user has no device registered, retrying would not help
+		return nil
+	}
 	if err != nil {
 		logger.ERROR.Printf("Find endpoint by user ID and application ARN error: %s", err.Error())
-		return
+		return err
 	}
 
 	// Send push notification
@@ -139,9 +159,10 @@ func (s *Service) sendIncidentsResolvedPushNotification(alarm *Alarm) {
 		fmt.Sprintf(incidentsResolvedPushNotificationTemplate, alarm.EndpointURL),
 		map[string]interface{}{},
 	)
+	countNotification(pushChannel, err)
 	if err != nil {
 		logger.ERROR.Printf("Publish message error: %s", err.Error())
-		return
+		return err
 	}
 
 	// Increment push notifications counter
@@ -152,9 +173,11 @@ func (s *Service) sendIncidentsResolvedPushNotification(alarm *Alarm) {
 	); err != nil {
 		logger.ERROR.Printf("Increment push notifications counter error: %s", err)
 	}
+
+	return nil
 }
 
-func (s *Service) sendIncidentsResolvedEmail(alarm *Alarm) {
+func (s *Service) sendIncidentsResolvedEmail(alarm *Alarm) error {
 	now := time.Now()
 
 	// Get alarm limits
@@ -168,7 +191,7 @@ func (s *Service) sendIncidentsResolvedEmail(alarm *Alarm) {
 	)
 	if err != nil {
 		logger.ERROR.Printf("Find notification counter error: %s", err.Error())
-		return
+		return err
 	}
 	if !alarmLimits.unlimitedEmails && notificationCounter.Email > alarmLimits.maxEmailsPerInterval {
 		logger.ERROR.Printf(
@@ -176,15 +199,17 @@ func (s *Service) sendIncidentsResolvedEmail(alarm *Alarm) {
 			notificationCounter.Email,
 			alarmLimits.maxEmailsPerInterval,
 		)
-		return
+		return nil
 	}
 
 	alarmUpEmail := s.emailFactory.NewIncidentsResolvedEmail(alarm)
 
 	// Send the email
-	if err := s.emailService.Send(alarmUpEmail); err != nil {
+	err = s.emailService.Send(alarmUpEmail)
+	countNotification(emailChannel, err)
+	if err != nil {
 		logger.ERROR.Printf("Send email error: %s", err)
-		return
+		return err
 	}
 
 	// Increment email notifications counter
@@ -195,29 +220,33 @@ func (s *Service) sendIncidentsResolvedEmail(alarm *Alarm) {
 	); err != nil {
 		logger.ERROR.Printf("Increment email notifications counter error: %s", err)
 	}
+
+	return nil
 }
 
-func (s *Service) sendIncidentsResolvedSlackMessage(alarm *Alarm) {
+func (s *Service) sendIncidentsResolvedSlackMessage(alarm *Alarm) error {
 	now := time.Now()
 
 	// Get alarm limits
 	alarmLimits := s.getAlarmLimits(alarm.User)
 
 	if !alarmLimits.slackAlerts {
-		return
+		return nil
 	}
 
 	newIncidentMessage := s.slackFactory.NewIncidentsResolvedMessage(alarm)
 
 	// Send slack message
-	if err := s.GetAccountsService().GetSlackAdapter(alarm.User).SendMessage(
+	err := s.GetAccountsService().GetSlackAdapter(alarm.User).SendMessage(
 		alarm.User.SlackChannel.String,
 		s.cnf.Slack.Username,
 		newIncidentMessage,
 		s.cnf.Slack.Emoji,
-	); err != nil {
+	)
+	countNotification(slackChannel, err)
+	if err != nil {
 		logger.ERROR.Printf("Send slack message error: %s", err)
-		return
+		return err
 	}
 
 	// Increment slack notifications counter
@@ -228,4 +257,6 @@ func (s *Service) sendIncidentsResolvedSlackMessage(alarm *Alarm) {
 	); err != nil {
 		logger.ERROR.Printf("Increment slack notifications counter error: %s", err)
 	}
+
+	return nil
 }
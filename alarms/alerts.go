@@ -0,0 +1,100 @@
+package alarms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/jobs"
+	"github.com/RichardKnop/pinglist-api/logger"
+)
+
+// AlertJob is the job type of incident alerts sent through the job queue
+const AlertJob = "incident_alert"
+
+var (
+	// ErrUnknownAlertChannel ...
+	ErrUnknownAlertChannel = errors.New("Unknown alert channel")
+
+	// Alerts are urgent, they go before other jobs
+	alertJobOptions = &jobs.EnqueueOptions{
+		Priority:          10,
+		MaxAttempts:       5,
+		VisibilityTimeout: time.Minute,
+	}
+)
+
+// alertPayload identifies an alert of a single channel so each channel is
+// retried on its own, alerts of resolved incidents have no incident ID
+type alertPayload struct {
+	AlarmID    uint   `json:"alarm_id"`
+	IncidentID uint   `json:"incident_id,omitempty"`
+	Channel    string `json:"channel"`
+}
+
+// dispatchAlert enqueues an alert so it is retried when sending fails,
+// without the job queue the alert is sent in the background
+func (s *Service) dispatchAlert(alarm *Alarm, incident *Incident, channel string, send func() error) {
+	if s.jobsService != nil {
+		payload := &alertPayload{AlarmID: alarm.ID, Channel: channel}
+		if incident != nil {
+			payload.IncidentID = incident.ID
+		}
+		_, err := s.jobsService.Enqueue(AlertJob, payload, alertJobOptions)
+		if err == nil {
+			return
+		}
+		logger.ERROR.Printf("Enqueue %s alert error: %s", channel, err)
+	}
+	s.background.Go(func() { send() })
+}
+
+// SendAlert sends an alert enqueued by dispatchAlert, it is the handler
+// of AlertJob jobs
+func (s *Service) SendAlert(ctx context.Context, job *jobs.Job) error {
+	payload := new(alertPayload)
+	if err := job.DecodePayload(payload); err != nil {
+		return err
+	}
+
+	alarm, err := s.FindAlarmByID(payload.AlarmID)
+	if err != nil {
+		// The alarm has been deleted since, there is nobody to alert
+		logger.INFO.Printf("Alarm #%d alert dropped: %s", payload.AlarmID, err)
+		return nil
+	}
+
+	// Incidents resolved alerts
+	if payload.IncidentID == 0 {
+		switch payload.Channel {
+		case pushChannel:
+			return s.sendIncidentsResolvedPushNotification(alarm)
+		case emailChannel:
+			return s.sendIncidentsResolvedEmail(alarm)
+		case slackChannel:
+			return s.sendIncidentsResolvedSlackMessage(alarm)
+		}
+		return fmt.Errorf("%s: %s", ErrUnknownAlertChannel, payload.Channel)
+	}
+
+	// New incident alerts
+	incident := new(Incident)
+	notFound := s.db.Where("alarm_id = ?", alarm.ID).
+		Preload("IncidentType").First(incident, payload.IncidentID).RecordNotFound()
+	if notFound {
+		logger.INFO.Printf("Incident #%d alert dropped: %s", payload.IncidentID, ErrIncidentNotFound)
+		return nil
+	}
+	incident.Alarm = alarm
+
+	switch payload.Channel {
+	case pushChannel:
+		return s.sendNewIncidentPushNotification(alarm, incident)
+	case emailChannel:
+		return s.sendNewIncidentEmail(incident)
+	case slackChannel:
+		return s.sendNewIncidentSlackMessage(alarm, incident)
+	}
+	return fmt.Errorf("%s: %s", ErrUnknownAlertChannel, payload.Channel)
+}
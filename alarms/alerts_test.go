@@ -0,0 +1,143 @@
+package alarms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/alarms/regions"
+	"github.com/RichardKnop/pinglist-api/email"
+	"github.com/RichardKnop/pinglist-api/jobs"
+	"github.com/RichardKnop/pinglist-api/notifications"
+	"github.com/RichardKnop/pinglist-api/subscriptions"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *AlarmsTestSuite) TestDispatchAlertEnqueuesJob() {
+	jobsServiceMock := new(jobs.ServiceMock)
+	suite.service.jobsService = jobsServiceMock
+	defer func() { suite.service.jobsService = nil }()
+
+	alarm := suite.alarms[0]
+	incident := new(Incident)
+	incident.ID = 42
+
+	// The alert is enqueued instead of being sent
+	jobsServiceMock.On(
+		"Enqueue",
+		AlertJob,
+		&alertPayload{AlarmID: alarm.ID, IncidentID: 42, Channel: emailChannel},
+		alertJobOptions,
+	).Return(new(jobs.Job), nil)
+	suite.service.dispatchAlert(alarm, incident, emailChannel, func() error {
+		assert.Fail(suite.T(), "The alert should not be sent directly")
+		return nil
+	})
+
+	jobsServiceMock.AssertExpectations(suite.T())
+}
+
+func (suite *AlarmsTestSuite) TestSendAlert() {
+	// Insert a test alarm with an open incident
+	testAlarm := &Alarm{
+		User:             suite.users[1],
+		Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
+		AlarmState:       &AlarmState{ID: alarmstates.Alarm},
+		EndpointURL:      "http://foobar",
+		ExpectedHTTPCode: 200,
+		MaxResponseTime:  1000,
+		Interval:         60,
+		EmailAlerts:      true,
+		Active:           true,
+	}
+	err := suite.db.Create(testAlarm).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+	testIncident := &Incident{
+		AlarmID:        util.PositiveIntOrNull(int64(testAlarm.ID)),
+		IncidentTypeID: util.StringOrNull(incidenttypes.Timeout),
+	}
+	err = suite.db.Create(testIncident).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	newJob := func(payload *alertPayload) *jobs.Job {
+		data, err := json.Marshal(payload)
+		assert.NoError(suite.T(), err, "JSON marshalling failed")
+		return &jobs.Job{Type: AlertJob, Payload: string(data)}
+	}
+	mockAlarmLimits := func() {
+		suite.mockFindTeamByMemberID(testAlarm.User.ID, nil, teams.ErrTeamNotFound)
+		suite.mockFindActiveSubscriptionByUserID(
+			testAlarm.User.ID,
+			&subscriptions.Subscription{
+				Plan: &subscriptions.Plan{UnlimitedEmails: true},
+			},
+			nil,
+		)
+	}
+
+	// New incident email
+	suite.resetMocks()
+	mockAlarmLimits()
+	suite.mockNewIncidentEmail()
+	err = suite.service.SendAlert(context.Background(), newJob(&alertPayload{
+		AlarmID:    testAlarm.ID,
+		IncidentID: testIncident.ID,
+		Channel:    emailChannel,
+	}))
+	assert.NoError(suite.T(), err)
+	suite.assertMockExpectations()
+
+	// A failed email is returned so the job gets retried
+	suite.resetMocks()
+	mockAlarmLimits()
+	emailMock := new(email.Email)
+	suite.emailFactoryMock.On(
+		"NewIncidentsResolvedEmail",
+		mock.AnythingOfType("*alarms.Alarm"),
+	).Return(emailMock)
+	suite.emailServiceMock.On("Send", emailMock).Return(errors.New("Email error"))
+	err = suite.service.SendAlert(context.Background(), newJob(&alertPayload{
+		AlarmID: testAlarm.ID,
+		Channel: emailChannel,
+	}))
+	assert.EqualError(suite.T(), err, "Email error")
+	suite.assertMockExpectations()
+
+	// Push alerts of users without a device are done, there is nothing to retry
+	for _, incidentID := range []uint{testIncident.ID, 0} {
+		suite.resetMocks()
+		suite.mockFindEndpointByUserIDAndApplicationARN(
+			testAlarm.User.ID,
+			suite.cnf.AWS.APNSPlatformApplicationARN,
+			nil,
+			notifications.ErrEndpointNotFound,
+		)
+		err = suite.service.SendAlert(context.Background(), newJob(&alertPayload{
+			AlarmID:    testAlarm.ID,
+			IncidentID: incidentID,
+			Channel:    pushChannel,
+		}))
+		assert.NoError(suite.T(), err)
+		suite.assertMockExpectations()
+	}
+
+	// Unknown channels are errors
+	suite.resetMocks()
+	err = suite.service.SendAlert(context.Background(), newJob(&alertPayload{
+		AlarmID: testAlarm.ID,
+		Channel: "pigeon",
+	}))
+	assert.Error(suite.T(), err)
+
+	// Alerts of deleted alarms are dropped
+	err = suite.service.SendAlert(context.Background(), newJob(&alertPayload{
+		AlarmID: testAlarm.ID + 1000,
+		Channel: emailChannel,
+	}))
+	assert.NoError(suite.T(), err)
+}
@@ -0,0 +1,207 @@
+package alarms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+const (
+	// MaxAnnotationTitleLength limits length of annotation titles
+	MaxAnnotationTitleLength = 254
+	// MaxListedAnnotations limits a number of annotations returned with
+	// response times and incidents, the most recent ones are kept
+	MaxListedAnnotations = 100
+)
+
+var (
+	// ErrAnnotationTitleRequired ...
+	ErrAnnotationTitleRequired = errors.New("Annotation title is required")
+	// ErrAnnotationTitleTooLong ...
+	ErrAnnotationTitleTooLong = fmt.Errorf("Annotation title cannot be longer than %d characters", MaxAnnotationTitleLength)
+	// ErrInvalidAnnotationTime ...
+	ErrInvalidAnnotationTime = errors.New("Invalid annotation time. Use RFC3339 format")
+	// ErrAnnotationEndsBeforeStart ...
+	ErrAnnotationEndsBeforeStart = errors.New("Annotation cannot end before it starts")
+	// ErrAnnotationAlarmNotFound ...
+	ErrAnnotationAlarmNotFound = errors.New("Annotation alarm not found")
+	// ErrAnnotationTargetRequired ...
+	ErrAnnotationTargetRequired = errors.New("Annotation needs alarm IDs or a team ID")
+	// ErrAnnotationTeamPermission ...
+	ErrAnnotationTeamPermission = errors.New("Need to be a member of the team to annotate its alarms")
+)
+
+// createAnnotation creates a new annotation posted on behalf of the user
+func (s *Service) createAnnotation(user *accounts.User, annotationRequest *AnnotationRequest) (*Annotation, error) {
+	title := strings.TrimSpace(annotationRequest.Title)
+	if title == "" {
+		return nil, ErrAnnotationTitleRequired
+	}
+	if len(title) > MaxAnnotationTitleLength {
+		return nil, ErrAnnotationTitleTooLong
+	}
+
+	// Annotations start now unless a time is given
+	startedAt, endedAt, err := getAnnotationTimes(annotationRequest, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Annotations apply to listed alarms or alarms of a team
+	if len(annotationRequest.AlarmIDs) == 0 && annotationRequest.TeamID == 0 {
+		return nil, ErrAnnotationTargetRequired
+	}
+
+	// Fetch the team if the annotation is meant for one
+	var team *teams.Team
+	if annotationRequest.TeamID > 0 {
+		team, err = s.teamsService.FindTeamByID(annotationRequest.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		if !isTeamMember(team, user) {
+			return nil, ErrAnnotationTeamPermission
+		}
+	}
+
+	// Fetch the annotated alarms
+	alarms, err := s.findAnnotationAlarms(user, annotationRequest.AlarmIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Fetch or create tags
+	tags, err := findOrCreateTags(tx, annotationRequest.Tags)
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Create a new annotation object
+	annotation := NewAnnotation(user, team, title, startedAt, endedAt)
+	annotation.Alarms = alarms
+	annotation.Tags = tags
+
+	// Save the annotation to the database
+	if err := tx.Create(annotation).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Assign related objects
+	annotation.User = user
+	annotation.Team = team
+
+	return annotation, nil
+}
+
+// getAnnotationTimes parses start and optional end of the annotation
+func getAnnotationTimes(annotationRequest *AnnotationRequest, now time.Time) (time.Time, *time.Time, error) {
+	startedAt := now
+	if annotationRequest.StartedAt != "" {
+		var err error
+		startedAt, err = util.ParseTimestamp(annotationRequest.StartedAt)
+		if err != nil {
+			return time.Time{}, nil, ErrInvalidAnnotationTime
+		}
+	}
+
+	if annotationRequest.EndedAt == "" {
+		return startedAt, nil, nil
+	}
+	endedAt, err := util.ParseTimestamp(annotationRequest.EndedAt)
+	if err != nil {
+		return time.Time{}, nil, ErrInvalidAnnotationTime
+	}
+	if endedAt.Before(startedAt) {
+		return time.Time{}, nil, ErrAnnotationEndsBeforeStart
+	}
+
+	return startedAt, &endedAt, nil
+}
+
+// findAnnotationAlarms fetches alarms of the user by IDs, all of them must
+// exist and belong to the user
+func (s *Service) findAnnotationAlarms(user *accounts.User, alarmIDs []uint) ([]*Alarm, error) {
+	alarms := make([]*Alarm, 0, len(alarmIDs))
+	if len(alarmIDs) == 0 {
+		return alarms, nil
+	}
+
+	err := s.db.Where("user_id = ? AND id IN (?)", user.ID, alarmIDs).
+		Order("id").Find(&alarms).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Duplicate IDs are fine, unknown ones and alarms of other users are not
+	found := make(map[uint]bool, len(alarms))
+	for _, alarm := range alarms {
+		found[alarm.ID] = true
+	}
+	for _, alarmID := range alarmIDs {
+		if !found[alarmID] {
+			return nil, ErrAnnotationAlarmNotFound
+		}
+	}
+
+	return alarms, nil
+}
+
+// findOverlappingAnnotations returns annotations of the alarm overlapping
+// the time range oldest first. Annotations apply to an alarm when the alarm
+// owner posted them for the alarm or when a user posted them for a team the
+// alarm owner belongs to. Only alarms of the owner are listed with them
+func (s *Service) findOverlappingAnnotations(alarm *Alarm, from, to *time.Time) ([]*Annotation, error) {
+	var annotations []*Annotation
+
+	query := s.db.Preload("Alarms", "alarm_alarms.user_id = ?", alarm.UserID.Int64).
+		Preload("Tags").Where(
+		`(user_id = ? AND id IN (
+			SELECT annotation_id FROM alarm_annotation_alarms WHERE alarm_id = ?
+		))
+		OR (user_id IS NOT NULL AND team_id IN (
+			SELECT id FROM team_teams WHERE owner_id = ?
+			UNION SELECT team_id FROM team_team_members WHERE user_id = ?
+		))`,
+		alarm.UserID.Int64,
+		alarm.ID,
+		alarm.UserID.Int64,
+		alarm.UserID.Int64,
+	)
+
+	// Annotations without an end are instant
+	if from != nil {
+		query = query.Where("COALESCE(ended_at, started_at) >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("started_at <= ?", *to)
+	}
+
+	err := query.Order("started_at DESC, id DESC").Limit(MaxListedAnnotations).
+		Find(&annotations).Error
+	if err != nil {
+		return annotations, err
+	}
+
+	// Oldest first
+	for i, j := 0, len(annotations)-1; i < j; i, j = i+1, j-1 {
+		annotations[i], annotations[j] = annotations[j], annotations[i]
+	}
+
+	return annotations, nil
+}
@@ -0,0 +1,179 @@
+package alarms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/RichardKnop/pinglist-api/alarms/regions"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAnnotationTimes(t *testing.T) {
+	var (
+		now       = time.Date(2016, time.March, 7, 9, 0, 0, 0, time.UTC)
+		startedAt time.Time
+		endedAt   *time.Time
+		err       error
+	)
+
+	// Annotations start now by default
+	startedAt, endedAt, err = getAnnotationTimes(&AnnotationRequest{}, now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, now, startedAt)
+		assert.Nil(t, endedAt)
+	}
+
+	startedAt, endedAt, err = getAnnotationTimes(&AnnotationRequest{
+		StartedAt: "2016-03-07T08:50:00Z",
+		EndedAt:   "2016-03-07T08:55:00Z",
+	}, now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2016-03-07T08:50:00Z", util.FormatTime(startedAt))
+		if assert.NotNil(t, endedAt) {
+			assert.Equal(t, "2016-03-07T08:55:00Z", util.FormatTime(*endedAt))
+		}
+	}
+
+	_, _, err = getAnnotationTimes(&AnnotationRequest{StartedAt: "bogus"}, now)
+	assert.Equal(t, ErrInvalidAnnotationTime, err)
+
+	_, _, err = getAnnotationTimes(&AnnotationRequest{EndedAt: "bogus"}, now)
+	assert.Equal(t, ErrInvalidAnnotationTime, err)
+
+	_, _, err = getAnnotationTimes(&AnnotationRequest{
+		StartedAt: "2016-03-07T08:50:00Z",
+		EndedAt:   "2016-03-07T08:45:00Z",
+	}, now)
+	assert.Equal(t, ErrAnnotationEndsBeforeStart, err)
+}
+
+func TestNewAnnotationResponse(t *testing.T) {
+	startedAt := time.Date(2016, time.March, 7, 9, 0, 0, 0, time.UTC)
+	annotation := &Annotation{
+		TeamID:    util.PositiveIntOrNull(3),
+		Title:     "Deploy v1.2.3",
+		StartedAt: startedAt,
+		Alarms:    []*Alarm{new(Alarm)},
+		Tags:      []*Tag{&Tag{Name: "deploy"}, &Tag{Name: "api"}},
+	}
+	annotation.ID = 1
+	annotation.Alarms[0].ID = 2
+
+	response, err := NewAnnotationResponse(annotation)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(1), response.ID)
+		assert.Equal(t, "Deploy v1.2.3", response.Title)
+		assert.Equal(t, []string{"deploy", "api"}, response.Tags)
+		assert.Equal(t, []uint{2}, response.AlarmIDs)
+		if assert.NotNil(t, response.TeamID) {
+			assert.Equal(t, uint(3), *response.TeamID)
+		}
+		assert.Equal(t, "2016-03-07T09:00:00Z", response.StartedAt)
+		assert.Nil(t, response.EndedAt)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestFindOverlappingAnnotations() {
+	var (
+		testAlarm = suite.alarms[0]
+		now       = time.Now().UTC()
+		hourAgo   = now.Add(-time.Hour)
+		dayAgo    = now.Add(-24 * time.Hour)
+		weekAgo   = now.Add(-7 * 24 * time.Hour)
+	)
+
+	// A team of the alarm owner and a team the owner does not belong to
+	ownTeam := teams.NewTeam(suite.users[1], nil, "own team")
+	err := suite.db.Create(ownTeam).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+	otherTeam := teams.NewTeam(suite.users[2], nil, "other team")
+	err = suite.db.Create(otherTeam).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// An alarm of another user
+	otherAlarm := &Alarm{
+		User:             suite.users[2],
+		Region:           &Region{ID: regions.USWest2, Name: "US West (Oregon)"},
+		AlarmState:       &AlarmState{ID: alarmstates.OK},
+		EndpointURL:      "http://other-endpoint",
+		ExpectedHTTPCode: 200,
+		MaxResponseTime:  1000,
+		Interval:         60,
+		Active:           true,
+	}
+	err = suite.db.Create(otherAlarm).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	annotations := []*Annotation{
+		// Annotation of the alarm
+		NewAnnotation(suite.users[1], nil, "alarm", hourAgo, nil),
+		// Annotation of another alarm
+		NewAnnotation(suite.users[1], nil, "other alarm", hourAgo, nil),
+		// Annotation of the owner's team posted by a team member
+		NewAnnotation(suite.users[2], ownTeam, "own team", hourAgo, nil),
+		// Annotation of a team the owner does not belong to
+		NewAnnotation(suite.users[2], otherTeam, "other team", hourAgo, nil),
+		// Annotation of the alarm posted by another user
+		NewAnnotation(suite.users[2], nil, "foreign", hourAgo, nil),
+		// Annotation of the alarm lasting from a week ago until a day ago
+		NewAnnotation(suite.users[1], nil, "maintenance", weekAgo, &dayAgo),
+	}
+	annotations[0].Alarms = []*Alarm{testAlarm}
+	annotations[1].Alarms = []*Alarm{suite.alarms[1]}
+	annotations[2].Alarms = []*Alarm{otherAlarm}
+	annotations[4].Alarms = []*Alarm{testAlarm}
+	annotations[5].Alarms = []*Alarm{testAlarm}
+	for _, annotation := range annotations {
+		err := suite.db.Create(annotation).Error
+		assert.NoError(suite.T(), err, "Inserting test data failed")
+	}
+
+	// Annotations posted before they were linked to users
+	legacy := NewAnnotation(suite.users[1], nil, "legacy", hourAgo, nil)
+	legacy.UserID = util.PositiveIntOrNull(0)
+	legacy.Alarms = []*Alarm{testAlarm}
+	err = suite.db.Create(legacy).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	titles := func(annotations []*Annotation) []string {
+		result := make([]string, len(annotations))
+		for i, annotation := range annotations {
+			result[i] = annotation.Title
+		}
+		return result
+	}
+
+	// Without a time range
+	found, err := suite.service.findOverlappingAnnotations(testAlarm, nil, nil)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(
+			suite.T(),
+			[]string{"maintenance", "alarm", "own team"},
+			titles(found),
+		)
+
+		// Alarms of other users are not listed
+		assert.Equal(suite.T(), 0, len(found[2].Alarms))
+	}
+
+	// Only recent annotations
+	from := now.Add(-2 * time.Hour)
+	found, err = suite.service.findOverlappingAnnotations(testAlarm, &from, &now)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(
+			suite.T(),
+			[]string{"alarm", "own team"},
+			titles(found),
+		)
+	}
+
+	// The maintenance window overlaps the range even though it started earlier
+	from, to := dayAgo.Add(-time.Hour), dayAgo.Add(time.Hour)
+	found, err = suite.service.findOverlappingAnnotations(testAlarm, &from, &to)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), []string{"maintenance"}, titles(found))
+	}
+}
@@ -0,0 +1,80 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/RichardKnop/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrInvalidAnnotationToken ...
+	ErrInvalidAnnotationToken = errors.New("Invalid annotation token")
+)
+
+// rotateAnnotationToken creates an annotation token for the user, an
+// existing token is replaced so it stops working immediately
+func (s *Service) rotateAnnotationToken(user *accounts.User) (*AnnotationToken, error) {
+	annotationToken := new(AnnotationToken)
+	notFound := s.db.Where("user_id = ?", user.ID).
+		First(annotationToken).RecordNotFound()
+
+	// Create a new token
+	if notFound {
+		annotationToken = &AnnotationToken{
+			UserID: util.PositiveIntOrNull(int64(user.ID)),
+			User:   user,
+			Token:  uuid.New(),
+		}
+		if err := s.db.Create(annotationToken).Error; err != nil {
+			return nil, err
+		}
+		return annotationToken, nil
+	}
+
+	// Replace the existing token
+	token, now := uuid.New(), gorm.NowFunc()
+	err := s.db.Model(annotationToken).UpdateColumns(AnnotationToken{
+		Token: token,
+		Model: gorm.Model{UpdatedAt: now},
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	annotationToken.Token = token
+	annotationToken.UpdatedAt = now
+	annotationToken.User = user
+
+	return annotationToken, nil
+}
+
+// findUserByAnnotationToken returns the user the annotation token belongs to
+func (s *Service) findUserByAnnotationToken(token string) (*accounts.User, error) {
+	if token == "" {
+		return nil, ErrInvalidAnnotationToken
+	}
+
+	annotationToken := new(AnnotationToken)
+	notFound := s.db.Preload("User").Where("token = ?", token).
+		First(annotationToken).RecordNotFound()
+	if notFound || annotationToken.User == nil {
+		return nil, ErrInvalidAnnotationToken
+	}
+
+	return annotationToken.User, nil
+}
+
+// getAnnotationToken returns an annotation token from the Authorization
+// header, tokens are not accepted in the query string so they do not end
+// up in CI and proxy logs
+func getAnnotationToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+}
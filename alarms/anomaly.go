@@ -0,0 +1,361 @@
+package alarms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/jobs"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// DefaultAnomalySensitivity is used for alarms created without a sensitivity
+	DefaultAnomalySensitivity = 3.0
+	// MaxAnomalySensitivity limits the sensitivity to a sensible biggest value
+	MaxAnomalySensitivity = 20.0
+	// DefaultAnomalyPeriod is used for alarms created without a period (seconds)
+	DefaultAnomalyPeriod = uint(300)
+	// MaxAnomalyPeriod limits the period to a sensible biggest value (seconds)
+	MaxAnomalyPeriod = uint(86400)
+	// HoursPerWeek is a number of baselines of an alarm
+	HoursPerWeek = 7 * 24
+	// BaselineJob is the job type of baseline refreshes run by the scheduler
+	BaselineJob = "refresh_baseline"
+)
+
+var (
+	// BaselineWindow is how much of the metrics history baselines learn from
+	BaselineWindow = 28 * 24 * time.Hour
+	// BaselineRefreshInterval defines how often baselines are recalculated
+	BaselineRefreshInterval = time.Hour
+	// BaselineMinSamples is a number of samples needed before an hour of the
+	// week baseline is used, the fixed max response time is used until then
+	BaselineMinSamples = uint(10)
+
+	// ErrInvalidAnomalySensitivity ...
+	ErrInvalidAnomalySensitivity = fmt.Errorf("Anomaly sensitivity must be greater than 0 and at most %g", MaxAnomalySensitivity)
+	// ErrAnomalyPeriodTooBig ...
+	ErrAnomalyPeriodTooBig = fmt.Errorf("Anomaly period cannot be greater than %d seconds", MaxAnomalyPeriod)
+	// ErrBaselineNotFound ...
+	ErrBaselineNotFound = errors.New("Baseline not found")
+
+	// Refreshing a baseline reads weeks of metrics history so it runs as
+	// a maintenance job after checks and alerts
+	baselineJobOptions = jobs.EnqueueOptions{
+		Priority:          -10,
+		MaxAttempts:       3,
+		VisibilityTimeout: 10 * time.Minute,
+	}
+)
+
+// baselinePayload is the payload of BaselineJob jobs
+type baselinePayload struct {
+	AlarmID uint `json:"alarm_id"`
+}
+
+const (
+	// madScale makes MAD a consistent estimator of standard deviation
+	madScale = 1.4826
+	// minDeviationRatio keeps very stable endpoints from alerting on noise,
+	// the allowed deviation is at least this fraction of the median
+	minDeviationRatio = 0.1
+)
+
+// getAnomalySensitivity validates a requested sensitivity, zero keeps the current one
+func getAnomalySensitivity(requested, current float64) (float64, error) {
+	if requested == 0 {
+		return current, nil
+	}
+	if requested < 0 || requested > MaxAnomalySensitivity {
+		return 0, ErrInvalidAnomalySensitivity
+	}
+	return requested, nil
+}
+
+// getAnomalyPeriod validates a requested period, zero keeps the current one
+func getAnomalyPeriod(requested, current uint) (uint, error) {
+	if requested == 0 {
+		return current, nil
+	}
+	if requested > MaxAnomalyPeriod {
+		return 0, ErrAnomalyPeriodTooBig
+	}
+	return requested, nil
+}
+
+// hourOfWeek returns hour of the week in UTC, 0 is Sunday midnight
+func hourOfWeek(t time.Time) uint {
+	t = t.UTC()
+	return uint(t.Weekday())*24 + uint(t.Hour())
+}
+
+// median returns the median of values, values get sorted
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	middle := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[middle]
+	}
+	return (values[middle-1] + values[middle]) / 2
+}
+
+// NewBaseline creates new Baseline instance from response times in milliseconds
+func NewBaseline(alarm *Alarm, hour uint, values []float64) *Baseline {
+	baseline := &Baseline{
+		AlarmID:    util.PositiveIntOrNull(int64(alarm.ID)),
+		HourOfWeek: hour,
+		Samples:    uint(len(values)),
+	}
+	if len(values) == 0 {
+		return baseline
+	}
+
+	baseline.Median = median(values)
+
+	// Median absolute deviation
+	deviations := make([]float64, len(values))
+	for i, value := range values {
+		deviations[i] = math.Abs(value - baseline.Median)
+	}
+	baseline.MAD = median(deviations)
+
+	return baseline
+}
+
+// Ready returns true if there are enough samples to rely on the baseline
+func (b *Baseline) Ready() bool {
+	return b.Samples >= BaselineMinSamples
+}
+
+// Threshold returns a response time in milliseconds above which a check
+// deviates from the baseline with the given sensitivity
+func (b *Baseline) Threshold(sensitivity float64) float64 {
+	deviation := math.Max(madScale*b.MAD, minDeviationRatio*b.Median)
+	return b.Median + sensitivity*deviation
+}
+
+// baselineFresh returns true if baselines of the alarm have been
+// recalculated within the refresh interval
+func baselineFresh(alarm *Alarm, now time.Time) bool {
+	return alarm.BaselineUpdatedAt.Valid && now.Sub(alarm.BaselineUpdatedAt.Time) < BaselineRefreshInterval
+}
+
+// scheduleBaselineRefresh enqueues a refresh of stale baselines so checks
+// never wait for it, jobs are keyed by the refresh interval so a failing
+// refresh is retried by the job queue and not by every check
+func (s *Service) scheduleBaselineRefresh(alarm *Alarm, now time.Time) {
+	// Without the job queue baselines are refreshed when requested
+	if s.jobsService == nil || baselineFresh(alarm, now) {
+		return
+	}
+
+	options := baselineJobOptions
+	options.Key = fmt.Sprintf(
+		"%s:%d:%d",
+		BaselineJob,
+		alarm.ID,
+		now.Truncate(BaselineRefreshInterval).Unix(),
+	)
+	_, err := s.jobsService.Enqueue(BaselineJob, &baselinePayload{AlarmID: alarm.ID}, &options)
+	if err != nil && err != jobs.ErrDuplicateJob {
+		logger.ERROR.Printf("Alarm #%d baseline refresh not queued: %s", alarm.ID, err)
+	}
+}
+
+// RefreshBaseline recalculates baselines of an alarm enqueued by
+// scheduleBaselineRefresh, it is the handler of BaselineJob jobs
+func (s *Service) RefreshBaseline(ctx context.Context, job *jobs.Job) error {
+	payload := new(baselinePayload)
+	if err := job.DecodePayload(payload); err != nil {
+		return err
+	}
+
+	alarm, err := s.FindAlarmByID(payload.AlarmID)
+	if err != nil {
+		// The alarm has been deleted since, there is nothing to refresh
+		logger.INFO.Printf("Alarm #%d baseline refresh dropped: %s", payload.AlarmID, err)
+		return nil
+	}
+
+	return s.refreshBaselines(alarm, time.Now())
+}
+
+// refreshBaselines recalculates baselines of the alarm from the metrics
+// history unless they have been recalculated recently
+func (s *Service) refreshBaselines(alarm *Alarm, now time.Time) error {
+	if baselineFresh(alarm, now) {
+		return nil
+	}
+
+	// Response times which opened slow incidents would raise the baseline
+	// until similar incidents are no longer detected
+	from := now.Add(-BaselineWindow)
+	incidents, err := s.findIncidentsSince([]uint{alarm.ID}, from)
+	if err != nil {
+		return err
+	}
+
+	// Bucket successful response times by hour of the week
+	samples := make([][]float64, HoursPerWeek)
+	err = s.metricsService.IterateResponseTimes(
+		int(alarm.ID),
+		&from,
+		&now,
+		func(responseTime *metrics.ResponseTime) error {
+			if responseTime.Failed || duringIncident(alarm, incidents, responseTime.Timestamp) {
+				return nil
+			}
+			hour := hourOfWeek(responseTime.Timestamp)
+			samples[hour] = append(
+				samples[hour],
+				float64(responseTime.Value)/float64(time.Millisecond),
+			)
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Replace old baselines
+	if err := tx.Unscoped().Where("alarm_id = ?", alarm.ID).Delete(new(Baseline)).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+	for hour, values := range samples {
+		if len(values) == 0 {
+			continue
+		}
+		if err := tx.Create(NewBaseline(alarm, uint(hour), values)).Error; err != nil {
+			tx.Rollback() // rollback the transaction
+			return err
+		}
+	}
+
+	// Update the refresh timestamp
+	updatedAt := gorm.NowFunc()
+	err = tx.Model(alarm).UpdateColumns(Alarm{
+		BaselineUpdatedAt: util.TimeOrNull(&updatedAt),
+	}).Error
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	return nil
+}
+
+// duringIncident returns true if a check at the given time was part of a
+// slow incident, the anomaly period and the check timeout before an incident
+// was opened cover checks which led to opening it. Checks of other incidents
+// failed so they are not part of baselines anyway
+func duringIncident(alarm *Alarm, incidents []*Incident, timestamp time.Time) bool {
+	lead := time.Duration(alarm.AnomalyPeriod)*time.Second + AlarmCheckTimeout
+	for _, incident := range incidents {
+		if incident.IncidentTypeID.String != incidenttypes.Slow {
+			continue
+		}
+		if timestamp.Before(incident.CreatedAt.Add(-lead)) {
+			continue
+		}
+		if incident.ResolvedAt.Valid && timestamp.After(incident.ResolvedAt.Time) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// findBaselines returns baselines of the alarm ordered by hour of the week
+func (s *Service) findBaselines(alarm *Alarm) ([]*Baseline, error) {
+	var baselines []*Baseline
+	if err := s.db.Where("alarm_id = ?", alarm.ID).Order("hour_of_week").
+		Find(&baselines).Error; err != nil {
+		return baselines, err
+	}
+	return baselines, nil
+}
+
+// findBaseline looks up a baseline of the alarm for an hour of the week
+func (s *Service) findBaseline(alarm *Alarm, hour uint) (*Baseline, error) {
+	baseline := new(Baseline)
+	notFound := s.db.Where("alarm_id = ? AND hour_of_week = ?", alarm.ID, hour).
+		First(baseline).RecordNotFound()
+	if notFound {
+		return nil, ErrBaselineNotFound
+	}
+	return baseline, nil
+}
+
+// isSlowAnomaly compares the response time with the alarm's baseline for
+// the hour of the week and returns true once it has been anomalous for
+// the alarm's period. Hours without enough history use the fixed max
+// response time instead
+func (s *Service) isSlowAnomaly(alarm *Alarm, start time.Time, elapsed time.Duration) (bool, error) {
+	// Stale baselines are used until the refresh job has run
+	s.scheduleBaselineRefresh(alarm, start)
+
+	responseTime := float64(elapsed) / float64(time.Millisecond)
+
+	baseline, err := s.findBaseline(alarm, hourOfWeek(start))
+	if err != nil && err != ErrBaselineNotFound {
+		return false, err
+	}
+	if baseline == nil || !baseline.Ready() {
+		return responseTime > float64(alarm.MaxResponseTime), nil
+	}
+
+	anomalous := responseTime > baseline.Threshold(alarm.AnomalySensitivity)
+	return s.updateAnomalyStreak(alarm, anomalous, start)
+}
+
+// updateAnomalyStreak keeps track of when the alarm started to deviate from
+// its baseline and returns true once the deviation lasts the whole period
+func (s *Service) updateAnomalyStreak(alarm *Alarm, anomalous bool, now time.Time) (bool, error) {
+	if !anomalous {
+		if !alarm.AnomalyStartedAt.Valid {
+			return false, nil
+		}
+		// Need to use map here as struct would skip the null value
+		err := s.db.Model(alarm).UpdateColumns(map[string]interface{}{
+			"anomaly_started_at": nil,
+		}).Error
+		if err != nil {
+			return false, err
+		}
+		alarm.AnomalyStartedAt = util.TimeOrNull(nil)
+		return false, nil
+	}
+
+	if !alarm.AnomalyStartedAt.Valid {
+		err := s.db.Model(alarm).UpdateColumns(Alarm{
+			AnomalyStartedAt: util.TimeOrNull(&now),
+		}).Error
+		if err != nil {
+			return false, err
+		}
+		alarm.AnomalyStartedAt = util.TimeOrNull(&now)
+	}
+
+	return now.Sub(alarm.AnomalyStartedAt.Time) >= time.Duration(alarm.AnomalyPeriod)*time.Second, nil
+}
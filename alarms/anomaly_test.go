@@ -0,0 +1,170 @@
+package alarms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAnomalySensitivity(t *testing.T) {
+	var (
+		sensitivity float64
+		err         error
+	)
+
+	// Zero keeps the current sensitivity
+	sensitivity, err = getAnomalySensitivity(0, DefaultAnomalySensitivity)
+	if assert.NoError(t, err) {
+		assert.Equal(t, DefaultAnomalySensitivity, sensitivity)
+	}
+
+	sensitivity, err = getAnomalySensitivity(4.5, DefaultAnomalySensitivity)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4.5, sensitivity)
+	}
+
+	_, err = getAnomalySensitivity(-1, DefaultAnomalySensitivity)
+	assert.Equal(t, ErrInvalidAnomalySensitivity, err)
+
+	_, err = getAnomalySensitivity(MaxAnomalySensitivity+1, DefaultAnomalySensitivity)
+	assert.Equal(t, ErrInvalidAnomalySensitivity, err)
+}
+
+func TestGetAnomalyPeriod(t *testing.T) {
+	var (
+		period uint
+		err    error
+	)
+
+	// Zero keeps the current period
+	period, err = getAnomalyPeriod(0, DefaultAnomalyPeriod)
+	if assert.NoError(t, err) {
+		assert.Equal(t, DefaultAnomalyPeriod, period)
+	}
+
+	period, err = getAnomalyPeriod(60, DefaultAnomalyPeriod)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(60), period)
+	}
+
+	_, err = getAnomalyPeriod(MaxAnomalyPeriod+1, DefaultAnomalyPeriod)
+	assert.Equal(t, ErrAnomalyPeriodTooBig, err)
+}
+
+func TestHourOfWeek(t *testing.T) {
+	// Sunday midnight
+	assert.Equal(t, uint(0), hourOfWeek(time.Date(2016, time.March, 6, 0, 30, 0, 0, time.UTC)))
+	// Monday 9am
+	assert.Equal(t, uint(33), hourOfWeek(time.Date(2016, time.March, 7, 9, 0, 0, 0, time.UTC)))
+	// Saturday 11pm
+	assert.Equal(t, uint(167), hourOfWeek(time.Date(2016, time.March, 12, 23, 59, 0, 0, time.UTC)))
+	// Converted to UTC first
+	assert.Equal(t, uint(0), hourOfWeek(time.Date(2016, time.March, 5, 23, 0, 0, 0, time.FixedZone("", -3600))))
+}
+
+func TestNewBaseline(t *testing.T) {
+	alarm := &Alarm{}
+	alarm.ID = 1
+
+	// No samples
+	baseline := NewBaseline(alarm, 10, []float64{})
+	assert.Equal(t, uint(10), baseline.HourOfWeek)
+	assert.Equal(t, uint(0), baseline.Samples)
+	assert.False(t, baseline.Ready())
+
+	// Odd number of samples, the outlier does not move the median
+	baseline = NewBaseline(alarm, 10, []float64{120, 100, 110, 90, 2000})
+	assert.Equal(t, int64(1), baseline.AlarmID.Int64)
+	assert.Equal(t, uint(5), baseline.Samples)
+	assert.Equal(t, 110.0, baseline.Median)
+	assert.Equal(t, 10.0, baseline.MAD) // deviations 10, 10, 0, 20, 1890
+	assert.False(t, baseline.Ready())
+
+	// Even number of samples
+	baseline = NewBaseline(alarm, 10, []float64{100, 100, 100, 100, 104, 104, 104, 104, 104, 104})
+	assert.Equal(t, 104.0, baseline.Median)
+	assert.Equal(t, 0.0, baseline.MAD)
+	assert.True(t, baseline.Ready())
+}
+
+func TestBaselineThreshold(t *testing.T) {
+	// Scaled MAD is used when it is bigger than the minimum deviation
+	baseline := &Baseline{Median: 100, MAD: 20}
+	assert.InDelta(t, 100+3*1.4826*20, baseline.Threshold(3), 1e-9)
+
+	// Stable endpoints use the minimum deviation, 10% of the median
+	baseline = &Baseline{Median: 100, MAD: 1}
+	assert.InDelta(t, 130, baseline.Threshold(3), 1e-9)
+	assert.InDelta(t, 110, baseline.Threshold(1), 1e-9)
+}
+
+func TestNewBaselineResponse(t *testing.T) {
+	updatedAt := time.Date(2016, time.March, 7, 9, 0, 0, 0, time.UTC)
+	alarm := &Alarm{
+		AnomalyDetection:   true,
+		AnomalySensitivity: 3,
+		AnomalyPeriod:      300,
+	}
+	alarm.ID = 1
+	alarm.BaselineUpdatedAt.Time = updatedAt
+	alarm.BaselineUpdatedAt.Valid = true
+
+	baselines := []*Baseline{
+		&Baseline{HourOfWeek: 33, Median: 123.4567, MAD: 1.0001, Samples: 12},
+	}
+
+	response, err := NewBaselineResponse(alarm, baselines)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(1), response.AlarmID)
+		assert.Equal(t, uint(1), response.Count)
+		assert.Nil(t, response.AnomalyStartedAt)
+		if assert.NotNil(t, response.UpdatedAt) {
+			assert.Equal(t, "2016-03-07T09:00:00Z", *response.UpdatedAt)
+		}
+		assert.Equal(t, "/v1/alarms/1/baseline", response.Links["self"].Href)
+	}
+
+	hourly, err := newHourlyBaselineResponse(baselines[0], alarm.AnomalySensitivity)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Monday", hourly.Weekday)
+		assert.Equal(t, uint(9), hourly.Hour)
+		assert.Equal(t, 123.457, hourly.Median)
+		assert.Equal(t, 1.0, hourly.MAD)
+		assert.Equal(t, 160.494, hourly.Threshold) // 123.4567 + 3 * 12.34567
+		assert.True(t, hourly.Ready)
+	}
+}
+
+func TestDuringIncident(t *testing.T) {
+	var (
+		now   = time.Date(2016, time.March, 7, 9, 0, 0, 0, time.UTC)
+		alarm = &Alarm{AnomalyPeriod: 60}
+	)
+	resolvedAt := now.Add(-time.Hour)
+	resolved := &Incident{
+		IncidentTypeID: util.StringOrNull(incidenttypes.Slow),
+		ResolvedAt:     util.TimeOrNull(&resolvedAt),
+	}
+	resolved.CreatedAt = now.Add(-2 * time.Hour)
+	open := &Incident{IncidentTypeID: util.StringOrNull(incidenttypes.Slow)}
+	open.CreatedAt = now.Add(-10 * time.Minute)
+	failed := &Incident{IncidentTypeID: util.StringOrNull(incidenttypes.Timeout)}
+	failed.CreatedAt = now.Add(-5 * time.Hour)
+	incidents := []*Incident{open, resolved, failed}
+
+	// Other incidents are ignored, their checks failed
+	assert.False(t, duringIncident(alarm, incidents, now.Add(-4*time.Hour)))
+	// Before the anomaly period which led to the incident
+	assert.False(t, duringIncident(alarm, incidents, now.Add(-3*time.Hour)))
+	// Checks which opened the incident
+	assert.True(t, duringIncident(alarm, incidents, now.Add(-2*time.Hour-time.Minute)))
+	// While the incident was open
+	assert.True(t, duringIncident(alarm, incidents, now.Add(-90*time.Minute)))
+	// After the incident was resolved
+	assert.False(t, duringIncident(alarm, incidents, now.Add(-30*time.Minute)))
+	// Incidents which are still open
+	assert.True(t, duringIncident(alarm, incidents, now))
+}
@@ -0,0 +1,191 @@
+package alarms
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/RichardKnop/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// BadgeMaxAge is how long clients and proxies can cache a badge
+	BadgeMaxAge = time.Minute
+)
+
+var (
+	// ErrInvalidBadgeToken ...
+	ErrInvalidBadgeToken = errors.New("Invalid badge token")
+
+	// badgePeriods maps badge names to uptime periods, the state badge
+	// does not need a period
+	badgePeriods = map[string]time.Duration{
+		"uptime-24h": 24 * time.Hour,
+		"uptime-7d":  7 * 24 * time.Hour,
+		"uptime-30d": 30 * 24 * time.Hour,
+	}
+
+	badgeStateColors = map[string]string{
+		alarmstates.OK:               "#4c1",
+		alarmstates.Alarm:            "#e05d44",
+		alarmstates.InsufficientData: "#9f9f9f",
+	}
+
+	badgeStateValues = map[string]string{
+		alarmstates.OK:               "up",
+		alarmstates.Alarm:            "down",
+		alarmstates.InsufficientData: "unknown",
+	}
+)
+
+// Flat badge in the widely used shields.io layout, placeholders are total
+// width, label width, value width, value color, label x, value x, label
+// and value (twice each because of the text shadow)
+var badgeSVGTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20">` +
+	`<linearGradient id="b" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+	`<mask id="a"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></mask>` +
+	`<g mask="url(#a)"><path fill="#555" d="M0 0h%[2]dv20H0z"/><path fill="%[4]s" d="M%[2]d 0h%[3]dv20H%[2]dz"/><path fill="url(#b)" d="M0 0h%[1]dv20H0z"/></g>` +
+	`<g fill="#fff" text-anchor="middle" font-family="DejaVu Sans,Verdana,Geneva,sans-serif" font-size="11">` +
+	`<text x="%[5]d" y="15" fill="#010101" fill-opacity=".3">%[7]s</text><text x="%[5]d" y="14">%[7]s</text>` +
+	`<text x="%[6]d" y="15" fill="#010101" fill-opacity=".3">%[8]s</text><text x="%[6]d" y="14">%[8]s</text>` +
+	`</g></svg>`
+
+// checkBadgeToken compares the token from a query string with the alarm's
+// badge token in constant time
+func checkBadgeToken(alarm *Alarm, token string) error {
+	if !alarm.BadgeToken.Valid || token == "" {
+		return ErrInvalidBadgeToken
+	}
+	if subtle.ConstantTimeCompare([]byte(alarm.BadgeToken.String), []byte(token)) != 1 {
+		return ErrInvalidBadgeToken
+	}
+	return nil
+}
+
+// rotateBadgeToken replaces the alarm's badge token, badge and feed URLs
+// with the old token stop working immediately
+func (s *Service) rotateBadgeToken(alarm *Alarm) error {
+	token, now := util.StringOrNull(uuid.New()), gorm.NowFunc()
+	err := s.db.Model(alarm).UpdateColumns(Alarm{
+		BadgeToken: token,
+		Model:      gorm.Model{UpdatedAt: now},
+	}).Error
+	if err != nil {
+		return err
+	}
+	alarm.BadgeToken = token
+	alarm.UpdatedAt = now
+	return nil
+}
+
+// getAlarmUptime returns percentage of time since from during which the
+// alarm did not have an open incident, time before the alarm was created
+// is not counted
+func (s *Service) getAlarmUptime(alarm *Alarm, from, now time.Time) (float64, error) {
+	if alarm.CreatedAt.After(from) {
+		from = alarm.CreatedAt
+	}
+
+	incidents, err := s.findIncidentsSince([]uint{alarm.ID}, from)
+	if err != nil {
+		return 0, err
+	}
+
+	return getWindowUptime(
+		getDowntimePeriods(incidents, []*Alarm{alarm}, now),
+		from,
+		now,
+	), nil
+}
+
+// getWindowUptime returns percentage of the window not covered by downtime
+// periods, periods are expected to be merged so they do not overlap
+func getWindowUptime(periods []*downtimePeriod, from, to time.Time) float64 {
+	total := to.Sub(from)
+	if total <= 0 {
+		return 100
+	}
+
+	downtime := windowDowntime(periods, from, to)
+
+	return float64(total-downtime) / float64(total) * 100
+}
+
+// renderStateBadge renders an SVG badge with the current alarm state
+func renderStateBadge(state string) []byte {
+	value, ok := badgeStateValues[state]
+	if !ok {
+		value = badgeStateValues[alarmstates.InsufficientData]
+	}
+	color, ok := badgeStateColors[state]
+	if !ok {
+		color = badgeStateColors[alarmstates.InsufficientData]
+	}
+	return renderBadge("status", value, color)
+}
+
+// renderUptimeBadge renders an SVG badge with an uptime percentage
+func renderUptimeBadge(label string, uptime float64) []byte {
+	return renderBadge(label, formatBadgeUptime(uptime), badgeUptimeColor(uptime))
+}
+
+// formatBadgeUptime formats uptime with precision which still shows
+// a difference between 99.9% and 99.99%
+func formatBadgeUptime(uptime float64) string {
+	if uptime >= 100 {
+		return "100%"
+	}
+	if uptime >= 99 {
+		// Truncate rather than round so a short outage never shows as 100%
+		return fmt.Sprintf("%.2f%%", math.Floor(uptime*100+1e-9)/100)
+	}
+	return fmt.Sprintf("%.1f%%", math.Floor(uptime*10+1e-9)/10)
+}
+
+// badgeUptimeColor returns a color for an uptime percentage
+func badgeUptimeColor(uptime float64) string {
+	switch {
+	case uptime >= 99.9:
+		return "#4c1"
+	case uptime >= 99:
+		return "#97ca00"
+	case uptime >= 95:
+		return "#dfb317"
+	default:
+		return "#e05d44"
+	}
+}
+
+// renderBadge renders an SVG badge, text width is estimated as fonts are
+// not available to measure it precisely
+func renderBadge(label, value, color string) []byte {
+	labelWidth := badgeTextWidth(label)
+	valueWidth := badgeTextWidth(value)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(
+		&buf,
+		badgeSVGTemplate,
+		labelWidth+valueWidth,
+		labelWidth,
+		valueWidth,
+		color,
+		labelWidth/2,
+		labelWidth+valueWidth/2,
+		html.EscapeString(label),
+		html.EscapeString(value),
+	)
+	return buf.Bytes()
+}
+
+// badgeTextWidth estimates width of a text in 11px Verdana plus padding
+func badgeTextWidth(text string) int {
+	return len(text)*7 + 10
+}
@@ -0,0 +1,59 @@
+package alarms
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBadgeToken(t *testing.T) {
+	alarm := &Alarm{BadgeToken: util.StringOrNull("some-token")}
+
+	assert.NoError(t, checkBadgeToken(alarm, "some-token"))
+	assert.Equal(t, ErrInvalidBadgeToken, checkBadgeToken(alarm, "bogus"))
+	assert.Equal(t, ErrInvalidBadgeToken, checkBadgeToken(alarm, ""))
+	assert.Equal(t, ErrInvalidBadgeToken, checkBadgeToken(new(Alarm), ""))
+}
+
+func TestGetWindowUptime(t *testing.T) {
+	to := time.Date(2016, 6, 4, 12, 0, 0, 0, time.UTC)
+	from := to.Add(-24 * time.Hour)
+
+	// No downtime
+	assert.Equal(t, float64(100), getWindowUptime(nil, from, to))
+
+	// Downtime partially before the window is clipped
+	periods := []*downtimePeriod{
+		&downtimePeriod{start: from.Add(-time.Hour), end: from.Add(6 * time.Hour)},
+		&downtimePeriod{start: to.Add(-6 * time.Hour), end: to},
+	}
+	assert.Equal(t, float64(50), getWindowUptime(periods, from, to))
+
+	// Empty window
+	assert.Equal(t, float64(100), getWindowUptime(periods, to, to))
+}
+
+func TestFormatBadgeUptime(t *testing.T) {
+	assert.Equal(t, "100%", formatBadgeUptime(100))
+	assert.Equal(t, "99.99%", formatBadgeUptime(99.999))
+	assert.Equal(t, "99.99%", formatBadgeUptime(99.99))
+	assert.Equal(t, "99.50%", formatBadgeUptime(99.5))
+	assert.Equal(t, "87.5%", formatBadgeUptime(87.56))
+}
+
+func TestRenderBadges(t *testing.T) {
+	badge := string(renderStateBadge(alarmstates.Alarm))
+	assert.True(t, strings.HasPrefix(badge, "<svg "))
+	assert.Contains(t, badge, ">status</text>")
+	assert.Contains(t, badge, ">down</text>")
+	assert.Contains(t, badge, "#e05d44")
+
+	badge = string(renderUptimeBadge("uptime 7d", 99.95))
+	assert.Contains(t, badge, ">uptime 7d</text>")
+	assert.Contains(t, badge, ">99.95%</text>")
+	assert.Contains(t, badge, "#4c1")
+}
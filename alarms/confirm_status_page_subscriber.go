@@ -0,0 +1,42 @@
+package alarms
+
+import (
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+// Handles requests to confirm a status page subscriber's email based on
+// a reference string (GET /v1/status-page-confirmations/{reference})
+func (s *Service) confirmStatusPageSubscriberHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated account from the request context
+	_, err := accounts.GetAuthenticatedAccount(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the reference from request URI
+	vars := mux.Vars(r)
+	reference := vars["reference"]
+
+	// Fetch the subscriber we want to confirm
+	subscriber, err := s.findStatusPageSubscriberByReference(reference)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Confirm the subscriber
+	if !subscriber.Confirmed {
+		if err := s.confirmStatusPageSubscriber(subscriber); err != nil {
+			response.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// 204 no content response
+	response.NoContent(w)
+}
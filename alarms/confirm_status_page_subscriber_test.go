@@ -0,0 +1,109 @@
+package alarms
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestConfirmStatusPageSubscriber() {
+	// Create a test status page and an unconfirmed subscriber
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+	subscriber := NewStatusPageSubscriber(statusPage, "john@reese.com")
+	err = suite.db.Create(subscriber).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/status-page-confirmations/%s", subscriber.Reference),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.SetBasicAuth("test_client_1", "test_secret")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "confirm_status_page_subscriber", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockClientAuth(suite.accounts[0])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 204, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Fetch the updated subscriber
+	subscriber, err = suite.service.findStatusPageSubscriberByReference(subscriber.Reference)
+	if assert.NoError(suite.T(), err) {
+		assert.True(suite.T(), subscriber.Confirmed)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestUnsubscribeFromStatusPage() {
+	// Create a test status page and a confirmed subscriber
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+	subscriber := NewStatusPageSubscriber(statusPage, "john@reese.com")
+	subscriber.Confirmed = true
+	err = suite.db.Create(subscriber).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// Prepare a request
+	r, err := http.NewRequest(
+		"DELETE",
+		fmt.Sprintf("http://1.2.3.4/v1/status-page-subscribers/%s", subscriber.Reference),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.SetBasicAuth("test_client_1", "test_secret")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "unsubscribe_from_status_page", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockClientAuth(suite.accounts[0])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 204, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// The subscriber should be gone for good
+	var count int
+	suite.db.Unscoped().Model(new(StatusPageSubscriber)).Count(&count)
+	assert.Equal(suite.T(), 0, count)
+}
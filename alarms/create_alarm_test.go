@@ -478,6 +478,9 @@ func (suite *AlarmsTestSuite) TestCreateAlarm() {
 		SlackAlerts:            true,
 		Active:                 true,
 		State:                  alarmstates.InsufficientData,
+		SLOTarget:              DefaultSLOTarget,
+		AnomalySensitivity:     DefaultAnomalySensitivity,
+		AnomalyPeriod:          DefaultAnomalyPeriod,
 		CreatedAt:              util.FormatTime(alarm.CreatedAt),
 		UpdatedAt:              util.FormatTime(alarm.UpdatedAt),
 	}
@@ -0,0 +1,58 @@
+package alarms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+)
+
+// Handles calls to create an annotation (POST /v1/annotations)
+func (s *Service) createAnnotationHandler(w http.ResponseWriter, r *http.Request) {
+	// Annotations are authenticated by the user's annotation token
+	user, err := s.findUserByAnnotationToken(getAnnotationToken(r))
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Request body cannot be nil
+	if r.Body == nil {
+		response.Error(w, "Request body cannot be nil", http.StatusBadRequest)
+		return
+	}
+
+	// Read the request body
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Unmarshal the request body into the request prototype
+	annotationRequest := new(AnnotationRequest)
+	if err := json.Unmarshal(payload, annotationRequest); err != nil {
+		logger.ERROR.Printf("Failed to unmarshal annotation request: %s", payload)
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Create a new annotation
+	annotation, err := s.createAnnotation(user, annotationRequest)
+	if err != nil {
+		logger.ERROR.Printf("Create annotation error: %s", err)
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// Create response
+	annotationResponse, err := NewAnnotationResponse(annotation)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, annotationResponse, http.StatusCreated)
+}
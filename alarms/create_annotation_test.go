@@ -0,0 +1,221 @@
+package alarms
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestCreateAnnotationRequiresAnnotationToken() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.createAnnotationHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an annotation token")
+
+	// Tokens are not accepted in the query string
+	annotationToken, err := suite.service.rotateAnnotationToken(suite.users[1])
+	assert.NoError(suite.T(), err, "Creating annotation token failed")
+	r, err = http.NewRequest("POST", "http://1.2.3.4/v1/annotations?token="+annotationToken.Token, nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an annotation token")
+}
+
+func (suite *AlarmsTestSuite) TestCreateAnnotationValidation() {
+	// A team the user does not belong to
+	otherTeam := teams.NewTeam(suite.users[2], nil, "other team")
+	err := suite.db.Create(otherTeam).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	testCases := []struct {
+		annotationRequest *AnnotationRequest
+		expectedCode      int
+		expectedErr       error
+	}{
+		// Unknown alarm
+		{
+			&AnnotationRequest{
+				Title:    "Deploy v1.2.3",
+				AlarmIDs: []uint{suite.alarms[0].ID, 12345},
+			},
+			http.StatusBadRequest,
+			ErrAnnotationAlarmNotFound,
+		},
+		// Alarm of another user
+		{
+			&AnnotationRequest{
+				Title:    "Deploy v1.2.3",
+				AlarmIDs: []uint{suite.alarms[0].ID},
+			},
+			http.StatusBadRequest,
+			ErrAnnotationAlarmNotFound,
+		},
+		// Team the user does not belong to
+		{
+			&AnnotationRequest{
+				Title:  "Deploy v1.2.3",
+				TeamID: otherTeam.ID,
+			},
+			http.StatusForbidden,
+			ErrAnnotationTeamPermission,
+		},
+		// Neither alarms nor a team
+		{
+			&AnnotationRequest{Title: "Deploy v1.2.3"},
+			http.StatusBadRequest,
+			ErrAnnotationTargetRequired,
+		},
+	}
+
+	// The first test case is posted by the alarm owner
+	users := []*accounts.User{suite.users[1], suite.users[2], suite.users[1], suite.users[1]}
+
+	for i, testCase := range testCases {
+		annotationToken, err := suite.service.rotateAnnotationToken(users[i])
+		assert.NoError(suite.T(), err, "Creating annotation token failed")
+
+		// Prepare a request
+		payload, err := json.Marshal(testCase.annotationRequest)
+		assert.NoError(suite.T(), err, "JSON marshalling failed")
+		r, err := http.NewRequest(
+			"POST",
+			"http://1.2.3.4/v1/annotations",
+			bytes.NewBuffer(payload),
+		)
+		assert.NoError(suite.T(), err, "Request setup should not get an error")
+		r.Header.Set("Authorization", "Bearer "+annotationToken.Token)
+
+		// Mock find team
+		if testCase.annotationRequest.TeamID > 0 {
+			suite.mockFindTeamByID(otherTeam.ID, otherTeam, nil)
+		}
+
+		// And serve the request
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, r)
+
+		// Check that the mock object expectations were met
+		suite.assertMockExpectations()
+		suite.resetMocks()
+
+		// Check the status code
+		if !assert.Equal(suite.T(), testCase.expectedCode, w.Code) {
+			log.Print(w.Body.String())
+		}
+
+		// Check the response body
+		expectedJSON, err := json.Marshal(
+			map[string]string{"error": testCase.expectedErr.Error()})
+		if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+			assert.Equal(
+				suite.T(),
+				string(expectedJSON),
+				strings.TrimRight(w.Body.String(), "\n"),
+				"Body should contain JSON detailing the error",
+			)
+		}
+	}
+
+	// Nothing should have been saved
+	var count int
+	suite.db.Model(new(Annotation)).Count(&count)
+	assert.Equal(suite.T(), 0, count)
+}
+
+func (suite *AlarmsTestSuite) TestCreateAnnotation() {
+	// Create a test team
+	testTeam := teams.NewTeam(suite.users[1], nil, "test team")
+	err := suite.db.Create(testTeam).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// Prepare a request
+	payload, err := json.Marshal(&AnnotationRequest{
+		Title:     " Deploy v1.2.3 ",
+		Tags:      []string{"deploy", "api"},
+		AlarmIDs:  []uint{suite.alarms[0].ID},
+		TeamID:    testTeam.ID,
+		StartedAt: "2016-03-07T08:50:00Z",
+		EndedAt:   "2016-03-07T08:55:00Z",
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/annotations",
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	annotationToken, err := suite.service.rotateAnnotationToken(suite.users[1])
+	assert.NoError(suite.T(), err, "Creating annotation token failed")
+	r.Header.Set("Authorization", "Bearer "+annotationToken.Token)
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "create_annotation", match.Route.GetName())
+	}
+
+	// Mock find team
+	suite.mockFindTeamByID(testTeam.ID, testTeam, nil)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 201, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Fetch the created annotation
+	annotation := new(Annotation)
+	notFound := suite.db.Preload("Alarms").Preload("Tags").
+		Last(annotation).RecordNotFound()
+	assert.False(suite.T(), notFound)
+
+	// Check that the correct data was saved
+	assert.Equal(suite.T(), "Deploy v1.2.3", annotation.Title)
+	assert.Equal(suite.T(), int64(suite.users[1].ID), annotation.UserID.Int64)
+	assert.Equal(suite.T(), suite.users[1].AccountID.Int64, annotation.AccountID.Int64)
+	assert.Equal(suite.T(), int64(testTeam.ID), annotation.TeamID.Int64)
+	if assert.Equal(suite.T(), 1, len(annotation.Alarms)) {
+		assert.Equal(suite.T(), suite.alarms[0].ID, annotation.Alarms[0].ID)
+	}
+	assert.Equal(suite.T(), 2, len(annotation.Tags))
+	assert.True(suite.T(), annotation.EndedAt.Valid)
+
+	// Check the response body
+	annotationResponse := new(AnnotationResponse)
+	err = json.Unmarshal(w.Body.Bytes(), annotationResponse)
+	if assert.NoError(suite.T(), err, "JSON unmarshalling failed") {
+		assert.Equal(suite.T(), annotation.ID, annotationResponse.ID)
+		assert.Equal(suite.T(), "Deploy v1.2.3", annotationResponse.Title)
+		assert.Equal(suite.T(), []string{"deploy", "api"}, annotationResponse.Tags)
+		assert.Equal(suite.T(), []uint{suite.alarms[0].ID}, annotationResponse.AlarmIDs)
+		if assert.NotNil(suite.T(), annotationResponse.TeamID) {
+			assert.Equal(suite.T(), testTeam.ID, *annotationResponse.TeamID)
+		}
+		assert.Equal(suite.T(), "2016-03-07T08:50:00Z", annotationResponse.StartedAt)
+		if assert.NotNil(suite.T(), annotationResponse.EndedAt) {
+			assert.Equal(suite.T(), "2016-03-07T08:55:00Z", *annotationResponse.EndedAt)
+		}
+	}
+}
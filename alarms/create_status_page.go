@@ -0,0 +1,62 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+)
+
+// Handles calls to create a status page (POST /v1/status-pages)
+func (s *Service) createStatusPageHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Request body cannot be nil
+	if r.Body == nil {
+		response.Error(w, "Request body cannot be nil", http.StatusBadRequest)
+		return
+	}
+
+	// Read the request body
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Unmarshal the request body into the request prototype
+	statusPageRequest := new(StatusPageRequest)
+	if err := json.Unmarshal(payload, statusPageRequest); err != nil {
+		logger.ERROR.Printf("Failed to unmarshal status page request: %s", payload)
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Create a new status page
+	statusPage, err := s.createStatusPage(authenticatedUser, statusPageRequest)
+	if err != nil {
+		logger.ERROR.Printf("Create status page error: %s", err)
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// Create response
+	statusPageResponse, err := NewStatusPageResponse(statusPage)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Set Location header to the newly created resource
+	w.Header().Set("Location", fmt.Sprintf("/v1/status-pages/%d", statusPage.ID))
+	// Write JSON response
+	response.WriteJSON(w, statusPageResponse, http.StatusCreated)
+}
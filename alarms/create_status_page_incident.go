@@ -0,0 +1,116 @@
+package alarms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrManageStatusPageIncidentsPermission ...
+	ErrManageStatusPageIncidentsPermission = errors.New("Need permission to manage status page incidents")
+)
+
+// Handles calls to report a status page incident (POST /v1/status-pages/{id:[0-9]+}/incidents)
+func (s *Service) createStatusPageIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	statusPageID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the status page
+	statusPage, err := s.findStatusPageByID(uint(statusPageID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkManageStatusPageIncidentsPermissions(authenticatedUser, statusPage); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Request body cannot be nil
+	if r.Body == nil {
+		response.Error(w, "Request body cannot be nil", http.StatusBadRequest)
+		return
+	}
+
+	// Read the request body
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Unmarshal the request body into the request prototype
+	incidentRequest := new(StatusPageIncidentRequest)
+	if err := json.Unmarshal(payload, incidentRequest); err != nil {
+		logger.ERROR.Printf("Failed to unmarshal status page incident request: %s", payload)
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Create a new incident
+	incident, err := s.createStatusPageIncident(statusPage, incidentRequest)
+	if err != nil {
+		logger.ERROR.Printf("Create status page incident error: %s", err)
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// Create response
+	incidentResponse, err := NewStatusPageIncidentResponse(incident)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Set Location header to the newly created resource
+	w.Header().Set("Location", fmt.Sprintf(
+		"/v1/status-pages/%d/incidents/%d",
+		statusPage.ID,
+		incident.ID,
+	))
+	// Write JSON response
+	response.WriteJSON(w, incidentResponse, http.StatusCreated)
+}
+
+func checkManageStatusPageIncidentsPermissions(authenticatedUser *accounts.User, statusPage *StatusPage) error {
+	// Superusers can manage incidents of any status pages
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can manage incidents of their own status pages
+	if authenticatedUser.ID == uint(statusPage.UserID.Int64) {
+		return nil
+	}
+
+	// Team members can manage incidents of status pages of their team
+	if statusPage.Team != nil && isTeamMember(statusPage.Team, authenticatedUser) {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrManageStatusPageIncidentsPermission
+}
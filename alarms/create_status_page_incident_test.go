@@ -0,0 +1,157 @@
+package alarms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidentstatuses"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestCreateStatusPageIncidentRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.createStatusPageIncidentHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestCreateStatusPageIncidentPermission() {
+	// Create a test status page
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+
+	// Prepare a request
+	payload, err := json.Marshal(&StatusPageIncidentRequest{
+		Title:   "API outage",
+		Status:  incidentstatuses.Investigating,
+		Message: "We are looking into it",
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("http://1.2.3.4/v1/status-pages/%d/incidents", statusPage.ID),
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication, the user does not own the status page
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+}
+
+func (suite *AlarmsTestSuite) TestCreateStatusPageIncident() {
+	// Create a test status page with a confirmed subscriber
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+	subscriber := NewStatusPageSubscriber(statusPage, "john@reese.com")
+	subscriber.Confirmed = true
+	err = suite.db.Create(subscriber).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// Prepare a request
+	payload, err := json.Marshal(&StatusPageIncidentRequest{
+		Title:   "API outage",
+		Status:  incidentstatuses.Investigating,
+		Message: "We are looking into it",
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("http://1.2.3.4/v1/status-pages/%d/incidents", statusPage.ID),
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "create_status_page_incident", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock incident update email
+	suite.mockStatusPageIncidentUpdateEmail()
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Sleep for the email goroutine to finish
+	time.Sleep(15 * time.Millisecond)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 201, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	resp := new(struct {
+		ID       uint   `json:"id"`
+		Status   string `json:"status"`
+		Embedded struct {
+			Updates []*StatusPageIncidentUpdateResponse `json:"updates"`
+		} `json:"_embedded"`
+	})
+	err = json.Unmarshal(w.Body.Bytes(), resp)
+	if !assert.NoError(suite.T(), err, "JSON unmarshalling failed") {
+		return
+	}
+	assert.Equal(suite.T(), incidentstatuses.Investigating, resp.Status)
+	if assert.Equal(suite.T(), 1, len(resp.Embedded.Updates)) {
+		assert.Equal(suite.T(), "We are looking into it", resp.Embedded.Updates[0].Message)
+	}
+
+	// Post an update resolving the incident
+	incident, err := suite.service.findStatusPageIncidentByID(statusPage, resp.ID)
+	assert.NoError(suite.T(), err, "Fetching the incident failed")
+	suite.mockStatusPageIncidentUpdateEmail()
+	_, err = suite.service.createStatusPageIncidentUpdate(incident, &StatusPageIncidentUpdateRequest{
+		Status:  incidentstatuses.Resolved,
+		Message: "Fixed",
+	})
+	assert.NoError(suite.T(), err, "Posting the update failed")
+	time.Sleep(15 * time.Millisecond)
+	suite.assertMockExpectations()
+
+	incident, err = suite.service.findStatusPageIncidentByID(statusPage, resp.ID)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), incidentstatuses.Resolved, incident.Status)
+		assert.True(suite.T(), incident.ResolvedAt.Valid)
+		assert.Equal(suite.T(), 2, len(incident.Updates))
+	}
+}
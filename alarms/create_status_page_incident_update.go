@@ -0,0 +1,94 @@
+package alarms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+// Handles calls to post a status page incident update
+// (POST /v1/status-pages/{id:[0-9]+}/incidents/{incident_id:[0-9]+}/updates)
+func (s *Service) createStatusPageIncidentUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the ids from request URI and type assert them
+	vars := mux.Vars(r)
+	statusPageID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	incidentID, err := strconv.Atoi(vars["incident_id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the status page
+	statusPage, err := s.findStatusPageByID(uint(statusPageID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkManageStatusPageIncidentsPermissions(authenticatedUser, statusPage); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Fetch the incident
+	incident, err := s.findStatusPageIncidentByID(statusPage, uint(incidentID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Request body cannot be nil
+	if r.Body == nil {
+		response.Error(w, "Request body cannot be nil", http.StatusBadRequest)
+		return
+	}
+
+	// Read the request body
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Unmarshal the request body into the request prototype
+	updateRequest := new(StatusPageIncidentUpdateRequest)
+	if err := json.Unmarshal(payload, updateRequest); err != nil {
+		logger.ERROR.Printf("Failed to unmarshal status page incident update request: %s", payload)
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Post the update
+	if _, err := s.createStatusPageIncidentUpdate(incident, updateRequest); err != nil {
+		logger.ERROR.Printf("Create status page incident update error: %s", err)
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// Create response
+	incidentResponse, err := NewStatusPageIncidentResponse(incident)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, incidentResponse, http.StatusCreated)
+}
@@ -0,0 +1,196 @@
+package alarms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestCreateStatusPageRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.createStatusPageHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestCreateStatusPageAlarmNotFound() {
+	// Prepare a request, alarm 1 belongs to a different user
+	payload, err := json.Marshal(&StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+		Components: []*StatusPageComponentRequest{
+			&StatusPageComponentRequest{Name: "API", AlarmIDs: []uint{1}},
+		},
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/status-pages",
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// Count before
+	var countBefore int
+	suite.db.Model(new(StatusPage)).Count(&countBefore)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 400, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Count after
+	var countAfter int
+	suite.db.Model(new(StatusPage)).Count(&countAfter)
+	assert.Equal(suite.T(), countBefore, countAfter)
+
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrStatusPageAlarmNotFound.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestCreateStatusPageInvalidSlug() {
+	// Prepare a request
+	payload, err := json.Marshal(&StatusPageRequest{
+		Slug:  "Acme Status",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/status-pages",
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 400, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrInvalidStatusPageSlug.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestCreateStatusPage() {
+	// Prepare a request
+	payload, err := json.Marshal(&StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+		Components: []*StatusPageComponentRequest{
+			&StatusPageComponentRequest{Name: "API", AlarmIDs: []uint{1, 2}},
+			&StatusPageComponentRequest{Name: "Website", AlarmIDs: []uint{3}},
+		},
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/status-pages",
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "create_status_page", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Count before
+	var countBefore int
+	suite.db.Model(new(StatusPage)).Count(&countBefore)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 201, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Count after
+	var countAfter int
+	suite.db.Model(new(StatusPage)).Count(&countAfter)
+	assert.Equal(suite.T(), countBefore+1, countAfter)
+
+	// Fetch the created status page
+	statusPage, err := suite.service.findStatusPageBySlug("acme")
+	if !assert.NoError(suite.T(), err) {
+		return
+	}
+
+	// Check that the correct data was saved
+	assert.Equal(suite.T(), suite.users[1].ID, uint(statusPage.UserID.Int64))
+	assert.False(suite.T(), statusPage.TeamID.Valid)
+	assert.Equal(suite.T(), "Acme Status", statusPage.Title)
+	if assert.Equal(suite.T(), 2, len(statusPage.Components)) {
+		assert.Equal(suite.T(), "API", statusPage.Components[0].Name)
+		assert.Equal(suite.T(), 2, len(statusPage.Components[0].Alarms))
+		assert.Equal(suite.T(), "Website", statusPage.Components[1].Name)
+		assert.Equal(suite.T(), 1, len(statusPage.Components[1].Alarms))
+	}
+
+	// Check the Location header
+	assert.Equal(
+		suite.T(),
+		fmt.Sprintf("/v1/status-pages/%d", statusPage.ID),
+		w.Header().Get("Location"),
+	)
+}
@@ -0,0 +1,77 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrDeleteStatusPagePermission ...
+	ErrDeleteStatusPagePermission = errors.New("Need permission to delete status page")
+)
+
+// Handles calls to delete a status page (DELETE /v1/status-pages/{id:[0-9]+})
+func (s *Service) deleteStatusPageHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	statusPageID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the status page we want to delete
+	statusPage, err := s.findStatusPageByID(uint(statusPageID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkDeleteStatusPagePermissions(authenticatedUser, statusPage); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Delete the status page
+	if err := s.deleteStatusPage(statusPage); err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 204 no content response
+	response.NoContent(w)
+}
+
+func checkDeleteStatusPagePermissions(authenticatedUser *accounts.User, statusPage *StatusPage) error {
+	// Superusers can delete any status pages
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can delete their own status pages
+	if authenticatedUser.ID == uint(statusPage.UserID.Int64) {
+		return nil
+	}
+
+	// Team members can delete status pages of their team
+	if statusPage.Team != nil && isTeamMember(statusPage.Team, authenticatedUser) {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrDeleteStatusPagePermission
+}
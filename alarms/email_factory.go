@@ -2,6 +2,7 @@ package alarms
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
 	"github.com/RichardKnop/pinglist-api/config"
@@ -89,6 +90,44 @@ Kind Regards,
 %s Team
 `
 
+var statusPageSubscriptionEmailSubjectTemplate = "Confirm your subscription to %s"
+
+var statusPageSubscriptionEmailTextTemplate = `
+Hello,
+
+You have subscribed to incident updates of %s.
+
+Please confirm your subscription: %s
+
+If you did not subscribe, just ignore this email.
+
+To unsubscribe at any time: %s
+
+Kind Regards,
+
+%s Team
+`
+
+var statusPageIncidentUpdateEmailSubjectTemplate = "[%s] %s: %s"
+
+var statusPageIncidentUpdateEmailTextTemplate = `
+Hello,
+
+%s has a new incident update.
+
+%s - %s [UTC]
+
+%s
+
+Follow the status page: %s
+
+To unsubscribe from these emails: %s
+
+Kind Regards,
+
+%s Team
+`
+
 // EmailFactory facilitates construction of email.Email objects
 type EmailFactory struct {
 	cnf *config.Config
@@ -197,3 +236,99 @@ func (f *EmailFactory) NewIncidentsResolvedEmail(alarm *Alarm) *email.Email {
 		Text: emailText,
 	}
 }
+
+// NewStatusPageSubscriptionEmail returns a status page subscription
+// confirmation email
+func (f *EmailFactory) NewStatusPageSubscriptionEmail(subscriber *StatusPageSubscriber) *email.Email {
+	// The email subject
+	subject := fmt.Sprintf(
+		statusPageSubscriptionEmailSubjectTemplate,
+		subscriber.StatusPage.Title,
+	)
+
+	// Replace placeholders in the email template
+	emailText := fmt.Sprintf(
+		statusPageSubscriptionEmailTextTemplate,
+		subscriber.StatusPage.Title,
+		f.statusPageSubscriptionConfirmLink(subscriber),
+		f.statusPageUnsubscribeLink(subscriber),
+		f.cnf.Web.AppHost,
+	)
+
+	return &email.Email{
+		Subject: subject,
+		Recipients: []*email.Recipient{&email.Recipient{
+			Email: subscriber.Email,
+		}},
+		From: &email.Sender{
+			Email: fmt.Sprintf("noreply@%s", f.cnf.Web.AppHost),
+			Name:  fmt.Sprintf("NOREPLY %s", f.cnf.Web.AppHost),
+		},
+		Text: emailText,
+	}
+}
+
+// NewStatusPageIncidentUpdateEmail returns a status page incident update
+// notification email
+func (f *EmailFactory) NewStatusPageIncidentUpdateEmail(subscriber *StatusPageSubscriber, update *StatusPageIncidentUpdate) *email.Email {
+	// The email subject
+	subject := fmt.Sprintf(
+		statusPageIncidentUpdateEmailSubjectTemplate,
+		subscriber.StatusPage.Title,
+		strings.Title(update.Status),
+		update.StatusPageIncident.Title,
+	)
+
+	// Public status page link
+	statusPageLink := fmt.Sprintf(
+		"%s://%s/status/%s",
+		f.cnf.Web.AppScheme,
+		f.cnf.Web.AppHost,
+		subscriber.StatusPage.Slug,
+	)
+
+	// Replace placeholders in the email template
+	emailText := fmt.Sprintf(
+		statusPageIncidentUpdateEmailTextTemplate,
+		subscriber.StatusPage.Title,
+		strings.Title(update.Status),
+		update.CreatedAt.UTC().Format(EmailTimeFormat),
+		update.Message,
+		statusPageLink,
+		f.statusPageUnsubscribeLink(subscriber),
+		f.cnf.Web.AppHost,
+	)
+
+	return &email.Email{
+		Subject: subject,
+		Recipients: []*email.Recipient{&email.Recipient{
+			Email: subscriber.Email,
+		}},
+		From: &email.Sender{
+			Email: fmt.Sprintf("noreply@%s", f.cnf.Web.AppHost),
+			Name:  fmt.Sprintf("NOREPLY %s", f.cnf.Web.AppHost),
+		},
+		Text: emailText,
+	}
+}
+
+// statusPageSubscriptionConfirmLink is where subscribers confirm their email
+func (f *EmailFactory) statusPageSubscriptionConfirmLink(subscriber *StatusPageSubscriber) string {
+	return fmt.Sprintf(
+		"%s://%s/web/confirm-status-page-subscription/%s",
+		f.cnf.Web.AppScheme,
+		f.cnf.Web.AppHost,
+		subscriber.Reference,
+	)
+}
+
+// statusPageUnsubscribeLink works without logging in as it only
+// needs the subscriber reference
+func (f *EmailFactory) statusPageUnsubscribeLink(subscriber *StatusPageSubscriber) string {
+	return fmt.Sprintf(
+		"%s://%s/web/unsubscribe-status-page/%s",
+		f.cnf.Web.AppScheme,
+		f.cnf.Web.AppHost,
+		subscriber.Reference,
+	)
+}
@@ -8,4 +8,6 @@ import (
 type EmailFactoryInterface interface {
 	NewIncidentEmail(incident *Incident) *email.Email
 	NewIncidentsResolvedEmail(alarm *Alarm) *email.Email
+	NewStatusPageSubscriptionEmail(subscriber *StatusPageSubscriber) *email.Email
+	NewStatusPageIncidentUpdateEmail(subscriber *StatusPageSubscriber, update *StatusPageIncidentUpdate) *email.Email
 }
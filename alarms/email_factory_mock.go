@@ -41,3 +41,35 @@ func (_m *EmailFactoryMock) NewIncidentsResolvedEmail(alarm *Alarm) *email.Email
 
 	return r0
 }
+
+// NewStatusPageSubscriptionEmail ...
+func (_m *EmailFactoryMock) NewStatusPageSubscriptionEmail(subscriber *StatusPageSubscriber) *email.Email {
+	ret := _m.Called(subscriber)
+
+	var r0 *email.Email
+	if rf, ok := ret.Get(0).(func(*StatusPageSubscriber) *email.Email); ok {
+		r0 = rf(subscriber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*email.Email)
+		}
+	}
+
+	return r0
+}
+
+// NewStatusPageIncidentUpdateEmail ...
+func (_m *EmailFactoryMock) NewStatusPageIncidentUpdateEmail(subscriber *StatusPageSubscriber, update *StatusPageIncidentUpdate) *email.Email {
+	ret := _m.Called(subscriber, update)
+
+	var r0 *email.Email
+	if rf, ok := ret.Get(0).(func(*StatusPageSubscriber, *StatusPageIncidentUpdate) *email.Email); ok {
+		r0 = rf(subscriber, update)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*email.Email)
+		}
+	}
+
+	return r0
+}
@@ -316,3 +316,92 @@ pingli.st Team
 `
 	assert.Equal(t, expectedText, email.Text)
 }
+
+func TestNewStatusPageSubscriptionEmail(t *testing.T) {
+	emailFactory := NewEmailFactory(&config.Config{
+		Web: config.WebConfig{
+			AppScheme: "https",
+			AppHost:   "pingli.st",
+		},
+	})
+
+	subscriber := &StatusPageSubscriber{
+		StatusPage: &StatusPage{Slug: "acme", Title: "Acme Status"},
+		Email:      "john@reese",
+		Reference:  "some-reference",
+	}
+	email := emailFactory.NewStatusPageSubscriptionEmail(subscriber)
+
+	assert.Equal(t, "Confirm your subscription to Acme Status", email.Subject)
+	assert.Equal(t, 1, len(email.Recipients))
+	assert.Equal(t, "john@reese", email.Recipients[0].Email)
+	assert.Equal(t, "noreply@pingli.st", email.From.Email)
+
+	expectedText := `
+Hello,
+
+You have subscribed to incident updates of Acme Status.
+
+Please confirm your subscription: https://pingli.st/web/confirm-status-page-subscription/some-reference
+
+If you did not subscribe, just ignore this email.
+
+To unsubscribe at any time: https://pingli.st/web/unsubscribe-status-page/some-reference
+
+Kind Regards,
+
+pingli.st Team
+`
+	assert.Equal(t, expectedText, email.Text)
+}
+
+func TestNewStatusPageIncidentUpdateEmail(t *testing.T) {
+	emailFactory := NewEmailFactory(&config.Config{
+		Web: config.WebConfig{
+			AppScheme: "https",
+			AppHost:   "pingli.st",
+		},
+	})
+
+	statusPage := &StatusPage{Slug: "acme", Title: "Acme Status"}
+	subscriber := &StatusPageSubscriber{
+		StatusPage: statusPage,
+		Email:      "john@reese",
+		Reference:  "some-reference",
+	}
+	update := &StatusPageIncidentUpdate{
+		Model: gorm.Model{
+			CreatedAt: time.Date(2016, 6, 4, 11, 26, 15, 0, time.UTC),
+		},
+		StatusPageIncident: &StatusPageIncident{
+			StatusPage: statusPage,
+			Title:      "API outage",
+		},
+		Status:  "identified",
+		Message: "A bad deploy, rolling back",
+	}
+	email := emailFactory.NewStatusPageIncidentUpdateEmail(subscriber, update)
+
+	assert.Equal(t, "[Acme Status] Identified: API outage", email.Subject)
+	assert.Equal(t, 1, len(email.Recipients))
+	assert.Equal(t, "john@reese", email.Recipients[0].Email)
+
+	expectedText := `
+Hello,
+
+Acme Status has a new incident update.
+
+Identified - Sat Jun 4 11:26:15 2016 [UTC]
+
+A bad deploy, rolling back
+
+Follow the status page: https://pingli.st/status/acme
+
+To unsubscribe from these emails: https://pingli.st/web/unsubscribe-status-page/some-reference
+
+Kind Regards,
+
+pingli.st Team
+`
+	assert.Equal(t, expectedText, email.Text)
+}
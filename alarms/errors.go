@@ -2,13 +2,49 @@ package alarms
 
 import (
 	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/teams"
 )
 
 var (
 	errStatusCodeMap = map[error]int{
 		ErrMaxAlarmsLimitReached: http.StatusBadRequest,
 		ErrMaxResponseTimeTooBig: http.StatusBadRequest,
+		ErrInvalidTag:            http.StatusBadRequest,
 		ErrRegionNotFound:        http.StatusBadRequest,
+		ErrInvalidSLOTarget:      http.StatusBadRequest,
+
+		ErrInvalidAnomalySensitivity: http.StatusBadRequest,
+		ErrAnomalyPeriodTooBig:       http.StatusBadRequest,
+
+		ErrInvalidStatusPageSlug:           http.StatusBadRequest,
+		ErrNumericStatusPageSlug:           http.StatusBadRequest,
+		ErrStatusPageSlugTaken:             http.StatusBadRequest,
+		ErrStatusPageTitleRequired:         http.StatusBadRequest,
+		ErrStatusPageComponentNameRequired: http.StatusBadRequest,
+		ErrStatusPageAlarmNotFound:         http.StatusBadRequest,
+		ErrStatusPageTeamPermission:        http.StatusForbidden,
+		ErrStatusPageTeamChangePermission:  http.StatusForbidden,
+		teams.ErrTeamNotFound:              http.StatusBadRequest,
+
+		ErrInvalidStatusPageIncidentStatus:   http.StatusBadRequest,
+		ErrStatusPageIncidentTitleRequired:   http.StatusBadRequest,
+		ErrStatusPageIncidentMessageRequired: http.StatusBadRequest,
+		ErrInvalidSubscriberEmail:            http.StatusBadRequest,
+
+		ErrAnnotationTitleRequired:   http.StatusBadRequest,
+		ErrAnnotationTitleTooLong:    http.StatusBadRequest,
+		ErrInvalidAnnotationTime:     http.StatusBadRequest,
+		ErrAnnotationEndsBeforeStart: http.StatusBadRequest,
+		ErrAnnotationAlarmNotFound:   http.StatusBadRequest,
+		ErrAnnotationTargetRequired:  http.StatusBadRequest,
+		ErrAnnotationTeamPermission:  http.StatusForbidden,
+
+		ErrAgentRegionNotFound:   http.StatusForbidden,
+		ErrAgentAlarmNotFound:    http.StatusNotFound,
+		ErrInvalidLeaseWatermark: http.StatusBadRequest,
+		ErrInvalidCheckStartedAt: http.StatusBadRequest,
+		ErrCheckAlreadyTriggered: http.StatusConflict,
 	}
 )
 
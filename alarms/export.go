@@ -0,0 +1,210 @@
+package alarms
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+var (
+	incidentExportHeader = []string{
+		"id",
+		"alarm_id",
+		"type",
+		"http_code",
+		"response_time",
+		"error_message",
+		"acknowledged_at",
+		"resolved_at",
+		"created_at",
+	}
+	responseTimeExportHeader = []string{
+		"timestamp",
+		"value",
+		"failed",
+		"dns_lookup",
+		"connect",
+		"tls_handshake",
+		"first_byte",
+		"transfer",
+	}
+	checkResultExportHeader = []string{
+		"timestamp",
+		"region",
+		"status_code",
+		"response_size",
+		"outcome",
+		"error",
+	}
+)
+
+// incidentExportRecord is a row of an incidents export, times are in
+// nanoseconds, missing values are null in NDJSON and empty in CSV
+type incidentExportRecord struct {
+	ID             uint    `json:"id"`
+	AlarmID        uint    `json:"alarm_id"`
+	Type           string  `json:"type"`
+	HTTPCode       *int64  `json:"http_code"`
+	ResponseTime   *int64  `json:"response_time"`
+	ErrorMessage   *string `json:"error_message"`
+	AcknowledgedAt *string `json:"acknowledged_at"`
+	ResolvedAt     *string `json:"resolved_at"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+// newIncidentExportRecord creates new incidentExportRecord instance
+func newIncidentExportRecord(incident *Incident) *incidentExportRecord {
+	record := &incidentExportRecord{
+		ID:        incident.ID,
+		AlarmID:   uint(incident.AlarmID.Int64),
+		Type:      incident.IncidentTypeID.String,
+		CreatedAt: util.FormatTime(incident.CreatedAt),
+	}
+	if incident.HTTPCode.Valid {
+		record.HTTPCode = &incident.HTTPCode.Int64
+	}
+	if incident.ResponseTime.Valid {
+		record.ResponseTime = &incident.ResponseTime.Int64
+	}
+	if incident.ErrorMessage.Valid {
+		record.ErrorMessage = &incident.ErrorMessage.String
+	}
+	if incident.AcknowledgedAt.Valid {
+		acknowledgedAt := util.FormatTime(incident.AcknowledgedAt.Time)
+		record.AcknowledgedAt = &acknowledgedAt
+	}
+	if incident.ResolvedAt.Valid {
+		resolvedAt := util.FormatTime(incident.ResolvedAt.Time)
+		record.ResolvedAt = &resolvedAt
+	}
+	return record
+}
+
+// CSVRecord returns values in the order of incidentExportHeader
+func (r *incidentExportRecord) CSVRecord() []string {
+	return []string{
+		strconv.FormatUint(uint64(r.ID), 10),
+		strconv.FormatUint(uint64(r.AlarmID), 10),
+		r.Type,
+		int64OrEmpty(r.HTTPCode),
+		int64OrEmpty(r.ResponseTime),
+		stringOrEmpty(r.ErrorMessage),
+		stringOrEmpty(r.AcknowledgedAt),
+		stringOrEmpty(r.ResolvedAt),
+		r.CreatedAt,
+	}
+}
+
+// responseTimeExportRecord is a row of a response times export, the value
+// and request phases are in nanoseconds
+type responseTimeExportRecord struct {
+	Timestamp    string `json:"timestamp"`
+	Value        int64  `json:"value"`
+	Failed       bool   `json:"failed"`
+	DNSLookup    int64  `json:"dns_lookup"`
+	Connect      int64  `json:"connect"`
+	TLSHandshake int64  `json:"tls_handshake"`
+	FirstByte    int64  `json:"first_byte"`
+	Transfer     int64  `json:"transfer"`
+}
+
+// newResponseTimeExportRecord creates new responseTimeExportRecord instance
+func newResponseTimeExportRecord(responseTime *metrics.ResponseTime) *responseTimeExportRecord {
+	return &responseTimeExportRecord{
+		Timestamp:    util.FormatTime(responseTime.Timestamp),
+		Value:        responseTime.Value,
+		Failed:       responseTime.Failed,
+		DNSLookup:    responseTime.DNSLookup,
+		Connect:      responseTime.Connect,
+		TLSHandshake: responseTime.TLSHandshake,
+		FirstByte:    responseTime.FirstByte,
+		Transfer:     responseTime.Transfer,
+	}
+}
+
+// CSVRecord returns values in the order of responseTimeExportHeader
+func (r *responseTimeExportRecord) CSVRecord() []string {
+	return []string{
+		r.Timestamp,
+		strconv.FormatInt(r.Value, 10),
+		strconv.FormatBool(r.Failed),
+		strconv.FormatInt(r.DNSLookup, 10),
+		strconv.FormatInt(r.Connect, 10),
+		strconv.FormatInt(r.TLSHandshake, 10),
+		strconv.FormatInt(r.FirstByte, 10),
+		strconv.FormatInt(r.Transfer, 10),
+	}
+}
+
+// checkResultExportRecord is a row of a checks export
+type checkResultExportRecord struct {
+	Timestamp    string `json:"timestamp"`
+	Region       string `json:"region"`
+	StatusCode   int    `json:"status_code"`
+	ResponseSize int64  `json:"response_size"`
+	Outcome      string `json:"outcome"`
+	Error        string `json:"error"`
+}
+
+// newCheckResultExportRecord creates new checkResultExportRecord instance
+func newCheckResultExportRecord(checkResult *metrics.CheckResult) *checkResultExportRecord {
+	return &checkResultExportRecord{
+		Timestamp:    util.FormatTime(checkResult.Timestamp),
+		Region:       checkResult.Region,
+		StatusCode:   checkResult.StatusCode,
+		ResponseSize: checkResult.ResponseSize,
+		Outcome:      checkResult.Outcome,
+		Error:        checkResult.Error,
+	}
+}
+
+// CSVRecord returns values in the order of checkResultExportHeader
+func (r *checkResultExportRecord) CSVRecord() []string {
+	return []string{
+		r.Timestamp,
+		r.Region,
+		strconv.Itoa(r.StatusCode),
+		strconv.FormatInt(r.ResponseSize, 10),
+		r.Outcome,
+		r.Error,
+	}
+}
+
+// iterateIncidents calls fn for every incident of the alarm opened in the
+// time range, oldest first, reading rows from a database cursor
+func (s *Service) iterateIncidents(alarm *Alarm, from, to *time.Time, fn func(*Incident) error) error {
+	rows, err := s.incidentsQuery(nil, alarm, nil, from, to).
+		Order("alarm_incidents.created_at").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		incident := new(Incident)
+		if err := s.db.ScanRows(rows, incident); err != nil {
+			return err
+		}
+		if err := fn(incident); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func int64OrEmpty(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func stringOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,124 @@
+package alarms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrExportAlarmChecksPermission ...
+	ErrExportAlarmChecksPermission = errors.New("Need permission to export alarm checks")
+)
+
+// Handles calls to export alarm checks as CSV or NDJSON
+// (GET /v1/alarms/{id:[0-9]+}/checks/export)
+func (s *Service) exportAlarmChecksHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkExportAlarmChecksPermissions(authenticatedUser, alarm); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Negotiate the export format
+	format, err := response.GetExportFormat(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get from / to, history is limited by the alarm owner's plan
+	_, from, to, err := metrics.GetParamsFromQueryString(
+		r,
+		s.metricsService.GetMetricsRetention(alarm.User.ID),
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get the optional outcome filter
+	outcome, err := metrics.GetOutcomeFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Start streaming the export
+	exportWriter, err := response.NewExportWriter(
+		w,
+		format,
+		fmt.Sprintf("alarm-%d-checks", alarm.ID),
+		checkResultExportHeader,
+	)
+	if err != nil {
+		logger.ERROR.Printf("Export alarm checks error: %s", err)
+		return
+	}
+
+	// Write check results as they are read from the database
+	err = s.metricsService.IterateCheckResults(
+		int(alarm.ID),
+		outcome,
+		from,
+		to,
+		func(checkResult *metrics.CheckResult) error {
+			return exportWriter.Write(newCheckResultExportRecord(checkResult))
+		},
+	)
+	if err != nil {
+		// Headers have already been sent, the export ends early
+		logger.ERROR.Printf("Export alarm checks error: %s", err)
+		return
+	}
+
+	// Flush the remaining records
+	if err := exportWriter.Flush(); err != nil {
+		logger.ERROR.Printf("Export alarm checks error: %s", err)
+	}
+}
+
+func checkExportAlarmChecksPermissions(authenticatedUser *accounts.User, alarm *Alarm) error {
+	// Superusers can export any alarm checks
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can export their own alarm checks
+	if authenticatedUser.ID == alarm.User.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrExportAlarmChecksPermission
+}
@@ -0,0 +1,108 @@
+package alarms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/pagination"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrExportAlarmIncidentsPermission ...
+	ErrExportAlarmIncidentsPermission = errors.New("Need permission to export alarm incidents")
+)
+
+// Handles calls to export alarm incidents as CSV or NDJSON
+// (GET /v1/alarms/{id:[0-9]+}/incidents/export)
+func (s *Service) exportAlarmIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkExportAlarmIncidentsPermissions(authenticatedUser, alarm); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Negotiate the export format
+	format, err := response.GetExportFormat(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get from / to
+	from, to, err := pagination.GetFromTo(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Start streaming the export
+	exportWriter, err := response.NewExportWriter(
+		w,
+		format,
+		fmt.Sprintf("alarm-%d-incidents", alarm.ID),
+		incidentExportHeader,
+	)
+	if err != nil {
+		logger.ERROR.Printf("Export alarm incidents error: %s", err)
+		return
+	}
+
+	// Write incidents as they are read from the database
+	err = s.iterateIncidents(alarm, from, to, func(incident *Incident) error {
+		return exportWriter.Write(newIncidentExportRecord(incident))
+	})
+	if err != nil {
+		// Headers have already been sent, the export ends early
+		logger.ERROR.Printf("Export alarm incidents error: %s", err)
+		return
+	}
+
+	// Flush the remaining records
+	if err := exportWriter.Flush(); err != nil {
+		logger.ERROR.Printf("Export alarm incidents error: %s", err)
+	}
+}
+
+func checkExportAlarmIncidentsPermissions(authenticatedUser *accounts.User, alarm *Alarm) error {
+	// Superusers can export any alarm incidents
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can export their own alarm incidents
+	if authenticatedUser.ID == alarm.User.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrExportAlarmIncidentsPermission
+}
@@ -0,0 +1,189 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestExportAlarmIncidentsRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.exportAlarmIncidentsHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestExportAlarmIncidentsWithoutPermission() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/incidents/export", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "export_alarm_incidents", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrExportAlarmIncidentsPermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestExportAlarmIncidentsInvalidFormat() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/incidents/export?format=xml", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 400, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": response.ErrInvalidExportFormat.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestExportAlarmIncidentsCSV() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/incidents/export?from=2016-02-15T00:00:00Z",
+			suite.alarms[0].ID,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+	r.Header.Set("Accept", "text/csv")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response
+	assert.Equal(suite.T(), "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(
+		suite.T(),
+		"id,alarm_id,type,http_code,response_time,error_message,acknowledged_at,resolved_at,created_at\n"+
+			"2,1,timeout,,,,,2016-02-15T22:30:12Z,2016-02-15T22:29:12Z\n"+
+			"3,1,other,,,Something else went wrong,,2016-02-20T22:30:12Z,2016-02-20T22:29:12Z\n"+
+			"4,1,bad code,500,,,,,2016-02-25T22:32:12Z\n",
+		w.Body.String(),
+	)
+}
+
+func (suite *AlarmsTestSuite) TestExportAlarmIncidentsNDJSON() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/incidents/export?format=ndjson",
+			suite.alarms[0].ID,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response, one incident per line oldest first
+	assert.Equal(suite.T(), "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimRight(w.Body.String(), "\n"), "\n")
+	if assert.Equal(suite.T(), 4, len(lines)) {
+		for i, line := range lines {
+			record := new(incidentExportRecord)
+			assert.NoError(suite.T(), json.Unmarshal([]byte(line), record))
+			assert.Equal(suite.T(), suite.incidents[i].ID, record.ID)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package alarms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrExportAlarmResponseTimesPermission ...
+	ErrExportAlarmResponseTimesPermission = errors.New("Need permission to export alarm response times")
+)
+
+// Handles calls to export alarm response times as CSV or NDJSON
+// (GET /v1/alarms/{id:[0-9]+}/response-times/export)
+func (s *Service) exportAlarmResponseTimesHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkExportAlarmResponseTimesPermissions(authenticatedUser, alarm); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Negotiate the export format
+	format, err := response.GetExportFormat(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get from / to, history is limited by the alarm owner's plan
+	_, from, to, err := metrics.GetParamsFromQueryString(
+		r,
+		s.metricsService.GetMetricsRetention(alarm.User.ID),
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Start streaming the export
+	exportWriter, err := response.NewExportWriter(
+		w,
+		format,
+		fmt.Sprintf("alarm-%d-response-times", alarm.ID),
+		responseTimeExportHeader,
+	)
+	if err != nil {
+		logger.ERROR.Printf("Export alarm response times error: %s", err)
+		return
+	}
+
+	// Write response times as they are read from the database
+	err = s.metricsService.IterateResponseTimes(
+		int(alarm.ID),
+		from,
+		to,
+		func(responseTime *metrics.ResponseTime) error {
+			return exportWriter.Write(newResponseTimeExportRecord(responseTime))
+		},
+	)
+	if err != nil {
+		// Headers have already been sent, the export ends early
+		logger.ERROR.Printf("Export alarm response times error: %s", err)
+		return
+	}
+
+	// Flush the remaining records
+	if err := exportWriter.Flush(); err != nil {
+		logger.ERROR.Printf("Export alarm response times error: %s", err)
+	}
+}
+
+func checkExportAlarmResponseTimesPermissions(authenticatedUser *accounts.User, alarm *Alarm) error {
+	// Superusers can export any alarm response times
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can export their own alarm response times
+	if authenticatedUser.ID == alarm.User.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrExportAlarmResponseTimesPermission
+}
@@ -0,0 +1,123 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestExportAlarmResponseTimesRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.exportAlarmResponseTimesHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestExportAlarmResponseTimesWithoutPermission() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/response-times/export", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "export_alarm_response_times", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrExportAlarmResponseTimesPermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestExportAlarmResponseTimes() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/response-times/export", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+	r.Header.Set("Accept", "application/x-ndjson")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock fetching of response times
+	timestamp := time.Date(2016, 2, 9, 10, 0, 0, 0, time.UTC)
+	testResponseTimes := []*metrics.ResponseTime{
+		metrics.NewResponseTime("", suite.alarms[0].ID, timestamp, 123000000),
+		metrics.NewResponseTime("", suite.alarms[0].ID, timestamp.Add(time.Minute), 234000000),
+	}
+	suite.mockGetMetricsRetention(suite.alarms[0].User.ID, 0)
+	suite.mockIterateResponseTimes(int(suite.alarms[0].ID), nil, nil, testResponseTimes, nil)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response
+	assert.Equal(suite.T(), "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(
+		suite.T(),
+		fmt.Sprintf("attachment; filename=\"alarm-%d-response-times.ndjson\"", suite.alarms[0].ID),
+		w.Header().Get("Content-Disposition"),
+	)
+	assert.Equal(
+		suite.T(),
+		"{\"timestamp\":\"2016-02-09T10:00:00Z\",\"value\":123000000,\"failed\":false,"+
+			"\"dns_lookup\":0,\"connect\":0,\"tls_handshake\":0,\"first_byte\":0,\"transfer\":0}\n"+
+			"{\"timestamp\":\"2016-02-09T10:01:00Z\",\"value\":234000000,\"failed\":false,"+
+			"\"dns_lookup\":0,\"connect\":0,\"tls_handshake\":0,\"first_byte\":0,\"transfer\":0}\n",
+		w.Body.String(),
+	)
+}
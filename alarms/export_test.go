@@ -0,0 +1,85 @@
+package alarms
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncidentExportRecord(t *testing.T) {
+	createdAt := time.Date(2016, 2, 25, 22, 32, 12, 0, time.UTC)
+	incident := &Incident{
+		Model:          gorm.Model{ID: 4, CreatedAt: createdAt},
+		AlarmID:        util.PositiveIntOrNull(1),
+		IncidentTypeID: util.StringOrNull("bad code"),
+		HTTPCode:       sql.NullInt64{Int64: 500, Valid: true},
+		ResolvedAt:     util.TimeOrNull(timePtr(createdAt.Add(time.Minute))),
+	}
+
+	record := newIncidentExportRecord(incident)
+	assert.Equal(t, []string{
+		"4",
+		"1",
+		"bad code",
+		"500",
+		"",
+		"",
+		"",
+		"2016-02-25T22:33:12Z",
+		"2016-02-25T22:32:12Z",
+	}, record.CSVRecord())
+	assert.Equal(t, len(incidentExportHeader), len(record.CSVRecord()))
+}
+
+func TestResponseTimeExportRecord(t *testing.T) {
+	responseTime := metrics.NewResponseTime(
+		"",
+		1,
+		time.Date(2016, 2, 9, 10, 0, 0, 0, time.UTC),
+		123000000,
+	)
+	responseTime.Failed = true
+	responseTime.FirstByte = 100000000
+
+	record := newResponseTimeExportRecord(responseTime)
+	assert.Equal(t, []string{
+		"2016-02-09T10:00:00Z",
+		"123000000",
+		"true",
+		"0",
+		"0",
+		"0",
+		"100000000",
+		"0",
+	}, record.CSVRecord())
+	assert.Equal(t, len(responseTimeExportHeader), len(record.CSVRecord()))
+}
+
+func TestCheckResultExportRecord(t *testing.T) {
+	checkResult := metrics.NewCheckResult(
+		"",
+		1,
+		time.Date(2016, 2, 9, 10, 0, 0, 0, time.UTC),
+		"us-west-2",
+		0,
+		0,
+		"timeout",
+		"Get http://endpoint-1: timeout",
+	)
+
+	record := newCheckResultExportRecord(checkResult)
+	assert.Equal(t, []string{
+		"2016-02-09T10:00:00Z",
+		"us-west-2",
+		"0",
+		"0",
+		"timeout",
+		"Get http://endpoint-1: timeout",
+	}, record.CSVRecord())
+	assert.Equal(t, len(checkResultExportHeader), len(record.CSVRecord()))
+}
@@ -0,0 +1,326 @@
+package alarms
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/config"
+)
+
+const (
+	// FeedIncidents is the number of most recent incidents in a feed
+	FeedIncidents = 20
+	// FeedMaxAge is how long clients and proxies can cache a feed
+	FeedMaxAge = 5 * time.Minute
+	// AtomFormat ...
+	AtomFormat = "atom"
+	// RSSFormat ...
+	RSSFormat = "rss"
+)
+
+var (
+	feedContentTypes = map[string]string{
+		AtomFormat: "application/atom+xml; charset=utf-8",
+		RSSFormat:  "application/rss+xml; charset=utf-8",
+	}
+
+	feedIncidentTypeDescriptions = map[string]string{
+		incidenttypes.Slow:    "slow response",
+		incidenttypes.Timeout: "timeout",
+		incidenttypes.BadCode: "bad status code",
+		incidenttypes.Other:   "request error",
+	}
+)
+
+// feed is a format independent feed which gets rendered as Atom or RSS
+type feed struct {
+	id      string
+	title   string
+	link    string
+	updated time.Time
+	items   []*feedItem
+}
+
+// feedItem is a single feed entry
+type feedItem struct {
+	id          string
+	title       string
+	link        string
+	description string
+	published   time.Time
+	updated     time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Updated string       `xml:"updated"`
+	Link    *atomLink    `xml:"link"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Link      *atomLink `xml:"link"`
+	Published string    `xml:"published"`
+	Updated   string    `xml:"updated"`
+	Summary   string    `xml:"summary"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Channel *rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        *rssGUID `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// newAlarmIncidentsFeed creates a feed of alarm incidents, the alarm owner
+// is the audience so the feed includes the endpoint and error details
+func newAlarmIncidentsFeed(cnf *config.Config, alarm *Alarm, incidents []*Incident) *feed {
+	link := fmt.Sprintf(
+		"%s://%s/alarms/%d/incidents/",
+		cnf.Web.AppScheme,
+		cnf.Web.AppHost,
+		alarm.ID,
+	)
+
+	f := &feed{
+		id:      fmt.Sprintf("tag:%s,2016:alarms/%d/incidents", cnf.Web.AppHost, alarm.ID),
+		title:   fmt.Sprintf("Incidents of %s", alarm.EndpointURL),
+		link:    link,
+		updated: alarm.UpdatedAt,
+		items:   make([]*feedItem, len(incidents)),
+	}
+
+	for i, incident := range incidents {
+		details := []string{feedIncidentPeriod(incident)}
+		if incident.HTTPCode.Valid {
+			details = append(details, fmt.Sprintf("HTTP code: %d.", incident.HTTPCode.Int64))
+		}
+		if incident.ErrorMessage.Valid && incident.ErrorMessage.String != "" {
+			details = append(details, fmt.Sprintf("Error: %s", incident.ErrorMessage.String))
+		}
+		f.items[i] = newIncidentFeedItem(cnf, incident, alarm.EndpointURL, link, details)
+	}
+
+	f.updated = latestFeedUpdate(f)
+
+	return f
+}
+
+// newStatusPageIncidentsFeed creates a public feed of status page incidents,
+// like on the status page itself, endpoints and error details are left out
+func newStatusPageIncidentsFeed(cnf *config.Config, statusPage *StatusPage, incidents []*Incident) *feed {
+	link := fmt.Sprintf(
+		"%s://%s/status/%s",
+		cnf.Web.AppScheme,
+		cnf.Web.AppHost,
+		statusPage.Slug,
+	)
+
+	f := &feed{
+		id:      fmt.Sprintf("tag:%s,2016:status-pages/%s/incidents", cnf.Web.AppHost, statusPage.Slug),
+		title:   fmt.Sprintf("%s incidents", statusPage.Title),
+		link:    link,
+		updated: statusPage.UpdatedAt,
+		items:   make([]*feedItem, len(incidents)),
+	}
+
+	// Incidents are labeled with the first component containing the alarm
+	alarmComponents := make(map[uint]string)
+	for _, component := range statusPage.Components {
+		for _, alarm := range component.Alarms {
+			if _, ok := alarmComponents[alarm.ID]; !ok {
+				alarmComponents[alarm.ID] = component.Name
+			}
+		}
+	}
+
+	for i, incident := range incidents {
+		f.items[i] = newIncidentFeedItem(
+			cnf,
+			incident,
+			alarmComponents[uint(incident.AlarmID.Int64)],
+			link,
+			[]string{feedIncidentPeriod(incident)},
+		)
+	}
+
+	f.updated = latestFeedUpdate(f)
+
+	return f
+}
+
+// newIncidentFeedItem creates a feed item for an incident
+func newIncidentFeedItem(cnf *config.Config, incident *Incident, subject, link string, details []string) *feedItem {
+	description, ok := feedIncidentTypeDescriptions[incident.IncidentTypeID.String]
+	if !ok {
+		description = incident.IncidentTypeID.String
+	}
+
+	title := fmt.Sprintf("%s: %s", subject, description)
+	updated := incident.CreatedAt
+	if incident.ResolvedAt.Valid {
+		title = fmt.Sprintf("[Resolved] %s", title)
+		updated = incident.ResolvedAt.Time
+	}
+
+	return &feedItem{
+		id:          fmt.Sprintf("tag:%s,2016:incidents/%d", cnf.Web.AppHost, incident.ID),
+		title:       title,
+		link:        link,
+		description: strings.Join(details, " "),
+		published:   incident.CreatedAt,
+		updated:     updated,
+	}
+}
+
+// feedIncidentPeriod describes when an incident started and ended
+func feedIncidentPeriod(incident *Incident) string {
+	period := fmt.Sprintf(
+		"Started at %s [UTC].",
+		incident.CreatedAt.UTC().Format(EmailTimeFormat),
+	)
+	if !incident.ResolvedAt.Valid {
+		return period + " Still open."
+	}
+	return fmt.Sprintf(
+		"%s Resolved at %s [UTC].",
+		period,
+		incident.ResolvedAt.Time.UTC().Format(EmailTimeFormat),
+	)
+}
+
+// latestFeedUpdate returns the most recent update time of a feed, the feed's
+// own updated time is used when there are no items so that the rendered feed
+// (and therefor its ETag) only changes when the content does
+func latestFeedUpdate(f *feed) time.Time {
+	latest := time.Time{}
+	for _, item := range f.items {
+		if item.updated.After(latest) {
+			latest = item.updated
+		}
+	}
+	if latest.IsZero() {
+		return f.updated
+	}
+	return latest
+}
+
+// renderFeed renders a feed in the requested format
+func renderFeed(f *feed, format string) ([]byte, error) {
+	var v interface{}
+	if format == RSSFormat {
+		v = newRSSFeed(f)
+	} else {
+		v = newAtomFeed(f)
+	}
+
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// newAtomFeed converts a feed to Atom
+func newAtomFeed(f *feed) *atomFeed {
+	atom := &atomFeed{
+		ID:      f.id,
+		Title:   f.title,
+		Updated: f.updated.UTC().Format(time.RFC3339),
+		Link:    &atomLink{Href: f.link, Rel: "alternate"},
+		Entries: make([]*atomEntry, len(f.items)),
+	}
+	for i, item := range f.items {
+		atom.Entries[i] = &atomEntry{
+			ID:        item.id,
+			Title:     item.title,
+			Link:      &atomLink{Href: item.link, Rel: "alternate"},
+			Published: item.published.UTC().Format(time.RFC3339),
+			Updated:   item.updated.UTC().Format(time.RFC3339),
+			Summary:   item.description,
+		}
+	}
+	return atom
+}
+
+// newRSSFeed converts a feed to RSS 2.0
+func newRSSFeed(f *feed) *rssFeed {
+	channel := &rssChannel{
+		Title:         f.title,
+		Link:          f.link,
+		Description:   f.title,
+		LastBuildDate: f.updated.UTC().Format(time.RFC1123Z),
+		Items:         make([]*rssItem, len(f.items)),
+	}
+	for i, item := range f.items {
+		channel.Items[i] = &rssItem{
+			Title:       item.title,
+			Link:        item.link,
+			Description: item.description,
+			GUID:        &rssGUID{Value: item.id},
+			PubDate:     item.published.UTC().Format(time.RFC1123Z),
+		}
+	}
+	return &rssFeed{Version: "2.0", Channel: channel}
+}
+
+// findStatusPageFeedIncidents returns the most recent incidents of all alarms
+// on a status page, most recent first
+func (s *Service) findStatusPageFeedIncidents(statusPage *StatusPage) ([]*Incident, error) {
+	incidents := make([]*Incident, 0)
+
+	var alarmIDs []uint
+	seen := make(map[uint]bool)
+	for _, component := range statusPage.Components {
+		for _, alarm := range component.Alarms {
+			if !seen[alarm.ID] {
+				seen[alarm.ID] = true
+				alarmIDs = append(alarmIDs, alarm.ID)
+			}
+		}
+	}
+	if len(alarmIDs) == 0 {
+		return incidents, nil
+	}
+
+	err := s.db.Where("alarm_id IN (?)", alarmIDs).
+		Order("created_at desc, id desc").Limit(FeedIncidents).
+		Find(&incidents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
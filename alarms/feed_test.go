@@ -0,0 +1,163 @@
+package alarms
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFeedConfig = &config.Config{
+	Web: config.WebConfig{
+		AppScheme: "https",
+		AppHost:   "pingli.st",
+	},
+}
+
+func testFeedIncidents() []*Incident {
+	createdAt := time.Date(2016, 6, 4, 11, 26, 15, 0, time.UTC)
+	resolvedAt := createdAt.Add(10 * time.Minute)
+	return []*Incident{
+		&Incident{
+			Model:          gorm.Model{ID: 2, CreatedAt: createdAt.Add(time.Hour)},
+			AlarmID:        util.PositiveIntOrNull(123),
+			IncidentTypeID: util.StringOrNull(incidenttypes.Timeout),
+			ErrorMessage:   util.StringOrNull("timeout error"),
+		},
+		&Incident{
+			Model:          gorm.Model{ID: 1, CreatedAt: createdAt},
+			AlarmID:        util.PositiveIntOrNull(123),
+			IncidentTypeID: util.StringOrNull(incidenttypes.BadCode),
+			HTTPCode:       util.PositiveIntOrNull(500),
+			ResolvedAt:     util.TimeOrNull(&resolvedAt),
+		},
+	}
+}
+
+func TestRenderAlarmIncidentsAtomFeed(t *testing.T) {
+	alarm := &Alarm{Model: gorm.Model{ID: 123}, EndpointURL: "http://endpoint-url"}
+	body, err := renderFeed(newAlarmIncidentsFeed(testFeedConfig, alarm, testFeedIncidents()), AtomFormat)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	atom := new(atomFeed)
+	if !assert.NoError(t, xml.Unmarshal(body, atom)) {
+		return
+	}
+	assert.Equal(t, "tag:pingli.st,2016:alarms/123/incidents", atom.ID)
+	assert.Equal(t, "Incidents of http://endpoint-url", atom.Title)
+	assert.Equal(t, "https://pingli.st/alarms/123/incidents/", atom.Link.Href)
+	assert.Equal(t, "2016-06-04T12:26:15Z", atom.Updated)
+	if assert.Equal(t, 2, len(atom.Entries)) {
+		assert.Equal(t, "http://endpoint-url: timeout", atom.Entries[0].Title)
+		assert.Equal(
+			t,
+			"Started at Sat Jun 4 12:26:15 2016 [UTC]. Still open. Error: timeout error",
+			atom.Entries[0].Summary,
+		)
+		assert.Equal(t, "[Resolved] http://endpoint-url: bad status code", atom.Entries[1].Title)
+		assert.Equal(
+			t,
+			"Started at Sat Jun 4 11:26:15 2016 [UTC]. Resolved at Sat Jun 4 11:36:15 2016 [UTC]. HTTP code: 500.",
+			atom.Entries[1].Summary,
+		)
+		assert.Equal(t, "2016-06-04T11:36:15Z", atom.Entries[1].Updated)
+		assert.Equal(t, "tag:pingli.st,2016:incidents/1", atom.Entries[1].ID)
+	}
+}
+
+func TestRenderStatusPageIncidentsRSSFeed(t *testing.T) {
+	statusPage := &StatusPage{
+		Slug:  "acme",
+		Title: "Acme Status",
+		Components: []*StatusPageComponent{
+			&StatusPageComponent{
+				Name:   "API",
+				Alarms: []*Alarm{&Alarm{Model: gorm.Model{ID: 123}, EndpointURL: "http://secret"}},
+			},
+		},
+	}
+	body, err := renderFeed(newStatusPageIncidentsFeed(testFeedConfig, statusPage, testFeedIncidents()), RSSFormat)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rss := new(rssFeed)
+	if !assert.NoError(t, xml.Unmarshal(body, rss)) {
+		return
+	}
+	assert.Equal(t, "2.0", rss.Version)
+	assert.Equal(t, "Acme Status incidents", rss.Channel.Title)
+	assert.Equal(t, "https://pingli.st/status/acme", rss.Channel.Link)
+	if assert.Equal(t, 2, len(rss.Channel.Items)) {
+		assert.Equal(t, "API: timeout", rss.Channel.Items[0].Title)
+		// Error details are not published on public feeds
+		assert.Equal(t, "Started at Sat Jun 4 12:26:15 2016 [UTC]. Still open.", rss.Channel.Items[0].Description)
+		assert.Equal(t, "Sat, 04 Jun 2016 12:26:15 +0000", rss.Channel.Items[0].PubDate)
+		assert.Equal(t, "tag:pingli.st,2016:incidents/2", rss.Channel.Items[0].GUID.Value)
+		assert.False(t, rss.Channel.Items[0].GUID.IsPermaLink)
+	}
+	assert.NotContains(t, string(body), "http://secret")
+}
+
+func TestRenderEmptyFeedIsStable(t *testing.T) {
+	alarm := &Alarm{
+		Model:       gorm.Model{ID: 123, UpdatedAt: time.Date(2016, 6, 4, 0, 0, 0, 0, time.UTC)},
+		EndpointURL: "http://endpoint-url",
+	}
+	first, err := renderFeed(newAlarmIncidentsFeed(testFeedConfig, alarm, nil), AtomFormat)
+	assert.NoError(t, err)
+	second, err := renderFeed(newAlarmIncidentsFeed(testFeedConfig, alarm, nil), AtomFormat)
+	assert.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+	assert.Contains(t, string(first), "<updated>2016-06-04T00:00:00Z</updated>")
+}
+
+func (suite *AlarmsTestSuite) TestFindStatusPageFeedIncidents() {
+	// Create a test status page showing the first alarm only
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+		Components: []*StatusPageComponentRequest{
+			&StatusPageComponentRequest{Name: "API", AlarmIDs: []uint{1}},
+			&StatusPageComponentRequest{Name: "Web", AlarmIDs: []uint{1}},
+		},
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+	statusPage, err = suite.service.findStatusPageByID(statusPage.ID)
+	assert.NoError(suite.T(), err, "Fetching test status page failed")
+
+	// Insert more recent incidents than fit in the feed, the alarm
+	// which is not on the status page has the most recent one
+	now := time.Now().UTC()
+	for i := 0; i <= FeedIncidents; i++ {
+		for _, alarmID := range []uint{1, 2} {
+			incident := &Incident{
+				AlarmID:        util.PositiveIntOrNull(int64(alarmID)),
+				IncidentTypeID: util.StringOrNull(incidenttypes.Timeout),
+			}
+			err := suite.db.Create(incident).Error
+			assert.NoError(suite.T(), err, "Inserting test data failed")
+			createdAt := now.Add(time.Duration(i)*time.Hour + time.Duration(alarmID)*time.Minute)
+			err = suite.db.Model(incident).UpdateColumn("created_at", createdAt).Error
+			assert.NoError(suite.T(), err, "Updating created_at failed")
+		}
+	}
+
+	incidents, err := suite.service.findStatusPageFeedIncidents(statusPage)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), FeedIncidents, len(incidents)) {
+		for i, incident := range incidents {
+			assert.Equal(suite.T(), int64(1), incident.AlarmID.Int64)
+			if i > 0 {
+				assert.True(suite.T(), incident.CreatedAt.Before(incidents[i-1].CreatedAt))
+			}
+		}
+		assert.Equal(suite.T(), now.Add(time.Duration(FeedIncidents)*time.Hour+time.Minute).Unix(), incidents[0].CreatedAt.Unix())
+	}
+}
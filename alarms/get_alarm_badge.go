@@ -0,0 +1,55 @@
+package alarms
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+// Handles calls to get an embeddable alarm badge, no user needs to be logged
+// in but the alarm's badge token is required
+// (GET /v1/alarms/{id:[0-9]+}/badges/{badge}.svg?token=...)
+func (s *Service) getAlarmBadgeHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm, an invalid token is reported the same way as a missing
+	// alarm so alarm IDs cannot be enumerated
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := checkBadgeToken(alarm, r.URL.Query().Get("token")); err != nil {
+		response.Error(w, ErrAlarmNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	// State badge
+	badge := vars["badge"]
+	period, ok := badgePeriods[badge]
+	if !ok {
+		response.WriteCacheable(w, r, "image/svg+xml", renderStateBadge(alarm.AlarmStateID.String), BadgeMaxAge)
+		return
+	}
+
+	// Uptime badge
+	now := time.Now()
+	uptime, err := s.getAlarmUptime(alarm, now.Add(-period), now)
+	if err != nil {
+		logger.ERROR.Printf("Get alarm uptime error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	label := "uptime " + badge[len("uptime-"):]
+	response.WriteCacheable(w, r, "image/svg+xml", renderUptimeBadge(label, uptime), BadgeMaxAge)
+}
@@ -0,0 +1,94 @@
+package alarms
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestGetAlarmBadgeInvalidToken() {
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/badges/state.svg?token=bogus", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmBadge() {
+	// Prepare a request, no authentication, just the token
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/badges/uptime-24h.svg?token=%s",
+			suite.alarms[0].ID,
+			suite.alarms[0].BadgeToken.String,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_alarm_badge", match.Route.GetName())
+	}
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Alarm 1 has an incident open for the whole period
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "public, max-age=60", w.Header().Get("Cache-Control"))
+	assert.True(suite.T(), strings.Contains(w.Body.String(), ">0.0%</text>"))
+
+	// A conditional request gets a 304 not modified response
+	r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	assert.Equal(suite.T(), 304, w.Code)
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmIncidentsFeed() {
+	// Prepare a request, no authentication, just the token
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/incidents.rss?token=%s",
+			suite.alarms[0].ID,
+			suite.alarms[0].BadgeToken.String,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_alarm_incidents_feed", match.Route.GetName())
+	}
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), "application/rss+xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotEqual(suite.T(), "", w.Header().Get("ETag"))
+	assert.True(suite.T(), strings.Contains(w.Body.String(), "<rss version=\"2.0\">"))
+}
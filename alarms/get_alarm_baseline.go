@@ -0,0 +1,88 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrGetAlarmBaselinePermission ...
+	ErrGetAlarmBaselinePermission = errors.New("Need permission to get alarm baseline")
+)
+
+// Handles calls to get an alarm response time baseline (GET /v1/alarms/{id:[0-9]+}/baseline)
+func (s *Service) getAlarmBaselineHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkGetAlarmBaselinePermissions(authenticatedUser, alarm); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Recalculate stale baselines, this also lets users preview baselines
+	// before enabling anomaly detection
+	if err := s.refreshBaselines(alarm, time.Now()); err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Fetch baselines
+	baselines, err := s.findBaselines(alarm)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	baselineResponse, err := NewBaselineResponse(alarm, baselines)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Write JSON response
+	response.WriteJSON(w, baselineResponse, http.StatusOK)
+}
+
+func checkGetAlarmBaselinePermissions(authenticatedUser *accounts.User, alarm *Alarm) error {
+	// Superusers can get any alarm baseline
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can get baselines of their own alarms
+	if authenticatedUser.ID == alarm.User.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrGetAlarmBaselinePermission
+}
@@ -0,0 +1,162 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestGetAlarmBaselineRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.getAlarmBaselineHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmBaselineWithoutPermission() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/baseline", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_alarm_baseline", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrGetAlarmBaselinePermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmBaseline() {
+	// A week ago, all samples fall into the same hour of the week
+	hour := time.Now().UTC().Add(-7 * 24 * time.Hour).Truncate(time.Hour)
+	responseTimes := make([]*metrics.ResponseTime, 0)
+	for i := 0; i < 12; i++ {
+		responseTimes = append(responseTimes, &metrics.ResponseTime{
+			ReferenceID: suite.alarms[0].ID,
+			Timestamp:   hour.Add(time.Duration(i) * time.Minute),
+			Value:       int64(100+i) * int64(time.Millisecond),
+		})
+	}
+	// Failed checks are not part of the baseline
+	responseTimes = append(responseTimes, &metrics.ResponseTime{
+		ReferenceID: suite.alarms[0].ID,
+		Timestamp:   hour.Add(30 * time.Minute),
+		Value:       int64(10 * time.Second),
+		Failed:      true,
+	})
+
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/baseline", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_alarm_baseline", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock the metrics history
+	suite.mockIterateBaselineResponseTimes(int(suite.alarms[0].ID), responseTimes, nil)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Baselines should be saved
+	baselines, err := suite.service.findBaselines(suite.alarms[0])
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 1, len(baselines)) {
+		assert.Equal(suite.T(), hourOfWeek(hour), baselines[0].HourOfWeek)
+		assert.Equal(suite.T(), uint(12), baselines[0].Samples)
+		assert.Equal(suite.T(), 105.5, baselines[0].Median)
+		assert.Equal(suite.T(), 3.0, baselines[0].MAD)
+	}
+
+	// Check the response body
+	var decoded struct {
+		AlarmID   uint    `json:"alarm_id"`
+		Count     uint    `json:"count"`
+		UpdatedAt *string `json:"updated_at"`
+		Embedded  struct {
+			Baselines []*HourlyBaselineResponse `json:"baselines"`
+		} `json:"_embedded"`
+	}
+	if assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &decoded)) {
+		assert.Equal(suite.T(), suite.alarms[0].ID, decoded.AlarmID)
+		assert.Equal(suite.T(), uint(1), decoded.Count)
+		assert.NotNil(suite.T(), decoded.UpdatedAt)
+		if assert.Equal(suite.T(), 1, len(decoded.Embedded.Baselines)) {
+			assert.Equal(suite.T(), hourOfWeek(hour), decoded.Embedded.Baselines[0].HourOfWeek)
+			assert.Equal(suite.T(), 105.5, decoded.Embedded.Baselines[0].Median)
+			assert.Equal(suite.T(), 137.15, decoded.Embedded.Baselines[0].Threshold)
+			assert.True(suite.T(), decoded.Embedded.Baselines[0].Ready)
+		}
+	}
+
+	// Baselines are not recalculated again within the refresh interval
+	alarm, err := suite.service.FindAlarmByID(suite.alarms[0].ID)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.service.refreshBaselines(alarm, time.Now()))
+	suite.assertMockExpectations()
+}
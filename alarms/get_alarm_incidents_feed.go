@@ -0,0 +1,62 @@
+package alarms
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+// Handles calls to get an Atom or RSS feed of alarm incidents, no user needs
+// to be logged in but the alarm's badge token is required
+// (GET /v1/alarms/{id:[0-9]+}/incidents.{format:atom|rss}?token=...)
+func (s *Service) getAlarmIncidentsFeedHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm, an invalid token is reported the same way as a missing
+	// alarm so alarm IDs cannot be enumerated
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := checkBadgeToken(alarm, r.URL.Query().Get("token")); err != nil {
+		response.Error(w, ErrAlarmNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Fetch the most recent incidents
+	incidents, err := s.findPaginatedIncidents(
+		0, // offset
+		FeedIncidents,
+		"created_at desc",
+		nil, // user
+		alarm,
+		nil, // incident type
+		nil, // from
+		nil, // to
+	)
+	if err != nil {
+		logger.ERROR.Printf("Find paginated incidents error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Render the feed
+	format := vars["format"]
+	body, err := renderFeed(newAlarmIncidentsFeed(s.cnf, alarm, incidents), format)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.WriteCacheable(w, r, feedContentTypes[format], body, FeedMaxAge)
+}
@@ -0,0 +1,88 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrGetAlarmSLAPermission ...
+	ErrGetAlarmSLAPermission = errors.New("Need permission to get alarm SLA")
+)
+
+// Handles calls to get an alarm SLA report (GET /v1/alarms/{id:[0-9]+}/sla)
+func (s *Service) getAlarmSLAHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkGetAlarmSLAPermissions(authenticatedUser, alarm); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Get the report window, the current month by default
+	now := time.Now()
+	from, to, err := getSLAWindowFromQueryString(r, now)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Calculate the report
+	report, err := s.getSLAReport(alarm, from, to, now)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	slaReportResponse, err := NewSLAReportResponse(report)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, slaReportResponse, http.StatusOK)
+}
+
+func checkGetAlarmSLAPermissions(authenticatedUser *accounts.User, alarm *Alarm) error {
+	// Superusers can get any alarm SLA
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can get SLA of their own alarms
+	if authenticatedUser.ID == alarm.User.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrGetAlarmSLAPermission
+}
@@ -0,0 +1,182 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/RichardKnop/jsonhal"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestGetAlarmSLARequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.getAlarmSLAHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmSLAWithoutPermission() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/sla", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_alarm_sla", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrGetAlarmSLAPermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmSLAInvalidWindow() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/sla?from=2016-03-01T00:00:00Z&to=2016-02-01T00:00:00Z",
+			suite.alarms[0].ID,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 400, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrInvalidSLAWindow.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetAlarmSLA() {
+	// Prepare a request for February 2016, the alarm was created on the
+	// 1st at 22:29:12 and the last incident is still open
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf(
+			"http://1.2.3.4/v1/alarms/%d/sla?from=2016-02-01T00:00:00Z&to=2016-03-01T00:00:00Z",
+			suite.alarms[0].ID,
+		),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_alarm_sla", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body, three one minute incidents and the open one
+	// make 351048s of downtime out of 2424648s monitored
+	expected := &SLAReportResponse{
+		Hal: jsonhal.Hal{
+			Links: map[string]*jsonhal.Link{
+				"self": &jsonhal.Link{
+					Href: fmt.Sprintf(
+						"/v1/alarms/%d/sla?from=2016-02-01T00%%3A00%%3A00Z&to=2016-03-01T00%%3A00%%3A00Z",
+						suite.alarms[0].ID,
+					),
+				},
+			},
+		},
+		AlarmID:              suite.alarms[0].ID,
+		EndpointURL:          suite.alarms[0].EndpointURL,
+		From:                 "2016-02-01T00:00:00Z",
+		To:                   "2016-03-01T00:00:00Z",
+		SLOTarget:            DefaultSLOTarget,
+		Uptime:               85.5217,
+		Downtime:             351048,
+		ErrorBudget:          2424,
+		RemainingErrorBudget: -348623,
+		BurnRate:             144.7831,
+		SLOMet:               false,
+	}
+	expectedJSON, err := json.Marshal(expected)
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"), // trim the trailing \n
+		)
+	}
+}
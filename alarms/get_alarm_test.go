@@ -165,6 +165,9 @@ func (suite *AlarmsTestSuite) TestGetAlarm() {
 		SlackAlerts:            alarm.SlackAlerts,
 		Active:                 alarm.Active,
 		State:                  alarm.AlarmStateID.String,
+		SLOTarget:              DefaultSLOTarget,
+		AnomalySensitivity:     DefaultAnomalySensitivity,
+		AnomalyPeriod:          DefaultAnomalyPeriod,
 		CreatedAt:              util.FormatTime(alarm.CreatedAt),
 		UpdatedAt:              util.FormatTime(alarm.UpdatedAt),
 	}
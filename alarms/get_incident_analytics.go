@@ -0,0 +1,131 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/pagination"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+var (
+	// ErrGetIncidentAnalyticsPermission ...
+	ErrGetIncidentAnalyticsPermission = errors.New("Need permission to get incident analytics")
+)
+
+// Handles calls to get incident analytics (GET /v1/incidents/analytics)
+func (s *Service) getIncidentAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Optional filtering by user
+	user, err := s.GetAccountsService().GetUserFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Optional filtering by team
+	var team *teams.Team
+	if r.URL.Query().Get("team_id") != "" {
+		teamID, err := strconv.Atoi(r.URL.Query().Get("team_id"))
+		if err != nil {
+			response.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		team, err = s.teamsService.FindTeamByID(uint(teamID))
+		if err != nil {
+			response.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+
+	// Default to the authenticated user
+	if user == nil && team == nil {
+		user = authenticatedUser
+	}
+
+	// Check permissions
+	if err := checkGetIncidentAnalyticsPermissions(authenticatedUser, user, team); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Get from / to, the last 30 days by default
+	from, to, err := pagination.GetFromTo(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to == nil {
+		now := time.Now()
+		to = &now
+	}
+	if from == nil {
+		start := to.Add(-DefaultIncidentAnalyticsPeriod)
+		from = &start
+	}
+
+	// Get optional grouping
+	groupBy, err := getIncidentAnalyticsGroupByFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Compute the analytics, only filter by team when one was requested
+	var analyticsUser *accounts.User
+	if team == nil {
+		analyticsUser = user
+	}
+	analytics, err := s.getIncidentAnalytics(analyticsUser, team, *from, *to, groupBy)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	analyticsResponse, err := NewIncidentAnalyticsResponse(
+		util.GetCurrentURL(r),
+		analytics,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, analyticsResponse, http.StatusOK)
+}
+
+func checkGetIncidentAnalyticsPermissions(authenticatedUser *accounts.User, user *accounts.User, team *teams.Team) error {
+	// Superusers can get any incident analytics
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Team owner and members can get analytics of team alarms
+	if team != nil {
+		if isTeamMember(team, authenticatedUser) {
+			return nil
+		}
+		return ErrGetIncidentAnalyticsPermission
+	}
+
+	// Users can get analytics of their own alarms
+	if user != nil && authenticatedUser.ID == user.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrGetIncidentAnalyticsPermission
+}
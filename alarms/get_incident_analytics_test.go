@@ -0,0 +1,247 @@
+package alarms
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestGetIncidentAnalyticsRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.getIncidentAnalyticsHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestGetIncidentAnalyticsWithoutPermission() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/incidents/analytics?user_id=2",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_incident_analytics", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// Mock user filtering
+	suite.mockUserFiltering(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrGetIncidentAnalyticsPermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetIncidentAnalyticsInvalidGroupBy() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/incidents/analytics?group_by=region",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock user filtering
+	suite.mockUserFiltering(nil)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 400, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrInvalidIncidentAnalyticsGroupBy.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetIncidentAnalytics() {
+	// Prepare a request for February 2016
+	r, err := http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/incidents/analytics?from=2016-02-01T00:00:00Z&to=2016-03-01T00:00:00Z",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock user filtering
+	suite.mockUserFiltering(nil)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	analyticsResponse := new(IncidentAnalyticsResponse)
+	err = json.Unmarshal(w.Body.Bytes(), analyticsResponse)
+	assert.NoError(suite.T(), err, "JSON unmarshalling failed")
+
+	assert.Equal(suite.T(), "2016-02-01T00:00:00Z", analyticsResponse.From)
+	assert.Equal(suite.T(), "2016-03-01T00:00:00Z", analyticsResponse.To)
+	assert.Equal(suite.T(), "", analyticsResponse.GroupBy)
+	assert.Equal(suite.T(), uint(4), analyticsResponse.Count)
+	assert.Nil(suite.T(), analyticsResponse.MTTA)
+	if assert.NotNil(suite.T(), analyticsResponse.MTTR) {
+		assert.Equal(suite.T(), int64(60), *analyticsResponse.MTTR)
+	}
+	// Failures were 5 days apart give or take a minute
+	if assert.NotNil(suite.T(), analyticsResponse.MTBF) {
+		assert.Equal(suite.T(), int64(432000), *analyticsResponse.MTBF)
+	}
+	assert.Equal(suite.T(), map[string]int{
+		incidenttypes.Slow:    0,
+		incidenttypes.Timeout: 2,
+		incidenttypes.BadCode: 1,
+		incidenttypes.Other:   1,
+	}, analyticsResponse.IncidentTypeCounts)
+
+	// All incidents were opened at 22:xx on a Wednesday, Monday,
+	// Saturday and Thursday
+	assert.Equal(suite.T(), 1, analyticsResponse.HourOfWeekCounts[2][22])
+	assert.Equal(suite.T(), 1, analyticsResponse.HourOfWeekCounts[0][22])
+	assert.Equal(suite.T(), 1, analyticsResponse.HourOfWeekCounts[5][22])
+	assert.Equal(suite.T(), 1, analyticsResponse.HourOfWeekCounts[3][22])
+
+	var noisiestAlarms []*IncidentStatsResponse
+	noisiestAlarmsJSON, err := json.Marshal(analyticsResponse.Embedded["noisiest_alarms"])
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	assert.NoError(suite.T(), json.Unmarshal(noisiestAlarmsJSON, &noisiestAlarms))
+	if assert.Equal(suite.T(), 1, len(noisiestAlarms)) {
+		assert.Equal(suite.T(), suite.alarms[0].ID, noisiestAlarms[0].AlarmID)
+		assert.Equal(suite.T(), suite.alarms[0].EndpointURL, noisiestAlarms[0].EndpointURL)
+		assert.Equal(suite.T(), uint(4), noisiestAlarms[0].Count)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetIncidentAnalyticsForTeamByIncidentType() {
+	testTeam := &teams.Team{
+		Model:   gorm.Model{ID: 1},
+		OwnerID: util.PositiveIntOrNull(int64(suite.users[1].ID)),
+	}
+
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/incidents/analytics?team_id=1&group_by=incident_type"+
+			"&from=2016-02-01T00:00:00Z&to=2016-03-01T00:00:00Z",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock user filtering
+	suite.mockUserFiltering(nil)
+
+	// Mock find team
+	suite.mockFindTeamByID(testTeam.ID, testTeam, nil)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	analyticsResponse := new(IncidentAnalyticsResponse)
+	err = json.Unmarshal(w.Body.Bytes(), analyticsResponse)
+	assert.NoError(suite.T(), err, "JSON unmarshalling failed")
+	assert.Equal(suite.T(), IncidentAnalyticsGroupByIncidentType, analyticsResponse.GroupBy)
+	assert.Equal(suite.T(), uint(4), analyticsResponse.Count)
+
+	var groups []*IncidentStatsResponse
+	groupsJSON, err := json.Marshal(analyticsResponse.Embedded["groups"])
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	assert.NoError(suite.T(), json.Unmarshal(groupsJSON, &groups))
+	if assert.Equal(suite.T(), 3, len(groups)) {
+		assert.Equal(suite.T(), incidenttypes.BadCode, groups[0].IncidentType)
+		assert.Equal(suite.T(), uint(1), groups[0].Count)
+		assert.Nil(suite.T(), groups[0].MTTR)
+		assert.Equal(suite.T(), incidenttypes.Other, groups[1].IncidentType)
+		assert.Equal(suite.T(), incidenttypes.Timeout, groups[2].IncidentType)
+		assert.Equal(suite.T(), uint(2), groups[2].Count)
+		if assert.NotNil(suite.T(), groups[2].MTTR) {
+			assert.Equal(suite.T(), int64(60), *groups[2].MTTR)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package alarms
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/prometheus"
+	"github.com/RichardKnop/pinglist-api/response"
+)
+
+// Handles calls to scrape Prometheus metrics of alarms (GET /v1/prometheus/metrics)
+func (s *Service) getPrometheusMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	// Scrapes are authenticated by the user's scrape token
+	userID, err := s.findUserIDByScrapeToken(getScrapeToken(r))
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Collect metrics of the user's alarms
+	families, err := s.getPrometheusFamilies(userID, time.Now())
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Write the text exposition format
+	w.Header().Set("Content-Type", prometheus.ContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := prometheus.WriteText(w, families); err != nil {
+		logger.ERROR.Printf("Write Prometheus metrics error: %s", err)
+	}
+}
@@ -0,0 +1,121 @@
+package alarms
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/prometheus"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestGetPrometheusMetricsInvalidToken() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/prometheus/metrics",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer bogus")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_prometheus_metrics", match.Route.GetName())
+	}
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 401, w.Code) {
+		log.Print(w.Body.String())
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetPrometheusMetrics() {
+	scrapeToken, err := suite.service.rotateScrapeToken(suite.users[1])
+	assert.NoError(suite.T(), err, "Creating scrape token failed")
+
+	// Prepare a request, the token is passed as a query string param
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/prometheus/metrics?token=%s", scrapeToken.Token),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	// Mock fetching of metrics
+	alarmIDs := []uint{
+		suite.alarms[0].ID,
+		suite.alarms[1].ID,
+		suite.alarms[2].ID,
+		suite.alarms[3].ID,
+	}
+	suite.mockLastResponseTimes(
+		alarmIDs,
+		map[uint]*metrics.ResponseTime{
+			suite.alarms[0].ID: metrics.NewResponseTime("", suite.alarms[0].ID, time.Now(), 123000000),
+		},
+		nil,
+	)
+	suite.mockCheckResultCounts(
+		alarmIDs,
+		map[uint]map[string]int{
+			suite.alarms[0].ID: map[string]int{
+				metrics.CheckOutcomeSuccess: 10,
+				"bad code":                  2,
+			},
+		},
+		nil,
+	)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+	assert.Equal(suite.T(), prometheus.ContentType, w.Header().Get("Content-Type"))
+
+	// Check the response body, the first alarm has an open incident
+	body := w.Body.String()
+	labels := `alarm_id="1",endpoint="http://endpoint-1"`
+	expectedLines := []string{
+		"# TYPE pinglist_alarm_state gauge",
+		`pinglist_alarm_state{` + labels + `,region="us-west-2",state="ok"} 1`,
+		`pinglist_alarm_state{` + labels + `,region="us-west-2",state="alarm"} 0`,
+		`pinglist_alarm_last_response_time_seconds{` + labels + `,region="us-west-2"} 0.123`,
+		`pinglist_alarm_open_incidents{` + labels + `,region="us-west-2"} 1`,
+		`pinglist_alarm_uptime_ratio{` + labels + `,region="us-west-2",window="24h"} 0`,
+		"# TYPE pinglist_alarm_checks_retained gauge",
+		`pinglist_alarm_checks_retained{` + labels + `,outcome="success",region="us-west-2"} 10`,
+		`pinglist_alarm_checks_retained{` + labels + `,outcome="bad code",region="us-west-2"} 2`,
+		`pinglist_alarm_incidents_total{` + labels + `,region="us-west-2",type="timeout"} 2`,
+		`pinglist_alarm_incidents_total{` + labels + `,region="us-west-2",type="bad code"} 1`,
+		`pinglist_alarm_open_incidents{alarm_id="2",endpoint="http://endpoint-2",region="us-west-2"} 0`,
+	}
+	lines := strings.Split(body, "\n")
+	for _, expectedLine := range expectedLines {
+		assert.Contains(suite.T(), lines, expectedLine)
+	}
+
+	// Other alarms have not been checked recently
+	assert.NotContains(suite.T(), body, `pinglist_alarm_last_response_time_seconds{alarm_id="2"`)
+}
@@ -0,0 +1,69 @@
+package alarms
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+// Handles calls to get a public status page (GET /v1/status-pages/{slug})
+func (s *Service) getPublicStatusPageHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the slug from request URI
+	vars := mux.Vars(r)
+
+	// Fetch the status page
+	statusPage, err := s.findStatusPageBySlug(vars["slug"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	now := time.Now().UTC()
+
+	// Fetch incidents open at any time during the uptime bars period
+	incidents, err := s.findStatusPageIncidents(
+		statusPage,
+		now.AddDate(0, 0, -StatusPageUptimeDays),
+	)
+	if err != nil {
+		logger.ERROR.Printf("Find status page incidents error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Incidents are ordered from the most recent one
+	recentIncidents := incidents
+	if len(recentIncidents) > StatusPageRecentIncidents {
+		recentIncidents = recentIncidents[:StatusPageRecentIncidents]
+	}
+
+	// Fetch reported incidents from the same period
+	statusPageIncidents, err := s.findRecentStatusPageIncidents(
+		statusPage,
+		now.AddDate(0, 0, -StatusPageUptimeDays),
+		StatusPageRecentIncidents,
+	)
+	if err != nil {
+		logger.ERROR.Printf("Find recent status page incidents error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	statusPageResponse, err := NewPublicStatusPageResponse(
+		statusPage,
+		incidents,
+		recentIncidents,
+		statusPageIncidents,
+		now,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, statusPageResponse, http.StatusOK)
+}
@@ -0,0 +1,95 @@
+package alarms
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestGetPublicStatusPageNotFound() {
+	r, err := http.NewRequest("GET", "http://1.2.3.4/v1/status-pages/bogus", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *AlarmsTestSuite) TestGetPublicStatusPage() {
+	// Create a test status page, alarm 1 has an open incident
+	_, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+		Components: []*StatusPageComponentRequest{
+			&StatusPageComponentRequest{Name: "API", AlarmIDs: []uint{1}},
+			&StatusPageComponentRequest{Name: "Website", AlarmIDs: []uint{2}},
+		},
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+	err = suite.db.Model(suite.alarms[0]).UpdateColumn("alarm_state_id", alarmstates.Alarm).Error
+	assert.NoError(suite.T(), err, "Updating alarm state failed")
+
+	// Prepare a request, no authentication
+	r, err := http.NewRequest("GET", "http://1.2.3.4/v1/status-pages/acme", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_public_status_page", match.Route.GetName())
+	}
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Restore the alarm state
+	err = suite.db.Model(suite.alarms[0]).UpdateColumn("alarm_state_id", alarmstates.OK).Error
+	assert.NoError(suite.T(), err, "Updating alarm state failed")
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	resp := new(struct {
+		State    string `json:"state"`
+		Embedded struct {
+			Components []*PublicComponentResponse `json:"components"`
+			Incidents  []*PublicIncidentResponse  `json:"incidents"`
+		} `json:"_embedded"`
+	})
+	err = json.Unmarshal(w.Body.Bytes(), resp)
+	if !assert.NoError(suite.T(), err, "JSON unmarshalling failed") {
+		return
+	}
+	assert.Equal(suite.T(), alarmstates.Alarm, resp.State)
+	if assert.Equal(suite.T(), 2, len(resp.Embedded.Components)) {
+		api, website := resp.Embedded.Components[0], resp.Embedded.Components[1]
+		assert.Equal(suite.T(), "API", api.Name)
+		assert.Equal(suite.T(), alarmstates.Alarm, api.State)
+		assert.Equal(suite.T(), StatusPageUptimeDays, len(api.UptimeBars))
+		assert.Equal(suite.T(), float64(0), api.Uptime)
+		assert.Equal(suite.T(), "Website", website.Name)
+		assert.Equal(suite.T(), alarmstates.OK, website.State)
+		assert.Equal(suite.T(), float64(100), website.Uptime)
+	}
+	// Only the open incident overlaps with the last 90 days
+	if assert.Equal(suite.T(), 1, len(resp.Embedded.Incidents)) {
+		assert.Equal(suite.T(), suite.incidents[3].ID, resp.Embedded.Incidents[0].ID)
+		assert.Equal(suite.T(), "API", resp.Embedded.Incidents[0].Component)
+		assert.Nil(suite.T(), resp.Embedded.Incidents[0].ResolvedAt)
+	}
+}
@@ -0,0 +1,41 @@
+package alarms
+
+import (
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+// Handles calls to get an Atom or RSS feed of status page incidents
+// (GET /v1/status-pages/{slug}/incidents.{format:atom|rss})
+func (s *Service) getStatusPageIncidentsFeedHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the slug from request URI
+	vars := mux.Vars(r)
+
+	// Fetch the status page
+	statusPage, err := s.findStatusPageBySlug(vars["slug"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Fetch the most recent incidents
+	incidents, err := s.findStatusPageFeedIncidents(statusPage)
+	if err != nil {
+		logger.ERROR.Printf("Find status page feed incidents error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Render the feed
+	format := vars["format"]
+	body, err := renderFeed(newStatusPageIncidentsFeed(s.cnf, statusPage, incidents), format)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.WriteCacheable(w, r, feedContentTypes[format], body, FeedMaxAge)
+}
@@ -0,0 +1,98 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestGetStatusPageRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.getStatusPageHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestGetStatusPage() {
+	// Create a test status page
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+
+	// The self link of the page is the request URI
+	statusPageResponse, err := NewStatusPageResponse(statusPage)
+	assert.NoError(suite.T(), err, "Creating response object failed")
+	selfLink := statusPageResponse.Links["self"].Href
+
+	r, err := http.NewRequest("GET", "http://1.2.3.4"+selfLink, nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_status_page", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), http.StatusOK, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(statusPageResponse)
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.JSONEq(suite.T(), string(expectedJSON), w.Body.String())
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetStatusPageWithoutPermission() {
+	// Create a test status page
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/status-pages/%d", statusPage.ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,93 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrGetTeamSLAReportPermission ...
+	ErrGetTeamSLAReportPermission = errors.New("Need permission to get team SLA report")
+)
+
+// Handles calls to get a monthly team SLA report
+// (GET /v1/teams/{id:[0-9]+}/sla-reports/{month:[0-9]{4}-[0-9]{2}})
+func (s *Service) getTeamSLAReportHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id and month from request URI and type assert them
+	vars := mux.Vars(r)
+	teamID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	month, err := parseSLAReportMonth(vars["month"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the team
+	team, err := s.teamsService.FindTeamByID(uint(teamID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkGetTeamSLAReportPermissions(authenticatedUser, team); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Calculate reports of team alarms
+	reports, err := s.getTeamSLAReports(team, month, time.Now())
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	teamSLAReportResponse, err := NewTeamSLAReportResponse(
+		util.GetCurrentURL(r),
+		team.ID,
+		month,
+		reports,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, teamSLAReportResponse, http.StatusOK)
+}
+
+func checkGetTeamSLAReportPermissions(authenticatedUser *accounts.User, team *teams.Team) error {
+	// Superusers can get any team SLA report
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Team owner and members can get the report
+	if isTeamMember(team, authenticatedUser) {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrGetTeamSLAReportPermission
+}
@@ -0,0 +1,134 @@
+package alarms
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestGetTeamSLAReportRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.getTeamSLAReportHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestGetTeamSLAReportWithoutPermission() {
+	testTeam := &teams.Team{
+		Model:   gorm.Model{ID: 1},
+		OwnerID: util.PositiveIntOrNull(int64(suite.users[1].ID)),
+	}
+
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/teams/1/sla-reports/2016-02",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "get_team_sla_report", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// Mock find team
+	suite.mockFindTeamByID(testTeam.ID, testTeam, nil)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrGetTeamSLAReportPermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestGetTeamSLAReport() {
+	testTeam := &teams.Team{
+		Model:   gorm.Model{ID: 1},
+		OwnerID: util.PositiveIntOrNull(int64(suite.users[1].ID)),
+	}
+
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/teams/1/sla-reports/2016-02",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock find team
+	suite.mockFindTeamByID(testTeam.ID, testTeam, nil)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	response := new(struct {
+		TeamSLAReportResponse
+		Embedded struct {
+			SLAReports []*SLAReportResponse `json:"sla_reports"`
+		} `json:"_embedded"`
+	})
+	err = json.Unmarshal(w.Body.Bytes(), response)
+	assert.NoError(suite.T(), err, "JSON unmarshalling failed")
+
+	// Only the first alarm of the owner existed in February 2016
+	assert.Equal(suite.T(), uint(1), response.TeamID)
+	assert.Equal(suite.T(), "2016-02", response.Month)
+	assert.Equal(suite.T(), uint(1), response.AlarmsCount)
+	assert.Equal(suite.T(), uint(0), response.SLOMetCount)
+	assert.Equal(suite.T(), 85.5217, response.Uptime)
+	if assert.Equal(suite.T(), 1, len(response.Embedded.SLAReports)) {
+		assert.Equal(suite.T(), suite.alarms[0].ID, response.Embedded.SLAReports[0].AlarmID)
+		assert.Equal(suite.T(), int64(351048), response.Embedded.SLAReports[0].Downtime)
+	}
+}
@@ -0,0 +1,90 @@
+package alarms
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxChecksPerHost is used when the per host limit is not configured
+const DefaultMaxChecksPerHost = 5
+
+// hostSlots is a semaphore of a single host
+type hostSlots struct {
+	slots   chan struct{}
+	waiters int
+}
+
+// hostLimiter limits a number of concurrent checks of a single host so
+// many alarms of one customer don't hammer their server at the same time
+type hostLimiter struct {
+	limit int
+	mutex sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+// newHostLimiter starts a new hostLimiter instance
+func newHostLimiter(limit int) *hostLimiter {
+	if limit <= 0 {
+		limit = DefaultMaxChecksPerHost
+	}
+	return &hostLimiter{
+		limit: limit,
+		hosts: make(map[string]*hostSlots),
+	}
+}
+
+// acquire waits for a free slot of the host unless the context is done,
+// the returned function releases the slot
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	l.mutex.Lock()
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &hostSlots{slots: make(chan struct{}, l.limit)}
+		l.hosts[host] = h
+	}
+	h.waiters++
+	l.mutex.Unlock()
+
+	select {
+	case h.slots <- struct{}{}:
+	default:
+		hostWaits.Inc()
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			l.forget(host, h)
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-h.slots
+			l.forget(host, h)
+		})
+	}, nil
+}
+
+// forget drops the semaphore of the host once nobody uses it
+func (l *hostLimiter) forget(host string, h *hostSlots) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	h.waiters--
+	if h.waiters == 0 {
+		delete(l.hosts, host)
+	}
+}
+
+// getHost returns a lowercase host (including a port) of the endpoint URL,
+// unparsable URLs are limited as a whole
+func getHost(endpointURL string) string {
+	u, err := url.Parse(endpointURL)
+	if err != nil || u.Host == "" {
+		return strings.ToLower(endpointURL)
+	}
+	return strings.ToLower(u.Host)
+}
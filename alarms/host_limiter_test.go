@@ -0,0 +1,59 @@
+package alarms
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(2)
+
+	// Two checks of the same host can run at once
+	release1, err := limiter.acquire(context.Background(), "foo.com")
+	assert.NoError(t, err)
+	release2, err := limiter.acquire(context.Background(), "foo.com")
+	assert.NoError(t, err)
+
+	// Other hosts are not affected
+	releaseOther, err := limiter.acquire(context.Background(), "bar.com")
+	assert.NoError(t, err)
+	releaseOther()
+
+	// The third check waits until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx, "foo.com")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// Or until a slot is released
+	acquired := make(chan func())
+	go func() {
+		release, err := limiter.acquire(context.Background(), "foo.com")
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	release1()
+	release3 := <-acquired
+
+	// Releasing twice does not free another slot
+	release1()
+
+	release2()
+	release3()
+
+	// Unused hosts are forgotten
+	assert.Equal(t, 0, len(limiter.hosts))
+}
+
+func TestNewHostLimiterDefaultLimit(t *testing.T) {
+	assert.Equal(t, DefaultMaxChecksPerHost, newHostLimiter(0).limit)
+}
+
+func TestGetHost(t *testing.T) {
+	assert.Equal(t, "foo.com", getHost("https://Foo.com/bar?qux=1"))
+	assert.Equal(t, "foo.com:8080", getHost("http://foo.com:8080"))
+	assert.Equal(t, "bogus", getHost("bogus"))
+}
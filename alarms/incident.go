@@ -1,6 +1,7 @@
 package alarms
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -11,6 +12,42 @@ import (
 	"github.com/jinzhu/gorm"
 )
 
+var (
+	// ErrIncidentNotFound ...
+	ErrIncidentNotFound = errors.New("Incident not found")
+)
+
+// findIncidentByID looks up an incident of the alarm by ID
+func (s *Service) findIncidentByID(alarm *Alarm, incidentID uint) (*Incident, error) {
+	incident := new(Incident)
+	notFound := s.db.Where("alarm_id = ?", alarm.ID).
+		First(incident, incidentID).RecordNotFound()
+
+	// Not found
+	if notFound {
+		return nil, ErrIncidentNotFound
+	}
+
+	// Assign related object
+	incident.Alarm = alarm
+
+	return incident, nil
+}
+
+// acknowledgeIncident marks the incident as acknowledged, incidents which
+// have already been acknowledged keep the original timestamp
+func (s *Service) acknowledgeIncident(incident *Incident) error {
+	if incident.AcknowledgedAt.Valid {
+		return nil
+	}
+
+	now := gorm.NowFunc()
+	return s.db.Model(incident).UpdateColumns(Incident{
+		AcknowledgedAt: util.TimeOrNull(&now),
+		Model:          gorm.Model{UpdatedAt: now},
+	}).Error
+}
+
 // openIncident opens a new alarm incident
 func (s *Service) openIncident(alarm *Alarm, incidentTypeID string, resp *http.Response, responseTime int64, errMsg string) error {
 	// Begin a transaction
@@ -18,6 +55,9 @@ func (s *Service) openIncident(alarm *Alarm, incidentTypeID string, resp *http.R
 
 	now := gorm.NowFunc()
 
+	// Set when a new incident gets opened
+	var newIncident *Incident
+
 	// Change the alarm state to alarmstates.Alarm if it isn't already
 	if alarm.AlarmStateID.String != alarmstates.Alarm {
 		now := gorm.NowFunc()
@@ -81,20 +121,7 @@ func (s *Service) openIncident(alarm *Alarm, incidentTypeID string, resp *http.R
 		}
 		alarm.Incidents = append(alarm.Incidents, incident)
 
-		// Send new incident push notification alert
-		if alarm.PushNotificationAlerts {
-			go s.sendNewIncidentPushNotification(alarm, incident)
-		}
-
-		// Send new incident notification email alert
-		if alarm.EmailAlerts {
-			go s.sendNewIncidentEmail(incident)
-		}
-
-		// Send new incident notification Slack alert
-		if alarm.SlackAlerts && alarm.User.SlackIncomingWebhook.Valid && alarm.User.SlackChannel.Valid {
-			go s.sendNewIncidentSlackMessage(alarm, incident)
-		}
+		newIncident = incident
 	}
 
 	// Commit the transaction
@@ -103,6 +130,32 @@ func (s *Service) openIncident(alarm *Alarm, incidentTypeID string, resp *http.R
 		return err
 	}
 
+	// Alerts go out only for new incidents, after they have been saved
+	if newIncident == nil {
+		return nil
+	}
+
+	// Send new incident push notification alert
+	if alarm.PushNotificationAlerts {
+		s.dispatchAlert(alarm, newIncident, pushChannel, func() error {
+			return s.sendNewIncidentPushNotification(alarm, newIncident)
+		})
+	}
+
+	// Send new incident notification email alert
+	if alarm.EmailAlerts {
+		s.dispatchAlert(alarm, newIncident, emailChannel, func() error {
+			return s.sendNewIncidentEmail(newIncident)
+		})
+	}
+
+	// Send new incident notification Slack alert
+	if alarm.SlackAlerts && alarm.User.SlackIncomingWebhook.Valid && alarm.User.SlackChannel.Valid {
+		s.dispatchAlert(alarm, newIncident, slackChannel, func() error {
+			return s.sendNewIncidentSlackMessage(alarm, newIncident)
+		})
+	}
+
 	return nil
 }
 
@@ -165,17 +218,23 @@ func (s *Service) resolveIncidents(alarm *Alarm) error {
 
 	// Send incidents resolved push notification alert
 	if alarm.PushNotificationAlerts && alarmInitialState != alarmstates.InsufficientData {
-		go s.sendIncidentsResolvedPushNotification(alarm)
+		s.dispatchAlert(alarm, nil, pushChannel, func() error {
+			return s.sendIncidentsResolvedPushNotification(alarm)
+		})
 	}
 
 	// Send incidents resolved notification email alert
 	if alarm.EmailAlerts && alarmInitialState != alarmstates.InsufficientData {
-		go s.sendIncidentsResolvedEmail(alarm)
+		s.dispatchAlert(alarm, nil, emailChannel, func() error {
+			return s.sendIncidentsResolvedEmail(alarm)
+		})
 	}
 
 	// Send incidents resolved notification Slack alert
 	if alarm.SlackAlerts && alarm.User.SlackIncomingWebhook.Valid && alarm.User.SlackChannel.Valid {
-		go s.sendIncidentsResolvedSlackMessage(alarm)
+		s.dispatchAlert(alarm, nil, slackChannel, func() error {
+			return s.sendIncidentsResolvedSlackMessage(alarm)
+		})
 	}
 
 	return nil
@@ -215,36 +274,50 @@ func (s *Service) incidentTypeCounts(user *accounts.User, alarm *Alarm, from, to
 	return incitentTypeCounts, nil
 }
 
-// getUptimeDowntime returns uptime and downtime percentages
-func (s *Service) getUptimeDowntime(alarm *Alarm) (float64, float64, error) {
-	query := `SELECT
-		COALESCE(
-			EXTRACT(EPOCH FROM (SELECT NOW() - created_at FROM alarm_alarms WHERE id = ?))
-				- EXTRACT(EPOCH FROM (SUM(resolved_at - created_at))),
-			100
-		) as uptime,
-		COALESCE(
-			EXTRACT(EPOCH FROM (SUM(COALESCE(resolved_at, NOW()) - created_at))),
-			0
-		) AS downtime
-	FROM alarm_incidents WHERE alarm_id = ? AND resolved_at IS NOT NULL;`
-	row := s.db.Raw(query, alarm.ID, alarm.ID).Row()
-
-	var uptime, downtime float64
-	if err := row.Scan(&uptime, &downtime); err != nil {
+// getUptimeDowntime returns uptime and downtime percentages of a window,
+// the window defaults to the whole lifetime of the alarm, incidents which
+// straddle edges of the window only count with the overlapping part
+func (s *Service) getUptimeDowntime(alarm *Alarm, from, to *time.Time) (float64, float64, error) {
+	var (
+		now        = time.Now()
+		windowFrom = alarm.CreatedAt
+		windowTo   = now
+	)
+	if from != nil {
+		windowFrom = *from
+	}
+	if to != nil {
+		windowTo = *to
+	}
+
+	report, err := s.getSLAReport(alarm, windowFrom, windowTo, now)
+	if err != nil {
 		return 0, 0, err
 	}
 
-	// Calculate percentages
-	total := uptime + downtime
-	if uptime > 0 {
-		uptime = uptime / total * 100
+	return report.uptime, 100 - report.uptime, nil
+}
+
+// findIncidentsBetween returns incidents of given alarms which were open
+// at any time during the window, most recent first
+func (s *Service) findIncidentsBetween(alarmIDs []uint, from, to time.Time) ([]*Incident, error) {
+	var incidents []*Incident
+
+	if len(alarmIDs) == 0 {
+		return incidents, nil
 	}
-	if downtime > 0 {
-		downtime = 100 - uptime
+
+	err := s.db.Where(
+		"alarm_id IN (?) AND created_at < ? AND (resolved_at IS NULL OR resolved_at > ?)",
+		alarmIDs,
+		to,
+		from,
+	).Order("created_at desc").Find(&incidents).Error
+	if err != nil {
+		return incidents, err
 	}
 
-	return uptime, downtime, nil
+	return incidents, nil
 }
 
 // incidentsCount returns a total count of incidents
@@ -308,12 +381,12 @@ func (s *Service) incidentsQuery(user *accounts.User, alarm *Alarm, incidentType
 
 	// Optionally filter incidents older than from
 	if from != nil {
-		incidentsQuery = incidentsQuery.Where("created_at >= ?", from)
+		incidentsQuery = incidentsQuery.Where("alarm_incidents.created_at >= ?", from)
 	}
 
 	// Optionally filter incidents younger than to
 	if to != nil {
-		incidentsQuery = incidentsQuery.Where("created_at <= ?", to)
+		incidentsQuery = incidentsQuery.Where("alarm_incidents.created_at <= ?", to)
 	}
 
 	return incidentsQuery
@@ -0,0 +1,314 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+const (
+	// IncidentAnalyticsGroupByAlarm groups incident statistics by alarm
+	IncidentAnalyticsGroupByAlarm = "alarm"
+	// IncidentAnalyticsGroupByIncidentType groups incident statistics by incident type
+	IncidentAnalyticsGroupByIncidentType = "incident_type"
+	// DefaultIncidentAnalyticsPeriod is used when the from param is not set
+	DefaultIncidentAnalyticsPeriod = 30 * 24 * time.Hour
+	// NoisiestAlarmsLimit is a number of alarms with most incidents listed
+	NoisiestAlarmsLimit = 10
+)
+
+var (
+	// ErrInvalidIncidentAnalyticsGroupBy ...
+	ErrInvalidIncidentAnalyticsGroupBy = errors.New("Invalid group_by value. Use one of: alarm, incident_type")
+)
+
+// incidentAnalyticsRow is an incident with fields needed for analytics
+type incidentAnalyticsRow struct {
+	alarmID        uint
+	incidentType   string
+	createdAt      time.Time
+	acknowledgedAt pq.NullTime
+	resolvedAt     pq.NullTime
+}
+
+// incidentStats are counts and mean times of a set of incidents
+type incidentStats struct {
+	count            int
+	acknowledged     int
+	acknowledgeTime  time.Duration
+	resolved         int
+	resolveTime      time.Duration
+	failureGaps      int
+	failureGapsTime  time.Duration
+	alarmID          uint
+	incidentType     string
+	typeCounts       map[string]int
+	hourOfWeekCounts [7][24]int
+}
+
+// meanTimeToAcknowledge returns false when no incident was acknowledged
+func (s *incidentStats) meanTimeToAcknowledge() (time.Duration, bool) {
+	if s.acknowledged == 0 {
+		return 0, false
+	}
+	return s.acknowledgeTime / time.Duration(s.acknowledged), true
+}
+
+// meanTimeToResolve returns false when no incident was resolved
+func (s *incidentStats) meanTimeToResolve() (time.Duration, bool) {
+	if s.resolved == 0 {
+		return 0, false
+	}
+	return s.resolveTime / time.Duration(s.resolved), true
+}
+
+// meanTimeBetweenFailures returns false when no alarm failed again
+// after a resolved incident
+func (s *incidentStats) meanTimeBetweenFailures() (time.Duration, bool) {
+	if s.failureGaps == 0 {
+		return 0, false
+	}
+	return s.failureGapsTime / time.Duration(s.failureGaps), true
+}
+
+// incidentAnalytics are statistics of incidents in a time range
+type incidentAnalytics struct {
+	from           time.Time
+	to             time.Time
+	groupBy        string
+	totals         *incidentStats
+	groups         []*incidentStats
+	noisiestAlarms []*incidentStats
+	alarms         map[uint]*Alarm
+}
+
+// getIncidentAnalytics computes statistics of incidents of the user or of
+// the team owner and members opened in the time range
+func (s *Service) getIncidentAnalytics(user *accounts.User, team *teams.Team, from, to time.Time, groupBy string) (*incidentAnalytics, error) {
+	rows, err := s.findIncidentAnalyticsRows(user, team, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := newIncidentAnalytics(rows, groupBy)
+	analytics.from = from
+	analytics.to = to
+
+	// Fetch the alarms so they can be described in the response
+	var alarmIDs []uint
+	for _, stats := range analytics.noisiestAlarms {
+		alarmIDs = append(alarmIDs, stats.alarmID)
+	}
+	if groupBy == IncidentAnalyticsGroupByAlarm {
+		for _, stats := range analytics.groups {
+			alarmIDs = append(alarmIDs, stats.alarmID)
+		}
+	}
+	if len(alarmIDs) > 0 {
+		var alarms []*Alarm
+		err := s.db.Unscoped().Where("id IN (?)", uniqueIDs(alarmIDs)).
+			Find(&alarms).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, alarm := range alarms {
+			analytics.alarms[alarm.ID] = alarm
+		}
+	}
+
+	return analytics, nil
+}
+
+// findIncidentAnalyticsRows returns incidents of the user or of the team
+// owner and members opened in the time range, oldest first
+func (s *Service) findIncidentAnalyticsRows(user *accounts.User, team *teams.Team, from, to time.Time) ([]*incidentAnalyticsRow, error) {
+	query := s.incidentsQuery(user, nil, nil, &from, &to)
+
+	// Optionally filter by team
+	if team != nil {
+		query = filterIncidentsByTeam(query, team)
+	}
+
+	sqlRows, err := query.Select(
+		"alarm_incidents.alarm_id, alarm_incidents.incident_type_id, " +
+			"alarm_incidents.created_at, alarm_incidents.acknowledged_at, " +
+			"alarm_incidents.resolved_at",
+	).Order("alarm_incidents.created_at").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlRows.Close()
+
+	var rows []*incidentAnalyticsRow
+	for sqlRows.Next() {
+		var (
+			alarmID int64
+			row     = new(incidentAnalyticsRow)
+		)
+		err := sqlRows.Scan(
+			&alarmID,
+			&row.incidentType,
+			&row.createdAt,
+			&row.acknowledgedAt,
+			&row.resolvedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		row.alarmID = uint(alarmID)
+		rows = append(rows, row)
+	}
+
+	return rows, sqlRows.Err()
+}
+
+// filterIncidentsByTeam limits incidents to alarms of the team owner and members
+func filterIncidentsByTeam(query *gorm.DB, team *teams.Team) *gorm.DB {
+	userIDs := []uint{uint(team.OwnerID.Int64)}
+	for _, member := range team.Members {
+		userIDs = append(userIDs, member.ID)
+	}
+	return query.Where(
+		"alarm_incidents.alarm_id IN (SELECT id FROM alarm_alarms WHERE user_id IN (?))",
+		userIDs,
+	)
+}
+
+// newIncidentAnalytics computes statistics of incidents, rows are expected
+// to be ordered by created_at
+func newIncidentAnalytics(rows []*incidentAnalyticsRow, groupBy string) *incidentAnalytics {
+	analytics := &incidentAnalytics{
+		groupBy: groupBy,
+		totals:  getIncidentStats(rows),
+		groups:  make([]*incidentStats, 0),
+		alarms:  make(map[uint]*Alarm),
+	}
+
+	// Split rows by alarm, keep the order of alarms stable
+	var (
+		alarmIDs    []uint
+		alarmRows   = make(map[uint][]*incidentAnalyticsRow)
+		typeRows    = make(map[string][]*incidentAnalyticsRow)
+		typeNames   []string
+		alarmGroups = make([]*incidentStats, 0)
+	)
+	for _, row := range rows {
+		if _, ok := alarmRows[row.alarmID]; !ok {
+			alarmIDs = append(alarmIDs, row.alarmID)
+		}
+		alarmRows[row.alarmID] = append(alarmRows[row.alarmID], row)
+		if _, ok := typeRows[row.incidentType]; !ok {
+			typeNames = append(typeNames, row.incidentType)
+		}
+		typeRows[row.incidentType] = append(typeRows[row.incidentType], row)
+	}
+	sort.Slice(alarmIDs, func(i, j int) bool { return alarmIDs[i] < alarmIDs[j] })
+	sort.Strings(typeNames)
+
+	for _, alarmID := range alarmIDs {
+		stats := getIncidentStats(alarmRows[alarmID])
+		stats.alarmID = alarmID
+		alarmGroups = append(alarmGroups, stats)
+	}
+
+	switch groupBy {
+	case IncidentAnalyticsGroupByAlarm:
+		analytics.groups = alarmGroups
+	case IncidentAnalyticsGroupByIncidentType:
+		for _, incidentType := range typeNames {
+			stats := getIncidentStats(typeRows[incidentType])
+			stats.incidentType = incidentType
+			analytics.groups = append(analytics.groups, stats)
+		}
+	}
+
+	// Noisiest alarms have most incidents, ties are ordered by alarm ID
+	noisiest := make([]*incidentStats, len(alarmGroups))
+	copy(noisiest, alarmGroups)
+	sort.SliceStable(noisiest, func(i, j int) bool {
+		return noisiest[i].count > noisiest[j].count
+	})
+	if len(noisiest) > NoisiestAlarmsLimit {
+		noisiest = noisiest[:NoisiestAlarmsLimit]
+	}
+	analytics.noisiestAlarms = noisiest
+
+	return analytics
+}
+
+// alarmFailureState tracks until when an alarm has been failing
+type alarmFailureState struct {
+	failingUntil time.Time
+	stillFailing bool
+}
+
+// getIncidentStats computes counts and mean times of incidents. Time between
+// failures is measured per alarm from resolving all its incidents to opening
+// the next one, rows are expected to be ordered by created_at
+func getIncidentStats(rows []*incidentAnalyticsRow) *incidentStats {
+	stats := &incidentStats{
+		count: len(rows),
+		typeCounts: map[string]int{
+			incidenttypes.Slow:    0,
+			incidenttypes.Timeout: 0,
+			incidenttypes.BadCode: 0,
+			incidenttypes.Other:   0,
+		},
+	}
+
+	failureStates := make(map[uint]*alarmFailureState)
+	for _, row := range rows {
+		stats.typeCounts[row.incidentType]++
+
+		// Hours of week start on Monday (UTC)
+		createdAt := row.createdAt.UTC()
+		stats.hourOfWeekCounts[(int(createdAt.Weekday())+6)%7][createdAt.Hour()]++
+
+		if row.acknowledgedAt.Valid && !row.acknowledgedAt.Time.Before(row.createdAt) {
+			stats.acknowledged++
+			stats.acknowledgeTime += row.acknowledgedAt.Time.Sub(row.createdAt)
+		}
+
+		// Concurrent incidents of the alarm do not count as a new failure
+		failureState, ok := failureStates[row.alarmID]
+		if !ok {
+			failureState = new(alarmFailureState)
+			failureStates[row.alarmID] = failureState
+		} else if !failureState.stillFailing && row.createdAt.After(failureState.failingUntil) {
+			stats.failureGaps++
+			stats.failureGapsTime += row.createdAt.Sub(failureState.failingUntil)
+		}
+
+		if !row.resolvedAt.Valid {
+			failureState.stillFailing = true
+			continue
+		}
+		if !row.resolvedAt.Time.Before(row.createdAt) {
+			stats.resolved++
+			stats.resolveTime += row.resolvedAt.Time.Sub(row.createdAt)
+		}
+		if row.resolvedAt.Time.After(failureState.failingUntil) {
+			failureState.failingUntil = row.resolvedAt.Time
+		}
+	}
+
+	return stats
+}
+
+// getIncidentAnalyticsGroupByFromQueryString returns optional grouping of
+// incident analytics (e.g. group_by=alarm)
+func getIncidentAnalyticsGroupByFromQueryString(r *http.Request) (string, error) {
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case "", IncidentAnalyticsGroupByAlarm, IncidentAnalyticsGroupByIncidentType:
+		return groupBy, nil
+	}
+	return "", ErrInvalidIncidentAnalyticsGroupBy
+}
@@ -0,0 +1,190 @@
+package alarms
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIncidentStats(t *testing.T) {
+	var (
+		// 6 June 2016 was a Monday
+		monday = time.Date(2016, 6, 6, 0, 0, 0, 0, time.UTC)
+		stats  *incidentStats
+		mean   time.Duration
+		ok     bool
+	)
+
+	// No incidents
+	stats = getIncidentStats([]*incidentAnalyticsRow{})
+	assert.Equal(t, 0, stats.count)
+	_, ok = stats.meanTimeToAcknowledge()
+	assert.False(t, ok)
+	_, ok = stats.meanTimeToResolve()
+	assert.False(t, ok)
+	_, ok = stats.meanTimeBetweenFailures()
+	assert.False(t, ok)
+
+	rows := []*incidentAnalyticsRow{
+		// Acknowledged after 10 minutes, resolved after an hour
+		&incidentAnalyticsRow{
+			alarmID:        1,
+			incidentType:   incidenttypes.Timeout,
+			createdAt:      monday.Add(10 * time.Hour),
+			acknowledgedAt: util.TimeOrNull(timePtr(monday.Add(10*time.Hour + 10*time.Minute))),
+			resolvedAt:     util.TimeOrNull(timePtr(monday.Add(11 * time.Hour))),
+		},
+		// Opened while the first one was still open, not a new failure
+		&incidentAnalyticsRow{
+			alarmID:      1,
+			incidentType: incidenttypes.Slow,
+			createdAt:    monday.Add(10*time.Hour + 30*time.Minute),
+			resolvedAt:   util.TimeOrNull(timePtr(monday.Add(12 * time.Hour))),
+		},
+		// A different alarm, its first failure
+		&incidentAnalyticsRow{
+			alarmID:        2,
+			incidentType:   incidenttypes.BadCode,
+			createdAt:      monday.Add(26 * time.Hour),
+			acknowledgedAt: util.TimeOrNull(timePtr(monday.Add(26*time.Hour + 20*time.Minute))),
+			resolvedAt:     util.TimeOrNull(timePtr(monday.Add(27 * time.Hour))),
+		},
+		// The first alarm fails again 20 hours after it recovered
+		&incidentAnalyticsRow{
+			alarmID:      1,
+			incidentType: incidenttypes.Timeout,
+			createdAt:    monday.Add(32 * time.Hour),
+		},
+		// Still failing, so this is not a new failure either
+		&incidentAnalyticsRow{
+			alarmID:      1,
+			incidentType: incidenttypes.Other,
+			createdAt:    monday.Add(40 * time.Hour),
+			resolvedAt:   util.TimeOrNull(timePtr(monday.Add(41 * time.Hour))),
+		},
+	}
+
+	stats = getIncidentStats(rows)
+	assert.Equal(t, 5, stats.count)
+	assert.Equal(t, map[string]int{
+		incidenttypes.Slow:    1,
+		incidenttypes.Timeout: 2,
+		incidenttypes.BadCode: 1,
+		incidenttypes.Other:   1,
+	}, stats.typeCounts)
+
+	mean, ok = stats.meanTimeToAcknowledge()
+	assert.True(t, ok)
+	assert.Equal(t, 15*time.Minute, mean)
+
+	// 60 + 90 + 60 + 60 minutes
+	mean, ok = stats.meanTimeToResolve()
+	assert.True(t, ok)
+	assert.Equal(t, 67*time.Minute+30*time.Second, mean)
+
+	mean, ok = stats.meanTimeBetweenFailures()
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Hour, mean)
+
+	assert.Equal(t, 2, stats.hourOfWeekCounts[0][10])
+	assert.Equal(t, 1, stats.hourOfWeekCounts[1][2])
+	assert.Equal(t, 1, stats.hourOfWeekCounts[1][8])
+	assert.Equal(t, 1, stats.hourOfWeekCounts[1][16])
+
+	// Sundays are the last day of the week
+	stats = getIncidentStats([]*incidentAnalyticsRow{
+		&incidentAnalyticsRow{
+			alarmID:      1,
+			incidentType: incidenttypes.Timeout,
+			createdAt:    monday.Add(-time.Hour),
+		},
+	})
+	assert.Equal(t, 1, stats.hourOfWeekCounts[6][23])
+}
+
+func TestNewIncidentAnalytics(t *testing.T) {
+	var (
+		start     = time.Date(2016, 6, 6, 0, 0, 0, 0, time.UTC)
+		rows      []*incidentAnalyticsRow
+		analytics *incidentAnalytics
+	)
+
+	// Alarm 3 has the most incidents, alarms 1 and 2 are tied
+	for i, alarmID := range []uint{3, 1, 3, 2, 3} {
+		incidentType := incidenttypes.Timeout
+		if alarmID == 2 {
+			incidentType = incidenttypes.BadCode
+		}
+		rows = append(rows, &incidentAnalyticsRow{
+			alarmID:      alarmID,
+			incidentType: incidentType,
+			createdAt:    start.Add(time.Duration(i) * time.Hour),
+			resolvedAt:   util.TimeOrNull(timePtr(start.Add(time.Duration(i)*time.Hour + time.Minute))),
+		})
+	}
+
+	// No grouping
+	analytics = newIncidentAnalytics(rows, "")
+	assert.Equal(t, 5, analytics.totals.count)
+	assert.Equal(t, 0, len(analytics.groups))
+	if assert.Equal(t, 3, len(analytics.noisiestAlarms)) {
+		assert.Equal(t, uint(3), analytics.noisiestAlarms[0].alarmID)
+		assert.Equal(t, 3, analytics.noisiestAlarms[0].count)
+		assert.Equal(t, uint(1), analytics.noisiestAlarms[1].alarmID)
+		assert.Equal(t, uint(2), analytics.noisiestAlarms[2].alarmID)
+	}
+
+	// Grouped by alarm
+	analytics = newIncidentAnalytics(rows, IncidentAnalyticsGroupByAlarm)
+	if assert.Equal(t, 3, len(analytics.groups)) {
+		assert.Equal(t, uint(1), analytics.groups[0].alarmID)
+		assert.Equal(t, 1, analytics.groups[0].count)
+		assert.Equal(t, uint(2), analytics.groups[1].alarmID)
+		assert.Equal(t, uint(3), analytics.groups[2].alarmID)
+		assert.Equal(t, 3, analytics.groups[2].count)
+	}
+
+	// Grouped by incident type
+	analytics = newIncidentAnalytics(rows, IncidentAnalyticsGroupByIncidentType)
+	if assert.Equal(t, 2, len(analytics.groups)) {
+		assert.Equal(t, incidenttypes.BadCode, analytics.groups[0].incidentType)
+		assert.Equal(t, 1, analytics.groups[0].count)
+		assert.Equal(t, incidenttypes.Timeout, analytics.groups[1].incidentType)
+		assert.Equal(t, 4, analytics.groups[1].count)
+	}
+}
+
+func TestGetIncidentAnalyticsGroupByFromQueryString(t *testing.T) {
+	var (
+		r       *http.Request
+		groupBy string
+		err     error
+	)
+
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/incidents/analytics", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	groupBy, err = getIncidentAnalyticsGroupByFromQueryString(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", groupBy)
+	}
+
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/incidents/analytics?group_by=incident_type", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	groupBy, err = getIncidentAnalyticsGroupByFromQueryString(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, IncidentAnalyticsGroupByIncidentType, groupBy)
+	}
+
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/incidents/analytics?group_by=region", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	_, err = getIncidentAnalyticsGroupByFromQueryString(r)
+	assert.Equal(t, ErrInvalidIncidentAnalyticsGroupBy, err)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
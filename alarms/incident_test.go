@@ -740,11 +740,33 @@ func (suite *AlarmsTestSuite) TestGetUptimeDowntime() {
 	assert.NoError(suite.T(), err, "Failed to update the test incident")
 
 	// Now fetch uptime and downtime values
-	uptime, downtime, err = suite.service.getUptimeDowntime(testAlarm)
+	uptime, downtime, err = suite.service.getUptimeDowntime(
+		testAlarm,
+		nil, // from
+		nil, // to
+	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), "85.00", fmt.Sprintf("%.2f", uptime))
 		assert.Equal(suite.T(), "15.00", fmt.Sprintf("%.2f", downtime))
 	}
+
+	// Within a window the second incident straddling its end is clipped,
+	// 100s + 25s of downtime out of 475s
+	from := now.Add(-950 * time.Second)
+	to := now.Add(-475 * time.Second)
+	uptime, downtime, err = suite.service.getUptimeDowntime(testAlarm, &from, &to)
+	if assert.Nil(suite.T(), err) {
+		assert.Equal(suite.T(), "73.68", fmt.Sprintf("%.2f", uptime))
+		assert.Equal(suite.T(), "26.32", fmt.Sprintf("%.2f", downtime))
+	}
+
+	// A window without incidents
+	from = now.Add(-400 * time.Second)
+	uptime, downtime, err = suite.service.getUptimeDowntime(testAlarm, &from, nil)
+	if assert.Nil(suite.T(), err) {
+		assert.Equal(suite.T(), "100.00", fmt.Sprintf("%.2f", uptime))
+		assert.Equal(suite.T(), "0.00", fmt.Sprintf("%.2f", downtime))
+	}
 }
 
 func (suite *AlarmsTestSuite) TestIncidentsCount() {
@@ -0,0 +1,12 @@
+package incidentstatuses
+
+const (
+	// Investigating - The cause of the incident is being looked into
+	Investigating = "investigating"
+	// Identified - The cause of the incident has been found
+	Identified = "identified"
+	// Monitoring - A fix has been deployed and results are being monitored
+	Monitoring = "monitoring"
+	// Resolved - The incident is over
+	Resolved = "resolved"
+)
@@ -0,0 +1,37 @@
+package alarms
+
+import (
+	"github.com/RichardKnop/pinglist-api/prometheus"
+)
+
+const (
+	emailChannel = "email"
+	pushChannel  = "push"
+	slackChannel = "slack"
+)
+
+var (
+	notificationsSent = prometheus.NewCounterVec(
+		"pinglist_notifications_sent_total",
+		"Number of notifications sent by channel.",
+		"channel",
+	)
+	notificationFailures = prometheus.NewCounterVec(
+		"pinglist_notification_failures_total",
+		"Number of notifications which failed to send by channel.",
+		"channel",
+	)
+	hostWaits = prometheus.NewCounterVec(
+		"pinglist_alarm_check_host_waits_total",
+		"Number of checks which had to wait for a free slot of their target host.",
+	)
+)
+
+// countNotification records the outcome of a single notification send
+func countNotification(channel string, err error) {
+	if err != nil {
+		notificationFailures.Inc(channel)
+		return
+	}
+	notificationsSent.Inc(channel)
+}
@@ -0,0 +1,68 @@
+package alarms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+// Handles calls to lease due checks by a region agent (POST /v1/agent/leases)
+func (s *Service) leaseAgentChecksHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated account from the request context
+	authenticatedAccount, err := accounts.GetAuthenticatedAccount(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Find the region the account is an agent of
+	region, err := s.findAgentRegion(authenticatedAccount)
+	if err != nil {
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// Request body cannot be nil
+	if r.Body == nil {
+		response.Error(w, "Request body cannot be nil", http.StatusBadRequest)
+		return
+	}
+
+	// Read the request body
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Unmarshal the request body into the request prototype
+	leaseRequest := new(CheckLeaseRequest)
+	if err := json.Unmarshal(payload, leaseRequest); err != nil {
+		logger.ERROR.Printf("Failed to unmarshal check lease request: %s", payload)
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Lease due checks of the region
+	alarms, err := s.leaseAgentChecks(region, leaseRequest.Limit, time.Now())
+	if err != nil {
+		logger.ERROR.Printf("Lease agent checks error: %s", err)
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// Create response
+	leasesResponse, err := NewListCheckLeasesResponse(util.GetCurrentURL(r), alarms)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, leasesResponse, http.StatusOK)
+}
@@ -0,0 +1,184 @@
+package alarms
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/RichardKnop/pinglist-api/alarms/regions"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestLeaseAgentChecksRequiresAccountAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.leaseAgentChecksHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated account")
+}
+
+func (suite *AlarmsTestSuite) TestLeaseAgentChecksNotAnAgent() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/agent/leases",
+		bytes.NewBufferString(`{"limit": 10}`),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.SetBasicAuth("test_client_1", "test_secret")
+
+	// Mock authentication
+	suite.mockClientAuth(suite.accounts[0])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), http.StatusForbidden, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrAgentRegionNotFound.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestLeaseAgentChecks() {
+	var (
+		agentRegion = suite.insertAgentRegion(suite.accounts[0])
+		alarmIDs    []uint
+	)
+
+	// Deactivate all alarms
+	err := suite.service.db.Model(new(Alarm)).UpdateColumn("active", false).Error
+	assert.NoError(suite.T(), err, "Deactivating alarms failed")
+
+	// Insert two due alarms in the agent's region
+	for i := 0; i < 2; i++ {
+		testAlarm := suite.insertAgentRegionAlarm(agentRegion)
+		alarmIDs = append(alarmIDs, testAlarm.ID)
+	}
+
+	// The local scheduler does not claim alarms of other regions
+	localAlarmIDs, err := suite.service.GetAlarmsToCheck(time.Now())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, len(localAlarmIDs))
+
+	// The agent leases one check at a time
+	for _, alarmID := range alarmIDs {
+		leasesResponse := suite.leaseAgentChecks(1)
+		if assert.Equal(suite.T(), uint(1), leasesResponse.Count) {
+			lease := leasesResponse.Embedded.CheckLeases[0]
+			assert.Equal(suite.T(), alarmID, lease.AlarmID)
+			assert.Equal(suite.T(), "http://foo", lease.EndpointURL)
+
+			// The watermark of the lease is the claim time
+			alarm, err := suite.service.FindAlarmByID(alarmID)
+			if assert.NoError(suite.T(), err) {
+				assert.Equal(
+					suite.T(),
+					alarm.Watermark.Time.UTC().Format(time.RFC3339Nano),
+					lease.Watermark,
+				)
+			}
+		}
+	}
+
+	// Nothing else is due
+	leasesResponse := suite.leaseAgentChecks(10)
+	assert.Equal(suite.T(), uint(0), leasesResponse.Count)
+}
+
+// insertAgentRegion inserts a remote region checked by the account's agent
+func (suite *AlarmsTestSuite) insertAgentRegion(account *accounts.Account) *Region {
+	agentRegion := &Region{
+		ID:             "eu-west-1",
+		Name:           "EU (Ireland)",
+		AgentAccountID: util.PositiveIntOrNull(int64(account.ID)),
+	}
+	err := suite.db.Create(agentRegion).Error
+	assert.NoError(suite.T(), err, "Inserting test region failed")
+	return agentRegion
+}
+
+// insertAgentRegionAlarm inserts an active alarm due for check in the region
+func (suite *AlarmsTestSuite) insertAgentRegionAlarm(region *Region) *Alarm {
+	testAlarm := &Alarm{
+		User:             suite.users[1],
+		Region:           region,
+		AlarmState:       &AlarmState{ID: alarmstates.InsufficientData},
+		EndpointURL:      "http://foo",
+		ExpectedHTTPCode: 200,
+		MaxResponseTime:  1000,
+		Interval:         60,
+		Active:           true,
+	}
+	err := suite.db.Create(testAlarm).Error
+	assert.NoError(suite.T(), err, "Inserting test alarm failed")
+	assert.NotEqual(suite.T(), regions.USWest2, testAlarm.RegionID.String)
+	return testAlarm
+}
+
+// leaseAgentChecks leases checks through the API as the agent
+func (suite *AlarmsTestSuite) leaseAgentChecks(limit int) *testCheckLeasesResponse {
+	payload, err := json.Marshal(&CheckLeaseRequest{Limit: limit})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/agent/leases",
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.SetBasicAuth("test_client_1", "test_secret")
+
+	// Mock authentication
+	suite.resetMocks()
+	suite.mockClientAuth(suite.accounts[0])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), http.StatusOK, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	leasesResponse := new(testCheckLeasesResponse)
+	err = json.Unmarshal(w.Body.Bytes(), leasesResponse)
+	assert.NoError(suite.T(), err, "JSON unmarshalling failed")
+	return leasesResponse
+}
+
+// testCheckLeasesResponse is ListCheckLeasesResponse with embedded leases
+type testCheckLeasesResponse struct {
+	Count    uint `json:"count"`
+	Embedded struct {
+		CheckLeases []*CheckLeaseResponse `json:"check_leases"`
+	} `json:"_embedded"`
+}
@@ -0,0 +1,143 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/pagination"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrListAlarmChecksPermission ...
+	ErrListAlarmChecksPermission = errors.New("Need permission to list alarm checks")
+)
+
+// Handles calls to list alarm checks (GET /v1/alarms/{id:[0-9]+}/checks)
+func (s *Service) listAlarmChecksHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the ID from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkListAlarmChecksPermissions(authenticatedUser, alarm); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Get page, limit and order by
+	page, limit, orderBy, err := pagination.GetPageLimitOrderBy(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get from / to, history is limited by the alarm owner's plan
+	_, from, to, err := metrics.GetParamsFromQueryString(
+		r,
+		s.metricsService.GetMetricsRetention(alarm.User.ID),
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get the optional outcome filter
+	outcome, err := metrics.GetOutcomeFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Count total number of results
+	count, err := s.metricsService.CheckResultsCount(
+		int(alarm.ID),
+		outcome,
+		from,
+		to,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Get pagination links
+	first, last, previous, next, err := pagination.GetPaginationLinks(
+		r.URL,
+		count,
+		page,
+		limit,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get paginated results
+	checkResults, err := s.metricsService.FindPaginatedCheckResults(
+		pagination.GetOffsetForPage(count, page, limit),
+		limit,
+		orderBy,
+		int(alarm.ID),
+		outcome,
+		from,
+		to,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	self := util.GetCurrentURL(r)
+	listChecksResponse, err := NewListChecksResponse(
+		count, page,
+		self, first, last, previous, next,
+		checkResults,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, listChecksResponse, http.StatusOK)
+}
+
+func checkListAlarmChecksPermissions(authenticatedUser *accounts.User, alarm *Alarm) error {
+	// Superusers can list any alarm checks
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can list their own alarm checks
+	if authenticatedUser.ID == alarm.User.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrListAlarmChecksPermission
+}
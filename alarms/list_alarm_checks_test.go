@@ -0,0 +1,236 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/jsonhal"
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/pagination"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestListAlarmChecksRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.listAlarmChecksHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestListAlarmChecksWithoutPermission() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/checks", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "list_alarm_checks", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrListAlarmChecksPermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestListAlarmChecksInvalidOutcome() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/checks?outcome=bogus", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock metrics retention
+	suite.mockGetMetricsRetention(suite.alarms[0].User.ID, 30*24*time.Hour)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 400, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": metrics.ErrInvalidOutcome.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestListAlarmChecks() {
+	var (
+		now  = time.Now().UTC()
+		self = fmt.Sprintf("/v1/alarms/%d/checks?outcome=timeout", suite.alarms[0].ID)
+	)
+
+	// Prepare a request
+	r, err := http.NewRequest("GET", "http://1.2.3.4"+self, nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "list_alarm_checks", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// Mock metrics retention
+	suite.mockGetMetricsRetention(suite.alarms[0].User.ID, 30*24*time.Hour)
+
+	// Mock paginated check results
+	suite.mockCheckResultsCount(
+		int(suite.alarms[0].ID), // reference ID
+		incidenttypes.Timeout,   // outcome
+		nil,                     // from
+		nil,                     // to
+		2,                       // returned count
+		nil,                     // returned error
+	)
+	testCheckResults := []*metrics.CheckResult{
+		metrics.NewCheckResult(
+			metrics.CheckResultParentTableName,
+			suite.alarms[0].ID,
+			now,
+			"us-west-2",
+			0, // status code
+			0, // response size
+			incidenttypes.Timeout,
+			"timeout awaiting response headers",
+		),
+		metrics.NewCheckResult(
+			metrics.CheckResultParentTableName,
+			suite.alarms[0].ID,
+			now.Add(time.Minute),
+			"us-west-2",
+			0, // status code
+			0, // response size
+			incidenttypes.Timeout,
+			"timeout awaiting response headers",
+		),
+	}
+	suite.mockFindPaginatedCheckResults(
+		0, // offset
+		pagination.DefaultLimit,
+		"",                      // order by
+		int(suite.alarms[0].ID), // reference ID
+		incidenttypes.Timeout,   // outcome
+		nil,                     // from
+		nil,                     // to
+		testCheckResults,        // returned check results
+		nil,                     // returned error
+	)
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	checkResultResponses := make([]*metrics.CheckResultResponse, len(testCheckResults))
+	for i, testCheckResult := range testCheckResults {
+		checkResultResponse, err := metrics.NewCheckResultResponse(testCheckResult)
+		assert.NoError(suite.T(), err, "Creating response object failed")
+		checkResultResponses[i] = checkResultResponse
+	}
+
+	expected := &ListChecksResponse{
+		Hal: jsonhal.Hal{
+			Links: map[string]*jsonhal.Link{
+				"self": &jsonhal.Link{
+					Href: self,
+				},
+				"first": &jsonhal.Link{
+					Href: self + "&page=1",
+				},
+				"last": &jsonhal.Link{
+					Href: self + "&page=1",
+				},
+				"prev": new(jsonhal.Link),
+				"next": new(jsonhal.Link),
+			},
+			Embedded: map[string]jsonhal.Embedded{
+				"checks": jsonhal.Embedded(checkResultResponses),
+			},
+		},
+		Count: 2,
+		Page:  1,
+	}
+
+	expectedJSON, err := json.Marshal(expected)
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"), // trim the trailing \n
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
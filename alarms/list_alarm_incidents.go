@@ -103,12 +103,19 @@ func (s *Service) listAlarmIncidentsHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Get annotations to draw over the incidents
+	annotations, err := s.findOverlappingAnnotations(alarm, from, to)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// Create response
 	self := util.GetCurrentURL(r)
 	listIncidentsResponse, err := NewListIncidentsResponse(
 		count, page,
 		self, first, last, previous, next,
-		incidents,
+		incidents, annotations,
 	)
 	if err != nil {
 		response.Error(w, err.Error(), http.StatusInternalServerError)
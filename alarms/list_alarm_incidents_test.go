@@ -129,7 +129,8 @@ func (suite *AlarmsTestSuite) TestListAlarmIncidents() {
 				"next": new(jsonhal.Link),
 			},
 			Embedded: map[string]jsonhal.Embedded{
-				"incidents": jsonhal.Embedded(incidentResponses),
+				"incidents":   jsonhal.Embedded(incidentResponses),
+				"annotations": jsonhal.Embedded([]*AnnotationResponse{}),
 			},
 		},
 		Count: 4,
@@ -58,8 +58,21 @@ func (s *Service) listAlarmResponseTimesHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Get other params
-	dateTrunc, from, to, err := metrics.GetParamsFromQueryString(r)
+	// Get other params, history is limited by the alarm owner's plan
+	dateTrunc, from, to, err := metrics.GetParamsFromQueryString(
+		r,
+		s.metricsService.GetMetricsRetention(alarm.User.ID),
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	aggregations, err := metrics.GetAggregationsFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	phase, err := metrics.GetPhaseFromQueryString(r)
 	if err != nil {
 		response.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -69,6 +82,7 @@ func (s *Service) listAlarmResponseTimesHandler(w http.ResponseWriter, r *http.R
 	count, err := s.metricsService.ResponseTimesCount(
 		int(alarm.ID),
 		dateTrunc,
+		phase,
 		from,
 		to,
 	)
@@ -82,6 +96,8 @@ func (s *Service) listAlarmResponseTimesHandler(w http.ResponseWriter, r *http.R
 		responseTimesChan      = make(chan []*metrics.ResponseTime)
 		incidentTypeCountsChan = make(chan map[string]int)
 		uptimeChan             = make(chan float64)
+		aggregationsChan       = make(chan map[string]float64)
+		annotationsChan        = make(chan []*Annotation)
 		errChan                = make(chan error)
 	)
 
@@ -94,6 +110,8 @@ func (s *Service) listAlarmResponseTimesHandler(w http.ResponseWriter, r *http.R
 			orderBy,
 			int(alarm.ID),
 			dateTrunc,
+			phase,
+			aggregations,
 			from,
 			to,
 		)
@@ -123,7 +141,7 @@ func (s *Service) listAlarmResponseTimesHandler(w http.ResponseWriter, r *http.R
 	wg.Add(1)
 	go func() {
 		// Calculate uptime
-		uptime, _, err := s.getUptimeDowntime(alarm)
+		uptime, _, err := s.getUptimeDowntime(alarm, from, to)
 		if err != nil {
 			errChan <- err
 			return
@@ -131,18 +149,54 @@ func (s *Service) listAlarmResponseTimesHandler(w http.ResponseWriter, r *http.R
 		uptimeChan <- uptime
 	}()
 
+	wg.Add(1)
+	go func() {
+		// Aggregations over the whole time range are only calculated on demand
+		if len(aggregations) == 0 {
+			aggregationsChan <- nil
+			return
+		}
+		rangeAggregations, err := s.metricsService.ResponseTimesAggregations(
+			int(alarm.ID),
+			phase,
+			aggregations,
+			from,
+			to,
+		)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		aggregationsChan <- rangeAggregations
+	}()
+
+	wg.Add(1)
+	go func() {
+		// Get annotations to draw over the chart
+		annotations, err := s.findOverlappingAnnotations(alarm, from, to)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		annotationsChan <- annotations
+	}()
+
 	var (
 		responseTimes      []*metrics.ResponseTime
 		incidentTypeCounts map[string]int
 		uptime             float64
+		rangeAggregations  map[string]float64
+		annotations        []*Annotation
 		errs               []error
 	)
 
-	for i := 0; i < 3; i++ {
+	for i := 0; i < 5; i++ {
 		select {
 		case responseTimes = <-responseTimesChan:
 		case incidentTypeCounts = <-incidentTypeCountsChan:
 		case uptime = <-uptimeChan:
+		case rangeAggregations = <-aggregationsChan:
+		case annotations = <-annotationsChan:
 		case err := <-errChan:
 			errs = append(errs, err)
 		}
@@ -179,6 +233,7 @@ func (s *Service) listAlarmResponseTimesHandler(w http.ResponseWriter, r *http.R
 		count, page,
 		self, first, last, previous, next,
 		responseTimes, uptime, incidentTypeCounts,
+		rangeAggregations, annotations,
 	)
 	if err != nil {
 		response.Error(w, err.Error(), http.StatusInternalServerError)
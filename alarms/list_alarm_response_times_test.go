@@ -129,10 +129,14 @@ func (suite *AlarmsTestSuite) TestListAlarmResponseTimesNoResults() {
 	// Mock authentication
 	suite.mockUserAuth(suite.users[1])
 
+	// Mock metrics retention
+	suite.mockGetMetricsRetention(suite.users[1].ID, 30*24*time.Hour)
+
 	// Mock paginated response time metrics
 	suite.mockResponseTimesCount(
 		int(testAlarm.ID), // reference ID
 		"",                // date_trunc
+		metrics.PhaseTotal, // phase
 		nil,               // from
 		nil,               // to
 		0,                 // returned count
@@ -144,6 +148,8 @@ func (suite *AlarmsTestSuite) TestListAlarmResponseTimesNoResults() {
 		"",                // order by
 		int(testAlarm.ID), // reference ID
 		"",                // date_trunc
+		metrics.PhaseTotal, // phase
+		nil,               // aggregations
 		nil,               // from
 		nil,               // to
 		[]*metrics.ResponseTime{}, // returned metrics
@@ -180,6 +186,7 @@ func (suite *AlarmsTestSuite) TestListAlarmResponseTimesNoResults() {
 			},
 			Embedded: map[string]jsonhal.Embedded{
 				"response_times": jsonhal.Embedded([]*metrics.MetricResponse{}),
+				"annotations":    jsonhal.Embedded([]*AnnotationResponse{}),
 			},
 		},
 		Uptime:  100,
@@ -232,10 +239,14 @@ func (suite *AlarmsTestSuite) TestListAlarmResponseTimes() {
 	// Mock authentication
 	suite.mockUserAuth(suite.users[1])
 
+	// Mock metrics retention
+	suite.mockGetMetricsRetention(suite.alarms[0].User.ID, 30*24*time.Hour)
+
 	// Mock paginated response time metrics
 	suite.mockResponseTimesCount(
 		int(suite.alarms[0].ID), // reference ID
 		"",  // date_trunc
+		metrics.PhaseTotal, // phase
 		nil, // from
 		nil, // to
 		4,   // returned count
@@ -253,6 +264,8 @@ func (suite *AlarmsTestSuite) TestListAlarmResponseTimes() {
 		"", // order by
 		int(suite.alarms[0].ID), // reference ID
 		"",          // date_trunc
+		metrics.PhaseTotal, // phase
+		nil,         // aggregations
 		nil,         // from
 		nil,         // to
 		testMetrics, // returned metrics
@@ -272,7 +285,11 @@ func (suite *AlarmsTestSuite) TestListAlarmResponseTimes() {
 	}
 
 	// Check the response body
-	uptime, _, err := suite.service.getUptimeDowntime(suite.alarms[0])
+	uptime, _, err := suite.service.getUptimeDowntime(
+		suite.alarms[0],
+		nil, // from
+		nil, // to
+	)
 	assert.NoError(suite.T(), err, "Failed to fetch uptime")
 	expectedUptime, err := strconv.ParseFloat(fmt.Sprintf("%.4f", uptime), 64)
 	assert.NoError(suite.T(), err, "Failed formatting uptime to 4 decimals")
@@ -306,6 +323,7 @@ func (suite *AlarmsTestSuite) TestListAlarmResponseTimes() {
 			},
 			Embedded: map[string]jsonhal.Embedded{
 				"response_times": jsonhal.Embedded(responseTimeResponses),
+				"annotations":    jsonhal.Embedded([]*AnnotationResponse{}),
 			},
 		},
 		Uptime:  expectedUptime,
@@ -0,0 +1,91 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrListAlarmSLAReportsPermission ...
+	ErrListAlarmSLAReportsPermission = errors.New("Need permission to list alarm SLA reports")
+)
+
+// Handles calls to list monthly alarm SLA reports (GET /v1/alarms/{id:[0-9]+}/sla-reports)
+func (s *Service) listAlarmSLAReportsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	alarmID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the alarm
+	alarm, err := s.FindAlarmByID(uint(alarmID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkListAlarmSLAReportsPermissions(authenticatedUser, alarm); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Get the number of months
+	months, err := getSLAReportMonthsFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Calculate a report for each month
+	reports, err := s.getMonthlySLAReports(alarm, months, time.Now())
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	listSLAReportsResponse, err := NewListSLAReportsResponse(
+		util.GetCurrentURL(r),
+		reports,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, listSLAReportsResponse, http.StatusOK)
+}
+
+func checkListAlarmSLAReportsPermissions(authenticatedUser *accounts.User, alarm *Alarm) error {
+	// Superusers can list any alarm SLA reports
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can list SLA reports of their own alarms
+	if authenticatedUser.ID == alarm.User.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrListAlarmSLAReportsPermission
+}
@@ -0,0 +1,131 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestListAlarmSLAReportsRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.listAlarmSLAReportsHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestListAlarmSLAReportsWithoutPermission() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/sla-reports", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "list_alarm_sla_reports", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 403, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrListAlarmSLAReportsPermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestListAlarmSLAReports() {
+	// Prepare a request
+	r, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/sla-reports?months=2", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "list_alarm_sla_reports", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	response := new(struct {
+		Count    uint `json:"count"`
+		Embedded struct {
+			SLAReports []*SLAReportResponse `json:"sla_reports"`
+		} `json:"_embedded"`
+	})
+	err = json.Unmarshal(w.Body.Bytes(), response)
+	assert.NoError(suite.T(), err, "JSON unmarshalling failed")
+
+	// The alarm has had an open incident for both months, most recent first
+	currentMonth := getMonthStart(time.Now())
+	assert.Equal(suite.T(), uint(2), response.Count)
+	if assert.Equal(suite.T(), 2, len(response.Embedded.SLAReports)) {
+		assert.Equal(suite.T(), util.FormatTime(currentMonth), response.Embedded.SLAReports[0].From)
+		assert.Equal(
+			suite.T(),
+			util.FormatTime(currentMonth.AddDate(0, -1, 0)),
+			response.Embedded.SLAReports[1].From,
+		)
+		for _, report := range response.Embedded.SLAReports {
+			assert.Equal(suite.T(), float64(0), report.Uptime)
+			assert.False(suite.T(), report.SLOMet)
+			assert.True(suite.T(), report.RemainingErrorBudget < 0)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/RichardKnop/pinglist-api/logger"
 	"github.com/RichardKnop/pinglist-api/migrations"
+	"github.com/RichardKnop/uuid"
 	"github.com/jinzhu/gorm"
 )
 
@@ -18,6 +19,54 @@ func MigrateAll(db *gorm.DB) error {
 		return err
 	}
 
+	if err := migrate0003(db); err != nil {
+		return err
+	}
+
+	if err := migrate0004(db); err != nil {
+		return err
+	}
+
+	if err := migrate0005(db); err != nil {
+		return err
+	}
+
+	if err := migrate0006(db); err != nil {
+		return err
+	}
+
+	if err := migrate0007(db); err != nil {
+		return err
+	}
+
+	if err := migrate0008(db); err != nil {
+		return err
+	}
+
+	if err := migrate0009(db); err != nil {
+		return err
+	}
+
+	if err := migrate0010(db); err != nil {
+		return err
+	}
+
+	if err := migrate0011(db); err != nil {
+		return err
+	}
+
+	if err := migrate0012(db); err != nil {
+		return err
+	}
+
+	if err := migrate0013(db); err != nil {
+		return err
+	}
+
+	if err := migrate0014(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -174,3 +223,786 @@ func migrate0002(db *gorm.DB) error {
 
 	return nil
 }
+
+// Migrate0003 adds alarm_status_pages and alarm_status_page_components tables
+func migrate0003(db *gorm.DB) error {
+	migrationName := "alarms_add_status_pages"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	var err error
+
+	// Create alarm_status_pages table
+	if err := db.CreateTable(new(StatusPage)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_status_pages table: %s", err)
+	}
+
+	// Create alarm_status_page_components table
+	if err := db.CreateTable(new(StatusPageComponent)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_status_page_components table: %s", err)
+	}
+
+	// Add foreign key on alarm_status_pages.user_id
+	err = db.Model(new(StatusPage)).AddForeignKey(
+		"user_id",
+		"account_users(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_status_pages.user_id for account_users(id): %s", err)
+	}
+
+	// Add foreign key on alarm_status_pages.team_id
+	err = db.Model(new(StatusPage)).AddForeignKey(
+		"team_id",
+		"team_teams(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_status_pages.team_id for team_teams(id): %s", err)
+	}
+
+	// Add foreign key on alarm_status_page_components.status_page_id
+	err = db.Model(new(StatusPageComponent)).AddForeignKey(
+		"status_page_id",
+		"alarm_status_pages(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_status_page_components.status_page_id for alarm_status_pages(id): %s", err)
+	}
+
+	// Add foreign key on alarm_status_page_component_alarms.status_page_component_id
+	err = db.Table("alarm_status_page_component_alarms").AddForeignKey(
+		"status_page_component_id",
+		"alarm_status_page_components(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_status_page_component_alarms.status_page_component_id "+
+			"for alarm_status_page_components(id): %s", err)
+	}
+
+	// Add foreign key on alarm_status_page_component_alarms.alarm_id
+	err = db.Table("alarm_status_page_component_alarms").AddForeignKey(
+		"alarm_id",
+		"alarm_alarms(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_status_page_component_alarms.alarm_id for alarm_alarms(id): %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0004 adds status page incidents, updates and subscribers tables
+func migrate0004(db *gorm.DB) error {
+	migrationName := "alarms_add_status_page_incidents_and_subscribers"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	var err error
+
+	// Create alarm_status_page_incidents table
+	if err := db.CreateTable(new(StatusPageIncident)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_status_page_incidents table: %s", err)
+	}
+
+	// Create alarm_status_page_incident_updates table
+	if err := db.CreateTable(new(StatusPageIncidentUpdate)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_status_page_incident_updates table: %s", err)
+	}
+
+	// Create alarm_status_page_subscribers table
+	if err := db.CreateTable(new(StatusPageSubscriber)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_status_page_subscribers table: %s", err)
+	}
+
+	// Add foreign key on alarm_status_page_incidents.status_page_id
+	err = db.Model(new(StatusPageIncident)).AddForeignKey(
+		"status_page_id",
+		"alarm_status_pages(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_status_page_incidents.status_page_id for alarm_status_pages(id): %s", err)
+	}
+
+	// Add foreign key on alarm_status_page_incident_updates.status_page_incident_id
+	err = db.Model(new(StatusPageIncidentUpdate)).AddForeignKey(
+		"status_page_incident_id",
+		"alarm_status_page_incidents(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_status_page_incident_updates.status_page_incident_id "+
+			"for alarm_status_page_incidents(id): %s", err)
+	}
+
+	// Add foreign key on alarm_status_page_subscribers.status_page_id
+	err = db.Model(new(StatusPageSubscriber)).AddForeignKey(
+		"status_page_id",
+		"alarm_status_pages(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_status_page_subscribers.status_page_id for alarm_status_pages(id): %s", err)
+	}
+
+	// One subscription per email and status page
+	err = db.Model(new(StatusPageSubscriber)).AddUniqueIndex(
+		"idx_status_page_subscribers_status_page_id_email",
+		"status_page_id",
+		"email",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating unique index on "+
+			"alarm_status_page_subscribers(status_page_id, email): %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0005 adds badge_token column to alarm_alarms table
+func migrate0005(db *gorm.DB) error {
+	migrationName := "alarms_add_badge_tokens"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	var err error
+
+	// Fresh databases already got the column from the initial migration
+	if !db.Dialect().HasColumn("alarm_alarms", "badge_token") {
+		err = db.Exec("ALTER TABLE alarm_alarms ADD COLUMN badge_token varchar(40)").Error
+		if err != nil {
+			return fmt.Errorf("Error adding badge_token column: %s", err)
+		}
+	}
+
+	// Generate tokens for existing alarms
+	var alarmIDs []uint
+	err = db.Table("alarm_alarms").Where("badge_token IS NULL").
+		Pluck("id", &alarmIDs).Error
+	if err != nil {
+		return fmt.Errorf("Error fetching alarms without badge token: %s", err)
+	}
+	for _, alarmID := range alarmIDs {
+		err = db.Table("alarm_alarms").Where("id = ?", alarmID).
+			UpdateColumn("badge_token", uuid.New()).Error
+		if err != nil {
+			return fmt.Errorf("Error generating badge token: %s", err)
+		}
+	}
+
+	err = db.Model(new(Alarm)).AddUniqueIndex(
+		"idx_alarm_alarms_badge_token",
+		"badge_token",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error adding unique index on alarm_alarms.badge_token: %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0006 adds alarm_tags table
+func migrate0006(db *gorm.DB) error {
+	migrationName := "alarms_add_tags"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	var err error
+
+	// Create alarm_tags table
+	if err := db.CreateTable(new(Tag)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_tags table: %s", err)
+	}
+
+	// Create alarm_alarm_tags join table, on fresh databases the initial
+	// migration has already created it together with alarm_alarms
+	if !db.Dialect().HasTable("alarm_alarm_tags") {
+		err = db.Exec(`CREATE TABLE alarm_alarm_tags (
+			alarm_id integer NOT NULL,
+			tag_id integer NOT NULL,
+			PRIMARY KEY (alarm_id, tag_id)
+		)`).Error
+		if err != nil {
+			return fmt.Errorf("Error creating alarm_alarm_tags table: %s", err)
+		}
+	}
+
+	// Add foreign keys
+	err = db.Table("alarm_alarm_tags").AddForeignKey(
+		"alarm_id", "alarm_alarms(id)",
+		"RESTRICT", "RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_alarm_tags.alarm_id for alarm_alarms(id): %s", err)
+	}
+	err = db.Table("alarm_alarm_tags").AddForeignKey(
+		"tag_id", "alarm_tags(id)",
+		"RESTRICT", "RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_alarm_tags.tag_id for alarm_tags(id): %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0007 adds slo_target column to alarm_alarms table
+func migrate0007(db *gorm.DB) error {
+	migrationName := "alarms_add_slo_targets"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Fresh databases already got the column from the initial migration,
+	// existing alarms get the default target
+	if !db.Dialect().HasColumn("alarm_alarms", "slo_target") {
+		err := db.Exec(
+			"ALTER TABLE alarm_alarms ADD COLUMN slo_target numeric(6,3) NOT NULL DEFAULT 99.9",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding slo_target column: %s", err)
+		}
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0008 adds acknowledged_at column to alarm_incidents table
+func migrate0008(db *gorm.DB) error {
+	migrationName := "alarms_add_incident_acknowledgements"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Fresh databases already got the column from the initial migration
+	if !db.Dialect().HasColumn("alarm_incidents", "acknowledged_at") {
+		err := db.Exec(
+			"ALTER TABLE alarm_incidents ADD COLUMN acknowledged_at timestamp with time zone",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding acknowledged_at column: %s", err)
+		}
+		err = db.Model(new(Incident)).AddIndex(
+			"idx_alarm_incidents_acknowledged_at",
+			"acknowledged_at",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding index on alarm_incidents.acknowledged_at: %s", err)
+		}
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0009 adds Prometheus scrape tokens
+func migrate0009(db *gorm.DB) error {
+	migrationName := "alarms_add_scrape_tokens"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	var err error
+
+	// Create alarm_scrape_tokens table
+	if err := db.CreateTable(new(ScrapeToken)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_scrape_tokens table: %s", err)
+	}
+
+	// Add foreign key on alarm_scrape_tokens.user_id
+	err = db.Model(new(ScrapeToken)).AddForeignKey(
+		"user_id",
+		"account_users(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_scrape_tokens.user_id for account_users(id): %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0010 adds anomaly detection settings and response time baselines
+func migrate0010(db *gorm.DB) error {
+	migrationName := "alarms_add_anomaly_detection"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Fresh databases already got the columns from the initial migration
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{"anomaly_detection", "boolean NOT NULL DEFAULT false"},
+		{"anomaly_sensitivity", "numeric(5,2) NOT NULL DEFAULT 3"},
+		{"anomaly_period", "integer NOT NULL DEFAULT 300"},
+		{"anomaly_started_at", "timestamp with time zone"},
+		{"baseline_updated_at", "timestamp with time zone"},
+	}
+	for _, column := range columns {
+		if db.Dialect().HasColumn("alarm_alarms", column.name) {
+			continue
+		}
+		err := db.Exec(fmt.Sprintf(
+			"ALTER TABLE alarm_alarms ADD COLUMN %s %s",
+			column.name,
+			column.definition,
+		)).Error
+		if err != nil {
+			return fmt.Errorf("Error adding %s column: %s", column.name, err)
+		}
+	}
+
+	// Create alarm_baselines table
+	if err := db.CreateTable(new(Baseline)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_baselines table: %s", err)
+	}
+
+	// Add foreign key on alarm_baselines.alarm_id
+	err := db.Model(new(Baseline)).AddForeignKey(
+		"alarm_id",
+		"alarm_alarms(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_baselines.alarm_id for alarm_alarms(id): %s", err)
+	}
+
+	// One baseline per alarm and hour of the week
+	err = db.Model(new(Baseline)).AddUniqueIndex(
+		"idx_alarm_baselines_alarm_id_hour_of_week",
+		"alarm_id",
+		"hour_of_week",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error adding unique index on alarm_baselines: %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0011 creates annotations tables
+func migrate0011(db *gorm.DB) error {
+	migrationName := "alarms_add_annotations"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Create alarm_annotations table, this also creates
+	// alarm_annotation_alarms and alarm_annotation_tags join tables
+	if err := db.CreateTable(new(Annotation)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_annotations table: %s", err)
+	}
+
+	// Add foreign key on alarm_annotations.account_id
+	err := db.Model(new(Annotation)).AddForeignKey(
+		"account_id",
+		"account_accounts(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_annotations.account_id for account_accounts(id): %s", err)
+	}
+
+	// Add foreign key on alarm_annotations.team_id
+	err = db.Model(new(Annotation)).AddForeignKey(
+		"team_id",
+		"team_teams(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_annotations.team_id for team_teams(id): %s", err)
+	}
+
+	// Add foreign key on alarm_annotation_alarms.annotation_id
+	err = db.Table("alarm_annotation_alarms").AddForeignKey(
+		"annotation_id",
+		"alarm_annotations(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_annotation_alarms.annotation_id for alarm_annotations(id): %s", err)
+	}
+
+	// Add foreign key on alarm_annotation_alarms.alarm_id
+	err = db.Table("alarm_annotation_alarms").AddForeignKey(
+		"alarm_id",
+		"alarm_alarms(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_annotation_alarms.alarm_id for alarm_alarms(id): %s", err)
+	}
+
+	// Add foreign key on alarm_annotation_tags.annotation_id
+	err = db.Table("alarm_annotation_tags").AddForeignKey(
+		"annotation_id",
+		"alarm_annotations(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_annotation_tags.annotation_id for alarm_annotations(id): %s", err)
+	}
+
+	// Add foreign key on alarm_annotation_tags.tag_id
+	err = db.Table("alarm_annotation_tags").AddForeignKey(
+		"tag_id",
+		"alarm_tags(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_annotation_tags.tag_id for alarm_tags(id): %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0012 adds a persisted next check time of alarms
+func migrate0012(db *gorm.DB) error {
+	migrationName := "alarms_add_next_check_at"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Fresh databases already got the column from the initial migration
+	if !db.Dialect().HasColumn("alarm_alarms", "next_check_at") {
+		err := db.Exec(
+			"ALTER TABLE alarm_alarms ADD COLUMN next_check_at timestamp with time zone",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding next_check_at column: %s", err)
+		}
+		err = db.Model(new(Alarm)).AddIndex(
+			"idx_alarm_alarms_next_check_at",
+			"next_check_at",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding index on alarm_alarms.next_check_at: %s", err)
+		}
+	}
+
+	// Alarms which have been checked before keep their current schedule
+	err := db.Exec(
+		"UPDATE alarm_alarms SET next_check_at = watermark + interval '1 second' * interval " +
+			"WHERE next_check_at IS NULL AND watermark IS NOT NULL",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error setting next_check_at of existing alarms: %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0013 links regions to accounts of their agents and drops proxy URLs
+func migrate0013(db *gorm.DB) error {
+	migrationName := "alarms_add_region_agents"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Fresh databases already got the column from the initial migration
+	if !db.Dialect().HasColumn("alarm_regions", "agent_account_id") {
+		err := db.Exec(
+			"ALTER TABLE alarm_regions ADD COLUMN agent_account_id integer",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding agent_account_id column: %s", err)
+		}
+		err = db.Model(new(Region)).AddIndex(
+			"idx_alarm_regions_agent_account_id",
+			"agent_account_id",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding index on alarm_regions.agent_account_id: %s", err)
+		}
+	}
+
+	// Add foreign key on alarm_regions.agent_account_id
+	err := db.Model(new(Region)).AddForeignKey(
+		"agent_account_id",
+		"account_accounts(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_regions.agent_account_id for account_accounts(id): %s", err)
+	}
+
+	// Remote regions are checked by agents instead of through proxies
+	if db.Dialect().HasColumn("alarm_regions", "proxy_url") {
+		err := db.Exec("ALTER TABLE alarm_regions DROP COLUMN proxy_url").Error
+		if err != nil {
+			return fmt.Errorf("Error dropping proxy_url column: %s", err)
+		}
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0014 adds annotation tokens and links annotations to users
+func migrate0014(db *gorm.DB) error {
+	migrationName := "alarms_add_annotation_tokens"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Create alarm_annotation_tokens table
+	if err := db.CreateTable(new(AnnotationToken)).Error; err != nil {
+		return fmt.Errorf("Error creating alarm_annotation_tokens table: %s", err)
+	}
+
+	// Add foreign key on alarm_annotation_tokens.user_id
+	err := db.Model(new(AnnotationToken)).AddForeignKey(
+		"user_id",
+		"account_users(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_annotation_tokens.user_id for account_users(id): %s", err)
+	}
+
+	// Fresh databases already got the column from the annotations migration
+	if !db.Dialect().HasColumn("alarm_annotations", "user_id") {
+		err := db.Exec(
+			"ALTER TABLE alarm_annotations ADD COLUMN user_id integer",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding user_id column: %s", err)
+		}
+		err = db.Model(new(Annotation)).AddIndex(
+			"idx_alarm_annotations_user_id",
+			"user_id",
+		).Error
+		if err != nil {
+			return fmt.Errorf("Error adding index on alarm_annotations.user_id: %s", err)
+		}
+	}
+
+	// Add foreign key on alarm_annotations.user_id
+	err = db.Model(new(Annotation)).AddForeignKey(
+		"user_id",
+		"account_users(id)",
+		"RESTRICT",
+		"RESTRICT",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating foreign key on "+
+			"alarm_annotations.user_id for account_users(id): %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
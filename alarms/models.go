@@ -4,10 +4,13 @@ import (
 	"database/sql"
 	"net/http"
 	"net/http/httputil"
+	"time"
 
 	"github.com/RichardKnop/pinglist-api/accounts"
 	"github.com/RichardKnop/pinglist-api/database"
+	"github.com/RichardKnop/pinglist-api/teams"
 	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/RichardKnop/uuid"
 	"github.com/jinzhu/gorm"
 	"github.com/lib/pq"
 )
@@ -15,9 +18,10 @@ import (
 // Region is a region from where alarm checks will be run
 type Region struct {
 	database.TimestampModel
-	ID       string         `gorm:"primary_key" sql:"type:varchar(20)"`
-	Name     string         `sql:"type:varchar(50);unique;not null"`
-	ProxyURL sql.NullString `sql:"type:varchar(200)"`
+	ID             string        `gorm:"primary_key" sql:"type:varchar(20)"`
+	Name           string        `sql:"type:varchar(50);unique;not null"`
+	AgentAccountID sql.NullInt64 `sql:"index"`
+	AgentAccount   *accounts.Account
 }
 
 // TableName specifies table name
@@ -47,17 +51,26 @@ type Alarm struct {
 	AlarmStateID           sql.NullString `sql:"type:varchar(20);index;not null"`
 	AlarmState             *AlarmState
 	Incidents              []*Incident
-	EndpointURL            string      `sql:"type:varchar(254);not null"`
-	ExpectedHTTPCode       uint        `sql:"default:200;not null"`
-	MaxResponseTime        uint        `sql:"default:60;not null"` // miliseconds
-	Interval               uint        `sql:"default:60;not null"` // seconds
-	EmailAlerts            bool        `sql:"default:false;index;not null"`
-	PushNotificationAlerts bool        `sql:"default:false;index;not null"`
-	SlackAlerts            bool        `sql:"default:false;index;not null"`
-	Active                 bool        `sql:"index;not null"`
-	Watermark              pq.NullTime `sql:"index"`
-	LastDowntimeStartedAt  pq.NullTime `sql:"index"`
-	LastUptimeStartedAt    pq.NullTime `sql:"index"`
+	Tags                   []*Tag         `gorm:"many2many:alarm_alarm_tags"`
+	EndpointURL            string         `sql:"type:varchar(254);not null"`
+	ExpectedHTTPCode       uint           `sql:"default:200;not null"`
+	MaxResponseTime        uint           `sql:"default:60;not null"` // miliseconds
+	Interval               uint           `sql:"default:60;not null"` // seconds
+	EmailAlerts            bool           `sql:"default:false;index;not null"`
+	PushNotificationAlerts bool           `sql:"default:false;index;not null"`
+	SlackAlerts            bool           `sql:"default:false;index;not null"`
+	Active                 bool           `sql:"index;not null"`
+	Watermark              pq.NullTime    `sql:"index"`
+	NextCheckAt            pq.NullTime    `sql:"index"`
+	LastDowntimeStartedAt  pq.NullTime    `sql:"index"`
+	LastUptimeStartedAt    pq.NullTime    `sql:"index"`
+	BadgeToken             sql.NullString `sql:"type:varchar(40)"`
+	SLOTarget              float64        `sql:"type:numeric(6,3);default:99.9;not null"` // percent
+	AnomalyDetection       bool           `sql:"default:false;not null"`
+	AnomalySensitivity     float64        `sql:"type:numeric(5,2);default:3;not null"` // scaled MADs
+	AnomalyPeriod          uint           `sql:"default:300;not null"`                 // seconds
+	AnomalyStartedAt       pq.NullTime
+	BaselineUpdatedAt      pq.NullTime
 }
 
 // TableName specifies table name
@@ -65,6 +78,82 @@ func (a *Alarm) TableName() string {
 	return "alarm_alarms"
 }
 
+// Baseline is the usual response time of an alarm in one hour of the week
+type Baseline struct {
+	gorm.Model
+	AlarmID    sql.NullInt64 `sql:"index;not null"`
+	Alarm      *Alarm
+	HourOfWeek uint    `sql:"not null"` // 0 is Sunday midnight UTC
+	Median     float64 `sql:"not null"` // milliseconds
+	MAD        float64 `sql:"not null"` // milliseconds
+	Samples    uint    `sql:"not null"`
+}
+
+// TableName specifies table name
+func (b *Baseline) TableName() string {
+	return "alarm_baselines"
+}
+
+// Annotation marks an event such as a deployment on response time charts
+// of alarms of the user who posted it or of a team the user belongs to
+type Annotation struct {
+	gorm.Model
+	AccountID sql.NullInt64 `sql:"index;not null"`
+	Account   *accounts.Account
+	UserID    sql.NullInt64 `sql:"index"`
+	User      *accounts.User
+	TeamID    sql.NullInt64 `sql:"index"`
+	Team      *teams.Team
+	Title     string      `sql:"type:varchar(254);not null"`
+	StartedAt time.Time   `sql:"index;not null"`
+	EndedAt   pq.NullTime `sql:"index"`
+	Alarms    []*Alarm    `gorm:"many2many:alarm_annotation_alarms"`
+	Tags      []*Tag      `gorm:"many2many:alarm_annotation_tags"`
+}
+
+// TableName specifies table name
+func (a *Annotation) TableName() string {
+	return "alarm_annotations"
+}
+
+// ScrapeToken authenticates Prometheus scrapes of alarm metrics of a user
+type ScrapeToken struct {
+	gorm.Model
+	UserID sql.NullInt64 `sql:"unique;not null"`
+	User   *accounts.User
+	Token  string `sql:"type:varchar(40);unique;not null"`
+}
+
+// TableName specifies table name
+func (t *ScrapeToken) TableName() string {
+	return "alarm_scrape_tokens"
+}
+
+// AnnotationToken authenticates annotations posted by CI and other
+// automation on behalf of a user
+type AnnotationToken struct {
+	gorm.Model
+	UserID sql.NullInt64 `sql:"unique;not null"`
+	User   *accounts.User
+	Token  string `sql:"type:varchar(40);unique;not null"`
+}
+
+// TableName specifies table name
+func (t *AnnotationToken) TableName() string {
+	return "alarm_annotation_tokens"
+}
+
+// Tag is a label used to group alarms
+type Tag struct {
+	gorm.Model
+	Name string `sql:"type:varchar(50);unique;not null"`
+}
+
+// TableName specifies table name
+func (t *Tag) TableName() string {
+	return "alarm_tags"
+}
+
 // IncidentType ...
 type IncidentType struct {
 	database.TimestampModel
@@ -88,6 +177,7 @@ type Incident struct {
 	ResponseTime   sql.NullInt64  // nanoseconds
 	Response       sql.NullString `sql:"type:text"`
 	ErrorMessage   sql.NullString
+	AcknowledgedAt pq.NullTime `sql:"index"`
 	ResolvedAt     pq.NullTime `sql:"index"`
 }
 
@@ -113,6 +203,85 @@ func (i *NotificationCounter) TableName() string {
 	return "alarm_notification_counters"
 }
 
+// StatusPage is a public page showing state of selected alarms
+type StatusPage struct {
+	gorm.Model
+	UserID     sql.NullInt64 `sql:"index;not null"`
+	User       *accounts.User
+	TeamID     sql.NullInt64 `sql:"index"`
+	Team       *teams.Team
+	Slug       string `sql:"type:varchar(50);unique;not null"`
+	Title      string `sql:"type:varchar(100);not null"`
+	Components []*StatusPageComponent
+}
+
+// TableName specifies table name
+func (p *StatusPage) TableName() string {
+	return "alarm_status_pages"
+}
+
+// StatusPageComponent groups alarms under a named status page component
+type StatusPageComponent struct {
+	gorm.Model
+	StatusPageID sql.NullInt64 `sql:"index;not null"`
+	StatusPage   *StatusPage
+	Name         string   `sql:"type:varchar(50);not null"`
+	Position     uint     `sql:"default:0;not null"`
+	Alarms       []*Alarm `gorm:"many2many:alarm_status_page_component_alarms"`
+}
+
+// TableName specifies table name
+func (c *StatusPageComponent) TableName() string {
+	return "alarm_status_page_components"
+}
+
+// StatusPageIncident is a human reported incident posted on a status page
+type StatusPageIncident struct {
+	gorm.Model
+	StatusPageID sql.NullInt64 `sql:"index;not null"`
+	StatusPage   *StatusPage
+	Title        string      `sql:"type:varchar(100);not null"`
+	Status       string      `sql:"type:varchar(20);index;not null"`
+	ResolvedAt   pq.NullTime `sql:"index"`
+	Updates      []*StatusPageIncidentUpdate
+}
+
+// TableName specifies table name
+func (i *StatusPageIncident) TableName() string {
+	return "alarm_status_page_incidents"
+}
+
+// StatusPageIncidentUpdate is a single update of a status page incident
+type StatusPageIncidentUpdate struct {
+	gorm.Model
+	StatusPageIncidentID sql.NullInt64 `sql:"index;not null"`
+	StatusPageIncident   *StatusPageIncident
+	Status               string `sql:"type:varchar(20);not null"`
+	Message              string `sql:"type:text;not null"`
+}
+
+// TableName specifies table name
+func (u *StatusPageIncidentUpdate) TableName() string {
+	return "alarm_status_page_incident_updates"
+}
+
+// StatusPageSubscriber receives status page incident updates by email
+type StatusPageSubscriber struct {
+	gorm.Model
+	StatusPageID sql.NullInt64 `sql:"index;not null"`
+	StatusPage   *StatusPage
+	Email        string `sql:"type:varchar(254);index;not null"`
+	Reference    string `sql:"type:varchar(40);unique;not null"`
+	Confirmed    bool   `sql:"index;not null"`
+	EmailSent    bool   `sql:"index;not null"`
+	EmailSentAt  pq.NullTime
+}
+
+// TableName specifies table name
+func (s *StatusPageSubscriber) TableName() string {
+	return "alarm_status_page_subscribers"
+}
+
 // NewAlarm creates new Alarm instance
 func NewAlarm(user *accounts.User, region *Region, alarmState *AlarmState, alarmRequest *AlarmRequest) *Alarm {
 	userID := util.PositiveIntOrNull(int64(user.ID))
@@ -130,10 +299,31 @@ func NewAlarm(user *accounts.User, region *Region, alarmState *AlarmState, alarm
 		PushNotificationAlerts: alarmRequest.PushNotificationAlerts,
 		SlackAlerts:            alarmRequest.SlackAlerts,
 		Active:                 alarmRequest.Active,
+		BadgeToken:             util.StringOrNull(uuid.New()),
+		SLOTarget:              alarmRequest.SLOTarget,
+		AnomalyDetection:       alarmRequest.AnomalyDetection,
+		AnomalySensitivity:     alarmRequest.AnomalySensitivity,
+		AnomalyPeriod:          alarmRequest.AnomalyPeriod,
 	}
 	return alarm
 }
 
+// NewAnnotation creates new Annotation instance
+func NewAnnotation(user *accounts.User, team *teams.Team, title string, startedAt time.Time, endedAt *time.Time) *Annotation {
+	userID := util.PositiveIntOrNull(int64(user.ID))
+	annotation := &Annotation{
+		AccountID: user.AccountID,
+		UserID:    userID,
+		Title:     title,
+		StartedAt: startedAt.UTC(),
+		EndedAt:   util.TimeOrNull(endedAt),
+	}
+	if team != nil {
+		annotation.TeamID = util.PositiveIntOrNull(int64(team.ID))
+	}
+	return annotation
+}
+
 // NewIncident creates new Incident instance
 func NewIncident(alarm *Alarm, incidentType *IncidentType, resp *http.Response, responseTime int64, errMsg string) *Incident {
 	alarmID := util.PositiveIntOrNull(int64(alarm.ID))
@@ -166,3 +356,64 @@ func NewIncident(alarm *Alarm, incidentType *IncidentType, resp *http.Response,
 
 	return incident
 }
+
+// NewStatusPage creates new StatusPage instance
+func NewStatusPage(user *accounts.User, team *teams.Team, slug, title string) *StatusPage {
+	userID := util.PositiveIntOrNull(int64(user.ID))
+	statusPage := &StatusPage{
+		UserID: userID,
+		Slug:   slug,
+		Title:  title,
+	}
+	if team != nil {
+		statusPage.TeamID = util.PositiveIntOrNull(int64(team.ID))
+	}
+	return statusPage
+}
+
+// NewStatusPageComponent creates new StatusPageComponent instance
+func NewStatusPageComponent(statusPage *StatusPage, name string, position uint, alarms []*Alarm) *StatusPageComponent {
+	statusPageID := util.PositiveIntOrNull(int64(statusPage.ID))
+	component := &StatusPageComponent{
+		StatusPageID: statusPageID,
+		Name:         name,
+		Position:     position,
+		Alarms:       alarms,
+	}
+	return component
+}
+
+// NewStatusPageIncident creates new StatusPageIncident instance
+func NewStatusPageIncident(statusPage *StatusPage, title, status string) *StatusPageIncident {
+	statusPageID := util.PositiveIntOrNull(int64(statusPage.ID))
+	incident := &StatusPageIncident{
+		StatusPageID: statusPageID,
+		Title:        title,
+		Status:       status,
+		ResolvedAt:   util.TimeOrNull(nil),
+	}
+	return incident
+}
+
+// NewStatusPageIncidentUpdate creates new StatusPageIncidentUpdate instance
+func NewStatusPageIncidentUpdate(incident *StatusPageIncident, status, message string) *StatusPageIncidentUpdate {
+	incidentID := util.PositiveIntOrNull(int64(incident.ID))
+	update := &StatusPageIncidentUpdate{
+		StatusPageIncidentID: incidentID,
+		Status:               status,
+		Message:              message,
+	}
+	return update
+}
+
+// NewStatusPageSubscriber creates new StatusPageSubscriber instance
+func NewStatusPageSubscriber(statusPage *StatusPage, email string) *StatusPageSubscriber {
+	statusPageID := util.PositiveIntOrNull(int64(statusPage.ID))
+	subscriber := &StatusPageSubscriber{
+		StatusPageID: statusPageID,
+		Email:        email,
+		Reference:    uuid.New(),
+		EmailSentAt:  util.TimeOrNull(nil),
+	}
+	return subscriber
+}
@@ -0,0 +1,234 @@
+package alarms
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/prometheus"
+)
+
+const (
+	// LastResponseTimeMaxAge limits how old the last response time exposed
+	// to Prometheus can be, alarms not checked recently have no sample
+	LastResponseTimeMaxAge = 24 * time.Hour
+)
+
+var (
+	prometheusAlarmStates = []string{
+		alarmstates.OK,
+		alarmstates.Alarm,
+		alarmstates.InsufficientData,
+	}
+
+	prometheusIncidentTypes = []string{
+		incidenttypes.Slow,
+		incidenttypes.Timeout,
+		incidenttypes.BadCode,
+		incidenttypes.Other,
+	}
+
+	prometheusCheckOutcomes = []string{
+		metrics.CheckOutcomeSuccess,
+		incidenttypes.Slow,
+		incidenttypes.Timeout,
+		incidenttypes.BadCode,
+		incidenttypes.Other,
+	}
+
+	// prometheusUptimeWindows are the same periods as uptime badges
+	prometheusUptimeWindows = []struct {
+		name   string
+		period time.Duration
+	}{
+		{"24h", badgePeriods["uptime-24h"]},
+		{"7d", badgePeriods["uptime-7d"]},
+		{"30d", badgePeriods["uptime-30d"]},
+	}
+)
+
+// alarmIncidentCounts are numbers of all and open incidents of an alarm
+type alarmIncidentCounts struct {
+	byType map[string]int
+	open   int
+}
+
+// getPrometheusFamilies returns Prometheus metrics of all alarms of the user,
+// every sample is labelled by alarm ID, endpoint and region
+func (s *Service) getPrometheusFamilies(userID uint, now time.Time) ([]*prometheus.Family, error) {
+	var alarms []*Alarm
+	if err := s.db.Where("user_id = ?", userID).Order("id").Find(&alarms).Error; err != nil {
+		return nil, err
+	}
+
+	alarmIDs := make([]uint, len(alarms))
+	for i, alarm := range alarms {
+		alarmIDs[i] = alarm.ID
+	}
+
+	incidentCounts, err := s.getAlarmIncidentCounts(alarmIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Incidents overlapping the longest uptime window
+	incidents, err := s.findIncidentsSince(alarmIDs, now.Add(-prometheusUptimeWindows[len(prometheusUptimeWindows)-1].period))
+	if err != nil {
+		return nil, err
+	}
+
+	lastResponseTimes, err := s.metricsService.LastResponseTimes(alarmIDs, now.Add(-LastResponseTimeMaxAge))
+	if err != nil {
+		return nil, err
+	}
+
+	checkResultCounts, err := s.metricsService.CheckResultCounts(alarmIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		stateFamily = prometheus.NewFamily(
+			"pinglist_alarm_state",
+			"Current state of the alarm, 1 for the state the alarm is in.",
+			prometheus.TypeGauge,
+		)
+		lastResponseTimeFamily = prometheus.NewFamily(
+			"pinglist_alarm_last_response_time_seconds",
+			"Response time of the most recent check of the alarm.",
+			prometheus.TypeGauge,
+		)
+		openIncidentsFamily = prometheus.NewFamily(
+			"pinglist_alarm_open_incidents",
+			"Number of open incidents of the alarm.",
+			prometheus.TypeGauge,
+		)
+		uptimeFamily = prometheus.NewFamily(
+			"pinglist_alarm_uptime_ratio",
+			"Ratio of time without an open incident over a window.",
+			prometheus.TypeGauge,
+		)
+		checksFamily = prometheus.NewFamily(
+			"pinglist_alarm_checks_retained",
+			"Number of checks of the alarm by outcome within metrics retention.",
+			prometheus.TypeGauge,
+		)
+		incidentsFamily = prometheus.NewFamily(
+			"pinglist_alarm_incidents_total",
+			"Number of incidents of the alarm by type.",
+			prometheus.TypeCounter,
+		)
+	)
+
+	for _, alarm := range alarms {
+		for _, state := range prometheusAlarmStates {
+			value := 0.0
+			if alarm.AlarmStateID.String == state {
+				value = 1
+			}
+			stateFamily.Add(value, alarmLabels(alarm, "state", state))
+		}
+
+		if responseTime, ok := lastResponseTimes[alarm.ID]; ok {
+			lastResponseTimeFamily.Add(
+				time.Duration(responseTime.Value).Seconds(),
+				alarmLabels(alarm, "", ""),
+			)
+		}
+
+		counts, ok := incidentCounts[alarm.ID]
+		if !ok {
+			counts = &alarmIncidentCounts{byType: make(map[string]int)}
+		}
+		openIncidentsFamily.Add(float64(counts.open), alarmLabels(alarm, "", ""))
+		for _, incidentType := range prometheusIncidentTypes {
+			incidentsFamily.Add(
+				float64(counts.byType[incidentType]),
+				alarmLabels(alarm, "type", incidentType),
+			)
+		}
+
+		periods := getDowntimePeriods(incidents, []*Alarm{alarm}, now)
+		for _, window := range prometheusUptimeWindows {
+			from := now.Add(-window.period)
+			if alarm.CreatedAt.After(from) {
+				from = alarm.CreatedAt
+			}
+			uptimeFamily.Add(
+				getWindowUptime(periods, from, now)/100,
+				alarmLabels(alarm, "window", window.name),
+			)
+		}
+
+		for _, outcome := range prometheusCheckOutcomes {
+			checksFamily.Add(
+				float64(checkResultCounts[alarm.ID][outcome]),
+				alarmLabels(alarm, "outcome", outcome),
+			)
+		}
+	}
+
+	return []*prometheus.Family{
+		stateFamily,
+		lastResponseTimeFamily,
+		openIncidentsFamily,
+		uptimeFamily,
+		checksFamily,
+		incidentsFamily,
+	}, nil
+}
+
+// getAlarmIncidentCounts returns numbers of incidents by type and numbers of
+// open incidents of alarms, keyed by alarm ID
+func (s *Service) getAlarmIncidentCounts(alarmIDs []uint) (map[uint]*alarmIncidentCounts, error) {
+	counts := make(map[uint]*alarmIncidentCounts)
+	if len(alarmIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := s.db.Model(new(Incident)).Select(
+		"alarm_id, incident_type_id, COUNT(*), "+
+			"SUM(CASE WHEN resolved_at IS NULL THEN 1 ELSE 0 END)",
+	).Where("alarm_id IN (?)", alarmIDs).
+		Group("alarm_id, incident_type_id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			alarmID      int64
+			incidentType string
+			count, open  int
+		)
+		if err := rows.Scan(&alarmID, &incidentType, &count, &open); err != nil {
+			return nil, err
+		}
+		alarmCounts, ok := counts[uint(alarmID)]
+		if !ok {
+			alarmCounts = &alarmIncidentCounts{byType: make(map[string]int)}
+			counts[uint(alarmID)] = alarmCounts
+		}
+		alarmCounts.byType[incidentType] = count
+		alarmCounts.open += open
+	}
+
+	return counts, rows.Err()
+}
+
+// alarmLabels returns labels identifying the alarm, optionally with one
+// more label
+func alarmLabels(alarm *Alarm, name, value string) prometheus.Labels {
+	labels := prometheus.Labels{
+		"alarm_id": strconv.FormatUint(uint64(alarm.ID), 10),
+		"endpoint": alarm.EndpointURL,
+		"region":   alarm.RegionID.String,
+	}
+	if name != "" {
+		labels[name] = value
+	}
+	return labels
+}
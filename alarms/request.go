@@ -2,13 +2,88 @@ package alarms
 
 // AlarmRequest ...
 type AlarmRequest struct {
-	Region                 string `json:"region"`
-	EndpointURL            string `json:"endpoint_url"`
-	ExpectedHTTPCode       uint   `json:"expected_http_code"`
-	MaxResponseTime        uint   `json:"max_response_time"`
-	Interval               uint   `json:"interval"`
-	EmailAlerts            bool   `json:"email_alerts"`
-	PushNotificationAlerts bool   `json:"push_notification_alerts"`
-	SlackAlerts            bool   `json:"slack_alerts"`
-	Active                 bool   `json:"active"`
+	Region                 string   `json:"region"`
+	EndpointURL            string   `json:"endpoint_url"`
+	ExpectedHTTPCode       uint     `json:"expected_http_code"`
+	MaxResponseTime        uint     `json:"max_response_time"`
+	Interval               uint     `json:"interval"`
+	EmailAlerts            bool     `json:"email_alerts"`
+	PushNotificationAlerts bool     `json:"push_notification_alerts"`
+	SlackAlerts            bool     `json:"slack_alerts"`
+	Active                 bool     `json:"active"`
+	Tags                   []string `json:"tags"`
+	SLOTarget              float64  `json:"slo_target"`
+	AnomalyDetection       bool     `json:"anomaly_detection"`
+	AnomalySensitivity     float64  `json:"anomaly_sensitivity"`
+	AnomalyPeriod          uint     `json:"anomaly_period"`
+}
+
+// StatusPageRequest ...
+type StatusPageRequest struct {
+	Slug       string                        `json:"slug"`
+	Title      string                        `json:"title"`
+	TeamID     uint                          `json:"team_id"`
+	Components []*StatusPageComponentRequest `json:"components"`
+}
+
+// StatusPageComponentRequest ...
+type StatusPageComponentRequest struct {
+	Name     string `json:"name"`
+	AlarmIDs []uint `json:"alarm_ids"`
+}
+
+// StatusPageIncidentRequest ...
+type StatusPageIncidentRequest struct {
+	Title   string `json:"title"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// StatusPageIncidentUpdateRequest ...
+type StatusPageIncidentUpdateRequest struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// StatusPageSubscriberRequest ...
+type StatusPageSubscriberRequest struct {
+	Email string `json:"email"`
+}
+
+// AnnotationRequest ...
+type AnnotationRequest struct {
+	Title     string   `json:"title"`
+	Tags      []string `json:"tags"`
+	AlarmIDs  []uint   `json:"alarm_ids"`
+	TeamID    uint     `json:"team_id"`
+	StartedAt string   `json:"started_at"`
+	EndedAt   string   `json:"ended_at"`
+}
+
+// CheckLeaseRequest ...
+type CheckLeaseRequest struct {
+	Limit int `json:"limit"`
+}
+
+// CheckResultRequest ...
+type CheckResultRequest struct {
+	AlarmID      uint                 `json:"alarm_id"`
+	Watermark    string               `json:"watermark"`
+	StartedAt    string               `json:"started_at"`
+	ResponseTime int64                `json:"response_time"` // nanoseconds
+	StatusCode   int                  `json:"status_code"`
+	Headers      map[string][]string  `json:"headers"`
+	ResponseSize int64                `json:"response_size"`
+	Timings      *CheckTimingsRequest `json:"timings"`
+	Timeout      bool                 `json:"timeout"`
+	ErrorMessage string               `json:"error_message"`
+}
+
+// CheckTimingsRequest ...
+type CheckTimingsRequest struct {
+	DNSLookup    int64 `json:"dns_lookup"`
+	Connect      int64 `json:"connect"`
+	TLSHandshake int64 `json:"tls_handshake"`
+	FirstByte    int64 `json:"first_byte"`
+	Transfer     int64 `json:"transfer"`
 }
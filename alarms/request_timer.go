@@ -0,0 +1,91 @@
+package alarms
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/metrics"
+)
+
+// requestTimer records when phases of a request started and finished,
+// hooks can be called from different goroutines so access is synchronized
+type requestTimer struct {
+	mutex        sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+// trace returns hooks recording the phases
+func (t *requestTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(&t.dnsDone)
+		},
+		ConnectStart: func(network, addr string) {
+			// Several addresses might be dialed, take the first attempt
+			t.mutex.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mutex.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				t.record(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.record(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(&t.tlsDone)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.record(&t.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			t.record(&t.firstByte)
+		},
+	}
+}
+
+// record sets a phase time to now
+func (t *requestTimer) record(phase *time.Time) {
+	t.mutex.Lock()
+	*phase = time.Now()
+	t.mutex.Unlock()
+}
+
+// timings returns durations of the phases, done is when the response
+// body has been read, phases which did not happen are zero
+func (t *requestTimer) timings(done time.Time) *metrics.Timings {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return &metrics.Timings{
+		DNSLookup:    phaseDuration(t.dnsStart, t.dnsDone),
+		Connect:      phaseDuration(t.connectStart, t.connectDone),
+		TLSHandshake: phaseDuration(t.tlsStart, t.tlsDone),
+		FirstByte:    phaseDuration(t.wroteRequest, t.firstByte),
+		Transfer:     phaseDuration(t.firstByte, done),
+	}
+}
+
+// phaseDuration returns nanoseconds between start and end of a phase
+func phaseDuration(start, end time.Time) int64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Nanoseconds()
+}
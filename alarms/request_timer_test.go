@@ -0,0 +1,52 @@
+package alarms
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, "OK")
+	}))
+	defer server.Close()
+
+	timer := new(requestTimer)
+	req, err := http.NewRequest("GET", server.URL, nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.trace()))
+
+	resp, err := new(http.Client).Do(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+	timings := timer.timings(time.Now())
+
+	// No DNS lookup nor TLS handshake for a plain HTTP request to an IP
+	assert.Equal(t, int64(0), timings.DNSLookup)
+	assert.Equal(t, int64(0), timings.TLSHandshake)
+
+	// The server was sleeping before writing the first byte
+	assert.True(t, timings.Connect > 0)
+	assert.True(t, timings.FirstByte >= (10 * time.Millisecond).Nanoseconds())
+	assert.True(t, timings.Transfer >= 0)
+}
+
+func TestPhaseDuration(t *testing.T) {
+	var (
+		start = time.Now()
+		end   = start.Add(time.Second)
+	)
+
+	assert.Equal(t, time.Second.Nanoseconds(), phaseDuration(start, end))
+	assert.Equal(t, int64(0), phaseDuration(time.Time{}, end))
+	assert.Equal(t, int64(0), phaseDuration(start, time.Time{}))
+	assert.Equal(t, int64(0), phaseDuration(end, start))
+}
@@ -2,9 +2,12 @@ package alarms
 
 import (
 	"fmt"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/RichardKnop/jsonhal"
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
 	"github.com/RichardKnop/pinglist-api/metrics"
 	"github.com/RichardKnop/pinglist-api/util"
 )
@@ -38,6 +41,12 @@ type AlarmResponse struct {
 	SlackAlerts            bool   `json:"slack_alerts"`
 	Active                 bool   `json:"active"`
 	State                  string `json:"state"`
+	BadgeToken             string   `json:"badge_token"`
+	Tags                   []string `json:"tags"`
+	SLOTarget              float64  `json:"slo_target"`
+	AnomalyDetection       bool     `json:"anomaly_detection"`
+	AnomalySensitivity     float64  `json:"anomaly_sensitivity"`
+	AnomalyPeriod          uint     `json:"anomaly_period"`
 	CreatedAt              string `json:"created_at"`
 	UpdatedAt              string `json:"updated_at"`
 }
@@ -52,16 +61,17 @@ type ListAlarmsResponse struct {
 // IncidentResponse ...
 type IncidentResponse struct {
 	jsonhal.Hal
-	ID           uint    `json:"id"`
-	AlarmID      uint    `json:"alarm_id"`
-	Type         string  `json:"type"`
-	HTTPCode     *uint   `json:"http_code"`
-	Response     *string `json:"response"`
-	ResponseTime *uint   `json:"response_time"`
-	ErrorMessage *string `json:"error_message"`
-	ResolvedAt   *string `json:"resolved_at"`
-	CreatedAt    string  `json:"created_at"`
-	UpdatedAt    string  `json:"updated_at"`
+	ID             uint    `json:"id"`
+	AlarmID        uint    `json:"alarm_id"`
+	Type           string  `json:"type"`
+	HTTPCode       *uint   `json:"http_code"`
+	Response       *string `json:"response"`
+	ResponseTime   *uint   `json:"response_time"`
+	ErrorMessage   *string `json:"error_message"`
+	AcknowledgedAt *string `json:"acknowledged_at"`
+	ResolvedAt     *string `json:"resolved_at"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
 }
 
 // ListIncidentsResponse ...
@@ -71,14 +81,22 @@ type ListIncidentsResponse struct {
 	Page  uint `json:"page"`
 }
 
+// ListChecksResponse ...
+type ListChecksResponse struct {
+	jsonhal.Hal
+	Count uint `json:"count"`
+	Page  uint `json:"page"`
+}
+
 // ListResponseTimesResponse ...
 type ListResponseTimesResponse struct {
 	jsonhal.Hal
 	Uptime             float64        `json:"uptime"`
-	Average            float64        `json:"average"`
-	IncidentTypeCounts map[string]int `json:"incident_type_counts"`
-	Count              uint           `json:"count"`
-	Page               uint           `json:"page"`
+	Average            float64            `json:"average"`
+	Aggregations       map[string]float64 `json:"aggregations,omitempty"`
+	IncidentTypeCounts map[string]int     `json:"incident_type_counts"`
+	Count              uint               `json:"count"`
+	Page               uint               `json:"page"`
 }
 
 // NewRegionResponse creates new ResultResponse instance
@@ -154,9 +172,18 @@ func NewAlarmResponse(alarm *Alarm) (*AlarmResponse, error) {
 		SlackAlerts:            alarm.SlackAlerts,
 		Active:                 alarm.Active,
 		State:                  alarm.AlarmStateID.String,
+		BadgeToken:             alarm.BadgeToken.String,
+		Tags:                   make([]string, len(alarm.Tags)),
+		SLOTarget:              alarm.SLOTarget,
+		AnomalyDetection:       alarm.AnomalyDetection,
+		AnomalySensitivity:     alarm.AnomalySensitivity,
+		AnomalyPeriod:          alarm.AnomalyPeriod,
 		CreatedAt:              util.FormatTime(alarm.CreatedAt),
 		UpdatedAt:              util.FormatTime(alarm.UpdatedAt),
 	}
+	for i, tag := range alarm.Tags {
+		response.Tags[i] = tag.Name
+	}
 
 	// Set the self link
 	response.SetLink(
@@ -234,6 +261,10 @@ func NewIncidentResponse(incident *Incident) (*IncidentResponse, error) {
 		e := incident.ErrorMessage.String
 		response.ErrorMessage = &e
 	}
+	if incident.AcknowledgedAt.Valid {
+		acknowledgedAt := util.FormatTime(incident.AcknowledgedAt.Time)
+		response.AcknowledgedAt = &acknowledgedAt
+	}
 	if incident.ResolvedAt.Valid {
 		resolvedAt := util.FormatTime(incident.ResolvedAt.Time)
 		response.ResolvedAt = &resolvedAt
@@ -254,7 +285,7 @@ func NewIncidentResponse(incident *Incident) (*IncidentResponse, error) {
 }
 
 // NewListIncidentsResponse creates new ListIncidentsResponse instance
-func NewListIncidentsResponse(count, page int, self, first, last, previous, next string, incidents []*Incident) (*ListIncidentsResponse, error) {
+func NewListIncidentsResponse(count, page int, self, first, last, previous, next string, incidents []*Incident, annotations []*Annotation) (*ListIncidentsResponse, error) {
 	response := &ListIncidentsResponse{
 		Count: uint(count),
 		Page:  uint(page),
@@ -291,11 +322,62 @@ func NewListIncidentsResponse(count, page int, self, first, last, previous, next
 		jsonhal.Embedded(incidentResponses),
 	)
 
+	// Set embedded annotations
+	annotationResponses, err := newAnnotationResponses(annotations)
+	if err != nil {
+		return nil, err
+	}
+	response.SetEmbedded(
+		"annotations",
+		jsonhal.Embedded(annotationResponses),
+	)
+
+	return response, nil
+}
+
+// NewListChecksResponse creates new ListChecksResponse instance
+func NewListChecksResponse(count, page int, self, first, last, previous, next string, checkResults []*metrics.CheckResult) (*ListChecksResponse, error) {
+	response := &ListChecksResponse{
+		Count: uint(count),
+		Page:  uint(page),
+	}
+
+	// Set the self link
+	response.SetLink("self", self, "")
+
+	// Set the first link
+	response.SetLink("first", first, "")
+
+	// Set the last link
+	response.SetLink("last", last, "")
+
+	// Set the previous link
+	response.SetLink("prev", previous, "")
+
+	// Set the next link
+	response.SetLink("next", next, "")
+
+	// Create slice of check result responses
+	checkResultResponses := make([]*metrics.CheckResultResponse, len(checkResults))
+	for i, checkResult := range checkResults {
+		checkResultResponse, err := metrics.NewCheckResultResponse(checkResult)
+		if err != nil {
+			return nil, err
+		}
+		checkResultResponses[i] = checkResultResponse
+	}
+
+	// Set embedded checks
+	response.SetEmbedded(
+		"checks",
+		jsonhal.Embedded(checkResultResponses),
+	)
+
 	return response, nil
 }
 
 // NewListResponseTimesResponse creates new ListResponseTimesResponse instance
-func NewListResponseTimesResponse(count, page int, self, first, last, previous, next string, responseTimes []*metrics.ResponseTime, uptime float64, incidentTypeCount map[string]int) (*ListResponseTimesResponse, error) {
+func NewListResponseTimesResponse(count, page int, self, first, last, previous, next string, responseTimes []*metrics.ResponseTime, uptime float64, incidentTypeCount map[string]int, aggregations map[string]float64, annotations []*Annotation) (*ListResponseTimesResponse, error) {
 	// Format uptime to 4 decimal numbers
 	uptime, err := strconv.ParseFloat(fmt.Sprintf("%.4f", uptime), 64)
 	if err != nil {
@@ -333,6 +415,8 @@ func NewListResponseTimesResponse(count, page int, self, first, last, previous,
 		if err != nil {
 			return nil, err
 		}
+		metricResponse.Aggregations = responseTime.Aggregations
+		metricResponse.Timings = metrics.NewTimingsResponse(responseTime)
 		metricResponses[i] = metricResponse
 	}
 
@@ -345,6 +429,11 @@ func NewListResponseTimesResponse(count, page int, self, first, last, previous,
 		response.Average = sum / float64(len(responseTimes))
 	}
 
+	// Aggregations over the whole time range (percentiles etc)
+	if len(aggregations) > 0 {
+		response.Aggregations = aggregations
+	}
+
 	// Aggregate incident counts based on type
 	response.IncidentTypeCounts = incidentTypeCount
 
@@ -354,5 +443,860 @@ func NewListResponseTimesResponse(count, page int, self, first, last, previous,
 		jsonhal.Embedded(metricResponses),
 	)
 
+	// Set embedded annotations
+	annotationResponses, err := newAnnotationResponses(annotations)
+	if err != nil {
+		return nil, err
+	}
+	response.SetEmbedded(
+		"annotations",
+		jsonhal.Embedded(annotationResponses),
+	)
+
+	return response, nil
+}
+
+// StatusPageResponse ...
+type StatusPageResponse struct {
+	jsonhal.Hal
+	ID        uint   `json:"id"`
+	UserID    uint   `json:"user_id"`
+	TeamID    *uint  `json:"team_id"`
+	Slug      string `json:"slug"`
+	Title     string `json:"title"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// StatusPageComponentResponse ...
+type StatusPageComponentResponse struct {
+	Name     string `json:"name"`
+	AlarmIDs []uint `json:"alarm_ids"`
+}
+
+// ListStatusPagesResponse ...
+type ListStatusPagesResponse struct {
+	jsonhal.Hal
+	Count uint `json:"count"`
+	Page  uint `json:"page"`
+}
+
+// PublicStatusPageResponse ...
+type PublicStatusPageResponse struct {
+	jsonhal.Hal
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+	State string `json:"state"`
+}
+
+// PublicComponentResponse ...
+type PublicComponentResponse struct {
+	Name       string               `json:"name"`
+	State      string               `json:"state"`
+	Uptime     float64              `json:"uptime"`
+	UptimeBars []*UptimeBarResponse `json:"uptime_bars"`
+}
+
+// UptimeBarResponse ...
+type UptimeBarResponse struct {
+	Date   string  `json:"date"`
+	Uptime float64 `json:"uptime"`
+}
+
+// PublicIncidentResponse ...
+type PublicIncidentResponse struct {
+	ID         uint    `json:"id"`
+	Component  string  `json:"component"`
+	Type       string  `json:"type"`
+	ResolvedAt *string `json:"resolved_at"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// StatusPageIncidentResponse ...
+type StatusPageIncidentResponse struct {
+	jsonhal.Hal
+	ID           uint    `json:"id"`
+	StatusPageID uint    `json:"status_page_id"`
+	Title        string  `json:"title"`
+	Status       string  `json:"status"`
+	ResolvedAt   *string `json:"resolved_at"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+// StatusPageIncidentUpdateResponse ...
+type StatusPageIncidentUpdateResponse struct {
+	ID        uint   `json:"id"`
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	CreatedAt string `json:"created_at"`
+}
+
+// NewStatusPageResponse creates new StatusPageResponse instance
+func NewStatusPageResponse(statusPage *StatusPage) (*StatusPageResponse, error) {
+	response := &StatusPageResponse{
+		ID:        statusPage.ID,
+		UserID:    uint(statusPage.UserID.Int64),
+		Slug:      statusPage.Slug,
+		Title:     statusPage.Title,
+		CreatedAt: util.FormatTime(statusPage.CreatedAt),
+		UpdatedAt: util.FormatTime(statusPage.UpdatedAt),
+	}
+	if statusPage.TeamID.Valid {
+		teamID := uint(statusPage.TeamID.Int64)
+		response.TeamID = &teamID
+	}
+
+	// Set the self link
+	response.SetLink(
+		"self", // name
+		fmt.Sprintf("/v1/status-pages/%d", statusPage.ID), // href
+		"", // title
+	)
+
+	// Set the public link
+	response.SetLink(
+		"public", // name
+		fmt.Sprintf("/v1/status-pages/%s", statusPage.Slug), // href
+		"", // title
+	)
+
+	// Create slice of component responses
+	componentResponses := make([]*StatusPageComponentResponse, len(statusPage.Components))
+	for i, component := range statusPage.Components {
+		alarmIDs := make([]uint, len(component.Alarms))
+		for j, alarm := range component.Alarms {
+			alarmIDs[j] = alarm.ID
+		}
+		componentResponses[i] = &StatusPageComponentResponse{
+			Name:     component.Name,
+			AlarmIDs: alarmIDs,
+		}
+	}
+
+	// Set embedded components
+	response.SetEmbedded(
+		"components",
+		jsonhal.Embedded(componentResponses),
+	)
+
+	return response, nil
+}
+
+// NewListStatusPagesResponse creates new ListStatusPagesResponse instance
+func NewListStatusPagesResponse(count, page int, self, first, last, previous, next string, statusPages []*StatusPage) (*ListStatusPagesResponse, error) {
+	response := &ListStatusPagesResponse{
+		Count: uint(count),
+		Page:  uint(page),
+	}
+
+	// Set the self link
+	response.SetLink("self", self, "")
+
+	// Set the first link
+	response.SetLink("first", first, "")
+
+	// Set the last link
+	response.SetLink("last", last, "")
+
+	// Set the previous link
+	response.SetLink("prev", previous, "")
+
+	// Set the next link
+	response.SetLink("next", next, "")
+
+	// Create slice of status page responses
+	statusPageResponses := make([]*StatusPageResponse, len(statusPages))
+	for i, statusPage := range statusPages {
+		statusPageResponse, err := NewStatusPageResponse(statusPage)
+		if err != nil {
+			return nil, err
+		}
+		statusPageResponses[i] = statusPageResponse
+	}
+
+	// Set embedded status pages
+	response.SetEmbedded(
+		"status_pages",
+		jsonhal.Embedded(statusPageResponses),
+	)
+
+	return response, nil
+}
+
+// NewPublicStatusPageResponse creates new PublicStatusPageResponse instance
+func NewPublicStatusPageResponse(statusPage *StatusPage, incidents []*Incident, recentIncidents []*Incident, statusPageIncidents []*StatusPageIncident, now time.Time) (*PublicStatusPageResponse, error) {
+	response := &PublicStatusPageResponse{
+		Slug:  statusPage.Slug,
+		Title: statusPage.Title,
+		State: alarmstates.OK,
+	}
+
+	// Set the self link
+	response.SetLink(
+		"self", // name
+		fmt.Sprintf("/v1/status-pages/%s", statusPage.Slug), // href
+		"", // title
+	)
+
+	// Create slice of component responses
+	componentResponses := make([]*PublicComponentResponse, len(statusPage.Components))
+	alarmComponents := make(map[uint]string)
+	for i, component := range statusPage.Components {
+		state := componentState(component)
+		if alarmStateSeverity[state] > alarmStateSeverity[response.State] {
+			response.State = state
+		}
+
+		bars := getUptimeBars(
+			getDowntimePeriods(incidents, component.Alarms, now),
+			now,
+			StatusPageUptimeDays,
+		)
+		uptime, err := strconv.ParseFloat(fmt.Sprintf("%.4f", averageUptime(bars)), 64)
+		if err != nil {
+			return nil, err
+		}
+		barResponses := make([]*UptimeBarResponse, len(bars))
+		for j, bar := range bars {
+			barUptime, err := strconv.ParseFloat(fmt.Sprintf("%.4f", bar.uptime), 64)
+			if err != nil {
+				return nil, err
+			}
+			barResponses[j] = &UptimeBarResponse{
+				Date:   bar.date.Format("2006-01-02"),
+				Uptime: barUptime,
+			}
+		}
+
+		componentResponses[i] = &PublicComponentResponse{
+			Name:       component.Name,
+			State:      state,
+			Uptime:     uptime,
+			UptimeBars: barResponses,
+		}
+
+		for _, alarm := range component.Alarms {
+			if _, ok := alarmComponents[alarm.ID]; !ok {
+				alarmComponents[alarm.ID] = component.Name
+			}
+		}
+	}
+
+	// Create slice of incident responses, details such as the endpoint
+	// or the response dump are left out on purpose
+	incidentResponses := make([]*PublicIncidentResponse, len(recentIncidents))
+	for i, incident := range recentIncidents {
+		incidentResponses[i] = &PublicIncidentResponse{
+			ID:        incident.ID,
+			Component: alarmComponents[uint(incident.AlarmID.Int64)],
+			Type:      incident.IncidentTypeID.String,
+			CreatedAt: util.FormatTime(incident.CreatedAt),
+		}
+		if incident.ResolvedAt.Valid {
+			resolvedAt := util.FormatTime(incident.ResolvedAt.Time)
+			incidentResponses[i].ResolvedAt = &resolvedAt
+		}
+	}
+
+	// Set embedded components
+	response.SetEmbedded(
+		"components",
+		jsonhal.Embedded(componentResponses),
+	)
+
+	// Create slice of reported incident responses
+	statusPageIncidentResponses := make([]*StatusPageIncidentResponse, len(statusPageIncidents))
+	for i, statusPageIncident := range statusPageIncidents {
+		statusPageIncidentResponse, err := NewStatusPageIncidentResponse(statusPageIncident)
+		if err != nil {
+			return nil, err
+		}
+		statusPageIncidentResponses[i] = statusPageIncidentResponse
+	}
+
+	// Set embedded incidents
+	response.SetEmbedded(
+		"incidents",
+		jsonhal.Embedded(incidentResponses),
+	)
+
+	// Set embedded reported incidents
+	response.SetEmbedded(
+		"status_page_incidents",
+		jsonhal.Embedded(statusPageIncidentResponses),
+	)
+
+	return response, nil
+}
+
+// NewStatusPageIncidentResponse creates new StatusPageIncidentResponse instance
+func NewStatusPageIncidentResponse(incident *StatusPageIncident) (*StatusPageIncidentResponse, error) {
+	response := &StatusPageIncidentResponse{
+		ID:           incident.ID,
+		StatusPageID: uint(incident.StatusPageID.Int64),
+		Title:        incident.Title,
+		Status:       incident.Status,
+		CreatedAt:    util.FormatTime(incident.CreatedAt),
+		UpdatedAt:    util.FormatTime(incident.UpdatedAt),
+	}
+	if incident.ResolvedAt.Valid {
+		resolvedAt := util.FormatTime(incident.ResolvedAt.Time)
+		response.ResolvedAt = &resolvedAt
+	}
+
+	// Set the self link
+	response.SetLink(
+		"self", // name
+		fmt.Sprintf(
+			"/v1/status-pages/%d/incidents/%d",
+			incident.StatusPageID.Int64,
+			incident.ID,
+		), // href
+		"", // title
+	)
+
+	// Create slice of update responses
+	updateResponses := make([]*StatusPageIncidentUpdateResponse, len(incident.Updates))
+	for i, update := range incident.Updates {
+		updateResponses[i] = &StatusPageIncidentUpdateResponse{
+			ID:        update.ID,
+			Status:    update.Status,
+			Message:   update.Message,
+			CreatedAt: util.FormatTime(update.CreatedAt),
+		}
+	}
+
+	// Set embedded updates
+	response.SetEmbedded(
+		"updates",
+		jsonhal.Embedded(updateResponses),
+	)
+
+	return response, nil
+}
+
+// SLAReportResponse ...
+type SLAReportResponse struct {
+	jsonhal.Hal
+	AlarmID              uint    `json:"alarm_id"`
+	EndpointURL          string  `json:"endpoint_url"`
+	From                 string  `json:"from"`
+	To                   string  `json:"to"`
+	SLOTarget            float64 `json:"slo_target"`
+	Uptime               float64 `json:"uptime"`
+	Downtime             int64   `json:"downtime"`               // seconds
+	ErrorBudget          int64   `json:"error_budget"`           // seconds
+	RemainingErrorBudget int64   `json:"remaining_error_budget"` // seconds
+	BurnRate             float64 `json:"burn_rate"`
+	SLOMet               bool    `json:"slo_met"`
+}
+
+// ListSLAReportsResponse ...
+type ListSLAReportsResponse struct {
+	jsonhal.Hal
+	Count uint `json:"count"`
+}
+
+// TeamSLAReportResponse ...
+type TeamSLAReportResponse struct {
+	jsonhal.Hal
+	TeamID      uint    `json:"team_id"`
+	Month       string  `json:"month"`
+	Uptime      float64 `json:"uptime"`
+	AlarmsCount uint    `json:"alarms_count"`
+	SLOMetCount uint    `json:"slo_met_count"`
+}
+
+// NewSLAReportResponse creates new SLAReportResponse instance
+func NewSLAReportResponse(report *slaReport) (*SLAReportResponse, error) {
+	// Format uptime and burn rate to 4 decimal numbers
+	uptime, err := strconv.ParseFloat(fmt.Sprintf("%.4f", report.uptime), 64)
+	if err != nil {
+		return nil, err
+	}
+	burnRate, err := strconv.ParseFloat(fmt.Sprintf("%.4f", report.burnRate), 64)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SLAReportResponse{
+		AlarmID:              report.alarm.ID,
+		EndpointURL:          report.alarm.EndpointURL,
+		From:                 util.FormatTime(report.from),
+		To:                   util.FormatTime(report.to),
+		SLOTarget:            report.sloTarget,
+		Uptime:               uptime,
+		Downtime:             int64(report.downtime.Seconds()),
+		ErrorBudget:          int64(report.errorBudget.Seconds()),
+		RemainingErrorBudget: int64(report.remainingErrorBudget.Seconds()),
+		BurnRate:             burnRate,
+		SLOMet:               report.sloMet(),
+	}
+
+	// Set the self link
+	query := url.Values{}
+	query.Set("from", response.From)
+	query.Set("to", response.To)
+	response.SetLink(
+		"self", // name
+		fmt.Sprintf("/v1/alarms/%d/sla?%s", report.alarm.ID, query.Encode()), // href
+		"", // title
+	)
+
+	return response, nil
+}
+
+// NewListSLAReportsResponse creates new ListSLAReportsResponse instance
+func NewListSLAReportsResponse(self string, reports []*slaReport) (*ListSLAReportsResponse, error) {
+	response := &ListSLAReportsResponse{
+		Count: uint(len(reports)),
+	}
+
+	// Set the self link
+	response.SetLink("self", self, "")
+
+	// Create slice of SLA report responses
+	reportResponses, err := newSLAReportResponses(reports)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set embedded SLA reports
+	response.SetEmbedded(
+		"sla_reports",
+		jsonhal.Embedded(reportResponses),
+	)
+
+	return response, nil
+}
+
+// NewTeamSLAReportResponse creates new TeamSLAReportResponse instance,
+// uptime of the team is an average uptime of its alarms
+func NewTeamSLAReportResponse(self string, teamID uint, month time.Time, reports []*slaReport) (*TeamSLAReportResponse, error) {
+	response := &TeamSLAReportResponse{
+		TeamID:      teamID,
+		Month:       month.Format("2006-01"),
+		Uptime:      100,
+		AlarmsCount: uint(len(reports)),
+	}
+
+	if len(reports) > 0 {
+		var sum float64
+		for _, report := range reports {
+			sum += report.uptime
+			if report.sloMet() {
+				response.SLOMetCount++
+			}
+		}
+		uptime, err := strconv.ParseFloat(fmt.Sprintf("%.4f", sum/float64(len(reports))), 64)
+		if err != nil {
+			return nil, err
+		}
+		response.Uptime = uptime
+	}
+
+	// Set the self link
+	response.SetLink("self", self, "")
+
+	// Create slice of SLA report responses
+	reportResponses, err := newSLAReportResponses(reports)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set embedded SLA reports
+	response.SetEmbedded(
+		"sla_reports",
+		jsonhal.Embedded(reportResponses),
+	)
+
+	return response, nil
+}
+
+// newSLAReportResponses creates a slice of SLAReportResponse instances
+func newSLAReportResponses(reports []*slaReport) ([]*SLAReportResponse, error) {
+	reportResponses := make([]*SLAReportResponse, len(reports))
+	for i, report := range reports {
+		reportResponse, err := NewSLAReportResponse(report)
+		if err != nil {
+			return nil, err
+		}
+		reportResponses[i] = reportResponse
+	}
+	return reportResponses, nil
+}
+
+// IncidentAnalyticsResponse ...
+type IncidentAnalyticsResponse struct {
+	jsonhal.Hal
+	From               string         `json:"from"`
+	To                 string         `json:"to"`
+	GroupBy            string         `json:"group_by,omitempty"`
+	Count              uint           `json:"count"`
+	MTTA               *int64         `json:"mtta"` // seconds
+	MTTR               *int64         `json:"mttr"` // seconds
+	MTBF               *int64         `json:"mtbf"` // seconds
+	IncidentTypeCounts map[string]int `json:"incident_type_counts"`
+	HourOfWeekCounts   [7][24]int     `json:"hour_of_week_counts"`
+}
+
+// IncidentStatsResponse ...
+type IncidentStatsResponse struct {
+	jsonhal.Hal
+	AlarmID      uint   `json:"alarm_id,omitempty"`
+	EndpointURL  string `json:"endpoint_url,omitempty"`
+	IncidentType string `json:"incident_type,omitempty"`
+	Count        uint   `json:"count"`
+	MTTA         *int64 `json:"mtta"` // seconds
+	MTTR         *int64 `json:"mttr"` // seconds
+	MTBF         *int64 `json:"mtbf"` // seconds
+}
+
+// NewIncidentAnalyticsResponse creates new IncidentAnalyticsResponse instance
+func NewIncidentAnalyticsResponse(self string, analytics *incidentAnalytics) (*IncidentAnalyticsResponse, error) {
+	response := &IncidentAnalyticsResponse{
+		From:               util.FormatTime(analytics.from),
+		To:                 util.FormatTime(analytics.to),
+		GroupBy:            analytics.groupBy,
+		Count:              uint(analytics.totals.count),
+		MTTA:               meanSeconds(analytics.totals.meanTimeToAcknowledge()),
+		MTTR:               meanSeconds(analytics.totals.meanTimeToResolve()),
+		MTBF:               meanSeconds(analytics.totals.meanTimeBetweenFailures()),
+		IncidentTypeCounts: analytics.totals.typeCounts,
+		HourOfWeekCounts:   analytics.totals.hourOfWeekCounts,
+	}
+
+	// Set the self link
+	response.SetLink("self", self, "")
+
+	// Create slice of group responses
+	groupResponses := make([]*IncidentStatsResponse, len(analytics.groups))
+	for i, stats := range analytics.groups {
+		groupResponses[i] = newIncidentStatsResponse(stats, analytics.alarms)
+	}
+
+	// Create slice of noisiest alarm responses
+	noisiestAlarmResponses := make([]*IncidentStatsResponse, len(analytics.noisiestAlarms))
+	for i, stats := range analytics.noisiestAlarms {
+		noisiestAlarmResponses[i] = newIncidentStatsResponse(stats, analytics.alarms)
+	}
+
+	// Set embedded groups
+	response.SetEmbedded(
+		"groups",
+		jsonhal.Embedded(groupResponses),
+	)
+
+	// Set embedded noisiest alarms
+	response.SetEmbedded(
+		"noisiest_alarms",
+		jsonhal.Embedded(noisiestAlarmResponses),
+	)
+
+	return response, nil
+}
+
+// newIncidentStatsResponse creates new IncidentStatsResponse instance,
+// statistics of an alarm link to the alarm
+func newIncidentStatsResponse(stats *incidentStats, alarms map[uint]*Alarm) *IncidentStatsResponse {
+	response := &IncidentStatsResponse{
+		AlarmID:      stats.alarmID,
+		IncidentType: stats.incidentType,
+		Count:        uint(stats.count),
+		MTTA:         meanSeconds(stats.meanTimeToAcknowledge()),
+		MTTR:         meanSeconds(stats.meanTimeToResolve()),
+		MTBF:         meanSeconds(stats.meanTimeBetweenFailures()),
+	}
+
+	if stats.alarmID > 0 {
+		if alarm, ok := alarms[stats.alarmID]; ok {
+			response.EndpointURL = alarm.EndpointURL
+		}
+
+		// Set the alarm link
+		response.SetLink(
+			"alarm", // name
+			fmt.Sprintf("/v1/alarms/%d", stats.alarmID), // href
+			"", // title
+		)
+	}
+
+	return response
+}
+
+// meanSeconds converts a mean duration to whole seconds, nil when there
+// was nothing to average
+func meanSeconds(mean time.Duration, ok bool) *int64 {
+	if !ok {
+		return nil
+	}
+	seconds := int64(mean.Seconds())
+	return &seconds
+}
+
+// ScrapeTokenResponse ...
+type ScrapeTokenResponse struct {
+	jsonhal.Hal
+	Token     string `json:"token"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// NewScrapeTokenResponse creates new ScrapeTokenResponse instance
+func NewScrapeTokenResponse(scrapeToken *ScrapeToken) (*ScrapeTokenResponse, error) {
+	response := &ScrapeTokenResponse{
+		Token:     scrapeToken.Token,
+		CreatedAt: util.FormatTime(scrapeToken.CreatedAt),
+		UpdatedAt: util.FormatTime(scrapeToken.UpdatedAt),
+	}
+
+	// Set the self link
+	response.SetLink("self", "/v1/prometheus/scrape-token", "")
+
+	// Set the metrics link
+	response.SetLink("metrics", "/v1/prometheus/metrics", "")
+
+	return response, nil
+}
+
+// AnnotationTokenResponse ...
+type AnnotationTokenResponse struct {
+	jsonhal.Hal
+	Token     string `json:"token"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// NewAnnotationTokenResponse creates new AnnotationTokenResponse instance
+func NewAnnotationTokenResponse(annotationToken *AnnotationToken) (*AnnotationTokenResponse, error) {
+	response := &AnnotationTokenResponse{
+		Token:     annotationToken.Token,
+		CreatedAt: util.FormatTime(annotationToken.CreatedAt),
+		UpdatedAt: util.FormatTime(annotationToken.UpdatedAt),
+	}
+
+	// Set the self link
+	response.SetLink("self", "/v1/annotations/token", "")
+
+	// Set the annotations link
+	response.SetLink("annotations", "/v1/annotations", "")
+
+	return response, nil
+}
+
+// BaselineResponse ...
+type BaselineResponse struct {
+	jsonhal.Hal
+	AlarmID            uint    `json:"alarm_id"`
+	AnomalyDetection   bool    `json:"anomaly_detection"`
+	AnomalySensitivity float64 `json:"anomaly_sensitivity"`
+	AnomalyPeriod      uint    `json:"anomaly_period"`
+	AnomalyStartedAt   *string `json:"anomaly_started_at"`
+	UpdatedAt          *string `json:"updated_at"`
+	Count              uint    `json:"count"`
+}
+
+// HourlyBaselineResponse ...
+type HourlyBaselineResponse struct {
+	jsonhal.Hal
+	HourOfWeek uint    `json:"hour_of_week"`
+	Weekday    string  `json:"weekday"`
+	Hour       uint    `json:"hour"`
+	Median     float64 `json:"median"`    // milliseconds
+	MAD        float64 `json:"mad"`       // milliseconds
+	Threshold  float64 `json:"threshold"` // milliseconds
+	Samples    uint    `json:"samples"`
+	Ready      bool    `json:"ready"`
+}
+
+// NewBaselineResponse creates new BaselineResponse instance
+func NewBaselineResponse(alarm *Alarm, baselines []*Baseline) (*BaselineResponse, error) {
+	response := &BaselineResponse{
+		AlarmID:            alarm.ID,
+		AnomalyDetection:   alarm.AnomalyDetection,
+		AnomalySensitivity: alarm.AnomalySensitivity,
+		AnomalyPeriod:      alarm.AnomalyPeriod,
+		Count:              uint(len(baselines)),
+	}
+	if alarm.AnomalyStartedAt.Valid {
+		anomalyStartedAt := util.FormatTime(alarm.AnomalyStartedAt.Time)
+		response.AnomalyStartedAt = &anomalyStartedAt
+	}
+	if alarm.BaselineUpdatedAt.Valid {
+		updatedAt := util.FormatTime(alarm.BaselineUpdatedAt.Time)
+		response.UpdatedAt = &updatedAt
+	}
+
+	// Set the self link
+	response.SetLink(
+		"self", // name
+		fmt.Sprintf("/v1/alarms/%d/baseline", alarm.ID), // href
+		"", // title
+	)
+
+	// Set the alarm link
+	response.SetLink(
+		"alarm", // name
+		fmt.Sprintf("/v1/alarms/%d", alarm.ID), // href
+		"", // title
+	)
+
+	// Create slice of hourly baseline responses
+	hourlyResponses := make([]*HourlyBaselineResponse, len(baselines))
+	for i, baseline := range baselines {
+		hourlyResponse, err := newHourlyBaselineResponse(baseline, alarm.AnomalySensitivity)
+		if err != nil {
+			return nil, err
+		}
+		hourlyResponses[i] = hourlyResponse
+	}
+
+	// Set embedded hourly baselines
+	response.SetEmbedded(
+		"baselines",
+		jsonhal.Embedded(hourlyResponses),
+	)
+
+	return response, nil
+}
+
+func newHourlyBaselineResponse(baseline *Baseline, sensitivity float64) (*HourlyBaselineResponse, error) {
+	// Format milliseconds to 3 decimal numbers
+	values := []float64{baseline.Median, baseline.MAD, baseline.Threshold(sensitivity)}
+	for i, value := range values {
+		rounded, err := strconv.ParseFloat(fmt.Sprintf("%.3f", value), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = rounded
+	}
+
+	return &HourlyBaselineResponse{
+		HourOfWeek: baseline.HourOfWeek,
+		Weekday:    time.Weekday(baseline.HourOfWeek / 24).String(),
+		Hour:       baseline.HourOfWeek % 24,
+		Median:     values[0],
+		MAD:        values[1],
+		Threshold:  values[2],
+		Samples:    baseline.Samples,
+		Ready:      baseline.Ready(),
+	}, nil
+}
+
+// AnnotationResponse ...
+type AnnotationResponse struct {
+	jsonhal.Hal
+	ID        uint     `json:"id"`
+	Title     string   `json:"title"`
+	Tags      []string `json:"tags"`
+	AlarmIDs  []uint   `json:"alarm_ids"`
+	TeamID    *uint    `json:"team_id"`
+	StartedAt string   `json:"started_at"`
+	EndedAt   *string  `json:"ended_at"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// CheckLeaseResponse ...
+type CheckLeaseResponse struct {
+	jsonhal.Hal
+	AlarmID     uint   `json:"alarm_id"`
+	EndpointURL string `json:"endpoint_url"`
+	Watermark   string `json:"watermark"`
+}
+
+// ListCheckLeasesResponse ...
+type ListCheckLeasesResponse struct {
+	jsonhal.Hal
+	Count uint `json:"count"`
+}
+
+// NewAnnotationResponse creates new AnnotationResponse instance
+func NewAnnotationResponse(annotation *Annotation) (*AnnotationResponse, error) {
+	response := &AnnotationResponse{
+		ID:        annotation.ID,
+		Title:     annotation.Title,
+		Tags:      make([]string, len(annotation.Tags)),
+		AlarmIDs:  make([]uint, len(annotation.Alarms)),
+		StartedAt: util.FormatTime(annotation.StartedAt),
+		CreatedAt: util.FormatTime(annotation.CreatedAt),
+		UpdatedAt: util.FormatTime(annotation.UpdatedAt),
+	}
+	for i, tag := range annotation.Tags {
+		response.Tags[i] = tag.Name
+	}
+	for i, alarm := range annotation.Alarms {
+		response.AlarmIDs[i] = alarm.ID
+	}
+	if annotation.TeamID.Valid {
+		teamID := uint(annotation.TeamID.Int64)
+		response.TeamID = &teamID
+	}
+	if annotation.EndedAt.Valid {
+		endedAt := util.FormatTime(annotation.EndedAt.Time)
+		response.EndedAt = &endedAt
+	}
+
+	return response, nil
+}
+
+// newAnnotationResponses creates a slice of annotation responses
+func newAnnotationResponses(annotations []*Annotation) ([]*AnnotationResponse, error) {
+	annotationResponses := make([]*AnnotationResponse, len(annotations))
+	for i, annotation := range annotations {
+		annotationResponse, err := NewAnnotationResponse(annotation)
+		if err != nil {
+			return nil, err
+		}
+		annotationResponses[i] = annotationResponse
+	}
+	return annotationResponses, nil
+}
+
+// NewCheckLeaseResponse creates new CheckLeaseResponse instance, the watermark
+// keeps full precision as it identifies the lease when the result is posted
+func NewCheckLeaseResponse(alarm *Alarm) (*CheckLeaseResponse, error) {
+	response := &CheckLeaseResponse{
+		AlarmID:     alarm.ID,
+		EndpointURL: alarm.EndpointURL,
+		Watermark:   alarm.Watermark.Time.UTC().Format(time.RFC3339Nano),
+	}
+
+	// Set the self link
+	response.SetLink(
+		"self", // name
+		fmt.Sprintf("/v1/alarms/%d", alarm.ID), // href
+		"", // title
+	)
+
+	return response, nil
+}
+
+// NewListCheckLeasesResponse creates new ListCheckLeasesResponse instance
+func NewListCheckLeasesResponse(self string, alarms []*Alarm) (*ListCheckLeasesResponse, error) {
+	response := &ListCheckLeasesResponse{
+		Count: uint(len(alarms)),
+	}
+
+	// Set the self link
+	response.SetLink("self", self, "")
+
+	// Create slice of check lease responses
+	leaseResponses := make([]*CheckLeaseResponse, len(alarms))
+	for i, alarm := range alarms {
+		leaseResponse, err := NewCheckLeaseResponse(alarm)
+		if err != nil {
+			return nil, err
+		}
+		leaseResponses[i] = leaseResponse
+	}
+
+	// Set embedded check leases
+	response.SetEmbedded(
+		"check_leases",
+		jsonhal.Embedded(leaseResponses),
+	)
+
 	return response, nil
 }
@@ -151,6 +151,7 @@ func TestNewListIncidentsResponse(t *testing.T) {
 		"/v1/alarms/1/incidents?page=1", // previous
 		"/v1/alarms/1/incidents?page=3", // next
 		incidents,
+		[]*Annotation{new(Annotation)},
 	)
 	// Error should be nil
 	assert.Nil(t, err)
@@ -195,6 +196,16 @@ func TestNewListIncidentsResponse(t *testing.T) {
 		}
 	}
 
+	// Test embedded annotations
+	embeddedAnnotations, err := response.GetEmbedded("annotations")
+	if assert.Nil(t, err) {
+		reflectedValue := reflect.ValueOf(embeddedAnnotations)
+		expectedType := reflect.SliceOf(reflect.TypeOf(new(AnnotationResponse)))
+		if assert.Equal(t, expectedType, reflectedValue.Type()) {
+			assert.Equal(t, 1, reflectedValue.Len())
+		}
+	}
+
 	// Test the rest
 	assert.Equal(t, uint(10), response.Count)
 	assert.Equal(t, uint(2), response.Page)
@@ -227,6 +238,8 @@ func TestNewListResponseTimesResponse(t *testing.T) {
 		responseTimes,
 		99.99, // uptime
 		incidentTypeCounts,
+		map[string]float64{metrics.AggregationP95: 3}, // aggregations
+		[]*Annotation{new(Annotation), new(Annotation)},
 	)
 
 	// Error should be nil
@@ -275,6 +288,7 @@ func TestNewListResponseTimesResponse(t *testing.T) {
 	// Test the rest
 	assert.Equal(t, 99.99, response.Uptime)
 	assert.Equal(t, 2.5, response.Average)
+	assert.Equal(t, 3.0, response.Aggregations[metrics.AggregationP95])
 	assert.Equal(t, uint(10), response.Count)
 	assert.Equal(t, uint(2), response.Page)
 }
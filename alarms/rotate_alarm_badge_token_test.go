@@ -0,0 +1,101 @@
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestRotateAlarmBadgeTokenRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.rotateAlarmBadgeTokenHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestRotateAlarmBadgeTokenWithoutPermission() {
+	r, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/badge-token", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication, alarm 1 belongs to a different user
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *AlarmsTestSuite) TestRotateAlarmBadgeToken() {
+	oldToken := suite.alarms[0].BadgeToken
+
+	// Restore the fixture token used by other tests
+	defer func() {
+		err := suite.db.Model(suite.alarms[0]).
+			UpdateColumn("badge_token", oldToken).Error
+		assert.NoError(suite.T(), err, "Restoring badge token failed")
+	}()
+
+	r, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("http://1.2.3.4/v1/alarms/%d/badge-token", suite.alarms[0].ID),
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "rotate_alarm_badge_token", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 200, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	alarmResponse := new(AlarmResponse)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), alarmResponse))
+	newToken := alarmResponse.BadgeToken
+	assert.Equal(suite.T(), 36, len(newToken))
+	assert.NotEqual(suite.T(), oldToken.String, newToken)
+
+	// The new token is stored, the old one no longer works
+	alarm, err := suite.service.FindAlarmByID(suite.alarms[0].ID)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), newToken, alarm.BadgeToken.String)
+		assert.Equal(suite.T(), ErrInvalidBadgeToken, checkBadgeToken(alarm, oldToken.String))
+		assert.NoError(suite.T(), checkBadgeToken(alarm, newToken))
+	}
+}
@@ -0,0 +1,85 @@
+package alarms
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestRotateAnnotationTokenRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.rotateAnnotationTokenHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestRotateAnnotationToken() {
+	var annotationTokenResponse *AnnotationTokenResponse
+
+	// Prepare a request
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/annotations/token",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "rotate_annotation_token", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 201, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	annotationTokenResponse = new(AnnotationTokenResponse)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), annotationTokenResponse))
+	firstToken := annotationTokenResponse.Token
+	assert.Equal(suite.T(), 36, len(firstToken))
+
+	// The token is stored
+	user, err := suite.service.findUserByAnnotationToken(firstToken)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), suite.users[1].ID, user.ID)
+	}
+
+	// Rotate the token
+	suite.mockUserAuth(suite.users[1])
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	if !assert.Equal(suite.T(), 201, w.Code) {
+		log.Print(w.Body.String())
+	}
+	annotationTokenResponse = new(AnnotationTokenResponse)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), annotationTokenResponse))
+	assert.NotEqual(suite.T(), firstToken, annotationTokenResponse.Token)
+
+	// The old token stopped working
+	_, err = suite.service.findUserByAnnotationToken(firstToken)
+	assert.Equal(suite.T(), ErrInvalidAnnotationToken, err)
+}
@@ -0,0 +1,35 @@
+package alarms
+
+import (
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/response"
+)
+
+// Handles calls to create or replace the authenticated user's Prometheus
+// scrape token (POST /v1/prometheus/scrape-token)
+func (s *Service) rotateScrapeTokenHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Create a new token, the old one stops working
+	scrapeToken, err := s.rotateScrapeToken(authenticatedUser)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	scrapeTokenResponse, err := NewScrapeTokenResponse(scrapeToken)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, scrapeTokenResponse, http.StatusCreated)
+}
@@ -0,0 +1,94 @@
+package alarms
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestRotateScrapeTokenRequiresUserAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.rotateScrapeTokenHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated user")
+}
+
+func (suite *AlarmsTestSuite) TestRotateScrapeToken() {
+	var (
+		scrapeTokenResponse *ScrapeTokenResponse
+		scrapeTokens        []*ScrapeToken
+	)
+
+	// Prepare a request
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/prometheus/scrape-token",
+		nil,
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "rotate_scrape_token", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[1])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 201, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	scrapeTokenResponse = new(ScrapeTokenResponse)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), scrapeTokenResponse))
+	firstToken := scrapeTokenResponse.Token
+	assert.Equal(suite.T(), 36, len(firstToken))
+
+	// The token is stored
+	userID, err := suite.service.findUserIDByScrapeToken(firstToken)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), suite.users[1].ID, userID)
+	}
+
+	// Rotate the token
+	suite.mockUserAuth(suite.users[1])
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	if !assert.Equal(suite.T(), 201, w.Code) {
+		log.Print(w.Body.String())
+	}
+	scrapeTokenResponse = new(ScrapeTokenResponse)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), scrapeTokenResponse))
+	assert.NotEqual(suite.T(), firstToken, scrapeTokenResponse.Token)
+
+	// The old token stopped working
+	_, err = suite.service.findUserIDByScrapeToken(firstToken)
+	assert.Equal(suite.T(), ErrInvalidScrapeToken, err)
+
+	// The user still has a single token
+	err = suite.db.Find(&scrapeTokens).Error
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 1, len(scrapeTokens)) {
+		assert.Equal(suite.T(), scrapeTokenResponse.Token, scrapeTokens[0].Token)
+	}
+}
@@ -88,5 +88,266 @@ func newRoutes(service ServiceInterface) []routes.Route {
 				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
 			},
 		},
+		routes.Route{
+			Name:        "list_alarm_checks",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/checks",
+			HandlerFunc: service.listAlarmChecksHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "export_alarm_incidents",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/incidents/export",
+			HandlerFunc: service.exportAlarmIncidentsHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "export_alarm_response_times",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/response-times/export",
+			HandlerFunc: service.exportAlarmResponseTimesHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "export_alarm_checks",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/checks/export",
+			HandlerFunc: service.exportAlarmChecksHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "acknowledge_incident",
+			Method:      "POST",
+			Pattern:     "/alarms/{id:[0-9]+}/incidents/{incident_id:[0-9]+}/acknowledgement",
+			HandlerFunc: service.acknowledgeIncidentHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "get_incident_analytics",
+			Method:      "GET",
+			Pattern:     "/incidents/analytics",
+			HandlerFunc: service.getIncidentAnalyticsHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "rotate_scrape_token",
+			Method:      "POST",
+			Pattern:     "/prometheus/scrape-token",
+			HandlerFunc: service.rotateScrapeTokenHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "get_alarm_sla",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/sla",
+			HandlerFunc: service.getAlarmSLAHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "get_alarm_baseline",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/baseline",
+			HandlerFunc: service.getAlarmBaselineHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "list_alarm_sla_reports",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/sla-reports",
+			HandlerFunc: service.listAlarmSLAReportsHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "get_team_sla_report",
+			Method:      "GET",
+			Pattern:     "/teams/{id:[0-9]+}/sla-reports/{month:[0-9]{4}-[0-9]{2}}",
+			HandlerFunc: service.getTeamSLAReportHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "create_status_page",
+			Method:      "POST",
+			Pattern:     "/status-pages",
+			HandlerFunc: service.createStatusPageHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "get_status_page",
+			Method:      "GET",
+			Pattern:     "/status-pages/{id:[0-9]+}",
+			HandlerFunc: service.getStatusPageHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "update_status_page",
+			Method:      "PUT",
+			Pattern:     "/status-pages/{id:[0-9]+}",
+			HandlerFunc: service.updateStatusPageHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "delete_status_page",
+			Method:      "DELETE",
+			Pattern:     "/status-pages/{id:[0-9]+}",
+			HandlerFunc: service.deleteStatusPageHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "list_status_pages",
+			Method:      "GET",
+			Pattern:     "/status-pages",
+			HandlerFunc: service.listStatusPagesHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "get_public_status_page",
+			Method:      "GET",
+			Pattern:     "/status-pages/{slug:[a-z0-9-]+}",
+			HandlerFunc: service.getPublicStatusPageHandler,
+		},
+		routes.Route{
+			Name:        "create_status_page_incident",
+			Method:      "POST",
+			Pattern:     "/status-pages/{id:[0-9]+}/incidents",
+			HandlerFunc: service.createStatusPageIncidentHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "create_status_page_incident_update",
+			Method:      "POST",
+			Pattern:     "/status-pages/{id:[0-9]+}/incidents/{incident_id:[0-9]+}/updates",
+			HandlerFunc: service.createStatusPageIncidentUpdateHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "subscribe_to_status_page",
+			Method:      "POST",
+			Pattern:     "/status-pages/{slug:[a-z0-9-]+}/subscribers",
+			HandlerFunc: service.subscribeToStatusPageHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewAccountAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "confirm_status_page_subscriber",
+			Method:      "GET",
+			Pattern:     "/status-page-confirmations/{reference:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}",
+			HandlerFunc: service.confirmStatusPageSubscriberHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewAccountAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "unsubscribe_from_status_page",
+			Method:      "DELETE",
+			Pattern:     "/status-page-subscribers/{reference:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}",
+			HandlerFunc: service.unsubscribeFromStatusPageHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewAccountAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "create_annotation",
+			Method:      "POST",
+			Pattern:     "/annotations",
+			HandlerFunc: service.createAnnotationHandler,
+		},
+		routes.Route{
+			Name:        "rotate_annotation_token",
+			Method:      "POST",
+			Pattern:     "/annotations/token",
+			HandlerFunc: service.rotateAnnotationTokenHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "lease_agent_checks",
+			Method:      "POST",
+			Pattern:     "/agent/leases",
+			HandlerFunc: service.leaseAgentChecksHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewAccountAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "submit_agent_check_result",
+			Method:      "POST",
+			Pattern:     "/agent/results",
+			HandlerFunc: service.submitAgentCheckResultHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewAccountAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "get_prometheus_metrics",
+			Method:      "GET",
+			Pattern:     "/prometheus/metrics",
+			HandlerFunc: service.getPrometheusMetricsHandler,
+		},
+		routes.Route{
+			Name:        "rotate_alarm_badge_token",
+			Method:      "POST",
+			Pattern:     "/alarms/{id:[0-9]+}/badge-token",
+			HandlerFunc: service.rotateAlarmBadgeTokenHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
+		routes.Route{
+			Name:        "get_alarm_badge",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/badges/{badge:state|uptime-24h|uptime-7d|uptime-30d}.svg",
+			HandlerFunc: service.getAlarmBadgeHandler,
+		},
+		routes.Route{
+			Name:        "get_alarm_incidents_feed",
+			Method:      "GET",
+			Pattern:     "/alarms/{id:[0-9]+}/incidents.{format:atom|rss}",
+			HandlerFunc: service.getAlarmIncidentsFeedHandler,
+		},
+		routes.Route{
+			Name:        "get_status_page_incidents_feed",
+			Method:      "GET",
+			Pattern:     "/status-pages/{slug:[a-z0-9-]+}/incidents.{format:atom|rss}",
+			HandlerFunc: service.getStatusPageIncidentsFeedHandler,
+		},
 	}
 }
@@ -0,0 +1,62 @@
+package alarms
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/jinzhu/gorm"
+)
+
+// CheckResult is an outcome of a single request to an alarm endpoint,
+// measured either by the scheduler or by a region agent
+type CheckResult struct {
+	StartedAt    time.Time
+	ResponseTime time.Duration
+	Response     *http.Response // nil when the request failed
+	ResponseSize int64
+	Timings      *metrics.Timings
+	Timeout      bool
+	ErrorMessage string
+}
+
+// RunCheck makes a request to the endpoint and measures it, the response
+// body is read and closed before returning. Failed requests are reported
+// in the result, an error is only returned when the request cannot be made
+func RunCheck(ctx context.Context, client *http.Client, endpointURL string) (*CheckResult, error) {
+	// Prepare a request
+	req, err := http.NewRequest("GET", endpointURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trace phases of the request (DNS, connect, TLS etc)
+	timer := new(requestTimer)
+	req = req.WithContext(httptrace.WithClientTrace(ctx, timer.trace()))
+
+	// Make the request
+	result := &CheckResult{StartedAt: gorm.NowFunc()}
+	resp, err := client.Do(req)
+	result.ResponseTime = time.Since(result.StartedAt)
+
+	// Read the response body to measure the transfer and the size
+	if resp != nil {
+		result.Response = resp
+		result.ResponseSize, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, MaxResponseBodySize))
+		resp.Body.Close()
+	}
+	result.Timings = timer.timings(time.Now())
+
+	if err != nil {
+		e, ok := err.(net.Error)
+		result.Timeout = ok && e.Timeout()
+		result.ErrorMessage = err.Error()
+	}
+
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package alarms
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// checkOffset returns a stable offset of the alarm within its interval,
+// alarms with the same interval are spread evenly instead of all being
+// checked at the start of the interval
+func checkOffset(alarmID, interval uint) time.Duration {
+	if interval == 0 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strconv.FormatUint(uint64(alarmID), 10)))
+	period := uint64(interval) * uint64(time.Second/time.Millisecond)
+	return time.Duration(uint64(h.Sum32())%period) * time.Millisecond
+}
+
+// nextCheckAt returns the first check slot of the alarm strictly after the
+// given time plus a random jitter. Slots are aligned to the unix epoch shifted
+// by the alarm's offset so late or jittered checks don't drift the schedule.
+// Jitter is capped at a quarter of the interval
+func nextCheckAt(alarmID, interval uint, after time.Time, maxJitter time.Duration) time.Time {
+	period := time.Duration(interval) * time.Second
+	if period <= 0 {
+		return after
+	}
+
+	// Find the first slot strictly after the given time
+	offset := checkOffset(alarmID, interval)
+	elapsed := after.Sub(time.Unix(0, 0).Add(offset))
+	slots := elapsed / period
+	if elapsed >= 0 || elapsed%period == 0 {
+		slots++
+	}
+	next := time.Unix(0, 0).Add(offset).Add(slots * period)
+
+	// Add a random jitter
+	if maxJitter > period/4 {
+		maxJitter = period / 4
+	}
+	if maxJitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(maxJitter))))
+	}
+
+	return next.In(after.Location())
+}
+
+// maxJitter returns the configured max jitter of alarm checks
+func (s *Service) maxJitter() time.Duration {
+	return time.Duration(s.cnf.Scheduler.MaxJitter) * time.Millisecond
+}
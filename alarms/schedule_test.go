@@ -0,0 +1,65 @@
+package alarms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOffset(t *testing.T) {
+	// Offsets are stable and within the interval
+	for alarmID := uint(1); alarmID <= 100; alarmID++ {
+		offset := checkOffset(alarmID, 60)
+		assert.Equal(t, offset, checkOffset(alarmID, 60))
+		assert.True(t, offset >= 0)
+		assert.True(t, offset < 60*time.Second)
+	}
+
+	// Alarms are spread over the interval
+	buckets := make(map[time.Duration]bool)
+	for alarmID := uint(1); alarmID <= 100; alarmID++ {
+		buckets[checkOffset(alarmID, 60)/(10*time.Second)] = true
+	}
+	assert.Equal(t, 6, len(buckets))
+
+	// Zero interval has no offset
+	assert.Equal(t, time.Duration(0), checkOffset(1, 0))
+}
+
+func TestNextCheckAt(t *testing.T) {
+	var (
+		alarmID  = uint(1)
+		interval = uint(60)
+		period   = time.Duration(interval) * time.Second
+		after    = time.Date(2016, time.March, 7, 9, 0, 0, 0, time.UTC)
+		next     time.Time
+	)
+
+	// The next slot is strictly after the given time and within the interval
+	next = nextCheckAt(alarmID, interval, after, 0)
+	assert.True(t, next.After(after))
+	assert.True(t, next.Sub(after) <= period)
+	assert.Equal(t, time.UTC, next.Location())
+
+	// Slots are aligned, any time within the same slot gives the same result
+	assert.Equal(t, next, nextCheckAt(alarmID, interval, next.Add(-period+time.Millisecond), 0))
+	assert.Equal(t, next.Add(period), nextCheckAt(alarmID, interval, next, 0))
+	assert.Equal(t, next.Add(period), nextCheckAt(alarmID, interval, next.Add(time.Millisecond), 0))
+
+	// Jitter delays the slot by less than the max jitter
+	for i := 0; i < 100; i++ {
+		jittered := nextCheckAt(alarmID, interval, after, 5*time.Second)
+		assert.False(t, jittered.Before(next))
+		assert.True(t, jittered.Sub(next) < 5*time.Second)
+
+		// A check running late due to jitter doesn't drift the schedule
+		assert.Equal(t, next.Add(period), nextCheckAt(alarmID, interval, jittered, 0))
+	}
+
+	// Jitter is capped at a quarter of the interval
+	for i := 0; i < 100; i++ {
+		jittered := nextCheckAt(alarmID, interval, after, time.Hour)
+		assert.True(t, jittered.Sub(next) < period/4)
+	}
+}
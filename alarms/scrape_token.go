@@ -0,0 +1,79 @@
+package alarms
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/RichardKnop/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrInvalidScrapeToken ...
+	ErrInvalidScrapeToken = errors.New("Invalid scrape token")
+)
+
+// rotateScrapeToken creates a scrape token for the user, an existing token
+// is replaced so it stops working immediately
+func (s *Service) rotateScrapeToken(user *accounts.User) (*ScrapeToken, error) {
+	scrapeToken := new(ScrapeToken)
+	notFound := s.db.Where("user_id = ?", user.ID).
+		First(scrapeToken).RecordNotFound()
+
+	// Create a new token
+	if notFound {
+		scrapeToken = &ScrapeToken{
+			UserID: util.PositiveIntOrNull(int64(user.ID)),
+			User:   user,
+			Token:  uuid.New(),
+		}
+		if err := s.db.Create(scrapeToken).Error; err != nil {
+			return nil, err
+		}
+		return scrapeToken, nil
+	}
+
+	// Replace the existing token
+	token, now := uuid.New(), gorm.NowFunc()
+	err := s.db.Model(scrapeToken).UpdateColumns(ScrapeToken{
+		Token: token,
+		Model: gorm.Model{UpdatedAt: now},
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	scrapeToken.Token = token
+	scrapeToken.UpdatedAt = now
+	scrapeToken.User = user
+
+	return scrapeToken, nil
+}
+
+// findUserIDByScrapeToken returns ID of the user the scrape token belongs to
+func (s *Service) findUserIDByScrapeToken(token string) (uint, error) {
+	if token == "" {
+		return 0, ErrInvalidScrapeToken
+	}
+
+	scrapeToken := new(ScrapeToken)
+	notFound := s.db.Where("token = ?", token).
+		First(scrapeToken).RecordNotFound()
+	if notFound {
+		return 0, ErrInvalidScrapeToken
+	}
+
+	return uint(scrapeToken.UserID.Int64), nil
+}
+
+// getScrapeToken returns a scrape token from the Authorization header
+// (bearer_token in Prometheus scrape config) or the token query string param
+func getScrapeToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	return r.URL.Query().Get("token")
+}
@@ -1,15 +1,18 @@
 package alarms
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/RichardKnop/pinglist-api/accounts"
 	"github.com/RichardKnop/pinglist-api/config"
 	"github.com/RichardKnop/pinglist-api/email"
+	"github.com/RichardKnop/pinglist-api/jobs"
 	"github.com/RichardKnop/pinglist-api/metrics"
 	"github.com/RichardKnop/pinglist-api/notifications"
 	"github.com/RichardKnop/pinglist-api/subscriptions"
 	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
 	"github.com/jinzhu/gorm"
 )
 
@@ -22,16 +25,19 @@ type Service struct {
 	teamsService         teams.ServiceInterface
 	metricsService       metrics.ServiceInterface
 	notificationsService notifications.ServiceInterface
+	jobsService          jobs.ServiceInterface
 	emailService         email.ServiceInterface
 	emailFactory         EmailFactoryInterface
 	slackFactory         SlackFactoryInterface
 	client               *http.Client
+	hostLimiter          *hostLimiter
+	background           util.Background
 }
 
 // NewService starts a new Service instance
-func NewService(cnf *config.Config, db *gorm.DB, accountsService accounts.ServiceInterface, subscriptionsService subscriptions.ServiceInterface, teamsService teams.ServiceInterface, metricsService metrics.ServiceInterface, notificationsService notifications.ServiceInterface, emailService email.ServiceInterface, emailFactory EmailFactoryInterface, slackFactory SlackFactoryInterface, client *http.Client) *Service {
+func NewService(cnf *config.Config, db *gorm.DB, accountsService accounts.ServiceInterface, subscriptionsService subscriptions.ServiceInterface, teamsService teams.ServiceInterface, metricsService metrics.ServiceInterface, notificationsService notifications.ServiceInterface, jobsService jobs.ServiceInterface, emailService email.ServiceInterface, emailFactory EmailFactoryInterface, slackFactory SlackFactoryInterface, client *http.Client) *Service {
 	if emailService == nil {
-		emailService = email.NewService(cnf)
+		emailService = email.NewServiceFromConfig(cnf)
 	}
 	if emailFactory == nil {
 		emailFactory = NewEmailFactory(cnf)
@@ -52,10 +58,12 @@ func NewService(cnf *config.Config, db *gorm.DB, accountsService accounts.Servic
 		teamsService:         teamsService,
 		metricsService:       metricsService,
 		notificationsService: notificationsService,
+		jobsService:          jobsService,
 		emailService:         emailService,
 		emailFactory:         emailFactory,
 		slackFactory:         slackFactory,
 		client:               client,
+		hostLimiter:          newHostLimiter(cnf.Scheduler.MaxChecksPerHost),
 	}
 }
 
@@ -63,3 +71,8 @@ func NewService(cnf *config.Config, db *gorm.DB, accountsService accounts.Servic
 func (s *Service) GetAccountsService() accounts.ServiceInterface {
 	return s.accountsService
 }
+
+// WaitForNotifications waits for alerts still being sent in the background
+func (s *Service) WaitForNotifications(ctx context.Context) error {
+	return s.background.Wait(ctx)
+}
@@ -1,10 +1,12 @@
 package alarms
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/jobs"
 )
 
 // ServiceInterface defines exported methods
@@ -13,7 +15,12 @@ type ServiceInterface interface {
 	GetAccountsService() accounts.ServiceInterface
 	FindAlarmByID(alarmID uint) (*Alarm, error)
 	GetAlarmsToCheck(now time.Time) ([]uint, error)
-	CheckAlarm(alarmID uint, watermark time.Time) error
+	CheckAlarm(ctx context.Context, alarmID uint, watermark time.Time) error
+	WaitForNotifications(ctx context.Context) error
+	SendAlert(ctx context.Context, job *jobs.Job) error
+	RefreshBaseline(ctx context.Context, job *jobs.Job) error
+	SendStatusPageIncidentUpdate(ctx context.Context, job *jobs.Job) error
+	SendStatusPageEmail(ctx context.Context, job *jobs.Job) error
 
 	// Needed for the newRoutes to be able to register handlers
 	listRegionsHandler(w http.ResponseWriter, r *http.Request)
@@ -24,4 +31,35 @@ type ServiceInterface interface {
 	listAlarmsHandler(w http.ResponseWriter, r *http.Request)
 	listAlarmIncidentsHandler(w http.ResponseWriter, r *http.Request)
 	listAlarmResponseTimesHandler(w http.ResponseWriter, r *http.Request)
+	listAlarmChecksHandler(w http.ResponseWriter, r *http.Request)
+	exportAlarmIncidentsHandler(w http.ResponseWriter, r *http.Request)
+	exportAlarmResponseTimesHandler(w http.ResponseWriter, r *http.Request)
+	exportAlarmChecksHandler(w http.ResponseWriter, r *http.Request)
+	acknowledgeIncidentHandler(w http.ResponseWriter, r *http.Request)
+	getIncidentAnalyticsHandler(w http.ResponseWriter, r *http.Request)
+	getAlarmSLAHandler(w http.ResponseWriter, r *http.Request)
+	getAlarmBaselineHandler(w http.ResponseWriter, r *http.Request)
+	rotateScrapeTokenHandler(w http.ResponseWriter, r *http.Request)
+	getPrometheusMetricsHandler(w http.ResponseWriter, r *http.Request)
+	listAlarmSLAReportsHandler(w http.ResponseWriter, r *http.Request)
+	getTeamSLAReportHandler(w http.ResponseWriter, r *http.Request)
+	createStatusPageHandler(w http.ResponseWriter, r *http.Request)
+	getStatusPageHandler(w http.ResponseWriter, r *http.Request)
+	updateStatusPageHandler(w http.ResponseWriter, r *http.Request)
+	deleteStatusPageHandler(w http.ResponseWriter, r *http.Request)
+	listStatusPagesHandler(w http.ResponseWriter, r *http.Request)
+	getPublicStatusPageHandler(w http.ResponseWriter, r *http.Request)
+	createStatusPageIncidentHandler(w http.ResponseWriter, r *http.Request)
+	createStatusPageIncidentUpdateHandler(w http.ResponseWriter, r *http.Request)
+	subscribeToStatusPageHandler(w http.ResponseWriter, r *http.Request)
+	confirmStatusPageSubscriberHandler(w http.ResponseWriter, r *http.Request)
+	unsubscribeFromStatusPageHandler(w http.ResponseWriter, r *http.Request)
+	createAnnotationHandler(w http.ResponseWriter, r *http.Request)
+	rotateAnnotationTokenHandler(w http.ResponseWriter, r *http.Request)
+	leaseAgentChecksHandler(w http.ResponseWriter, r *http.Request)
+	submitAgentCheckResultHandler(w http.ResponseWriter, r *http.Request)
+	rotateAlarmBadgeTokenHandler(w http.ResponseWriter, r *http.Request)
+	getAlarmBadgeHandler(w http.ResponseWriter, r *http.Request)
+	getAlarmIncidentsFeedHandler(w http.ResponseWriter, r *http.Request)
+	getStatusPageIncidentsFeedHandler(w http.ResponseWriter, r *http.Request)
 }
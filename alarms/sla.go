@@ -0,0 +1,254 @@
+package alarms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/pagination"
+	"github.com/RichardKnop/pinglist-api/teams"
+)
+
+const (
+	// DefaultSLOTarget is used for alarms created without an SLO target
+	DefaultSLOTarget = 99.9
+	// DefaultSLAReportMonths is a number of monthly reports listed by default
+	DefaultSLAReportMonths = 12
+	// MaxSLAReportMonths limits a number of monthly reports listed at once
+	MaxSLAReportMonths = 24
+)
+
+var (
+	// ErrInvalidSLOTarget ...
+	ErrInvalidSLOTarget = errors.New("SLO target must be greater than 0 and less than 100")
+	// ErrInvalidSLAWindow ...
+	ErrInvalidSLAWindow = errors.New("SLA window must end after it starts")
+	// ErrInvalidSLAReportMonth ...
+	ErrInvalidSLAReportMonth = errors.New("Invalid month value. Use YYYY-MM format")
+	// ErrInvalidSLAReportMonths ...
+	ErrInvalidSLAReportMonths = fmt.Errorf("Invalid months value. Use a number between 1 and %d", MaxSLAReportMonths)
+)
+
+// slaReport is uptime of an alarm in a window compared to its SLO target
+type slaReport struct {
+	alarm                *Alarm
+	from                 time.Time
+	to                   time.Time
+	sloTarget            float64
+	elapsed              time.Duration // monitored part of the window so far
+	downtime             time.Duration
+	uptime               float64
+	errorBudget          time.Duration
+	remainingErrorBudget time.Duration // negative when the budget is exhausted
+	burnRate             float64
+}
+
+// sloMet returns true if uptime so far is not below the SLO target
+func (r *slaReport) sloMet() bool {
+	return r.uptime >= r.sloTarget
+}
+
+// getSLOTarget validates a requested SLO target, zero keeps the current one
+func getSLOTarget(requested, current float64) (float64, error) {
+	if requested == 0 {
+		return current, nil
+	}
+	if requested < 0 || requested >= 100 {
+		return 0, ErrInvalidSLOTarget
+	}
+	return requested, nil
+}
+
+// getSLAReport returns an SLA report of the alarm for a window
+func (s *Service) getSLAReport(alarm *Alarm, from, to, now time.Time) (*slaReport, error) {
+	incidents, err := s.findIncidentsBetween([]uint{alarm.ID}, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSLAReport(
+		alarm,
+		getDowntimePeriods(incidents, []*Alarm{alarm}, now),
+		from,
+		to,
+		now,
+	), nil
+}
+
+// getMonthlySLAReports returns SLA reports of the alarm for a number of
+// calendar months (UTC) up to the current one, most recent first
+func (s *Service) getMonthlySLAReports(alarm *Alarm, months int, now time.Time) ([]*slaReport, error) {
+	currentMonth := getMonthStart(now)
+	from := currentMonth.AddDate(0, 1-months, 0)
+
+	incidents, err := s.findIncidentsBetween([]uint{alarm.ID}, from, now)
+	if err != nil {
+		return nil, err
+	}
+	periods := getDowntimePeriods(incidents, []*Alarm{alarm}, now)
+
+	reports := make([]*slaReport, months)
+	for i := 0; i < months; i++ {
+		monthStart := currentMonth.AddDate(0, -i, 0)
+		reports[i] = newSLAReport(
+			alarm,
+			periods,
+			monthStart,
+			monthStart.AddDate(0, 1, 0),
+			now,
+		)
+	}
+
+	return reports, nil
+}
+
+// getTeamSLAReports returns SLA reports for a calendar month (UTC) of all
+// alarms belonging to the team owner and members which existed that month
+func (s *Service) getTeamSLAReports(team *teams.Team, month, now time.Time) ([]*slaReport, error) {
+	from := getMonthStart(month)
+	to := from.AddDate(0, 1, 0)
+
+	userIDs := []uint{uint(team.OwnerID.Int64)}
+	for _, member := range team.Members {
+		userIDs = append(userIDs, member.ID)
+	}
+
+	var alarms []*Alarm
+	err := s.db.Where("user_id IN (?) AND created_at < ?", userIDs, to).
+		Order("id").Find(&alarms).Error
+	if err != nil {
+		return nil, err
+	}
+
+	alarmIDs := make([]uint, len(alarms))
+	for i, alarm := range alarms {
+		alarmIDs[i] = alarm.ID
+	}
+	incidents, err := s.findIncidentsBetween(alarmIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*slaReport, len(alarms))
+	for i, alarm := range alarms {
+		reports[i] = newSLAReport(
+			alarm,
+			getDowntimePeriods(incidents, []*Alarm{alarm}, now),
+			from,
+			to,
+			now,
+		)
+	}
+
+	return reports, nil
+}
+
+// newSLAReport calculates an SLA report for a window. Only time after the
+// alarm was created and before now is monitored. The error budget is downtime
+// allowed over the whole window, burn rate is how fast it is being used up
+// so far, 1 means the budget would be exactly used up at the end of the window
+func newSLAReport(alarm *Alarm, periods []*downtimePeriod, from, to, now time.Time) *slaReport {
+	report := &slaReport{
+		alarm:     alarm,
+		from:      from,
+		to:        to,
+		sloTarget: alarm.SLOTarget,
+		uptime:    100,
+	}
+	if report.sloTarget <= 0 {
+		report.sloTarget = DefaultSLOTarget
+	}
+
+	start := from
+	if alarm.CreatedAt.After(start) {
+		start = alarm.CreatedAt
+	}
+	end := to
+	if now.Before(end) {
+		end = now
+	}
+
+	// The alarm did not exist during the window
+	if !to.After(start) {
+		return report
+	}
+
+	allowed := 1 - report.sloTarget/100
+	report.errorBudget = time.Duration(float64(to.Sub(start)) * allowed)
+
+	if end.After(start) {
+		report.elapsed = end.Sub(start)
+		report.downtime = windowDowntime(periods, start, end)
+		report.uptime = float64(report.elapsed-report.downtime) / float64(report.elapsed) * 100
+		report.burnRate = float64(report.downtime) / float64(report.elapsed) / allowed
+	}
+	report.remainingErrorBudget = report.errorBudget - report.downtime
+
+	return report
+}
+
+// windowDowntime returns how much of the window is covered by downtime
+// periods, periods are expected to be merged so they do not overlap
+func windowDowntime(periods []*downtimePeriod, from, to time.Time) time.Duration {
+	var downtime time.Duration
+	for _, period := range periods {
+		downtime += overlap(period.start, period.end, from, to)
+	}
+	return downtime
+}
+
+// getMonthStart returns the first moment of the calendar month (UTC)
+func getMonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// getSLAWindowFromQueryString returns from / to params of an SLA report,
+// the window defaults to the current calendar month (UTC)
+func getSLAWindowFromQueryString(r *http.Request, now time.Time) (time.Time, time.Time, error) {
+	from, to, err := pagination.GetFromTo(r)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	windowFrom := getMonthStart(now)
+	if from != nil {
+		windowFrom = *from
+	}
+	windowTo := getMonthStart(now).AddDate(0, 1, 0)
+	if to != nil {
+		windowTo = *to
+	}
+
+	if !windowTo.After(windowFrom) {
+		return time.Time{}, time.Time{}, ErrInvalidSLAWindow
+	}
+
+	return windowFrom, windowTo, nil
+}
+
+// getSLAReportMonthsFromQueryString returns a number of monthly SLA reports
+// to list (e.g. months=6)
+func getSLAReportMonthsFromQueryString(r *http.Request) (int, error) {
+	if r.URL.Query().Get("months") == "" {
+		return DefaultSLAReportMonths, nil
+	}
+
+	months, err := strconv.Atoi(r.URL.Query().Get("months"))
+	if err != nil || months < 1 || months > MaxSLAReportMonths {
+		return 0, ErrInvalidSLAReportMonths
+	}
+
+	return months, nil
+}
+
+// parseSLAReportMonth parses a month in YYYY-MM format
+func parseSLAReportMonth(month string) (time.Time, error) {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, ErrInvalidSLAReportMonth
+	}
+	return t, nil
+}
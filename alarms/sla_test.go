@@ -0,0 +1,151 @@
+package alarms
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSLOTarget(t *testing.T) {
+	var (
+		target float64
+		err    error
+	)
+
+	// Zero keeps the current target
+	target, err = getSLOTarget(0, DefaultSLOTarget)
+	if assert.NoError(t, err) {
+		assert.Equal(t, DefaultSLOTarget, target)
+	}
+
+	target, err = getSLOTarget(99.5, DefaultSLOTarget)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 99.5, target)
+	}
+
+	_, err = getSLOTarget(100, DefaultSLOTarget)
+	assert.Equal(t, ErrInvalidSLOTarget, err)
+
+	_, err = getSLOTarget(-1, DefaultSLOTarget)
+	assert.Equal(t, ErrInvalidSLOTarget, err)
+}
+
+func TestNewSLAReport(t *testing.T) {
+	var (
+		from   = time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)
+		to     = from.Add(1000 * time.Hour)
+		alarm  = &Alarm{SLOTarget: 99}
+		report *slaReport
+	)
+	alarm.CreatedAt = from.Add(-time.Hour)
+
+	// Downtime straddling the window start is clipped
+	periods := []*downtimePeriod{
+		&downtimePeriod{start: from.Add(-time.Hour), end: from.Add(2 * time.Hour)},
+		&downtimePeriod{start: from.Add(400 * time.Hour), end: from.Add(401 * time.Hour)},
+	}
+
+	// Half of the window has elapsed
+	report = newSLAReport(alarm, periods, from, to, from.Add(500*time.Hour))
+	assert.Equal(t, 500*time.Hour, report.elapsed)
+	assert.Equal(t, 3*time.Hour, report.downtime)
+	assert.InDelta(t, 99.4, report.uptime, 1e-9)
+	assert.Equal(t, 10*time.Hour, report.errorBudget)
+	assert.Equal(t, 7*time.Hour, report.remainingErrorBudget)
+	assert.InDelta(t, 0.6, report.burnRate, 1e-9)
+	assert.True(t, report.sloMet())
+
+	// The whole window has elapsed, the second period is ongoing
+	periods[1].end = to.Add(time.Hour)
+	report = newSLAReport(alarm, periods, from, to, to.Add(time.Hour))
+	assert.Equal(t, 1000*time.Hour, report.elapsed)
+	assert.Equal(t, 602*time.Hour, report.downtime)
+	assert.Equal(t, -592*time.Hour, report.remainingErrorBudget)
+	assert.False(t, report.sloMet())
+
+	// Time before the alarm was created is not monitored
+	alarm.CreatedAt = from.Add(500 * time.Hour)
+	report = newSLAReport(alarm, nil, from, to, to)
+	assert.Equal(t, 500*time.Hour, report.elapsed)
+	assert.Equal(t, 5*time.Hour, report.errorBudget)
+	assert.Equal(t, float64(100), report.uptime)
+
+	// The alarm did not exist during the window
+	alarm.CreatedAt = to
+	report = newSLAReport(alarm, periods, from, to, to)
+	assert.Equal(t, time.Duration(0), report.elapsed)
+	assert.Equal(t, time.Duration(0), report.errorBudget)
+	assert.Equal(t, float64(100), report.uptime)
+	assert.Equal(t, float64(0), report.burnRate)
+}
+
+func TestGetSLAWindowFromQueryString(t *testing.T) {
+	var (
+		now      = time.Date(2016, 6, 15, 12, 0, 0, 0, time.UTC)
+		from, to time.Time
+		r        *http.Request
+		err      error
+	)
+
+	// Defaults to the current month
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/alarms/1/sla", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	from, to, err = getSLAWindowFromQueryString(r, now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC), from)
+		assert.Equal(t, time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC), to)
+	}
+
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/alarms/1/sla?from=2016-01-01T00:00:00Z&to=2016-02-01T00:00:00Z", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	from, to, err = getSLAWindowFromQueryString(r, now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), from.UTC())
+		assert.Equal(t, time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC), to.UTC())
+	}
+
+	// The window must not be empty
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/alarms/1/sla?from=2016-07-01T00:00:00Z", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	_, _, err = getSLAWindowFromQueryString(r, now)
+	assert.Equal(t, ErrInvalidSLAWindow, err)
+}
+
+func TestGetSLAReportMonthsFromQueryString(t *testing.T) {
+	var (
+		months int
+		r      *http.Request
+		err    error
+	)
+
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/alarms/1/sla-reports", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	months, err = getSLAReportMonthsFromQueryString(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, DefaultSLAReportMonths, months)
+	}
+
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/alarms/1/sla-reports?months=3", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	months, err = getSLAReportMonthsFromQueryString(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, months)
+	}
+
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/alarms/1/sla-reports?months=25", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	_, err = getSLAReportMonthsFromQueryString(r)
+	assert.Equal(t, ErrInvalidSLAReportMonths, err)
+}
+
+func TestParseSLAReportMonth(t *testing.T) {
+	month, err := parseSLAReportMonth("2016-02")
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC), month)
+	}
+
+	_, err = parseSLAReportMonth("2016-13")
+	assert.Equal(t, ErrInvalidSLAReportMonth, err)
+}
@@ -0,0 +1,631 @@
+package alarms
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// StatusPageUptimeDays is a number of daily uptime bars on a status page
+	StatusPageUptimeDays = 90
+	// StatusPageRecentIncidents limits incidents listed on a status page
+	StatusPageRecentIncidents = 10
+)
+
+var (
+	// ErrStatusPageNotFound ...
+	ErrStatusPageNotFound = errors.New("Status page not found")
+	// ErrInvalidStatusPageSlug ...
+	ErrInvalidStatusPageSlug = errors.New("Slug must be 3-50 lowercase letters, numbers or dashes")
+	// ErrNumericStatusPageSlug ...
+	ErrNumericStatusPageSlug = errors.New("Slug cannot consist of numbers only")
+	// ErrStatusPageSlugTaken ...
+	ErrStatusPageSlugTaken = errors.New("Slug is already taken")
+	// ErrStatusPageTitleRequired ...
+	ErrStatusPageTitleRequired = errors.New("Title is required")
+	// ErrStatusPageComponentNameRequired ...
+	ErrStatusPageComponentNameRequired = errors.New("Component name is required")
+	// ErrStatusPageTeamPermission ...
+	ErrStatusPageTeamPermission = errors.New("Need to be a member of the team")
+	// ErrStatusPageTeamChangePermission ...
+	ErrStatusPageTeamChangePermission = errors.New("Only the owner can change the team of a status page")
+	// ErrStatusPageAlarmNotFound ...
+	ErrStatusPageAlarmNotFound = errors.New("Status page alarm not found")
+
+	statusPageSlugRegexp = regexp.MustCompile("^[a-z0-9]+(-[a-z0-9]+)*$")
+	// Numeric slugs would clash with status page IDs in URLs
+	numericSlugRegexp = regexp.MustCompile("^[0-9]+$")
+
+	// Higher number means worse state, used to pick a component state
+	alarmStateSeverity = map[string]int{
+		alarmstates.OK:               0,
+		alarmstates.InsufficientData: 1,
+		alarmstates.Alarm:            2,
+	}
+)
+
+// downtimePeriod is a time range during which an alarm had an open incident
+type downtimePeriod struct {
+	start, end time.Time
+}
+
+// uptimeBar is uptime percentage for a single day
+type uptimeBar struct {
+	date   time.Time
+	uptime float64
+}
+
+// findStatusPageByID looks up a status page by ID
+func (s *Service) findStatusPageByID(statusPageID uint) (*StatusPage, error) {
+	statusPage := new(StatusPage)
+	notFound := s.statusPagePreloads(s.db).First(statusPage, statusPageID).RecordNotFound()
+
+	// Not found
+	if notFound {
+		return nil, ErrStatusPageNotFound
+	}
+
+	return statusPage, nil
+}
+
+// findStatusPageBySlug looks up a status page by slug
+func (s *Service) findStatusPageBySlug(slug string) (*StatusPage, error) {
+	statusPage := new(StatusPage)
+	notFound := s.statusPagePreloads(s.db).Where("slug = ?", slug).
+		First(statusPage).RecordNotFound()
+
+	// Not found
+	if notFound {
+		return nil, ErrStatusPageNotFound
+	}
+
+	return statusPage, nil
+}
+
+// statusPagePreloads preloads status page relations including components
+// ordered by position
+func (s *Service) statusPagePreloads(query *gorm.DB) *gorm.DB {
+	return query.Preload("User").Preload("Team.Members").
+		Preload("Components", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Preload("Components.Alarms")
+}
+
+// createStatusPage creates a new status page
+func (s *Service) createStatusPage(user *accounts.User, statusPageRequest *StatusPageRequest) (*StatusPage, error) {
+	// Validate the request
+	if err := s.validateStatusPageRequest(nil, statusPageRequest); err != nil {
+		return nil, err
+	}
+
+	// Fetch the team if the page should be owned by one
+	team, err := s.getStatusPageTeam(user, statusPageRequest.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the alarms grouped into components
+	componentAlarms, err := s.getStatusPageComponentAlarms(user, team, statusPageRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Create a new status page object
+	statusPage := NewStatusPage(user, team, statusPageRequest.Slug, statusPageRequest.Title)
+
+	// Save the status page to the database
+	if err := tx.Create(statusPage).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Save the components
+	if err := createStatusPageComponents(tx, statusPage, statusPageRequest, componentAlarms); err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Assign related objects
+	statusPage.User = user
+	statusPage.Team = team
+
+	return statusPage, nil
+}
+
+// updateStatusPage updates an existing status page, components are replaced
+func (s *Service) updateStatusPage(statusPage *StatusPage, user *accounts.User, statusPageRequest *StatusPageRequest) error {
+	// Validate the request
+	if err := s.validateStatusPageRequest(statusPage, statusPageRequest); err != nil {
+		return err
+	}
+
+	// Only the owner can move the page to another team, the team must be
+	// one the owner is a member of
+	team := statusPage.Team
+	if statusPageRequest.TeamID != uint(statusPage.TeamID.Int64) {
+		if user.Role.Name != roles.Superuser && user.ID != statusPage.User.ID {
+			return ErrStatusPageTeamChangePermission
+		}
+
+		var err error
+		team, err = s.getStatusPageTeam(statusPage.User, statusPageRequest.TeamID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Fetch the alarms grouped into components, alarms are resolved against
+	// the page owner and the page's team rather than the updating user
+	componentAlarms, err := s.getStatusPageComponentAlarms(statusPage.User, team, statusPageRequest)
+	if err != nil {
+		return err
+	}
+
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	var teamID sql.NullInt64
+	if team != nil {
+		teamID = util.PositiveIntOrNull(int64(team.ID))
+	}
+
+	// Update the status page (need to use map here because team might be
+	// changing to NULL which would not work with struct)
+	if err := tx.Model(statusPage).UpdateColumns(map[string]interface{}{
+		"slug":       statusPageRequest.Slug,
+		"title":      statusPageRequest.Title,
+		"team_id":    teamID,
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Delete old components
+	if err := deleteStatusPageComponents(tx, statusPage); err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Save new components
+	statusPage.Components = nil
+	if err := createStatusPageComponents(tx, statusPage, statusPageRequest, componentAlarms); err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Keep the object up-to-date
+	statusPage.Slug = statusPageRequest.Slug
+	statusPage.Title = statusPageRequest.Title
+	statusPage.TeamID = teamID
+	statusPage.Team = team
+
+	return nil
+}
+
+// deleteStatusPage deletes a status page with its components, incidents and
+// subscribers, the page is deleted permanently so the slug can be reused
+func (s *Service) deleteStatusPage(statusPage *StatusPage) error {
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Delete the components
+	if err := deleteStatusPageComponents(tx, statusPage); err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Delete incident updates
+	err := tx.Exec(
+		"DELETE FROM alarm_status_page_incident_updates WHERE status_page_incident_id IN "+
+			"(SELECT id FROM alarm_status_page_incidents WHERE status_page_id = ?)",
+		statusPage.ID,
+	).Error
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Delete incidents
+	err = tx.Unscoped().Where("status_page_id = ?", statusPage.ID).
+		Delete(new(StatusPageIncident)).Error
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Delete subscribers
+	err = tx.Unscoped().Where("status_page_id = ?", statusPage.ID).
+		Delete(new(StatusPageSubscriber)).Error
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Delete the status page
+	if err := tx.Unscoped().Delete(statusPage).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	return nil
+}
+
+// validateStatusPageRequest checks the slug, title and component names
+func (s *Service) validateStatusPageRequest(statusPage *StatusPage, statusPageRequest *StatusPageRequest) error {
+	slug := statusPageRequest.Slug
+	if len(slug) < 3 || len(slug) > 50 || !statusPageSlugRegexp.MatchString(slug) {
+		return ErrInvalidStatusPageSlug
+	}
+	if numericSlugRegexp.MatchString(slug) {
+		return ErrNumericStatusPageSlug
+	}
+
+	if statusPageRequest.Title == "" {
+		return ErrStatusPageTitleRequired
+	}
+
+	for _, componentRequest := range statusPageRequest.Components {
+		if componentRequest.Name == "" {
+			return ErrStatusPageComponentNameRequired
+		}
+	}
+
+	// Make sure the slug is not used by another status page
+	query := s.db.Unscoped().Model(new(StatusPage)).Where("slug = ?", slug)
+	if statusPage != nil {
+		query = query.Where("id != ?", statusPage.ID)
+	}
+	var count int
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrStatusPageSlugTaken
+	}
+
+	return nil
+}
+
+// getStatusPageTeam returns a team the user is a member or an owner of
+func (s *Service) getStatusPageTeam(user *accounts.User, teamID uint) (*teams.Team, error) {
+	if teamID == 0 {
+		return nil, nil
+	}
+
+	team, err := s.teamsService.FindTeamByID(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isTeamMember(team, user) {
+		return nil, ErrStatusPageTeamPermission
+	}
+
+	return team, nil
+}
+
+// getStatusPageComponentAlarms fetches alarms for each requested component,
+// alarms must belong to the user or to the team when the page is team owned
+func (s *Service) getStatusPageComponentAlarms(user *accounts.User, team *teams.Team, statusPageRequest *StatusPageRequest) ([][]*Alarm, error) {
+	// Users whose alarms can be displayed on the page
+	userIDs := []uint{user.ID}
+	if team != nil {
+		userIDs = append(userIDs, uint(team.OwnerID.Int64))
+		for _, member := range team.Members {
+			userIDs = append(userIDs, member.ID)
+		}
+	}
+
+	componentAlarms := make([][]*Alarm, len(statusPageRequest.Components))
+	for i, componentRequest := range statusPageRequest.Components {
+		componentAlarms[i] = make([]*Alarm, 0)
+		if len(componentRequest.AlarmIDs) == 0 {
+			continue
+		}
+
+		var alarms []*Alarm
+		err := s.db.Where("id IN (?) AND user_id IN (?)", componentRequest.AlarmIDs, userIDs).
+			Find(&alarms).Error
+		if err != nil {
+			return nil, err
+		}
+
+		// Every alarm ID must have been found
+		if len(alarms) != len(uniqueIDs(componentRequest.AlarmIDs)) {
+			return nil, ErrStatusPageAlarmNotFound
+		}
+
+		componentAlarms[i] = alarms
+	}
+
+	return componentAlarms, nil
+}
+
+// statusPagesCount returns a total count of status pages
+// Can be optionally filtered by user
+func (s *Service) statusPagesCount(user *accounts.User) (int, error) {
+	var count int
+	if err := s.statusPagesQuery(user).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// findPaginatedStatusPages returns paginated status page records
+// Results can optionally be filtered by user
+func (s *Service) findPaginatedStatusPages(offset, limit int, orderBy string, user *accounts.User) ([]*StatusPage, error) {
+	var statusPages []*StatusPage
+
+	// Get the pagination query
+	statusPagesQuery := s.statusPagesQuery(user)
+
+	// Default ordering
+	if orderBy == "" {
+		orderBy = "id"
+	}
+
+	// Retrieve paginated results from the database
+	err := s.statusPagePreloads(statusPagesQuery).Offset(offset).Limit(limit).
+		Order(orderBy).Find(&statusPages).Error
+	if err != nil {
+		return statusPages, err
+	}
+
+	return statusPages, nil
+}
+
+// statusPagesQuery returns a generic db query for fetching status pages
+func (s *Service) statusPagesQuery(user *accounts.User) *gorm.DB {
+	// Basic query
+	statusPagesQuery := s.db.Model(new(StatusPage))
+
+	// Optionally filter by user, including pages of teams the user belongs to
+	if user != nil {
+		statusPagesQuery = statusPagesQuery.Where(
+			"user_id = ? OR team_id IN (SELECT id FROM team_teams WHERE owner_id = ?) "+
+				"OR team_id IN (SELECT team_id FROM team_team_members WHERE user_id = ?)",
+			user.ID, user.ID, user.ID,
+		)
+	}
+
+	return statusPagesQuery
+}
+
+// findStatusPageIncidents returns incidents of status page alarms which
+// were open at any time since from, most recent first
+func (s *Service) findStatusPageIncidents(statusPage *StatusPage, from time.Time) ([]*Incident, error) {
+	return s.findIncidentsSince(statusPageAlarmIDs(statusPage), from)
+}
+
+// findIncidentsSince returns incidents of given alarms which were open
+// at any time since from, most recent first
+func (s *Service) findIncidentsSince(alarmIDs []uint, from time.Time) ([]*Incident, error) {
+	var incidents []*Incident
+
+	if len(alarmIDs) == 0 {
+		return incidents, nil
+	}
+
+	err := s.db.Where(
+		"alarm_id IN (?) AND (resolved_at IS NULL OR resolved_at >= ?)",
+		alarmIDs,
+		from,
+	).Order("created_at desc").Find(&incidents).Error
+	if err != nil {
+		return incidents, err
+	}
+
+	return incidents, nil
+}
+
+// createStatusPageComponents saves components of a status page
+func createStatusPageComponents(tx *gorm.DB, statusPage *StatusPage, statusPageRequest *StatusPageRequest, componentAlarms [][]*Alarm) error {
+	for i, componentRequest := range statusPageRequest.Components {
+		component := NewStatusPageComponent(
+			statusPage,
+			componentRequest.Name,
+			uint(i),
+			componentAlarms[i],
+		)
+		if err := tx.Create(component).Error; err != nil {
+			return err
+		}
+		statusPage.Components = append(statusPage.Components, component)
+	}
+	return nil
+}
+
+// deleteStatusPageComponents deletes all components of a status page
+func deleteStatusPageComponents(tx *gorm.DB, statusPage *StatusPage) error {
+	err := tx.Exec(
+		"DELETE FROM alarm_status_page_component_alarms WHERE status_page_component_id IN "+
+			"(SELECT id FROM alarm_status_page_components WHERE status_page_id = ?)",
+		statusPage.ID,
+	).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("status_page_id = ?", statusPage.ID).
+		Delete(new(StatusPageComponent)).Error
+}
+
+// isTeamMember returns true if the user is the owner or a member of the team
+func isTeamMember(team *teams.Team, user *accounts.User) bool {
+	if uint(team.OwnerID.Int64) == user.ID {
+		return true
+	}
+	for _, member := range team.Members {
+		if member.ID == user.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// statusPageAlarmIDs returns unique IDs of alarms on a status page
+func statusPageAlarmIDs(statusPage *StatusPage) []uint {
+	var alarmIDs []uint
+	for _, component := range statusPage.Components {
+		for _, alarm := range component.Alarms {
+			alarmIDs = append(alarmIDs, alarm.ID)
+		}
+	}
+	return uniqueIDs(alarmIDs)
+}
+
+// uniqueIDs removes duplicate IDs
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// componentState returns the worst state of component alarms
+func componentState(component *StatusPageComponent) string {
+	state := alarmstates.OK
+	for _, alarm := range component.Alarms {
+		if alarmStateSeverity[alarm.AlarmStateID.String] > alarmStateSeverity[state] {
+			state = alarm.AlarmStateID.String
+		}
+	}
+	return state
+}
+
+// getDowntimePeriods converts incidents of given alarms to merged downtime
+// periods sorted by start, open incidents last until now
+func getDowntimePeriods(incidents []*Incident, alarms []*Alarm, now time.Time) []*downtimePeriod {
+	alarmIDs := make(map[uint]bool, len(alarms))
+	for _, alarm := range alarms {
+		alarmIDs[alarm.ID] = true
+	}
+
+	periods := make([]*downtimePeriod, 0)
+	for _, incident := range incidents {
+		if !alarmIDs[uint(incident.AlarmID.Int64)] {
+			continue
+		}
+		end := now
+		if incident.ResolvedAt.Valid {
+			end = incident.ResolvedAt.Time
+		}
+		if !end.After(incident.CreatedAt) {
+			continue
+		}
+		periods = append(periods, &downtimePeriod{start: incident.CreatedAt, end: end})
+	}
+
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].start.Before(periods[j].start)
+	})
+
+	// Merge overlapping periods so concurrent incidents are not counted twice
+	merged := make([]*downtimePeriod, 0, len(periods))
+	for _, period := range periods {
+		last := len(merged) - 1
+		if last >= 0 && !period.start.After(merged[last].end) {
+			if period.end.After(merged[last].end) {
+				merged[last].end = period.end
+			}
+			continue
+		}
+		merged = append(merged, &downtimePeriod{start: period.start, end: period.end})
+	}
+
+	return merged
+}
+
+// getUptimeBars returns daily uptime percentages for the last number of
+// days (UTC) ending today, today only counts time elapsed so far
+func getUptimeBars(periods []*downtimePeriod, now time.Time, days int) []*uptimeBar {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	bars := make([]*uptimeBar, days)
+	for i := 0; i < days; i++ {
+		dayStart := today.AddDate(0, 0, i-days+1)
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		if dayEnd.After(now) {
+			dayEnd = now
+		}
+
+		var downtime time.Duration
+		for _, period := range periods {
+			downtime += overlap(period.start, period.end, dayStart, dayEnd)
+		}
+
+		uptime := float64(100)
+		if total := dayEnd.Sub(dayStart); total > 0 {
+			uptime = float64(total-downtime) / float64(total) * 100
+		}
+		bars[i] = &uptimeBar{date: dayStart, uptime: uptime}
+	}
+
+	return bars
+}
+
+// averageUptime returns the average of daily uptime percentages
+func averageUptime(bars []*uptimeBar) float64 {
+	if len(bars) == 0 {
+		return 100
+	}
+	var sum float64
+	for _, bar := range bars {
+		sum += bar.uptime
+	}
+	return sum / float64(len(bars))
+}
+
+// overlap returns duration of the intersection of two time ranges
+func overlap(start, end, rangeStart, rangeEnd time.Time) time.Duration {
+	if start.Before(rangeStart) {
+		start = rangeStart
+	}
+	if end.After(rangeEnd) {
+		end = rangeEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,212 @@
+package alarms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/jobs"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// StatusPageUpdateJob is the job type of incident updates which get
+	// fanned out to a StatusPageEmailJob per subscriber
+	StatusPageUpdateJob = "status_page_update"
+
+	// StatusPageEmailJob is the job type of emails to a single status page
+	// subscriber, confirmation emails have no update ID
+	StatusPageEmailJob = "status_page_email"
+)
+
+var statusPageEmailJobOptions = &jobs.EnqueueOptions{
+	MaxAttempts:       5,
+	VisibilityTimeout: time.Minute,
+}
+
+// statusPageUpdatePayload is the payload of StatusPageUpdateJob jobs
+type statusPageUpdatePayload struct {
+	UpdateID uint `json:"update_id"`
+}
+
+// statusPageEmailPayload is the payload of StatusPageEmailJob jobs
+type statusPageEmailPayload struct {
+	SubscriberID uint `json:"subscriber_id"`
+	UpdateID     uint `json:"update_id,omitempty"`
+}
+
+// dispatchStatusPageIncidentUpdate enqueues emails of an incident update to
+// subscribers, without the job queue they are sent in the background
+func (s *Service) dispatchStatusPageIncidentUpdate(update *StatusPageIncidentUpdate) {
+	if s.jobsService != nil {
+		payload := &statusPageUpdatePayload{UpdateID: update.ID}
+		_, err := s.jobsService.Enqueue(StatusPageUpdateJob, payload, statusPageEmailJobOptions)
+		if err == nil {
+			return
+		}
+		logger.ERROR.Printf("Enqueue status page update error: %s", err)
+	}
+	s.background.Go(func() { s.sendStatusPageIncidentUpdateEmails(update) })
+}
+
+// dispatchStatusPageSubscriptionEmail enqueues a confirmation email to a new
+// subscriber, without the job queue it is sent in the background
+func (s *Service) dispatchStatusPageSubscriptionEmail(subscriber *StatusPageSubscriber) {
+	if s.jobsService != nil {
+		payload := &statusPageEmailPayload{SubscriberID: subscriber.ID}
+		_, err := s.jobsService.Enqueue(StatusPageEmailJob, payload, statusPageEmailJobOptions)
+		if err == nil {
+			return
+		}
+		logger.ERROR.Printf("Enqueue status page confirmation error: %s", err)
+	}
+	s.background.Go(func() {
+		if err := s.sendStatusPageSubscriptionEmail(subscriber); err != nil {
+			logger.ERROR.Printf("Send email error: %s", err)
+		}
+	})
+}
+
+// SendStatusPageIncidentUpdate enqueues an email of the update for every
+// confirmed subscriber of the status page, it is the handler of
+// StatusPageUpdateJob jobs. Emails are keyed by the update and the
+// subscriber so a retried fan-out doesn't email anybody twice
+func (s *Service) SendStatusPageIncidentUpdate(ctx context.Context, job *jobs.Job) error {
+	payload := new(statusPageUpdatePayload)
+	if err := job.DecodePayload(payload); err != nil {
+		return err
+	}
+
+	update, err := s.findStatusPageIncidentUpdateByID(payload.UpdateID)
+	if err != nil {
+		// The status page has been deleted since, there is nobody to email
+		logger.INFO.Printf("Status page update #%d dropped: %s", payload.UpdateID, err)
+		return nil
+	}
+
+	subscribers, err := s.findConfirmedStatusPageSubscribers(update.StatusPageIncident.StatusPage)
+	if err != nil {
+		return err
+	}
+
+	for _, subscriber := range subscribers {
+		options := *statusPageEmailJobOptions
+		options.Key = fmt.Sprintf("%s:%d:%d", StatusPageEmailJob, update.ID, subscriber.ID)
+		emailPayload := &statusPageEmailPayload{SubscriberID: subscriber.ID, UpdateID: update.ID}
+		_, err := s.jobsService.Enqueue(StatusPageEmailJob, emailPayload, &options)
+		if err != nil && err != jobs.ErrDuplicateJob {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SendStatusPageEmail sends an email to a single status page subscriber,
+// it is the handler of StatusPageEmailJob jobs
+func (s *Service) SendStatusPageEmail(ctx context.Context, job *jobs.Job) error {
+	payload := new(statusPageEmailPayload)
+	if err := job.DecodePayload(payload); err != nil {
+		return err
+	}
+
+	subscriber := new(StatusPageSubscriber)
+	notFound := s.db.Preload("StatusPage").First(subscriber, payload.SubscriberID).RecordNotFound()
+	if notFound {
+		// The subscriber has unsubscribed since
+		logger.INFO.Printf("Status page subscriber #%d email dropped: %s", payload.SubscriberID, ErrStatusPageSubscriberNotFound)
+		return nil
+	}
+
+	// Confirmation emails
+	if payload.UpdateID == 0 {
+		if subscriber.Confirmed {
+			return nil
+		}
+		return s.sendStatusPageSubscriptionEmail(subscriber)
+	}
+
+	// Incident update emails
+	update, err := s.findStatusPageIncidentUpdateByID(payload.UpdateID)
+	if err != nil {
+		logger.INFO.Printf("Status page update #%d dropped: %s", payload.UpdateID, err)
+		return nil
+	}
+	return s.sendStatusPageIncidentUpdateEmail(subscriber, update)
+}
+
+// findStatusPageIncidentUpdateByID looks up an incident update together with
+// its incident and status page
+func (s *Service) findStatusPageIncidentUpdateByID(id uint) (*StatusPageIncidentUpdate, error) {
+	update := new(StatusPageIncidentUpdate)
+	notFound := s.db.Preload("StatusPageIncident.StatusPage").First(update, id).RecordNotFound()
+	if notFound || update.StatusPageIncident == nil || update.StatusPageIncident.StatusPage == nil {
+		return nil, ErrStatusPageIncidentNotFound
+	}
+	return update, nil
+}
+
+// findConfirmedStatusPageSubscribers returns subscribers of the status page
+// who have confirmed their email address
+func (s *Service) findConfirmedStatusPageSubscribers(statusPage *StatusPage) ([]*StatusPageSubscriber, error) {
+	var subscribers []*StatusPageSubscriber
+	err := s.db.Where("status_page_id = ? AND confirmed = ?", statusPage.ID, true).
+		Order("id").Find(&subscribers).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, subscriber := range subscribers {
+		subscriber.StatusPage = statusPage
+	}
+	return subscribers, nil
+}
+
+// sendStatusPageSubscriptionEmail sends a confirmation email to the
+// subscriber and marks it as sent
+func (s *Service) sendStatusPageSubscriptionEmail(subscriber *StatusPageSubscriber) error {
+	confirmationEmail := s.emailFactory.NewStatusPageSubscriptionEmail(subscriber)
+
+	// Try to send the confirmation email
+	err := s.emailService.Send(confirmationEmail)
+	countNotification(emailChannel, err)
+	if err != nil {
+		return err
+	}
+
+	// If the email was sent successfully, update the email_sent flag
+	now := time.Now()
+	return s.db.Model(subscriber).UpdateColumns(StatusPageSubscriber{
+		EmailSent:   true,
+		EmailSentAt: util.TimeOrNull(&now),
+		Model:       gorm.Model{UpdatedAt: now},
+	}).Error
+}
+
+// sendStatusPageIncidentUpdateEmail emails an incident update to a single
+// subscriber
+func (s *Service) sendStatusPageIncidentUpdateEmail(subscriber *StatusPageSubscriber, update *StatusPageIncidentUpdate) error {
+	updateEmail := s.emailFactory.NewStatusPageIncidentUpdateEmail(subscriber, update)
+	err := s.emailService.Send(updateEmail)
+	countNotification(emailChannel, err)
+	return err
+}
+
+// sendStatusPageIncidentUpdateEmails emails an incident update to every
+// confirmed subscriber of the status page
+func (s *Service) sendStatusPageIncidentUpdateEmails(update *StatusPageIncidentUpdate) {
+	subscribers, err := s.findConfirmedStatusPageSubscribers(update.StatusPageIncident.StatusPage)
+	if err != nil {
+		logger.ERROR.Printf("Find status page subscribers error: %s", err)
+		return
+	}
+
+	// Failure to reach one subscriber should not stop the update from
+	// reaching others
+	for _, subscriber := range subscribers {
+		if err := s.sendStatusPageIncidentUpdateEmail(subscriber, update); err != nil {
+			logger.ERROR.Printf("Send email error: %s", err)
+		}
+	}
+}
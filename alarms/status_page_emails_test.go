@@ -0,0 +1,136 @@
+package alarms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidentstatuses"
+	"github.com/RichardKnop/pinglist-api/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *AlarmsTestSuite) TestStatusPageIncidentUpdateEmailJobs() {
+	jobsServiceMock := new(jobs.ServiceMock)
+	suite.service.jobsService = jobsServiceMock
+	defer func() { suite.service.jobsService = nil }()
+
+	// Create a test status page with a confirmed and an unconfirmed subscriber
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+	confirmed := NewStatusPageSubscriber(statusPage, "john@reese.com")
+	confirmed.Confirmed = true
+	err = suite.db.Create(confirmed).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+	unconfirmed := NewStatusPageSubscriber(statusPage, "harold@finch.com")
+	err = suite.db.Create(unconfirmed).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	newJob := func(jobType string, payload interface{}) *jobs.Job {
+		data, err := json.Marshal(payload)
+		assert.NoError(suite.T(), err, "JSON marshalling failed")
+		return &jobs.Job{Type: jobType, Payload: string(data)}
+	}
+
+	// The update is enqueued instead of being sent
+	jobsServiceMock.On(
+		"Enqueue",
+		StatusPageUpdateJob,
+		mock.AnythingOfType("*alarms.statusPageUpdatePayload"),
+		statusPageEmailJobOptions,
+	).Return(new(jobs.Job), nil).Once()
+	incident, err := suite.service.createStatusPageIncident(statusPage, &StatusPageIncidentRequest{
+		Title:   "API outage",
+		Status:  incidentstatuses.Investigating,
+		Message: "We are looking into it",
+	})
+	assert.NoError(suite.T(), err, "Creating the incident failed")
+	update := incident.Updates[0]
+	suite.assertMockExpectations()
+	jobsServiceMock.AssertExpectations(suite.T())
+
+	// The update is fanned out to confirmed subscribers only, a subscriber
+	// emailed by a previous attempt is skipped
+	emailKey := fmt.Sprintf("status_page_email:%d:%d", update.ID, confirmed.ID)
+	jobsServiceMock.On(
+		"Enqueue",
+		StatusPageEmailJob,
+		&statusPageEmailPayload{SubscriberID: confirmed.ID, UpdateID: update.ID},
+		mock.MatchedBy(func(options *jobs.EnqueueOptions) bool {
+			return options.Key == emailKey
+		}),
+	).Return(nil, jobs.ErrDuplicateJob).Once()
+	err = suite.service.SendStatusPageIncidentUpdate(
+		context.Background(),
+		newJob(StatusPageUpdateJob, &statusPageUpdatePayload{UpdateID: update.ID}),
+	)
+	assert.NoError(suite.T(), err)
+	jobsServiceMock.AssertExpectations(suite.T())
+
+	// Each email is sent by its own job
+	suite.mockStatusPageIncidentUpdateEmail()
+	err = suite.service.SendStatusPageEmail(
+		context.Background(),
+		newJob(StatusPageEmailJob, &statusPageEmailPayload{SubscriberID: confirmed.ID, UpdateID: update.ID}),
+	)
+	assert.NoError(suite.T(), err)
+	suite.assertMockExpectations()
+
+	// Emails to subscribers who have unsubscribed since are dropped
+	assert.NoError(suite.T(), suite.service.unsubscribeFromStatusPage(confirmed))
+	err = suite.service.SendStatusPageEmail(
+		context.Background(),
+		newJob(StatusPageEmailJob, &statusPageEmailPayload{SubscriberID: confirmed.ID, UpdateID: update.ID}),
+	)
+	assert.NoError(suite.T(), err)
+	suite.assertMockExpectations()
+}
+
+func (suite *AlarmsTestSuite) TestStatusPageSubscriptionEmailJob() {
+	jobsServiceMock := new(jobs.ServiceMock)
+	suite.service.jobsService = jobsServiceMock
+	defer func() { suite.service.jobsService = nil }()
+
+	// Create a test status page
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+
+	// The confirmation email is enqueued instead of being sent
+	jobsServiceMock.On(
+		"Enqueue",
+		StatusPageEmailJob,
+		mock.AnythingOfType("*alarms.statusPageEmailPayload"),
+		statusPageEmailJobOptions,
+	).Return(new(jobs.Job), nil).Once()
+	subscriber, err := suite.service.subscribeToStatusPage(statusPage, &StatusPageSubscriberRequest{
+		Email: "john@reese.com",
+	})
+	assert.NoError(suite.T(), err, "Subscribing failed")
+	suite.assertMockExpectations()
+	jobsServiceMock.AssertExpectations(suite.T())
+
+	data, err := json.Marshal(&statusPageEmailPayload{SubscriberID: subscriber.ID})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	job := &jobs.Job{Type: StatusPageEmailJob, Payload: string(data)}
+
+	// The job sends the email and marks it as sent
+	suite.mockStatusPageSubscriptionEmail()
+	assert.NoError(suite.T(), suite.service.SendStatusPageEmail(context.Background(), job))
+	suite.assertMockExpectations()
+	subscriber, err = suite.service.findStatusPageSubscriberByReference(subscriber.Reference)
+	if assert.NoError(suite.T(), err) {
+		assert.True(suite.T(), subscriber.EmailSent)
+	}
+
+	// A retry after the subscriber has confirmed sends nothing
+	assert.NoError(suite.T(), suite.service.confirmStatusPageSubscriber(subscriber))
+	assert.NoError(suite.T(), suite.service.SendStatusPageEmail(context.Background(), job))
+	suite.assertMockExpectations()
+}
@@ -0,0 +1,185 @@
+package alarms
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidentstatuses"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrStatusPageIncidentNotFound ...
+	ErrStatusPageIncidentNotFound = errors.New("Status page incident not found")
+	// ErrInvalidStatusPageIncidentStatus ...
+	ErrInvalidStatusPageIncidentStatus = errors.New("Status must be investigating, identified, monitoring or resolved")
+	// ErrStatusPageIncidentTitleRequired ...
+	ErrStatusPageIncidentTitleRequired = errors.New("Title is required")
+	// ErrStatusPageIncidentMessageRequired ...
+	ErrStatusPageIncidentMessageRequired = errors.New("Message is required")
+
+	validIncidentStatuses = map[string]bool{
+		incidentstatuses.Investigating: true,
+		incidentstatuses.Identified:    true,
+		incidentstatuses.Monitoring:    true,
+		incidentstatuses.Resolved:      true,
+	}
+)
+
+// findStatusPageIncidentByID looks up a status page incident by ID
+func (s *Service) findStatusPageIncidentByID(statusPage *StatusPage, incidentID uint) (*StatusPageIncident, error) {
+	incident := new(StatusPageIncident)
+	notFound := s.db.Where("status_page_id = ?", statusPage.ID).
+		Preload("Updates", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at desc")
+		}).
+		First(incident, incidentID).RecordNotFound()
+
+	// Not found
+	if notFound {
+		return nil, ErrStatusPageIncidentNotFound
+	}
+
+	// Assign related object
+	incident.StatusPage = statusPage
+
+	return incident, nil
+}
+
+// createStatusPageIncident reports a new incident on a status page together
+// with its first update and notifies subscribers
+func (s *Service) createStatusPageIncident(statusPage *StatusPage, incidentRequest *StatusPageIncidentRequest) (*StatusPageIncident, error) {
+	if incidentRequest.Title == "" {
+		return nil, ErrStatusPageIncidentTitleRequired
+	}
+	if err := validateStatusPageIncidentUpdate(incidentRequest.Status, incidentRequest.Message); err != nil {
+		return nil, err
+	}
+
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Create a new incident object
+	incident := NewStatusPageIncident(statusPage, incidentRequest.Title, incidentRequest.Status)
+	if incidentRequest.Status == incidentstatuses.Resolved {
+		now := time.Now()
+		incident.ResolvedAt = util.TimeOrNull(&now)
+	}
+
+	// Save the incident to the database
+	if err := tx.Create(incident).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Create the first update
+	update := NewStatusPageIncidentUpdate(incident, incidentRequest.Status, incidentRequest.Message)
+	if err := tx.Create(update).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Assign related objects
+	incident.StatusPage = statusPage
+	incident.Updates = []*StatusPageIncidentUpdate{update}
+	update.StatusPageIncident = incident
+
+	// Send the update to subscribers
+	s.dispatchStatusPageIncidentUpdate(update)
+
+	return incident, nil
+}
+
+// createStatusPageIncidentUpdate posts a new update on an incident, changes
+// the incident status and notifies subscribers
+func (s *Service) createStatusPageIncidentUpdate(incident *StatusPageIncident, updateRequest *StatusPageIncidentUpdateRequest) (*StatusPageIncidentUpdate, error) {
+	if err := validateStatusPageIncidentUpdate(updateRequest.Status, updateRequest.Message); err != nil {
+		return nil, err
+	}
+
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Create a new update object
+	update := NewStatusPageIncidentUpdate(incident, updateRequest.Status, updateRequest.Message)
+
+	// Save the update to the database
+	if err := tx.Create(update).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Resolved incidents get a resolved timestamp, reopened ones lose it
+	now := time.Now()
+	resolvedAt := util.TimeOrNull(nil)
+	if updateRequest.Status == incidentstatuses.Resolved {
+		resolvedAt = util.TimeOrNull(&now)
+	}
+
+	// Update the incident status (need to use map here because resolved_at
+	// might be changing to NULL which would not work with struct)
+	if err := tx.Model(incident).UpdateColumns(map[string]interface{}{
+		"status":      updateRequest.Status,
+		"resolved_at": resolvedAt,
+		"updated_at":  now,
+	}).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return nil, err
+	}
+
+	// Keep the incident object up-to-date
+	incident.Status = updateRequest.Status
+	incident.ResolvedAt = resolvedAt
+	incident.Updates = append([]*StatusPageIncidentUpdate{update}, incident.Updates...)
+	update.StatusPageIncident = incident
+
+	// Send the update to subscribers
+	s.dispatchStatusPageIncidentUpdate(update)
+
+	return update, nil
+}
+
+// findRecentStatusPageIncidents returns incidents which are open or were
+// resolved since from, most recent first, with updates most recent first
+func (s *Service) findRecentStatusPageIncidents(statusPage *StatusPage, from time.Time, limit int) ([]*StatusPageIncident, error) {
+	var incidents []*StatusPageIncident
+
+	err := s.db.Where(
+		"status_page_id = ? AND (resolved_at IS NULL OR resolved_at >= ?)",
+		statusPage.ID,
+		from,
+	).Order("created_at desc").Limit(limit).
+		Preload("Updates", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at desc")
+		}).
+		Find(&incidents).Error
+	if err != nil {
+		return incidents, err
+	}
+
+	return incidents, nil
+}
+
+// validateStatusPageIncidentUpdate checks the status and message
+func validateStatusPageIncidentUpdate(status, message string) error {
+	if !validIncidentStatuses[status] {
+		return ErrInvalidStatusPageIncidentStatus
+	}
+	if message == "" {
+		return ErrStatusPageIncidentMessageRequired
+	}
+	return nil
+}
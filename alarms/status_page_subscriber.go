@@ -0,0 +1,105 @@
+package alarms
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// ConfirmationResendInterval is how long subscribing again waits before
+	// the confirmation email is resent to an unconfirmed address
+	ConfirmationResendInterval = 10 * time.Minute
+)
+
+var (
+	// ErrStatusPageSubscriberNotFound ...
+	ErrStatusPageSubscriberNotFound = errors.New("Status page subscriber not found")
+	// ErrInvalidSubscriberEmail ...
+	ErrInvalidSubscriberEmail = errors.New("Invalid email address")
+)
+
+// findStatusPageSubscriberByReference looks up a subscriber by a reference
+func (s *Service) findStatusPageSubscriberByReference(reference string) (*StatusPageSubscriber, error) {
+	subscriber := new(StatusPageSubscriber)
+	notFound := s.db.Where("reference = ?", reference).Preload("StatusPage").
+		First(subscriber).RecordNotFound()
+
+	// Not found
+	if notFound {
+		return nil, ErrStatusPageSubscriberNotFound
+	}
+
+	return subscriber, nil
+}
+
+// subscribeToStatusPage creates an unconfirmed subscriber and sends
+// a confirmation email, subscribing again just resends the email at most
+// once per ConfirmationResendInterval
+func (s *Service) subscribeToStatusPage(statusPage *StatusPage, subscriberRequest *StatusPageSubscriberRequest) (*StatusPageSubscriber, error) {
+	address, err := mail.ParseAddress(subscriberRequest.Email)
+	if err != nil {
+		return nil, ErrInvalidSubscriberEmail
+	}
+	emailAddress := strings.ToLower(address.Address)
+
+	// Fetch an existing subscriber or create a new one
+	subscriber := new(StatusPageSubscriber)
+	notFound := s.db.Where("status_page_id = ? AND email = ?", statusPage.ID, emailAddress).
+		First(subscriber).RecordNotFound()
+	if notFound {
+		subscriber = NewStatusPageSubscriber(statusPage, emailAddress)
+		if err := s.db.Create(subscriber).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Assign related object
+	subscriber.StatusPage = statusPage
+
+	// Confirmed subscribers do not need another confirmation
+	if subscriber.Confirmed {
+		return subscriber, nil
+	}
+
+	// Throttle resends so a public page can't be used to flood an inbox,
+	// the send is claimed by bumping email_sent_at so concurrent requests
+	// don't send more than one email either
+	now := time.Now()
+	claim := s.db.Model(new(StatusPageSubscriber)).
+		Where("id = ? AND (email_sent_at IS NULL OR email_sent_at < ?)",
+			subscriber.ID, now.Add(-ConfirmationResendInterval)).
+		UpdateColumn("email_sent_at", now)
+	if err := claim.Error; err != nil {
+		return nil, err
+	}
+	if claim.RowsAffected == 0 {
+		return subscriber, nil
+	}
+
+	// Send confirmation email
+	s.dispatchStatusPageSubscriptionEmail(subscriber)
+
+	return subscriber, nil
+}
+
+// confirmStatusPageSubscriber sets confirmed flag to true
+func (s *Service) confirmStatusPageSubscriber(subscriber *StatusPageSubscriber) error {
+	if err := s.db.Model(subscriber).UpdateColumns(StatusPageSubscriber{
+		Confirmed: true,
+		Model:     gorm.Model{UpdatedAt: time.Now()},
+	}).Error; err != nil {
+		return err
+	}
+	subscriber.Confirmed = true
+	return nil
+}
+
+// unsubscribeFromStatusPage deletes the subscriber permanently so no email
+// address is kept after unsubscribing
+func (s *Service) unsubscribeFromStatusPage(subscriber *StatusPageSubscriber) error {
+	return s.db.Unscoped().Delete(subscriber).Error
+}
@@ -0,0 +1,98 @@
+package alarms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/alarmstates"
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDowntimePeriods(t *testing.T) {
+	var (
+		now     = time.Date(2016, 3, 10, 12, 0, 0, 0, time.UTC)
+		resolve = func(t time.Time) *time.Time { return &t }
+		alarms  = []*Alarm{
+			&Alarm{Model: gorm.Model{ID: 1}},
+			&Alarm{Model: gorm.Model{ID: 2}},
+		}
+		incidents = []*Incident{
+			// Overlaps with the next incident of the other alarm
+			&Incident{
+				AlarmID:    util.PositiveIntOrNull(1),
+				Model:      gorm.Model{CreatedAt: now.Add(-10 * time.Hour)},
+				ResolvedAt: util.TimeOrNull(resolve(now.Add(-8 * time.Hour))),
+			},
+			&Incident{
+				AlarmID:    util.PositiveIntOrNull(2),
+				Model:      gorm.Model{CreatedAt: now.Add(-9 * time.Hour)},
+				ResolvedAt: util.TimeOrNull(resolve(now.Add(-7 * time.Hour))),
+			},
+			// Belongs to an alarm not in the component
+			&Incident{
+				AlarmID:    util.PositiveIntOrNull(3),
+				Model:      gorm.Model{CreatedAt: now.Add(-5 * time.Hour)},
+				ResolvedAt: util.TimeOrNull(resolve(now.Add(-4 * time.Hour))),
+			},
+			// Still open
+			&Incident{
+				AlarmID: util.PositiveIntOrNull(1),
+				Model:   gorm.Model{CreatedAt: now.Add(-1 * time.Hour)},
+			},
+		}
+	)
+
+	periods := getDowntimePeriods(incidents, alarms, now)
+
+	if assert.Equal(t, 2, len(periods)) {
+		assert.Equal(t, now.Add(-10*time.Hour), periods[0].start)
+		assert.Equal(t, now.Add(-7*time.Hour), periods[0].end)
+		assert.Equal(t, now.Add(-1*time.Hour), periods[1].start)
+		assert.Equal(t, now, periods[1].end)
+	}
+}
+
+func TestGetUptimeBars(t *testing.T) {
+	now := time.Date(2016, 3, 10, 12, 0, 0, 0, time.UTC)
+	periods := []*downtimePeriod{
+		// 6 hours on the 8th, 6 hours on the 9th
+		&downtimePeriod{
+			start: time.Date(2016, 3, 8, 18, 0, 0, 0, time.UTC),
+			end:   time.Date(2016, 3, 9, 6, 0, 0, 0, time.UTC),
+		},
+		// 3 hours of the 12 elapsed today
+		&downtimePeriod{
+			start: time.Date(2016, 3, 10, 9, 0, 0, 0, time.UTC),
+			end:   now,
+		},
+	}
+
+	bars := getUptimeBars(periods, now, 4)
+
+	if assert.Equal(t, 4, len(bars)) {
+		assert.Equal(t, time.Date(2016, 3, 7, 0, 0, 0, 0, time.UTC), bars[0].date)
+		assert.Equal(t, float64(100), bars[0].uptime)
+		assert.Equal(t, float64(75), bars[1].uptime)
+		assert.Equal(t, float64(75), bars[2].uptime)
+		assert.Equal(t, time.Date(2016, 3, 10, 0, 0, 0, 0, time.UTC), bars[3].date)
+		assert.Equal(t, float64(75), bars[3].uptime)
+	}
+	assert.Equal(t, 81.25, averageUptime(bars))
+}
+
+func TestComponentState(t *testing.T) {
+	component := &StatusPageComponent{Alarms: []*Alarm{
+		&Alarm{AlarmStateID: util.StringOrNull(alarmstates.OK)},
+		&Alarm{AlarmStateID: util.StringOrNull(alarmstates.InsufficientData)},
+	}}
+	assert.Equal(t, alarmstates.InsufficientData, componentState(component))
+
+	component.Alarms = append(component.Alarms, &Alarm{
+		AlarmStateID: util.StringOrNull(alarmstates.Alarm),
+	})
+	assert.Equal(t, alarmstates.Alarm, componentState(component))
+
+	assert.Equal(t, alarmstates.OK, componentState(new(StatusPageComponent)))
+}
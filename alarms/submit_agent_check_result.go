@@ -0,0 +1,59 @@
+package alarms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+)
+
+// Handles calls to submit a check result by a region agent (POST /v1/agent/results)
+func (s *Service) submitAgentCheckResultHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated account from the request context
+	authenticatedAccount, err := accounts.GetAuthenticatedAccount(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Find the region the account is an agent of
+	region, err := s.findAgentRegion(authenticatedAccount)
+	if err != nil {
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// Request body cannot be nil
+	if r.Body == nil {
+		response.Error(w, "Request body cannot be nil", http.StatusBadRequest)
+		return
+	}
+
+	// Read the request body
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Unmarshal the request body into the request prototype
+	resultRequest := new(CheckResultRequest)
+	if err := json.Unmarshal(payload, resultRequest); err != nil {
+		logger.ERROR.Printf("Failed to unmarshal check result request: %s", payload)
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Process the result
+	if err := s.submitAgentCheckResult(region, resultRequest); err != nil {
+		logger.ERROR.Printf("Submit agent check result error: %s", err)
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// 204 no content response
+	response.NoContent(w)
+}
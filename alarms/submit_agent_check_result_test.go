@@ -0,0 +1,140 @@
+package alarms
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestSubmitAgentCheckResultRequiresAccountAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.submitAgentCheckResultHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated account")
+}
+
+func (suite *AlarmsTestSuite) TestSubmitAgentCheckResultOtherRegion() {
+	suite.insertAgentRegion(suite.accounts[0])
+
+	// Alarms of the local region cannot be checked by the agent
+	w := suite.submitAgentCheckResult(&CheckResultRequest{
+		AlarmID:      suite.alarms[0].ID,
+		Watermark:    time.Now().UTC().Format(time.RFC3339Nano),
+		StartedAt:    time.Now().UTC().Format(time.RFC3339Nano),
+		ResponseTime: int64(50 * time.Millisecond),
+		StatusCode:   200,
+	})
+
+	// Check the status code
+	if !assert.Equal(suite.T(), http.StatusNotFound, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the response body
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrAgentAlarmNotFound.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestSubmitAgentCheckResult() {
+	var (
+		agentRegion = suite.insertAgentRegion(suite.accounts[0])
+		testAlarm   = suite.insertAgentRegionAlarm(agentRegion)
+		startedAt   = time.Date(2016, time.March, 7, 9, 0, 0, 0, time.UTC)
+	)
+
+	// Lease the check
+	leasedAlarms, err := suite.service.leaseAgentChecks(agentRegion, 10, time.Now())
+	assert.NoError(suite.T(), err)
+	if !assert.Equal(suite.T(), 1, len(leasedAlarms)) {
+		return
+	}
+	lease, err := NewCheckLeaseResponse(leasedAlarms[0])
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), testAlarm.ID, lease.AlarmID)
+
+	// The agent got a bad status code
+	resultRequest := &CheckResultRequest{
+		AlarmID:      lease.AlarmID,
+		Watermark:    lease.Watermark,
+		StartedAt:    startedAt.Format(time.RFC3339Nano),
+		ResponseTime: int64(50 * time.Millisecond),
+		StatusCode:   500,
+		Headers:      map[string][]string{"Content-Type": {"text/plain"}},
+		ResponseSize: 12,
+		Timings:      &CheckTimingsRequest{DNSLookup: 1000, FirstByte: 2000},
+	}
+	suite.mockLogCheckResult(startedAt, testAlarm.ID, 500, incidenttypes.BadCode, nil)
+	suite.mockLogResponseTime(startedAt, testAlarm.ID, nil)
+	w := suite.submitAgentCheckResult(resultRequest)
+
+	// Check the status code
+	if !assert.Equal(suite.T(), http.StatusNoContent, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// The result went through the normal incident path
+	alarm := new(Alarm)
+	assert.False(suite.T(), suite.service.db.Preload("Incidents").
+		First(alarm, testAlarm.ID).RecordNotFound())
+	if assert.Equal(suite.T(), 1, len(alarm.Incidents)) {
+		incident := alarm.Incidents[0]
+		assert.Equal(suite.T(), incidenttypes.BadCode, incident.IncidentTypeID.String)
+		assert.Equal(suite.T(), int64(500), incident.HTTPCode.Int64)
+		assert.Equal(suite.T(), int64(50*time.Millisecond), incident.ResponseTime.Int64)
+		assert.Contains(suite.T(), incident.Response.String, "500 Internal Server Error")
+		assert.Contains(suite.T(), incident.Response.String, "Content-Type: text/plain")
+	}
+
+	// The next check is scheduled
+	assert.True(suite.T(), alarm.NextCheckAt.Valid)
+
+	// Posting the same result again is rejected
+	w = suite.submitAgentCheckResult(resultRequest)
+	if !assert.Equal(suite.T(), http.StatusConflict, w.Code) {
+		log.Print(w.Body.String())
+	}
+}
+
+// submitAgentCheckResult posts a check result through the API as the agent
+func (suite *AlarmsTestSuite) submitAgentCheckResult(resultRequest *CheckResultRequest) *httptest.ResponseRecorder {
+	payload, err := json.Marshal(resultRequest)
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/agent/results",
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.SetBasicAuth("test_client_1", "test_secret")
+
+	// Mock authentication
+	suite.mockClientAuth(suite.accounts[0])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	return w
+}
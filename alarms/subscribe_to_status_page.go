@@ -0,0 +1,64 @@
+package alarms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+// Handles calls to subscribe to a status page (POST /v1/status-pages/{slug}/subscribers)
+func (s *Service) subscribeToStatusPageHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated account from the request context
+	_, err := accounts.GetAuthenticatedAccount(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the slug from request URI
+	vars := mux.Vars(r)
+
+	// Fetch the status page
+	statusPage, err := s.findStatusPageBySlug(vars["slug"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Request body cannot be nil
+	if r.Body == nil {
+		response.Error(w, "Request body cannot be nil", http.StatusBadRequest)
+		return
+	}
+
+	// Read the request body
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Unmarshal the request body into the request prototype
+	subscriberRequest := new(StatusPageSubscriberRequest)
+	if err := json.Unmarshal(payload, subscriberRequest); err != nil {
+		logger.ERROR.Printf("Failed to unmarshal status page subscriber request: %s", payload)
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Subscribe, the response does not reveal whether the email
+	// has already been subscribed
+	if _, err := s.subscribeToStatusPage(statusPage, subscriberRequest); err != nil {
+		logger.ERROR.Printf("Subscribe to status page error: %s", err)
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// 204 no content response
+	response.NoContent(w)
+}
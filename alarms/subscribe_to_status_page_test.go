@@ -0,0 +1,165 @@
+package alarms
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestSubscribeToStatusPageRequiresAccountAuthentication() {
+	r, err := http.NewRequest("", "", nil)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+
+	w := httptest.NewRecorder()
+
+	suite.service.subscribeToStatusPageHandler(w, r)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code, "This requires an authenticated account")
+}
+
+func (suite *AlarmsTestSuite) TestSubscribeToStatusPageInvalidEmail() {
+	// Create a test status page
+	_, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+
+	// Prepare a request
+	payload, err := json.Marshal(&StatusPageSubscriberRequest{Email: "bogus"})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/status-pages/acme/subscribers",
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.SetBasicAuth("test_client_1", "test_secret")
+
+	// Mock authentication
+	suite.mockClientAuth(suite.accounts[0])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 400, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Nothing should have been saved
+	var count int
+	suite.db.Model(new(StatusPageSubscriber)).Count(&count)
+	assert.Equal(suite.T(), 0, count)
+}
+
+func (suite *AlarmsTestSuite) TestSubscribeToStatusPage() {
+	// Create a test status page
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+
+	// Prepare a request
+	payload, err := json.Marshal(&StatusPageSubscriberRequest{
+		Email: "John Reese <John@Reese.com>",
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"POST",
+		"http://1.2.3.4/v1/status-pages/acme/subscribers",
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.SetBasicAuth("test_client_1", "test_secret")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "subscribe_to_status_page", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockClientAuth(suite.accounts[0])
+
+	// Mock confirmation email
+	suite.mockStatusPageSubscriptionEmail()
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Sleep for the email goroutine to finish
+	time.Sleep(15 * time.Millisecond)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), 204, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Fetch the created subscriber
+	subscriber := new(StatusPageSubscriber)
+	notFound := suite.db.Where("status_page_id = ?", statusPage.ID).
+		First(subscriber).RecordNotFound()
+	if assert.False(suite.T(), notFound) {
+		assert.Equal(suite.T(), "john@reese.com", subscriber.Email)
+		assert.False(suite.T(), subscriber.Confirmed)
+		assert.True(suite.T(), subscriber.EmailSent)
+		assert.True(suite.T(), subscriber.EmailSentAt.Valid)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestSubscribeToStatusPageThrottlesResends() {
+	// Create a test status page
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+
+	// Mock confirmation email, it should only be sent once
+	suite.mockStatusPageSubscriptionEmail()
+
+	// Subscribe repeatedly
+	subscriberRequest := &StatusPageSubscriberRequest{Email: "john@reese.com"}
+	for i := 0; i < 3; i++ {
+		_, err := suite.service.subscribeToStatusPage(statusPage, subscriberRequest)
+		assert.NoError(suite.T(), err)
+	}
+
+	// Sleep for the email goroutine to finish
+	time.Sleep(15 * time.Millisecond)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+	suite.emailServiceMock.AssertNumberOfCalls(suite.T(), "Send", 1)
+
+	// Once the interval has passed the email is sent again
+	err = suite.db.Model(new(StatusPageSubscriber)).
+		Where("status_page_id = ?", statusPage.ID).
+		UpdateColumn("email_sent_at", time.Now().Add(-ConfirmationResendInterval-time.Minute)).
+		Error
+	assert.NoError(suite.T(), err, "Updating email_sent_at failed")
+	_, err = suite.service.subscribeToStatusPage(statusPage, subscriberRequest)
+	assert.NoError(suite.T(), err)
+
+	// Sleep for the email goroutine to finish
+	time.Sleep(15 * time.Millisecond)
+
+	suite.emailServiceMock.AssertNumberOfCalls(suite.T(), "Send", 2)
+}
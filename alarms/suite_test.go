@@ -7,6 +7,7 @@ import (
 
 	slack "github.com/RichardKnop/go-slack"
 	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/alarms/regions"
 	"github.com/RichardKnop/pinglist-api/config"
 	"github.com/RichardKnop/pinglist-api/database"
 	"github.com/RichardKnop/pinglist-api/email"
@@ -149,6 +150,7 @@ func (suite *AlarmsTestSuite) SetupSuite() {
 		suite.teamsServiceMock,
 		suite.metricsServiceMock,
 		suite.notificationsServiceMock,
+		nil, // jobs.Service
 		suite.emailServiceMock,
 		suite.emailFactoryMock,
 		suite.slackFactoryMock,
@@ -172,10 +174,28 @@ func (suite *AlarmsTestSuite) SetupTest() {
 	suite.db.Unscoped().Delete(new(subscriptions.Customer))
 	suite.db.Unscoped().Delete(new(subscriptions.Plan))
 	suite.db.Unscoped().Delete(new(NotificationCounter))
+	suite.db.Unscoped().Delete(new(ScrapeToken))
+	suite.db.Unscoped().Delete(new(StatusPageIncidentUpdate))
+	suite.db.Unscoped().Delete(new(StatusPageIncident))
+	suite.db.Unscoped().Delete(new(StatusPageSubscriber))
+	suite.db.Exec("DELETE FROM alarm_status_page_component_alarms;")
+	suite.db.Unscoped().Delete(new(StatusPageComponent))
+	suite.db.Unscoped().Delete(new(StatusPage))
+	suite.db.Exec("DELETE FROM alarm_annotation_alarms;")
+	suite.db.Exec("DELETE FROM alarm_annotation_tags;")
+	suite.db.Unscoped().Delete(new(Annotation))
+	suite.db.Unscoped().Delete(new(AnnotationToken))
 	suite.db.Exec("DELETE FROM team_team_members;")
 	suite.db.Unscoped().Delete(new(teams.Team))
+	suite.db.Exec("DELETE FROM alarm_alarm_tags;")
+	suite.db.Unscoped().Delete(new(Tag))
+	suite.db.Unscoped().Delete(new(Baseline))
 	suite.db.Unscoped().Not("id", []int64{1, 2, 3, 4}).Delete(new(Incident))
+	suite.db.Exec("UPDATE alarm_incidents SET acknowledged_at = NULL;")
+	suite.db.Exec("UPDATE alarm_alarms SET anomaly_started_at = NULL, baseline_updated_at = NULL;")
 	suite.db.Unscoped().Not("id", []int64{1, 2, 3, 4}).Delete(new(Alarm))
+	suite.db.Unscoped().Not("id", regions.USWest2).Delete(new(Region))
+	suite.db.Exec("UPDATE alarm_regions SET agent_account_id = NULL;")
 
 	suite.resetMocks()
 }
@@ -246,6 +266,24 @@ func (suite *AlarmsTestSuite) mockUserAuth(user *accounts.User) {
 		Return(user, nil)
 }
 
+// Mock client credentials grant auth
+func (suite *AlarmsTestSuite) mockClientAuth(account *accounts.Account) {
+	// Mock GetConfig call to return the config object
+	suite.accountsServiceMock.On("GetConfig").Return(suite.cnf)
+
+	// Mock GetOauthService to return a mock oauth service
+	suite.accountsServiceMock.On("GetOauthService").Return(suite.oauthServiceMock)
+
+	// Mock AuthClient to return a mock client
+	suite.oauthServiceMock.On("AuthClient", "test_client_1", "test_secret").
+		Return(account.OauthClient, nil)
+
+	// Mock FindAccountByOauthClientID to return the wanted account
+	suite.accountsServiceMock.
+		On("FindAccountByOauthClientID", account.OauthClient.ID).
+		Return(account, nil)
+}
+
 // Mock find team
 func (suite *AlarmsTestSuite) mockFindTeamByMemberID(userID uint, team *teams.Team, err error) {
 	suite.teamsServiceMock.On(
@@ -254,6 +292,14 @@ func (suite *AlarmsTestSuite) mockFindTeamByMemberID(userID uint, team *teams.Te
 	).Return(team, err)
 }
 
+// Mock find team by ID
+func (suite *AlarmsTestSuite) mockFindTeamByID(teamID uint, team *teams.Team, err error) {
+	suite.teamsServiceMock.On(
+		"FindTeamByID",
+		teamID,
+	).Return(team, err)
+}
+
 // Mock find active subscription
 func (suite *AlarmsTestSuite) mockFindActiveSubscriptionByUserID(userID uint, subscription *subscriptions.Subscription, err error) {
 	suite.subscriptionsServiceMock.On(
@@ -290,6 +336,27 @@ func (suite *AlarmsTestSuite) mockIncidentsResolvedEmail() {
 	suite.emailServiceMock.On("Send", emailMock).Return(nil)
 }
 
+// Mock status page subscription confirmation email
+func (suite *AlarmsTestSuite) mockStatusPageSubscriptionEmail() {
+	emailMock := new(email.Email)
+	suite.emailFactoryMock.On(
+		"NewStatusPageSubscriptionEmail",
+		mock.AnythingOfType("*alarms.StatusPageSubscriber"),
+	).Return(emailMock)
+	suite.emailServiceMock.On("Send", emailMock).Return(nil)
+}
+
+// Mock status page incident update email
+func (suite *AlarmsTestSuite) mockStatusPageIncidentUpdateEmail() {
+	emailMock := new(email.Email)
+	suite.emailFactoryMock.On(
+		"NewStatusPageIncidentUpdateEmail",
+		mock.AnythingOfType("*alarms.StatusPageSubscriber"),
+		mock.AnythingOfType("*alarms.StatusPageIncidentUpdate"),
+	).Return(emailMock)
+	suite.emailServiceMock.On("Send", emailMock).Return(nil)
+}
+
 // Mock find endpoint
 func (suite *AlarmsTestSuite) mockFindEndpointByUserIDAndApplicationARN(userID uint, applicationARN string, endpoint *notifications.Endpoint, err error) {
 	suite.notificationsServiceMock.On(
@@ -316,22 +383,125 @@ func (suite *AlarmsTestSuite) mockLogResponseTime(timestamp time.Time, reference
 		timestamp,
 		referenceID,
 		mock.AnythingOfType("int64"),
+		mock.AnythingOfType("bool"),
+		mock.AnythingOfType("*metrics.Timings"),
+	).Return(err)
+}
+
+// Mock logging of a check result
+func (suite *AlarmsTestSuite) mockLogCheckResult(timestamp time.Time, referenceID uint, statusCode int, outcome string, err error) {
+	suite.metricsServiceMock.On(
+		"LogCheckResult",
+		timestamp,
+		referenceID,
+		mock.AnythingOfType("string"),
+		statusCode,
+		mock.AnythingOfType("int64"),
+		outcome,
+		mock.AnythingOfType("string"),
 	).Return(err)
 }
 
+// Mock counting of check results
+func (suite *AlarmsTestSuite) mockCheckResultsCount(alarmID int, outcome string, from, to *time.Time, count int, err error) {
+	suite.metricsServiceMock.On(
+		"CheckResultsCount",
+		alarmID,
+		outcome,
+		from,
+		to,
+	).Return(count, err)
+}
+
+// Mock finding paginated check results
+func (suite *AlarmsTestSuite) mockFindPaginatedCheckResults(offset, limit int, orderBy string, alarmID int, outcome string, from, to *time.Time, checkResults []*metrics.CheckResult, err error) {
+	suite.metricsServiceMock.On(
+		"FindPaginatedCheckResults",
+		offset,
+		limit,
+		orderBy,
+		alarmID,
+		outcome,
+		from,
+		to,
+	).Return(checkResults, err)
+}
+
+// Mock iterating over response time metrics, fn is called for every record
+func (suite *AlarmsTestSuite) mockIterateResponseTimes(alarmID int, from, to *time.Time, responseTimes []*metrics.ResponseTime, err error) {
+	suite.metricsServiceMock.On(
+		"IterateResponseTimes",
+		alarmID,
+		from,
+		to,
+		mock.AnythingOfType("func(*metrics.ResponseTime) error"),
+	).Return(func(alarmID int, from, to *time.Time, fn func(*metrics.ResponseTime) error) error {
+		for _, responseTime := range responseTimes {
+			if err := fn(responseTime); err != nil {
+				return err
+			}
+		}
+		return err
+	})
+}
+
+// Mock iterating over the response time history baselines are learnt from
+func (suite *AlarmsTestSuite) mockIterateBaselineResponseTimes(alarmID int, responseTimes []*metrics.ResponseTime, err error) {
+	suite.metricsServiceMock.On(
+		"IterateResponseTimes",
+		alarmID,
+		mock.AnythingOfType("*time.Time"),
+		mock.AnythingOfType("*time.Time"),
+		mock.AnythingOfType("func(*metrics.ResponseTime) error"),
+	).Return(func(alarmID int, from, to *time.Time, fn func(*metrics.ResponseTime) error) error {
+		for _, responseTime := range responseTimes {
+			if err := fn(responseTime); err != nil {
+				return err
+			}
+		}
+		return err
+	})
+}
+
+// Mock fetching of the last response times
+func (suite *AlarmsTestSuite) mockLastResponseTimes(alarmIDs []uint, lastResponseTimes map[uint]*metrics.ResponseTime, err error) {
+	suite.metricsServiceMock.On(
+		"LastResponseTimes",
+		alarmIDs,
+		mock.AnythingOfType("time.Time"),
+	).Return(lastResponseTimes, err)
+}
+
+// Mock counting of check results by outcome
+func (suite *AlarmsTestSuite) mockCheckResultCounts(alarmIDs []uint, counts map[uint]map[string]int, err error) {
+	suite.metricsServiceMock.On(
+		"CheckResultCounts",
+		alarmIDs,
+	).Return(counts, err)
+}
+
 // Mock counting of response time metrics
-func (suite *AlarmsTestSuite) mockResponseTimesCount(alarmID int, dateTrunc string, from, to *time.Time, count int, err error) {
+func (suite *AlarmsTestSuite) mockResponseTimesCount(alarmID int, dateTrunc, phase string, from, to *time.Time, count int, err error) {
 	suite.metricsServiceMock.On(
 		"ResponseTimesCount",
 		alarmID,
 		dateTrunc,
+		phase,
 		from,
 		to,
 	).Return(count, err)
 }
 
+// Mock metrics retention of a user
+func (suite *AlarmsTestSuite) mockGetMetricsRetention(userID uint, retention time.Duration) {
+	suite.metricsServiceMock.On(
+		"GetMetricsRetention",
+		userID,
+	).Return(retention)
+}
+
 // Mock finding paginated response time metrics
-func (suite *AlarmsTestSuite) mockFindPaginatedResponseTimes(offset, limit int, orderBy string, alarmID int, dateTrunc string, from, to *time.Time, ResponseTimes []*metrics.ResponseTime, err error) {
+func (suite *AlarmsTestSuite) mockFindPaginatedResponseTimes(offset, limit int, orderBy string, alarmID int, dateTrunc, phase string, aggregations []string, from, to *time.Time, ResponseTimes []*metrics.ResponseTime, err error) {
 	suite.metricsServiceMock.On(
 		"FindPaginatedResponseTimes",
 		offset,
@@ -339,11 +509,25 @@ func (suite *AlarmsTestSuite) mockFindPaginatedResponseTimes(offset, limit int,
 		orderBy,
 		alarmID,
 		dateTrunc,
+		phase,
+		aggregations,
 		from,
 		to,
 	).Return(ResponseTimes, err)
 }
 
+// Mock aggregating response time metrics over the whole time range
+func (suite *AlarmsTestSuite) mockResponseTimesAggregations(alarmID int, phase string, aggregations []string, from, to *time.Time, result map[string]float64, err error) {
+	suite.metricsServiceMock.On(
+		"ResponseTimesAggregations",
+		alarmID,
+		phase,
+		aggregations,
+		from,
+		to,
+	).Return(result, err)
+}
+
 // Mock new incident notification Slack message
 func (suite *AlarmsTestSuite) mockNewIncidentSlackMessage(user *accounts.User) {
 	msg := "Some mock message..."
@@ -0,0 +1,42 @@
+package alarms
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrInvalidTag ...
+	ErrInvalidTag = errors.New("Tags must be 1-50 characters long and can only contain lowercase letters, numbers, dashes, underscores, dots and colons")
+
+	tagRegexp = regexp.MustCompile("^[a-z0-9][a-z0-9_.:-]{0,49}$")
+)
+
+// findOrCreateTags returns tags with given names, creating the missing ones,
+// names are normalised to lowercase and duplicates are ignored
+func findOrCreateTags(db *gorm.DB, names []string) ([]*Tag, error) {
+	tags := make([]*Tag, 0, len(names))
+	seen := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !tagRegexp.MatchString(name) {
+			return nil, ErrInvalidTag
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		tag := new(Tag)
+		if err := db.Where(Tag{Name: name}).FirstOrCreate(tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,39 @@
+package alarms
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestFindOrCreateTags() {
+	var (
+		countBefore, countAfter int
+		tags                    []*Tag
+		err                     error
+	)
+
+	// Invalid tag names should be rejected
+	tags, err = findOrCreateTags(suite.db, []string{"prod", "not valid"})
+	assert.Nil(suite.T(), tags)
+	assert.Equal(suite.T(), ErrInvalidTag, err)
+
+	// Count before
+	suite.db.Model(new(Tag)).Count(&countBefore)
+
+	// Names are normalised and duplicates ignored
+	tags, err = findOrCreateTags(suite.db, []string{"Prod", " prod ", "api"})
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 2, len(tags)) {
+		assert.Equal(suite.T(), "prod", tags[0].Name)
+		assert.Equal(suite.T(), "api", tags[1].Name)
+	}
+
+	// Count after
+	suite.db.Model(new(Tag)).Count(&countAfter)
+	assert.Equal(suite.T(), countBefore+2, countAfter)
+
+	// Existing tags should be reused
+	tags, err = findOrCreateTags(suite.db, []string{"api"})
+	assert.NoError(suite.T(), err)
+	suite.db.Model(new(Tag)).Count(&countAfter)
+	assert.Equal(suite.T(), countBefore+2, countAfter)
+}
@@ -0,0 +1,40 @@
+package alarms
+
+import (
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+// Handles requests to unsubscribe from a status page based on a reference
+// string, no user needs to be logged in (DELETE /v1/status-page-subscribers/{reference})
+func (s *Service) unsubscribeFromStatusPageHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated account from the request context
+	_, err := accounts.GetAuthenticatedAccount(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the reference from request URI
+	vars := mux.Vars(r)
+	reference := vars["reference"]
+
+	// Fetch the subscriber we want to delete
+	subscriber, err := s.findStatusPageSubscriberByReference(reference)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Delete the subscriber
+	if err := s.unsubscribeFromStatusPage(subscriber); err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 204 no content response
+	response.NoContent(w)
+}
@@ -535,6 +535,9 @@ func (suite *AlarmsTestSuite) TestUpdateAlarm() {
 		SlackAlerts:            false,
 		Active:                 true,
 		State:                  alarmstates.InsufficientData,
+		SLOTarget:              DefaultSLOTarget,
+		AnomalySensitivity:     DefaultAnomalySensitivity,
+		AnomalyPeriod:          DefaultAnomalyPeriod,
 		CreatedAt:              util.FormatTime(alarm.CreatedAt),
 		UpdatedAt:              util.FormatTime(alarm.UpdatedAt),
 	}
@@ -0,0 +1,108 @@
+package alarms
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrUpdateStatusPagePermission ...
+	ErrUpdateStatusPagePermission = errors.New("Need permission to update status page")
+)
+
+// Handles calls to update a status page (PUT /v1/status-pages/{id:[0-9]+})
+func (s *Service) updateStatusPageHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Get the id from request URI and type assert it
+	vars := mux.Vars(r)
+	statusPageID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the status page we want to update
+	statusPage, err := s.findStatusPageByID(uint(statusPageID))
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Check permissions
+	if err := checkUpdateStatusPagePermissions(authenticatedUser, statusPage); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Request body cannot be nil
+	if r.Body == nil {
+		response.Error(w, "Request body cannot be nil", http.StatusBadRequest)
+		return
+	}
+
+	// Read the request body
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	// Unmarshal the request body into the request prototype
+	statusPageRequest := new(StatusPageRequest)
+	if err := json.Unmarshal(payload, statusPageRequest); err != nil {
+		logger.ERROR.Printf("Failed to unmarshal status page request: %s", payload)
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Update the status page
+	if err := s.updateStatusPage(statusPage, authenticatedUser, statusPageRequest); err != nil {
+		logger.ERROR.Printf("Update status page error: %s", err)
+		response.Error(w, err.Error(), getErrStatusCode(err))
+		return
+	}
+
+	// Create response
+	statusPageResponse, err := NewStatusPageResponse(statusPage)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, statusPageResponse, http.StatusOK)
+}
+
+func checkUpdateStatusPagePermissions(authenticatedUser *accounts.User, statusPage *StatusPage) error {
+	// Superusers can update any status pages
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Users can update their own status pages
+	if authenticatedUser.ID == uint(statusPage.UserID.Int64) {
+		return nil
+	}
+
+	// Team members can update status pages of their team
+	if statusPage.Team != nil && isTeamMember(statusPage.Team, authenticatedUser) {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrUpdateStatusPagePermission
+}
@@ -0,0 +1,139 @@
+package alarms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *AlarmsTestSuite) TestUpdateStatusPageTeamChangeRequiresOwner() {
+	// Insert a test team
+	testTeam := &teams.Team{
+		Owner:   suite.users[1],
+		Name:    "Test Team",
+		Members: []*accounts.User{suite.users[2]},
+	}
+	err := suite.db.Create(testTeam).Error
+	assert.NoError(suite.T(), err, "Inserting test team failed")
+
+	// Create a test status page owned by the team
+	suite.mockFindTeamByID(testTeam.ID, testTeam, nil)
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:   "acme",
+		Title:  "Acme Status",
+		TeamID: testTeam.ID,
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+	suite.resetMocks()
+
+	// Prepare a request, a team member tries to take the page off the team
+	payload, err := json.Marshal(&StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"PUT",
+		fmt.Sprintf("http://1.2.3.4/v1/status-pages/%d", statusPage.ID),
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[2])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), http.StatusForbidden, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	expectedJSON, err := json.Marshal(
+		map[string]string{"error": ErrStatusPageTeamChangePermission.Error()})
+	if assert.NoError(suite.T(), err, "JSON marshalling failed") {
+		assert.Equal(
+			suite.T(),
+			string(expectedJSON),
+			strings.TrimRight(w.Body.String(), "\n"),
+			"Body should contain JSON detailing the error",
+		)
+	}
+
+	// The page still belongs to the team
+	statusPage, err = suite.service.findStatusPageByID(statusPage.ID)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), int64(testTeam.ID), statusPage.TeamID.Int64)
+	}
+}
+
+func (suite *AlarmsTestSuite) TestUpdateStatusPageResolvesAlarmsAgainstOwner() {
+	// Create a test status page, alarms belong to the page owner
+	statusPage, err := suite.service.createStatusPage(suite.users[1], &StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+	})
+	assert.NoError(suite.T(), err, "Creating test status page failed")
+
+	// Prepare a request, a superuser adds the owner's alarm to the page
+	payload, err := json.Marshal(&StatusPageRequest{
+		Slug:  "acme",
+		Title: "Acme Status",
+		Components: []*StatusPageComponentRequest{
+			&StatusPageComponentRequest{Name: "API", AlarmIDs: []uint{1}},
+		},
+	})
+	assert.NoError(suite.T(), err, "JSON marshalling failed")
+	r, err := http.NewRequest(
+		"PUT",
+		fmt.Sprintf("http://1.2.3.4/v1/status-pages/%d", statusPage.ID),
+		bytes.NewBuffer(payload),
+	)
+	assert.NoError(suite.T(), err, "Request setup should not get an error")
+	r.Header.Set("Authorization", "Bearer test_token")
+
+	// Check the routing
+	match := new(mux.RouteMatch)
+	suite.router.Match(r, match)
+	if assert.NotNil(suite.T(), match.Route) {
+		assert.Equal(suite.T(), "update_status_page", match.Route.GetName())
+	}
+
+	// Mock authentication
+	suite.mockUserAuth(suite.users[0])
+
+	// And serve the request
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, r)
+
+	// Check that the mock object expectations were met
+	suite.assertMockExpectations()
+
+	// Check the status code
+	if !assert.Equal(suite.T(), http.StatusOK, w.Code) {
+		log.Print(w.Body.String())
+	}
+
+	// Check the components were saved
+	statusPage, err = suite.service.findStatusPageByID(statusPage.ID)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 1, len(statusPage.Components)) {
+		if assert.Equal(suite.T(), 1, len(statusPage.Components[0].Alarms)) {
+			assert.Equal(suite.T(), uint(1), statusPage.Components[0].Alarms[0].ID)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/RichardKnop/pinglist-api/database"
+	"github.com/RichardKnop/pinglist-api/jobs"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/prometheus"
+	"github.com/jinzhu/gorm"
+)
+
+// startAdminServer serves internal metrics and jobs on a separate listener so
+// they are never exposed on the public API port, empty listen address
+// disables it
+func startAdminServer(cnf *config.Config, db *gorm.DB) {
+	if cnf.Admin.Listen == "" {
+		return
+	}
+
+	prometheus.Register(database.NewStatsCollector(db.DB()))
+	prometheus.Register(jobs.NewStatsCollector(jobsService))
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.DefaultRegistry.Handler())
+	jobsHandler := jobs.NewAdminHandler(jobsService)
+	mux.Handle("/jobs", jobsHandler)
+	mux.Handle("/jobs/", jobsHandler)
+
+	go func() {
+		logger.INFO.Printf("Admin listener on %s", cnf.Admin.Listen)
+		if err := http.ListenAndServe(cnf.Admin.Listen, mux); err != nil {
+			logger.ERROR.Printf("Admin listener error: %s", err)
+		}
+	}()
+}
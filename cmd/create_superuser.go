@@ -23,7 +23,7 @@ func CreateSuperuser() error {
 	oauthService := oauth.NewService(cnf, db)
 
 	// Initialise the email service
-	emailService := email.NewService(cnf)
+	emailService := email.NewServiceFromConfig(cnf)
 
 	// Initialise the accounts service
 	accountsService := accounts.NewService(
@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/RichardKnop/pinglist-api/accounts"
 	"github.com/RichardKnop/pinglist-api/alarms"
+	"github.com/RichardKnop/pinglist-api/jobs"
 	"github.com/RichardKnop/pinglist-api/metrics"
 	"github.com/RichardKnop/pinglist-api/migrations"
 	"github.com/RichardKnop/pinglist-api/notifications"
@@ -17,9 +18,10 @@ var migrationFunctions = []func(*gorm.DB) error{
 	accounts.MigrateAll,
 	metrics.MigrateAll,
 	subscriptions.MigrateAll,
-	alarms.MigrateAll,
 	teams.MigrateAll,
+	alarms.MigrateAll,
 	notifications.MigrateAll,
+	jobs.MigrateAll,
 }
 
 // Migrate runs database migrations
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/agent"
+	"github.com/RichardKnop/pinglist-api/logger"
+)
+
+var (
+	// ErrAgentConfig ...
+	ErrAgentConfig = errors.New("API URL, client ID and client secret are required")
+)
+
+// RunAgent runs a region agent, it needs no database or config store,
+// only the API URL and client credentials of the region's agent account
+func RunAgent(apiURL, clientID, secret string, workers int) error {
+	if apiURL == "" || clientID == "" || secret == "" {
+		return ErrAgentConfig
+	}
+
+	// Handle shutdown signals from now on
+	signals := notifyShutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		sig := <-signals
+		logger.INFO.Printf("Received %s, shutting down", sig)
+		cancel()
+	}()
+
+	// Init the agent
+	client := agent.NewClient(apiURL, clientID, secret, nil)
+	theAgent := agent.New(client, workers, nil)
+
+	// Lease checks every second until shut down
+	leaseInterval := time.Second
+	logger.INFO.Printf("Agent leasing checks from %s", apiURL)
+	theAgent.Run(ctx, leaseInterval)
+
+	return nil
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"net/http"
 	"time"
 
+	"github.com/RichardKnop/pinglist-api/logger"
 	"github.com/RichardKnop/pinglist-api/scheduler"
 )
 
@@ -16,9 +19,17 @@ func RunAll() error {
 	if err := initServices(cnf, db); err != nil {
 		return err
 	}
+	// Flush buffered metrics before the database is closed
+	defer metricsService.Close()
+
+	// Handle shutdown signals from now on
+	signals := notifyShutdown()
+
+	// Serve internal metrics on the admin listener
+	startAdminServer(cnf, db)
 
 	// Init the scheduler
-	theScheduler := scheduler.New(metricsService, alarmsService)
+	theScheduler := scheduler.New(cnf, metricsService, alarmsService, jobsService)
 
 	// Init the app
 	app, err := initApp(cnf, db)
@@ -27,15 +38,35 @@ func RunAll() error {
 	}
 
 	// Run the scheduling goroutines
-	alarmsInterval := time.Duration(10)     // alarms check interval = 10s
-	partitionInterval := time.Duration(600) // partition / rotate interval = 10m
-	stoppedChan := theScheduler.Start(alarmsInterval, partitionInterval)
+	alarmsInterval := time.Duration(cnf.Scheduler.CheckInterval) // alarms check interval, 10s by default
+	partitionInterval := time.Duration(600)                      // partition / rotate interval = 10m
+	theScheduler.Start(alarmsInterval, partitionInterval)
 
 	// Run the server on port 8080
-	app.Run(":8080")
+	server := &http.Server{Addr: ":8080", Handler: app}
+	serverErr := startServer(server)
+
+	// Wait for a shutdown signal or the server failing
+	select {
+	case sig := <-signals:
+		logger.INFO.Printf("Received %s, shutting down", sig)
+	case err = <-serverErr:
+		logger.ERROR.Printf("Server error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting new requests and drain active ones
+	stopServer(ctx, server)
+
+	// Stop scheduling new checks and wait for in-flight ones
+	if err := theScheduler.Stop(ctx); err != nil {
+		logger.ERROR.Printf("Scheduler shutdown error: %s", err)
+	}
 
-	// Stop the scheduler
-	stoppedChan <- true
+	// Wait for notifications, metrics and the database get closed after that
+	waitForNotifications(ctx)
 
-	return nil
+	return err
 }
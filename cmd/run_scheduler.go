@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"time"
 
+	"github.com/RichardKnop/pinglist-api/logger"
 	"github.com/RichardKnop/pinglist-api/scheduler"
 )
 
@@ -16,14 +18,37 @@ func RunScheduler() error {
 	if err := initServices(cnf, db); err != nil {
 		return err
 	}
+	// Flush buffered metrics before the database is closed
+	defer metricsService.Close()
+
+	// Handle shutdown signals from now on
+	signals := notifyShutdown()
+
+	// Serve internal metrics on the admin listener
+	startAdminServer(cnf, db)
 
 	// Init the scheduler
-	theScheduler := scheduler.New(metricsService, alarmsService)
+	theScheduler := scheduler.New(cnf, metricsService, alarmsService, jobsService)
 
 	// Run the scheduling goroutines
-	alarmsInterval := time.Duration(10)     // alarms check interval = 10s
-	partitionInterval := time.Duration(600) // partition / rotate interval = 10m
-	<-theScheduler.Start(alarmsInterval, partitionInterval)
+	alarmsInterval := time.Duration(cnf.Scheduler.CheckInterval) // alarms check interval, 10s by default
+	partitionInterval := time.Duration(600)                      // partition / rotate interval = 10m
+	theScheduler.Start(alarmsInterval, partitionInterval)
+
+	// Wait for a shutdown signal
+	sig := <-signals
+	logger.INFO.Printf("Received %s, shutting down", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	// Stop scheduling new checks and wait for in-flight ones
+	if err := theScheduler.Stop(ctx); err != nil {
+		logger.ERROR.Printf("Scheduler shutdown error: %s", err)
+	}
+
+	// Wait for notifications, metrics and the database get closed after that
+	waitForNotifications(ctx)
 
 	return nil
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/RichardKnop/pinglist-api/accounts"
@@ -8,6 +9,7 @@ import (
 	"github.com/RichardKnop/pinglist-api/config"
 	"github.com/RichardKnop/pinglist-api/facebook"
 	"github.com/RichardKnop/pinglist-api/health"
+	"github.com/RichardKnop/pinglist-api/logger"
 	"github.com/RichardKnop/pinglist-api/metrics"
 	"github.com/RichardKnop/pinglist-api/notifications"
 	"github.com/RichardKnop/pinglist-api/oauth"
@@ -29,6 +31,14 @@ func RunServer() error {
 	if err := initServices(cnf, db); err != nil {
 		return err
 	}
+	// Flush buffered metrics before the database is closed
+	defer metricsService.Close()
+
+	// Handle shutdown signals from now on
+	signals := notifyShutdown()
+
+	// Serve internal metrics on the admin listener
+	startAdminServer(cnf, db)
 
 	// Init the app
 	app, err := initApp(cnf, db)
@@ -37,9 +47,27 @@ func RunServer() error {
 	}
 
 	// Run the server on port 8080
-	app.Run(":8080")
+	server := &http.Server{Addr: ":8080", Handler: app}
+	serverErr := startServer(server)
+
+	// Wait for a shutdown signal or the server failing
+	select {
+	case sig := <-signals:
+		logger.INFO.Printf("Received %s, shutting down", sig)
+	case err = <-serverErr:
+		logger.ERROR.Printf("Server error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting new requests and drain active ones
+	stopServer(ctx, server)
+
+	// Wait for notifications, metrics and the database get closed after that
+	waitForNotifications(ctx)
 
-	return nil
+	return err
 }
 
 // initApp starts all services, creates a negroni app, registers all routes
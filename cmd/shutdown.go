@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+)
+
+// ShutdownTimeout limits how long draining HTTP requests, alarm checks and
+// notifications may take before the process exits
+var ShutdownTimeout = 30 * time.Second
+
+// notifyShutdown returns a channel receiving SIGINT and SIGTERM signals
+func notifyShutdown() <-chan os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	return signals
+}
+
+// startServer runs the HTTP server in a goroutine, the returned channel
+// receives an error if the server stops other than by being shut down
+func startServer(server *http.Server) <-chan error {
+	errChan := make(chan error, 1)
+	go func() {
+		logger.INFO.Printf("Listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			errChan <- err
+		}
+	}()
+	return errChan
+}
+
+// stopServer stops accepting new requests and drains active ones
+func stopServer(ctx context.Context, server *http.Server) {
+	if err := server.Shutdown(ctx); err != nil {
+		logger.ERROR.Printf("Server shutdown error: %s", err)
+	}
+}
+
+// waitForNotifications waits for emails and alerts sent in the background
+func waitForNotifications(ctx context.Context) {
+	if err := accountsService.WaitForNotifications(ctx); err != nil {
+		logger.ERROR.Printf("Account emails not sent before shutdown: %s", err)
+	}
+	if err := alarmsService.WaitForNotifications(ctx); err != nil {
+		logger.ERROR.Printf("Alarm notifications not sent before shutdown: %s", err)
+	}
+}
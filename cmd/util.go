@@ -7,6 +7,7 @@ import (
 	"github.com/RichardKnop/pinglist-api/database"
 	"github.com/RichardKnop/pinglist-api/facebook"
 	"github.com/RichardKnop/pinglist-api/health"
+	"github.com/RichardKnop/pinglist-api/jobs"
 	"github.com/RichardKnop/pinglist-api/metrics"
 	"github.com/RichardKnop/pinglist-api/notifications"
 	"github.com/RichardKnop/pinglist-api/oauth"
@@ -24,6 +25,7 @@ var (
 	teamsService         teams.ServiceInterface
 	metricsService       metrics.ServiceInterface
 	notificationsService notifications.ServiceInterface
+	jobsService          jobs.ServiceInterface
 	alarmsService        alarms.ServiceInterface
 )
 
@@ -66,13 +68,14 @@ func initServices(cnf *config.Config, db *gorm.DB) error {
 		nil, // subscriptions.StripeAdapter
 	)
 	teamsService = teams.NewService(cnf, db, accountsService, subscriptionsService)
-	metricsService = metrics.NewService(cnf, db, accountsService)
+	metricsService = metrics.NewService(cnf, db, accountsService, subscriptionsService, teamsService)
 	notificationsService = notifications.NewService(
 		cnf,
 		db,
 		accountsService,
 		nil, // notifications.SNSAdapter
 	)
+	jobsService = jobs.NewService(cnf, db)
 	alarmsService = alarms.NewService(
 		cnf,
 		db,
@@ -81,6 +84,7 @@ func initServices(cnf *config.Config, db *gorm.DB) error {
 		teamsService,
 		metricsService,
 		notificationsService,
+		jobsService,
 		nil, // email.Service
 		nil, // alarms.EmailFactory
 		nil, // alarms.SlackFactory
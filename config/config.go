@@ -35,7 +35,23 @@ type FacebookConfig struct {
 
 // SendgridConfig stores sengrid configuration options
 type SendgridConfig struct {
-	APIKey string
+	APIKey  string
+	Timeout int // seconds, deadline of a whole API request
+}
+
+// SMTPConfig stores SMTP server connection options
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	StartTLS bool
+	Timeout  int // seconds, deadline of a whole SMTP session
+}
+
+// EmailConfig stores email delivery options, providers are tried in order
+type EmailConfig struct {
+	Providers []string
 }
 
 // StripeConfig stores stripe configuration options
@@ -62,6 +78,26 @@ type PinglistConfig struct {
 	ContactEmail          string
 }
 
+// MetricsConfig stores options of the buffered metrics writer
+type MetricsConfig struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval int // milliseconds
+}
+
+// AdminConfig stores options of the internal admin listener
+type AdminConfig struct {
+	Listen string // e.g. 127.0.0.1:9090, empty disables the listener
+}
+
+// SchedulerConfig stores options of the job workers and alarm checks
+type SchedulerConfig struct {
+	Workers          int // number of concurrently running jobs
+	MaxChecksPerHost int // concurrent checks of a single target host
+	MaxJitter        int // milliseconds, random delay added to each check
+	CheckInterval    int // seconds, how often due alarms are claimed
+}
+
 // Config stores all configuration options
 type Config struct {
 	Database      DatabaseConfig
@@ -69,9 +105,14 @@ type Config struct {
 	AWS           AWSConfig
 	Facebook      FacebookConfig
 	Sendgrid      SendgridConfig
+	SMTP          SMTPConfig
+	Email         EmailConfig
 	Stripe        StripeConfig
 	Slack         SlackConfig
 	Web           WebConfig
 	Pinglist      PinglistConfig
+	Metrics       MetricsConfig
+	Admin         AdminConfig
+	Scheduler     SchedulerConfig
 	IsDevelopment bool
 }
@@ -46,7 +46,19 @@ var cnf = &Config{
 		AppSecret: "facebook_app_secret",
 	},
 	Sendgrid: SendgridConfig{
-		APIKey: "sendgrid_api_key",
+		APIKey:  "sendgrid_api_key",
+		Timeout: 30,
+	},
+	SMTP: SMTPConfig{
+		Host:     "localhost",
+		Port:     587,
+		Username: "",
+		Password: "",
+		StartTLS: true,
+		Timeout:  30,
+	},
+	Email: EmailConfig{
+		Providers: []string{"sendgrid"},
 	},
 	Stripe: StripeConfig{
 		SecretKey:      "stripe_secret_key",
@@ -64,6 +76,20 @@ var cnf = &Config{
 		PasswordResetLifetime: 604800, // 7 days
 		ContactEmail:          "contact@pingli.st",
 	},
+	Metrics: MetricsConfig{
+		BufferSize:    10000,
+		BatchSize:     500,
+		FlushInterval: 1000, // 1 second
+	},
+	Admin: AdminConfig{
+		Listen: "127.0.0.1:9090",
+	},
+	Scheduler: SchedulerConfig{
+		Workers:          20,
+		MaxChecksPerHost: 5,
+		MaxJitter:        0,
+		CheckInterval:    10,
+	},
 	IsDevelopment: true,
 }
 
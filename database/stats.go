@@ -0,0 +1,63 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/RichardKnop/pinglist-api/prometheus"
+)
+
+// NewStatsCollector returns a collector exposing connection pool statistics
+func NewStatsCollector(db *sql.DB) prometheus.Collector {
+	return prometheus.CollectorFunc(func() []*prometheus.Family {
+		stats := db.Stats()
+
+		gauge := func(name, help string, value float64) *prometheus.Family {
+			family := prometheus.NewFamily(name, help, prometheus.TypeGauge)
+			family.Add(value, nil)
+			return family
+		}
+		counter := func(name, help string, value float64) *prometheus.Family {
+			family := prometheus.NewFamily(name, help, prometheus.TypeCounter)
+			family.Add(value, nil)
+			return family
+		}
+
+		return []*prometheus.Family{
+			gauge(
+				"pinglist_db_max_open_connections",
+				"Maximum number of open connections to the database.",
+				float64(stats.MaxOpenConnections),
+			),
+			gauge(
+				"pinglist_db_open_connections",
+				"Number of established connections both in use and idle.",
+				float64(stats.OpenConnections),
+			),
+			gauge(
+				"pinglist_db_in_use_connections",
+				"Number of connections currently in use.",
+				float64(stats.InUse),
+			),
+			gauge(
+				"pinglist_db_idle_connections",
+				"Number of idle connections.",
+				float64(stats.Idle),
+			),
+			counter(
+				"pinglist_db_wait_count_total",
+				"Total number of connections waited for.",
+				float64(stats.WaitCount),
+			),
+			counter(
+				"pinglist_db_wait_duration_seconds_total",
+				"Total time blocked waiting for a new connection.",
+				stats.WaitDuration.Seconds(),
+			),
+			counter(
+				"pinglist_db_max_idle_closed_total",
+				"Total number of connections closed due to the max idle limit.",
+				float64(stats.MaxIdleClosed),
+			),
+		}
+	})
+}
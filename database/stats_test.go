@@ -0,0 +1,31 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStatsCollector(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(3)
+	assert.NoError(t, db.Ping())
+
+	values := make(map[string]float64)
+	for _, family := range NewStatsCollector(db).Collect() {
+		if assert.Len(t, family.Samples, 1) {
+			values[family.Name] = family.Samples[0].Value
+		}
+	}
+
+	assert.Equal(t, float64(3), values["pinglist_db_max_open_connections"])
+	assert.Equal(t, float64(1), values["pinglist_db_open_connections"])
+	assert.Equal(t, float64(0), values["pinglist_db_in_use_connections"])
+	assert.Equal(t, float64(1), values["pinglist_db_idle_connections"])
+	assert.Contains(t, values, "pinglist_db_wait_count_total")
+}
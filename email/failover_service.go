@@ -0,0 +1,67 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/RichardKnop/pinglist-api/logger"
+)
+
+var (
+	// ErrNoProviders ...
+	ErrNoProviders = errors.New("No email providers configured")
+)
+
+// FailoverService sends emails through a list of providers, falling back
+// to the next one whenever a provider fails
+type FailoverService struct {
+	providers []ServiceInterface
+}
+
+// NewFailoverService starts a new FailoverService instance
+func NewFailoverService(providers ...ServiceInterface) *FailoverService {
+	return &FailoverService{providers: providers}
+}
+
+// NewServiceFromConfig returns an email service for the providers listed
+// in the config, unknown provider names are skipped
+func NewServiceFromConfig(cnf *config.Config) ServiceInterface {
+	var providers []ServiceInterface
+	for _, name := range cnf.Email.Providers {
+		switch name {
+		case "sendgrid":
+			providers = append(providers, NewService(cnf))
+		case "smtp":
+			providers = append(providers, NewSMTPService(cnf))
+		default:
+			logger.WARNING.Printf("Unknown email provider: %s", name)
+		}
+	}
+
+	// Default to sendgrid
+	if len(providers) == 0 {
+		return NewService(cnf)
+	}
+	if len(providers) == 1 {
+		return providers[0]
+	}
+	return NewFailoverService(providers...)
+}
+
+// Send tries each provider in order and returns once one of them succeeds
+func (s *FailoverService) Send(email *Email) error {
+	if len(s.providers) == 0 {
+		return ErrNoProviders
+	}
+
+	var err error
+	for i, provider := range s.providers {
+		if err = provider.Send(email); err == nil {
+			return nil
+		}
+		logger.WARNING.Printf("Email provider %d/%d failed: %s", i+1, len(s.providers), err)
+	}
+
+	return fmt.Errorf("All email providers failed, last error: %s", err)
+}
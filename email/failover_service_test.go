@@ -0,0 +1,104 @@
+package email
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailoverServiceSend(t *testing.T) {
+	var (
+		first  = new(ServiceMock)
+		second = new(ServiceMock)
+		third  = new(ServiceMock)
+		e      = &Email{Subject: "Test subject"}
+	)
+	first.On("Send", e).Return(errors.New("first failed"))
+	second.On("Send", e).Return(nil)
+
+	err := NewFailoverService(first, second, third).Send(e)
+	assert.NoError(t, err)
+
+	first.AssertExpectations(t)
+	second.AssertExpectations(t)
+	third.AssertNotCalled(t, "Send", e)
+}
+
+func TestFailoverServiceSendAllFail(t *testing.T) {
+	var (
+		first  = new(ServiceMock)
+		second = new(ServiceMock)
+		e      = &Email{Subject: "Test subject"}
+	)
+	first.On("Send", e).Return(errors.New("first failed"))
+	second.On("Send", e).Return(errors.New("second failed"))
+
+	err := NewFailoverService(first, second).Send(e)
+	if assert.Error(t, err) {
+		assert.Equal(t, "All email providers failed, last error: second failed", err.Error())
+	}
+
+	err = NewFailoverService().Send(e)
+	assert.Equal(t, ErrNoProviders, err)
+}
+
+func TestFailoverServiceSendSendgridErrorStatus(t *testing.T) {
+	// Sendgrid is down, the client does not return an error for it
+	sendgridStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"errors":[{"message":"Internal error"}]}`))
+	}))
+	defer sendgridStub.Close()
+
+	standIn := newSMTPStandIn(t)
+	defer standIn.listener.Close()
+
+	cnf := &config.Config{
+		Sendgrid: config.SendgridConfig{APIKey: "sendgrid_api_key"},
+		SMTP: config.SMTPConfig{
+			Host: "127.0.0.1",
+			Port: standIn.port(),
+		},
+	}
+	sendgridService := NewService(cnf)
+	sendgridService.host = sendgridStub.URL
+
+	e := &Email{
+		Subject:    "Test subject",
+		Recipients: []*Recipient{&Recipient{Email: "john@example.com"}},
+		From:       &Sender{Email: "noreply@example.com"},
+		Text:       "Hello",
+	}
+
+	err := sendgridService.Send(e)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Sendgrid responded with status 500")
+	}
+
+	// The email gets sent through SMTP instead
+	err = NewFailoverService(sendgridService, NewSMTPService(cnf)).Send(e)
+	assert.NoError(t, err)
+	<-standIn.done
+	assert.Equal(t, []string{"john@example.com"}, standIn.recipients)
+}
+
+func TestNewServiceFromConfig(t *testing.T) {
+	cnf := new(config.Config)
+
+	_, ok := NewServiceFromConfig(cnf).(*Service)
+	assert.True(t, ok, "Should default to sendgrid")
+
+	cnf.Email.Providers = []string{"smtp"}
+	_, ok = NewServiceFromConfig(cnf).(*SMTPService)
+	assert.True(t, ok)
+
+	cnf.Email.Providers = []string{"smtp", "bogus", "sendgrid"}
+	failover, ok := NewServiceFromConfig(cnf).(*FailoverService)
+	if assert.True(t, ok) {
+		assert.Equal(t, 2, len(failover.providers))
+	}
+}
@@ -1,19 +1,37 @@
 package email
 
 import (
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/sendgrid/rest"
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
 )
 
+// DefaultSendgridTimeout is used when no sendgrid timeout is configured
+const DefaultSendgridTimeout = 30 * time.Second
+
 // Service struct keeps config object to avoid passing it around
 type Service struct {
-	cnf *config.Config
+	cnf    *config.Config
+	client *rest.Client
+	host   string
 }
 
 // NewService starts a new Service instance
 func NewService(cnf *config.Config) *Service {
-	return &Service{cnf: cnf}
+	timeout := time.Duration(cnf.Sendgrid.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultSendgridTimeout
+	}
+	return &Service{
+		cnf:    cnf,
+		client: &rest.Client{HTTPClient: &http.Client{Timeout: timeout}},
+		host:   "https://api.sendgrid.com",
+	}
 }
 
 // Send sends email using sendgrid
@@ -34,13 +52,19 @@ func (s *Service) Send(email *Email) error {
 	request := sendgrid.GetRequest(
 		s.cnf.Sendgrid.APIKey,
 		"/v3/mail/send",
-		"https://api.sendgrid.com",
+		s.host,
 	)
 	request.Method = "POST"
 	request.Body = mail.GetRequestBody(m)
-	_, err := sendgrid.API(request)
+	response, err := s.client.API(request)
 	if err != nil {
 		return err
 	}
+
+	// The API does not return an error for rejected requests, those have
+	// to fail as well so the next provider can be tried
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("Sendgrid responded with status %d: %s", response.StatusCode, response.Body)
+	}
 	return nil
 }
@@ -0,0 +1,124 @@
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/config"
+)
+
+// DefaultSMTPTimeout is used when no SMTP timeout is configured
+const DefaultSMTPTimeout = 30 * time.Second
+
+// SMTPService sends emails through a plain SMTP server
+type SMTPService struct {
+	cnf *config.Config
+}
+
+// NewSMTPService starts a new SMTPService instance
+func NewSMTPService(cnf *config.Config) *SMTPService {
+	return &SMTPService{cnf: cnf}
+}
+
+// Send sends email using the configured SMTP server
+func (s *SMTPService) Send(email *Email) error {
+	addr := net.JoinHostPort(s.cnf.SMTP.Host, strconv.Itoa(s.cnf.SMTP.Port))
+
+	timeout := time.Duration(s.cnf.SMTP.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultSMTPTimeout
+	}
+
+	// Connect to the SMTP server, the deadline covers the whole session
+	// so a hung server fails the send and the next provider can be tried
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, s.cnf.SMTP.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	// Upgrade the connection if STARTTLS is enabled
+	if s.cnf.SMTP.StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server %s does not support STARTTLS", addr)
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: s.cnf.SMTP.Host}); err != nil {
+			return err
+		}
+	}
+
+	// Authenticate if credentials are configured
+	if s.cnf.SMTP.Username != "" {
+		auth := smtp.PlainAuth(
+			"",
+			s.cnf.SMTP.Username,
+			s.cnf.SMTP.Password,
+			s.cnf.SMTP.Host,
+		)
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	// Set the sender and recipients
+	if err := c.Mail(email.From.Email); err != nil {
+		return err
+	}
+	for _, recipient := range email.Recipients {
+		if err := c.Rcpt(recipient.Email); err != nil {
+			return err
+		}
+	}
+
+	// Write the message
+	wc, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := wc.Write(buildMessage(email, time.Now())); err != nil {
+		wc.Close()
+		return err
+	}
+	if err := wc.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// buildMessage formats an email as a plain text RFC 5322 message
+func buildMessage(email *Email, now time.Time) []byte {
+	to := make([]string, len(email.Recipients))
+	for i, recipient := range email.Recipients {
+		to[i] = (&mail.Address{Name: recipient.Name, Address: recipient.Email}).String()
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", (&mail.Address{Name: email.From.Name, Address: email.From.Email}).String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.Replace(email.Text, "\n", "\r\n", -1))
+	return buf.Bytes()
+}
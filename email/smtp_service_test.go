@@ -0,0 +1,186 @@
+package email
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpStandIn is a minimal in-process SMTP server used in tests
+type smtpStandIn struct {
+	listener   net.Listener
+	from       string
+	recipients []string
+	data       string
+	done       chan bool
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStandIn{listener: listener, done: make(chan bool, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	defer func() { s.done <- true }()
+
+	r := bufio.NewReader(conn)
+	write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	write("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			write("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			write("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.recipients = append(s.recipients, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			write("250 OK")
+		case cmd == "DATA":
+			write("354 End data with <CR><LF>.<CR><LF>")
+			var data []string
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data = append(data, dataLine)
+			}
+			s.data = strings.Join(data, "")
+			write("250 OK")
+		case cmd == "QUIT":
+			write("221 Bye")
+			return
+		default:
+			write("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPServiceSend(t *testing.T) {
+	standIn := newSMTPStandIn(t)
+	defer standIn.listener.Close()
+
+	cnf := &config.Config{
+		SMTP: config.SMTPConfig{
+			Host: "127.0.0.1",
+			Port: standIn.port(),
+		},
+	}
+	service := NewSMTPService(cnf)
+
+	err := service.Send(&Email{
+		Subject: "Test subject",
+		Recipients: []*Recipient{
+			&Recipient{Email: "john@example.com", Name: "John Reese"},
+			&Recipient{Email: "harold@example.com", Name: "Harold Finch"},
+		},
+		From: &Sender{Email: "noreply@example.com", Name: "NOREPLY example.com"},
+		Text: "Hello\nWorld",
+	})
+	assert.NoError(t, err)
+	<-standIn.done
+
+	assert.Equal(t, "noreply@example.com", standIn.from)
+	assert.Equal(t, []string{"john@example.com", "harold@example.com"}, standIn.recipients)
+	assert.Contains(t, standIn.data, "Subject: Test subject\r\n")
+	assert.Contains(t, standIn.data, "To: \"John Reese\" <john@example.com>, \"Harold Finch\" <harold@example.com>\r\n")
+	assert.True(t, strings.HasSuffix(standIn.data, "\r\n\r\nHello\r\nWorld\r\n"))
+}
+
+func TestSMTPServiceSendRequiresStartTLS(t *testing.T) {
+	standIn := newSMTPStandIn(t)
+	defer standIn.listener.Close()
+
+	cnf := &config.Config{
+		SMTP: config.SMTPConfig{
+			Host:     "127.0.0.1",
+			Port:     standIn.port(),
+			StartTLS: true,
+		},
+	}
+	service := NewSMTPService(cnf)
+
+	err := service.Send(&Email{
+		Subject:    "Test subject",
+		Recipients: []*Recipient{&Recipient{Email: "john@example.com"}},
+		From:       &Sender{Email: "noreply@example.com"},
+		Text:       "Hello",
+	})
+	if assert.Error(t, err) {
+		assert.Equal(
+			t,
+			"SMTP server 127.0.0.1:"+strconv.Itoa(standIn.port())+" does not support STARTTLS",
+			err.Error(),
+		)
+	}
+}
+
+func TestSMTPServiceSendStalledServerFailsOver(t *testing.T) {
+	// The server accepts connections but never sends a greeting
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(5 * time.Second)
+	}()
+
+	cnf := &config.Config{
+		SMTP: config.SMTPConfig{
+			Host:    "127.0.0.1",
+			Port:    listener.Addr().(*net.TCPAddr).Port,
+			Timeout: 1,
+		},
+	}
+	e := &Email{
+		Subject:    "Test subject",
+		Recipients: []*Recipient{&Recipient{Email: "john@example.com"}},
+		From:       &Sender{Email: "noreply@example.com"},
+		Text:       "Hello",
+	}
+	next := new(ServiceMock)
+	next.On("Send", e).Return(nil)
+
+	start := time.Now()
+	err = NewFailoverService(NewSMTPService(cnf), next).Send(e)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) < 3*time.Second, "Send should time out")
+	next.AssertExpectations(t)
+}
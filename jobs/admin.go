@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+)
+
+// NewAdminHandler returns a handler to inspect jobs on the internal admin
+// listener. GET /jobs lists the most recent jobs with counts per state,
+// optionally filtered with state, type and limit query parameters.
+// GET /jobs/{id} returns a single job
+func NewAdminHandler(service ServiceInterface) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			response.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/jobs"), "/")
+		if id == "" {
+			listJobs(service, w, r)
+			return
+		}
+
+		jobID, err := strconv.Atoi(id)
+		if err != nil {
+			response.Error(w, ErrJobNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		job, err := service.FindJobByID(uint(jobID))
+		if err != nil {
+			response.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		response.WriteJSON(w, NewJobResponse(job), http.StatusOK)
+	})
+}
+
+// listJobs writes the most recent jobs matching the query parameters
+func listJobs(service ServiceInterface, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var limit int
+	if query.Get("limit") != "" {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			response.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	counts, err := service.CountJobsByState()
+	if err != nil {
+		logger.ERROR.Printf("Count jobs error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jobs, err := service.FindJobs(query.Get("state"), query.Get("type"), limit)
+	if err != nil {
+		logger.ERROR.Printf("Find jobs error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.WriteJSON(w, NewListJobsResponse(counts, jobs), http.StatusOK)
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminHandlerListJobs(t *testing.T) {
+	var (
+		serviceMock = new(ServiceMock)
+		now         = time.Date(2016, time.March, 7, 9, 0, 0, 0, time.UTC)
+		job         = &Job{
+			Type:        "check_alarm",
+			Payload:     `{"alarm_id":1}`,
+			State:       StateDead,
+			RunAt:       now,
+			Attempts:    3,
+			MaxAttempts: 3,
+		}
+		counts = map[string]int{StateQueued: 2, StateDead: 1}
+	)
+	job.ID = 7
+	job.LastError.String, job.LastError.Valid = "boom", true
+
+	serviceMock.On("CountJobsByState").Return(counts, nil)
+	serviceMock.On("FindJobs", StateDead, "check_alarm", 20).Return([]*Job{job}, nil)
+
+	r, err := http.NewRequest("GET", "http://1.2.3.4/jobs?state=dead&type=check_alarm&limit=20", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	w := httptest.NewRecorder()
+	NewAdminHandler(serviceMock).ServeHTTP(w, r)
+
+	serviceMock.AssertExpectations(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	listResponse := new(ListJobsResponse)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), listResponse))
+	assert.Equal(t, counts, listResponse.Counts)
+	if assert.Equal(t, 1, len(listResponse.Jobs)) {
+		assert.Equal(t, uint(7), listResponse.Jobs[0].ID)
+		assert.Equal(t, `{"alarm_id":1}`, string(listResponse.Jobs[0].Payload))
+		assert.Equal(t, "boom", listResponse.Jobs[0].LastError)
+		assert.Equal(t, "2016-03-07T09:00:00Z", listResponse.Jobs[0].RunAt)
+	}
+}
+
+func TestAdminHandlerGetJob(t *testing.T) {
+	serviceMock := new(ServiceMock)
+	serviceMock.On("FindJobByID", uint(7)).Return(&Job{Type: "cleanup", Payload: "{}"}, nil)
+	serviceMock.On("FindJobByID", uint(8)).Return(nil, ErrJobNotFound)
+
+	handler := NewAdminHandler(serviceMock)
+
+	r, err := http.NewRequest("GET", "http://1.2.3.4/jobs/7", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	r, err = http.NewRequest("GET", "http://1.2.3.4/jobs/8", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	r, err = http.NewRequest("POST", "http://1.2.3.4/jobs/7", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	serviceMock.AssertExpectations(t)
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"github.com/RichardKnop/pinglist-api/prometheus"
+)
+
+var (
+	jobsEnqueued = prometheus.NewCounterVec(
+		"pinglist_jobs_enqueued_total",
+		"Number of enqueued jobs by type.",
+		"type",
+	)
+	jobsProcessed = prometheus.NewCounterVec(
+		"pinglist_jobs_processed_total",
+		"Number of processed jobs by type and result (done, retry, dead, released or error).",
+		"type",
+		"result",
+	)
+	jobDuration = prometheus.NewHistogramVec(
+		"pinglist_job_duration_seconds",
+		"Duration of job handlers by type.",
+		[]float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		"type",
+	)
+	workersBusy = prometheus.NewGaugeVec(
+		"pinglist_job_workers_busy",
+		"Number of job workers running a job.",
+	)
+)
@@ -0,0 +1,334 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+const (
+	// DefaultMaxAttempts is used when a job does not set its max attempts
+	DefaultMaxAttempts = 5
+	// DefaultVisibilityTimeout is used when a job does not set for how long
+	// a lease hides it from other workers
+	DefaultVisibilityTimeout = 5 * time.Minute
+	// MinBackoff is the delay before the first retry of a failed job
+	MinBackoff = 10 * time.Second
+	// MaxBackoff caps the delay between retries
+	MaxBackoff = time.Hour
+	// DefaultFindJobsLimit is used when listing jobs without a limit
+	DefaultFindJobsLimit = 50
+	// MaxFindJobsLimit caps how many jobs can be listed at once
+	MaxFindJobsLimit = 500
+)
+
+var (
+	// ErrJobNotFound ...
+	ErrJobNotFound = errors.New("Job not found")
+	// ErrDuplicateJob ...
+	ErrDuplicateJob = errors.New("Job with the same key already exists")
+	// ErrLeaseLost ...
+	ErrLeaseLost = errors.New("Job lease has expired")
+)
+
+// EnqueueOptions are optional settings of an enqueued job, zero values
+// get defaults
+type EnqueueOptions struct {
+	// Key makes the job unique, enqueueing another job with the same key
+	// fails with ErrDuplicateJob
+	Key string
+	// RunAt delays the job, it runs as soon as possible by default
+	RunAt time.Time
+	// Priority orders due jobs, higher runs first
+	Priority int
+	// MaxAttempts before the job is moved to the dead state
+	MaxAttempts int
+	// VisibilityTimeout hides a leased job from other workers, the job
+	// is leased again if it has not finished by then
+	VisibilityTimeout time.Duration
+}
+
+// Enqueue saves a new job, the payload is stored as JSON
+func (s *Service) Enqueue(jobType string, payload interface{}, options *EnqueueOptions) (*Job, error) {
+	if options == nil {
+		options = new(EnqueueOptions)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	runAt := options.RunAt
+	if runAt.IsZero() {
+		runAt = gorm.NowFunc()
+	}
+	maxAttempts := options.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	visibilityTimeout := options.VisibilityTimeout
+	if visibilityTimeout < time.Second {
+		visibilityTimeout = DefaultVisibilityTimeout
+	}
+
+	job := &Job{
+		Type:              jobType,
+		Key:               util.StringOrNull(options.Key),
+		Payload:           string(data),
+		State:             StateQueued,
+		Priority:          options.Priority,
+		RunAt:             runAt,
+		VisibilityTimeout: int(visibilityTimeout / time.Second),
+		MaxAttempts:       maxAttempts,
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateJob
+		}
+		return nil, err
+	}
+
+	jobsEnqueued.Inc(jobType)
+	return job, nil
+}
+
+// Lease claims up to limit due jobs of the given types, highest priority
+// first. Leased jobs are hidden from other workers for their visibility
+// timeout, rows locked by another worker are skipped. Jobs whose lease has
+// expired are leased again, or moved to the dead state when they are out
+// of attempts
+func (s *Service) Lease(jobTypes []string, limit int, now time.Time) ([]*Job, error) {
+	jobs := make([]*Job, 0)
+	if len(jobTypes) == 0 || limit <= 0 {
+		return jobs, nil
+	}
+
+	// Expired leases of jobs out of attempts will not be retried
+	err := s.db.Model(new(Job)).Where(
+		"type IN (?) AND state = ? AND leased_until <= ? AND attempts >= max_attempts",
+		jobTypes,
+		StateRunning,
+		now,
+	).UpdateColumns(map[string]interface{}{
+		"state":        StateDead,
+		"last_error":   ErrLeaseLost.Error(),
+		"leased_until": nil,
+		"finished_at":  now,
+		"updated_at":   now,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query := `WITH leasable AS (
+		SELECT id FROM job_jobs
+		WHERE
+			deleted_at IS NULL
+			AND type IN (?)
+			AND (
+				(state = ? AND run_at <= ?)
+				OR (state = ? AND leased_until <= ?)
+			)
+		ORDER BY priority DESC, run_at, id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE job_jobs j SET
+		state = ?,
+		attempts = j.attempts + 1,
+		leased_until = CAST(? AS timestamp with time zone) + interval '1 second' * j.visibility_timeout,
+		updated_at = ?
+	FROM leasable
+	WHERE j.id = leasable.id
+	RETURNING j.id;`
+
+	rows, err := s.db.Raw(
+		query,
+		jobTypes,
+		StateQueued,
+		now,
+		StateRunning,
+		now,
+		limit,
+		StateRunning,
+		now,
+		now,
+	).Rows() // (*sql.Rows, error)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobIDs []uint
+	for rows.Next() {
+		var jobID uint
+		if err := rows.Scan(&jobID); err != nil {
+			return nil, err
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+	if len(jobIDs) == 0 {
+		return jobs, nil
+	}
+
+	err = s.db.Where("id IN (?)", jobIDs).
+		Order("priority DESC, run_at, id").Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Complete marks a leased job as done
+func (s *Service) Complete(job *Job) error {
+	now := gorm.NowFunc()
+	return s.updateLeasedJob(job, map[string]interface{}{
+		"state":        StateDone,
+		"leased_until": nil,
+		"finished_at":  now,
+		"updated_at":   now,
+	})
+}
+
+// Fail records the error of a leased job and schedules a retry with
+// an exponential backoff, jobs out of attempts are moved to the dead state
+func (s *Service) Fail(job *Job, jobErr error) error {
+	now := gorm.NowFunc()
+	columns := map[string]interface{}{
+		"last_error":   jobErr.Error(),
+		"leased_until": nil,
+		"updated_at":   now,
+	}
+	if job.Attempts >= job.MaxAttempts {
+		columns["state"] = StateDead
+		columns["finished_at"] = now
+	} else {
+		columns["state"] = StateQueued
+		columns["run_at"] = now.Add(RetryBackoff(job.Attempts))
+	}
+	return s.updateLeasedJob(job, columns)
+}
+
+// Release returns a leased job to the queue without counting the attempt,
+// e.g. when a worker is shutting down before the job has finished
+func (s *Service) Release(job *Job) error {
+	return s.updateLeasedJob(job, map[string]interface{}{
+		"state":        StateQueued,
+		"attempts":     job.Attempts - 1,
+		"leased_until": nil,
+		"updated_at":   gorm.NowFunc(),
+	})
+}
+
+// FindJobByID looks up a job by ID and returns it
+func (s *Service) FindJobByID(jobID uint) (*Job, error) {
+	job := new(Job)
+	if s.db.First(job, jobID).RecordNotFound() {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// FindJobs returns the most recent jobs, optionally filtered by state
+// and type
+func (s *Service) FindJobs(state, jobType string, limit int) ([]*Job, error) {
+	if limit <= 0 {
+		limit = DefaultFindJobsLimit
+	}
+	if limit > MaxFindJobsLimit {
+		limit = MaxFindJobsLimit
+	}
+
+	jobsQuery := s.db.Model(new(Job))
+	if state != "" {
+		jobsQuery = jobsQuery.Where("state = ?", state)
+	}
+	if jobType != "" {
+		jobsQuery = jobsQuery.Where("type = ?", jobType)
+	}
+
+	var jobs []*Job
+	err := jobsQuery.Order("id DESC").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// CountJobsByState returns number of jobs in each state
+func (s *Service) CountJobsByState() (map[string]int, error) {
+	counts := map[string]int{
+		StateQueued:  0,
+		StateRunning: 0,
+		StateDone:    0,
+		StateDead:    0,
+	}
+
+	rows, err := s.db.Model(new(Job)).
+		Select("state, COUNT(*)").Group("state").Rows() // (*sql.Rows, error)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			state string
+			count int
+		)
+		if err := rows.Scan(&state, &count); err != nil {
+			return nil, err
+		}
+		counts[state] = count
+	}
+	return counts, nil
+}
+
+// PurgeFinishedJobs deletes done and dead jobs which finished before
+// the given time and returns how many were deleted
+func (s *Service) PurgeFinishedJobs(before time.Time) (int64, error) {
+	result := s.db.Unscoped().Where(
+		"state IN (?) AND finished_at < ?",
+		[]string{StateDone, StateDead},
+		before,
+	).Delete(new(Job))
+	return result.RowsAffected, result.Error
+}
+
+// RetryBackoff returns the delay before the next attempt of a job which has
+// failed the given number of times, it doubles with each attempt
+func RetryBackoff(attempts int) time.Duration {
+	backoff := MinBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// updateLeasedJob updates the job only if it is still leased by the caller,
+// once the lease has expired the job may have been leased by another worker
+func (s *Service) updateLeasedJob(job *Job, columns map[string]interface{}) error {
+	result := s.db.Model(new(Job)).Where(
+		"id = ? AND state = ? AND attempts = ?",
+		job.ID,
+		StateRunning,
+		job.Attempts,
+	).UpdateColumns(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// isUniqueViolation returns true for Postgres unique constraint errors
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
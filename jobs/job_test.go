@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPayload struct {
+	AlarmID uint `json:"alarm_id"`
+}
+
+func (suite *JobsTestSuite) TestEnqueue() {
+	job, err := suite.service.Enqueue("test", &testPayload{AlarmID: 1}, nil)
+	if !assert.NoError(suite.T(), err) {
+		return
+	}
+
+	// Defaults
+	assert.Equal(suite.T(), StateQueued, job.State)
+	assert.Equal(suite.T(), `{"alarm_id":1}`, job.Payload)
+	assert.Equal(suite.T(), DefaultMaxAttempts, job.MaxAttempts)
+	assert.Equal(suite.T(), int(DefaultVisibilityTimeout/time.Second), job.VisibilityTimeout)
+	assert.False(suite.T(), job.Key.Valid)
+
+	payload := new(testPayload)
+	assert.NoError(suite.T(), job.DecodePayload(payload))
+	assert.Equal(suite.T(), uint(1), payload.AlarmID)
+
+	// Jobs with the same key are enqueued only once
+	options := &EnqueueOptions{Key: "test:1"}
+	_, err = suite.service.Enqueue("test", nil, options)
+	assert.NoError(suite.T(), err)
+	_, err = suite.service.Enqueue("test", nil, options)
+	assert.Equal(suite.T(), ErrDuplicateJob, err)
+}
+
+func (suite *JobsTestSuite) TestLease() {
+	var (
+		now     = time.Now()
+		options = []*EnqueueOptions{
+			{Priority: 0},
+			{Priority: 5},
+			{RunAt: now.Add(time.Minute)},
+			{Priority: 1},
+		}
+		jobIDs []uint
+	)
+	for _, o := range options {
+		job, err := suite.service.Enqueue("test", nil, o)
+		assert.NoError(suite.T(), err)
+		jobIDs = append(jobIDs, job.ID)
+	}
+	_, err := suite.service.Enqueue("other", nil, nil)
+	assert.NoError(suite.T(), err)
+
+	// Due jobs of the type are leased by priority
+	jobs, err := suite.service.Lease([]string{"test"}, 2, now)
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 2, len(jobs)) {
+		assert.Equal(suite.T(), jobIDs[1], jobs[0].ID)
+		assert.Equal(suite.T(), jobIDs[3], jobs[1].ID)
+		assert.Equal(suite.T(), StateRunning, jobs[0].State)
+		assert.Equal(suite.T(), 1, jobs[0].Attempts)
+		assert.True(suite.T(), jobs[0].LeasedUntil.Valid)
+	}
+
+	// Leased jobs are hidden, the delayed job is not due yet
+	jobs, err = suite.service.Lease([]string{"test"}, 10, now)
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 1, len(jobs)) {
+		assert.Equal(suite.T(), jobIDs[0], jobs[0].ID)
+	}
+
+	// Once the visibility timeout passes, the jobs are leased again
+	later := now.Add(DefaultVisibilityTimeout + time.Minute)
+	jobs, err = suite.service.Lease([]string{"test"}, 10, later)
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 4, len(jobs)) {
+		assert.Equal(suite.T(), 2, jobs[0].Attempts)
+	}
+}
+
+func (suite *JobsTestSuite) TestComplete() {
+	_, err := suite.service.Enqueue("test", nil, nil)
+	assert.NoError(suite.T(), err)
+	jobs, err := suite.service.Lease([]string{"test"}, 1, time.Now())
+	assert.NoError(suite.T(), err)
+	if !assert.Equal(suite.T(), 1, len(jobs)) {
+		return
+	}
+
+	assert.NoError(suite.T(), suite.service.Complete(jobs[0]))
+
+	job, err := suite.service.FindJobByID(jobs[0].ID)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), StateDone, job.State)
+		assert.True(suite.T(), job.FinishedAt.Valid)
+		assert.False(suite.T(), job.LeasedUntil.Valid)
+	}
+
+	// The job is no longer leased
+	assert.Equal(suite.T(), ErrLeaseLost, suite.service.Complete(jobs[0]))
+}
+
+func (suite *JobsTestSuite) TestFailRetriesThenDeadLetters() {
+	_, err := suite.service.Enqueue("test", nil, &EnqueueOptions{MaxAttempts: 2})
+	assert.NoError(suite.T(), err)
+
+	// The first failure schedules a retry
+	now := time.Now()
+	jobs, err := suite.service.Lease([]string{"test"}, 1, now)
+	assert.NoError(suite.T(), err)
+	if !assert.Equal(suite.T(), 1, len(jobs)) {
+		return
+	}
+	assert.NoError(suite.T(), suite.service.Fail(jobs[0], errors.New("boom")))
+
+	job, err := suite.service.FindJobByID(jobs[0].ID)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), StateQueued, job.State)
+		assert.Equal(suite.T(), "boom", job.LastError.String)
+		assert.True(suite.T(), job.RunAt.After(now.Add(MinBackoff-time.Second)))
+	}
+
+	// Not due before the backoff passes
+	jobs, err = suite.service.Lease([]string{"test"}, 1, now)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, len(jobs))
+
+	// The last failure moves the job to the dead state
+	jobs, err = suite.service.Lease([]string{"test"}, 1, now.Add(MinBackoff+time.Second))
+	assert.NoError(suite.T(), err)
+	if !assert.Equal(suite.T(), 1, len(jobs)) {
+		return
+	}
+	assert.NoError(suite.T(), suite.service.Fail(jobs[0], errors.New("boom again")))
+
+	job, err = suite.service.FindJobByID(jobs[0].ID)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), StateDead, job.State)
+		assert.Equal(suite.T(), 2, job.Attempts)
+		assert.Equal(suite.T(), "boom again", job.LastError.String)
+	}
+
+	counts, err := suite.service.CountJobsByState()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, counts[StateDead])
+	assert.Equal(suite.T(), 0, counts[StateQueued])
+}
+
+func (suite *JobsTestSuite) TestExpiredLeaseOutOfAttempts() {
+	job, err := suite.service.Enqueue("test", nil, &EnqueueOptions{MaxAttempts: 1})
+	assert.NoError(suite.T(), err)
+
+	now := time.Now()
+	jobs, err := suite.service.Lease([]string{"test"}, 1, now)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, len(jobs))
+
+	// The worker died, the job is out of attempts
+	later := now.Add(DefaultVisibilityTimeout + time.Minute)
+	jobs, err = suite.service.Lease([]string{"test"}, 1, later)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, len(jobs))
+
+	job, err = suite.service.FindJobByID(job.ID)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), StateDead, job.State)
+		assert.Equal(suite.T(), ErrLeaseLost.Error(), job.LastError.String)
+	}
+}
+
+func (suite *JobsTestSuite) TestRelease() {
+	_, err := suite.service.Enqueue("test", nil, nil)
+	assert.NoError(suite.T(), err)
+	jobs, err := suite.service.Lease([]string{"test"}, 1, time.Now())
+	assert.NoError(suite.T(), err)
+	if !assert.Equal(suite.T(), 1, len(jobs)) {
+		return
+	}
+
+	assert.NoError(suite.T(), suite.service.Release(jobs[0]))
+
+	// The job is due again and the attempt was not counted
+	jobs, err = suite.service.Lease([]string{"test"}, 1, time.Now())
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 1, len(jobs)) {
+		assert.Equal(suite.T(), 1, jobs[0].Attempts)
+	}
+}
+
+func (suite *JobsTestSuite) TestFindAndPurgeJobs() {
+	for i := 0; i < 3; i++ {
+		_, err := suite.service.Enqueue("test", nil, nil)
+		assert.NoError(suite.T(), err)
+	}
+	_, err := suite.service.Enqueue("other", nil, nil)
+	assert.NoError(suite.T(), err)
+
+	jobs, err := suite.service.FindJobs(StateQueued, "test", 2)
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 2, len(jobs)) {
+		assert.True(suite.T(), jobs[0].ID > jobs[1].ID)
+	}
+
+	// Finish one job
+	leased, err := suite.service.Lease([]string{"other"}, 1, time.Now())
+	assert.NoError(suite.T(), err)
+	if assert.Equal(suite.T(), 1, len(leased)) {
+		assert.NoError(suite.T(), suite.service.Complete(leased[0]))
+	}
+
+	// Only finished jobs get purged
+	purged, err := suite.service.PurgeFinishedJobs(time.Now().Add(time.Minute))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), purged)
+
+	jobs, err = suite.service.FindJobs("", "", 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, len(jobs))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, MinBackoff, RetryBackoff(0))
+	assert.Equal(t, MinBackoff, RetryBackoff(1))
+	assert.Equal(t, 2*MinBackoff, RetryBackoff(2))
+	assert.Equal(t, 8*MinBackoff, RetryBackoff(4))
+	assert.Equal(t, MaxBackoff, RetryBackoff(20))
+	assert.Equal(t, MaxBackoff, RetryBackoff(1000))
+}
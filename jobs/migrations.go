@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/migrations"
+	"github.com/jinzhu/gorm"
+)
+
+// MigrateAll executes all migrations
+func MigrateAll(db *gorm.DB) error {
+	if err := migrate0001(db); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Migrate0001 creates jobs schema
+func migrate0001(db *gorm.DB) error {
+	migrationName := "jobs_initial"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Create job_jobs table
+	if err := db.CreateTable(new(Job)).Error; err != nil {
+		return fmt.Errorf("Error creating job_jobs table: %s", err)
+	}
+
+	// Due jobs are leased by state and run time
+	err := db.Model(new(Job)).AddIndex(
+		"idx_job_jobs_state_run_at",
+		"state",
+		"run_at",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error creating index on job_jobs table: %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+const (
+	// StateQueued jobs wait for their run time and a free worker
+	StateQueued = "queued"
+	// StateRunning jobs are leased by a worker until their lease expires
+	StateRunning = "running"
+	// StateDone jobs have finished successfully
+	StateDone = "done"
+	// StateDead jobs have failed too many times and will not be retried
+	StateDead = "dead"
+)
+
+// Job is a unit of background work stored in the queue
+type Job struct {
+	gorm.Model
+	Type              string         `sql:"type:varchar(50);index;not null"`
+	Key               sql.NullString `sql:"type:varchar(200);unique"`
+	Payload           string         `sql:"type:text"`
+	State             string         `sql:"type:varchar(20);index;not null"`
+	Priority          int            `sql:"index"`
+	RunAt             time.Time      `sql:"index;not null"`
+	VisibilityTimeout int            // seconds
+	Attempts          int
+	MaxAttempts       int
+	LeasedUntil       pq.NullTime    `sql:"index"`
+	LastError         sql.NullString `sql:"type:text"`
+	FinishedAt        pq.NullTime
+}
+
+// TableName specifies table name
+func (j *Job) TableName() string {
+	return "job_jobs"
+}
+
+// DecodePayload unmarshals the JSON payload of the job into v
+func (j *Job) DecodePayload(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"encoding/json"
+
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+// JobResponse ...
+type JobResponse struct {
+	ID                uint            `json:"id"`
+	Type              string          `json:"type"`
+	Key               string          `json:"key,omitempty"`
+	Payload           json.RawMessage `json:"payload"`
+	State             string          `json:"state"`
+	Priority          int             `json:"priority"`
+	RunAt             string          `json:"run_at"`
+	VisibilityTimeout int             `json:"visibility_timeout"`
+	Attempts          int             `json:"attempts"`
+	MaxAttempts       int             `json:"max_attempts"`
+	LeasedUntil       string          `json:"leased_until,omitempty"`
+	LastError         string          `json:"last_error,omitempty"`
+	FinishedAt        string          `json:"finished_at,omitempty"`
+	CreatedAt         string          `json:"created_at"`
+	UpdatedAt         string          `json:"updated_at"`
+}
+
+// ListJobsResponse ...
+type ListJobsResponse struct {
+	Counts map[string]int `json:"counts"`
+	Jobs   []*JobResponse `json:"jobs"`
+}
+
+// NewJobResponse creates new JobResponse instance
+func NewJobResponse(job *Job) *JobResponse {
+	response := &JobResponse{
+		ID:                job.ID,
+		Type:              job.Type,
+		Key:               job.Key.String,
+		Payload:           json.RawMessage(job.Payload),
+		State:             job.State,
+		Priority:          job.Priority,
+		RunAt:             util.FormatTime(job.RunAt),
+		VisibilityTimeout: job.VisibilityTimeout,
+		Attempts:          job.Attempts,
+		MaxAttempts:       job.MaxAttempts,
+		LastError:         job.LastError.String,
+		CreatedAt:         util.FormatTime(job.CreatedAt),
+		UpdatedAt:         util.FormatTime(job.UpdatedAt),
+	}
+	if job.LeasedUntil.Valid {
+		response.LeasedUntil = util.FormatTime(job.LeasedUntil.Time)
+	}
+	if job.FinishedAt.Valid {
+		response.FinishedAt = util.FormatTime(job.FinishedAt.Time)
+	}
+	return response
+}
+
+// NewListJobsResponse creates new ListJobsResponse instance
+func NewListJobsResponse(counts map[string]int, jobs []*Job) *ListJobsResponse {
+	jobResponses := make([]*JobResponse, len(jobs))
+	for i, job := range jobs {
+		jobResponses[i] = NewJobResponse(job)
+	}
+	return &ListJobsResponse{
+		Counts: counts,
+		Jobs:   jobResponses,
+	}
+}
@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"time"
+)
+
+// ServiceInterface defines exported methods
+type ServiceInterface interface {
+	// Exported methods
+	Enqueue(jobType string, payload interface{}, options *EnqueueOptions) (*Job, error)
+	Lease(jobTypes []string, limit int, now time.Time) ([]*Job, error)
+	Complete(job *Job) error
+	Fail(job *Job, jobErr error) error
+	Release(job *Job) error
+	FindJobByID(jobID uint) (*Job, error)
+	FindJobs(state, jobType string, limit int) ([]*Job, error)
+	CountJobsByState() (map[string]int, error)
+	PurgeFinishedJobs(before time.Time) (int64, error)
+}
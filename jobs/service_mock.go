@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// ServiceMock is a mocked object implementing ServiceInterface
+type ServiceMock struct {
+	mock.Mock
+}
+
+// Enqueue ...
+func (_m *ServiceMock) Enqueue(jobType string, payload interface{}, options *EnqueueOptions) (*Job, error) {
+	ret := _m.Called(jobType, payload, options)
+
+	var r0 *Job
+	if rf, ok := ret.Get(0).(func(string, interface{}, *EnqueueOptions) *Job); ok {
+		r0 = rf(jobType, payload, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, interface{}, *EnqueueOptions) error); ok {
+		r1 = rf(jobType, payload, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Lease ...
+func (_m *ServiceMock) Lease(jobTypes []string, limit int, now time.Time) ([]*Job, error) {
+	ret := _m.Called(jobTypes, limit, now)
+
+	var r0 []*Job
+	if rf, ok := ret.Get(0).(func([]string, int, time.Time) []*Job); ok {
+		r0 = rf(jobTypes, limit, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string, int, time.Time) error); ok {
+		r1 = rf(jobTypes, limit, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Complete ...
+func (_m *ServiceMock) Complete(job *Job) error {
+	ret := _m.Called(job)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*Job) error); ok {
+		r0 = rf(job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Fail ...
+func (_m *ServiceMock) Fail(job *Job, jobErr error) error {
+	ret := _m.Called(job, jobErr)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*Job, error) error); ok {
+		r0 = rf(job, jobErr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Release ...
+func (_m *ServiceMock) Release(job *Job) error {
+	ret := _m.Called(job)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*Job) error); ok {
+		r0 = rf(job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindJobByID ...
+func (_m *ServiceMock) FindJobByID(jobID uint) (*Job, error) {
+	ret := _m.Called(jobID)
+
+	var r0 *Job
+	if rf, ok := ret.Get(0).(func(uint) *Job); ok {
+		r0 = rf(jobID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(jobID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindJobs ...
+func (_m *ServiceMock) FindJobs(state string, jobType string, limit int) ([]*Job, error) {
+	ret := _m.Called(state, jobType, limit)
+
+	var r0 []*Job
+	if rf, ok := ret.Get(0).(func(string, string, int) []*Job); ok {
+		r0 = rf(state, jobType, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, int) error); ok {
+		r1 = rf(state, jobType, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountJobsByState ...
+func (_m *ServiceMock) CountJobsByState() (map[string]int, error) {
+	ret := _m.Called()
+
+	var r0 map[string]int
+	if rf, ok := ret.Get(0).(func() map[string]int); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeFinishedJobs ...
+func (_m *ServiceMock) PurgeFinishedJobs(before time.Time) (int64, error) {
+	ret := _m.Called(before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(time.Time) int64); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/prometheus"
+)
+
+// NewStatsCollector returns a collector exposing how many jobs wait in the
+// queue and how many are leased by workers
+func NewStatsCollector(service ServiceInterface) prometheus.Collector {
+	return prometheus.CollectorFunc(func() []*prometheus.Family {
+		counts, err := service.CountJobsByState()
+		if err != nil {
+			logger.ERROR.Printf("Count jobs by state error: %s", err)
+			return []*prometheus.Family{}
+		}
+
+		gauge := func(name, help string, value float64) *prometheus.Family {
+			family := prometheus.NewFamily(name, help, prometheus.TypeGauge)
+			family.Add(value, nil)
+			return family
+		}
+
+		return []*prometheus.Family{
+			gauge(
+				"pinglist_jobs_queued",
+				"Number of jobs waiting for their run time or a free worker.",
+				float64(counts[StateQueued]),
+			),
+			gauge(
+				"pinglist_jobs_running",
+				"Number of jobs leased by a worker.",
+				float64(counts[StateRunning]),
+			),
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStatsCollector(t *testing.T) {
+	serviceMock := new(ServiceMock)
+	serviceMock.On("CountJobsByState").Return(map[string]int{
+		StateQueued:  7,
+		StateRunning: 3,
+		StateDone:    100,
+		StateDead:    1,
+	}, nil).Once()
+
+	values := make(map[string]float64)
+	for _, family := range NewStatsCollector(serviceMock).Collect() {
+		if assert.Len(t, family.Samples, 1) {
+			values[family.Name] = family.Samples[0].Value
+		}
+	}
+
+	assert.Equal(t, map[string]float64{
+		"pinglist_jobs_queued":  7,
+		"pinglist_jobs_running": 3,
+	}, values)
+
+	// Nothing is exposed when jobs cannot be counted
+	serviceMock.On("CountJobsByState").Return(nil, errors.New("db error")).Once()
+	assert.Empty(t, NewStatsCollector(serviceMock).Collect())
+
+	serviceMock.AssertExpectations(t)
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"log"
+	"testing"
+
+	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/RichardKnop/pinglist-api/database"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	testDbUser = "pinglist"
+	testDbName = "pinglist_jobs_test"
+)
+
+var testFixtures = []string{}
+
+// db migrations needed for tests
+var testMigrations = []func(*gorm.DB) error{
+	MigrateAll,
+}
+
+// JobsTestSuite needs to be exported so the tests run
+type JobsTestSuite struct {
+	suite.Suite
+	cnf     *config.Config
+	db      *gorm.DB
+	service *Service
+}
+
+// The SetupSuite method will be run by testify once, at the very
+// start of the testing suite, before any tests are run.
+func (suite *JobsTestSuite) SetupSuite() {
+	// NOTE: using Postgres test database instead of sqlite here as
+	// leasing relies on FOR UPDATE SKIP LOCKED
+
+	// Initialise the config
+	suite.cnf = config.NewConfig(false, false)
+
+	// Create the test database
+	db, err := database.CreateTestDatabasePostgres(
+		testDbUser,
+		testDbName,
+		testMigrations,
+		testFixtures,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	suite.db = db
+
+	// Initialise the service
+	suite.service = NewService(suite.cnf, suite.db)
+}
+
+// The TearDownSuite method will be run by testify once, at the very
+// end of the testing suite, after all tests have been run.
+func (suite *JobsTestSuite) TearDownSuite() {
+	//
+}
+
+// The SetupTest method will be run before every test in the suite.
+func (suite *JobsTestSuite) SetupTest() {
+	suite.db.Unscoped().Delete(new(Job))
+}
+
+// The TearDownTest method will be run after every test in the suite.
+func (suite *JobsTestSuite) TearDownTest() {
+	//
+}
+
+// TestJobsTestSuite ...
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestJobsTestSuite(t *testing.T) {
+	suite.Run(t, new(JobsTestSuite))
+}
@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+// DefaultWorkers is used when number of workers is not configured
+const DefaultWorkers = 20
+
+// Handler processes a single job of its type, returning an error
+// schedules a retry of the job
+type Handler func(ctx context.Context, job *Job) error
+
+// Worker leases due jobs of registered types and runs their handlers,
+// at most a fixed number of jobs run at once
+type Worker struct {
+	service  ServiceInterface
+	handlers map[string]Handler
+	jobTypes []string
+	slots    chan struct{}
+	running  util.Background
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewWorker starts a new Worker instance
+func NewWorker(service ServiceInterface, workers int) *Worker {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Worker{
+		service:  service,
+		handlers: make(map[string]Handler),
+		slots:    make(chan struct{}, workers),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register sets the handler of a job type, it must be called before Start
+func (w *Worker) Register(jobType string, handler Handler) {
+	if _, ok := w.handlers[jobType]; !ok {
+		w.jobTypes = append(w.jobTypes, jobType)
+	}
+	w.handlers[jobType] = handler
+}
+
+// Start leases due jobs every interval until the worker is stopped
+func (w *Worker) Start(interval time.Duration) {
+	w.stop = make(chan struct{})
+	w.stopped = make(chan struct{})
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer close(w.stopped)
+		for {
+			select {
+			case tick := <-ticker.C:
+				w.leaseJobs(tick)
+			case <-w.stop:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops leasing jobs and waits for running jobs to finish. When the
+// context is done first, running jobs are cancelled and released back
+// to the queue
+func (w *Worker) Stop(ctx context.Context) error {
+	close(w.stop)
+	<-w.stopped
+
+	err := w.running.Wait(ctx)
+	w.cancel()
+	if err != nil {
+		w.running.Wait(context.Background())
+	}
+	return err
+}
+
+// leaseJobs leases as many jobs as there are free workers and runs them
+func (w *Worker) leaseJobs(now time.Time) {
+	free := cap(w.slots) - len(w.slots)
+	if free < 1 {
+		return
+	}
+
+	jobs, err := w.service.Lease(w.jobTypes, free, now)
+	if err != nil {
+		logger.ERROR.Printf("Lease jobs error: %s", err)
+		return
+	}
+
+	for _, job := range jobs {
+		job := job
+		w.slots <- struct{}{}
+		w.running.Go(func() {
+			defer func() { <-w.slots }()
+			w.process(job)
+		})
+	}
+}
+
+// process runs the handler of the job and records its outcome
+func (w *Worker) process(job *Job) {
+	workersBusy.Add(1)
+	defer workersBusy.Add(-1)
+
+	// Handlers are stopped when the lease expires, the job is leased by
+	// another worker after that and must not keep running here
+	ctx, cancel := jobContext(w.ctx, job)
+	defer cancel()
+
+	start := time.Now()
+	err := w.handlers[job.Type](ctx, job)
+	jobDuration.Observe(time.Since(start).Seconds(), job.Type)
+
+	var (
+		result    string
+		updateErr error
+	)
+	switch {
+	case err != nil && w.ctx.Err() != nil:
+		result = "released"
+		updateErr = w.service.Release(job)
+	case err != nil && job.Attempts >= job.MaxAttempts:
+		result = "dead"
+		logger.ERROR.Printf("Job #%d (%s) failed for the last time: %s", job.ID, job.Type, err)
+		updateErr = w.service.Fail(job, err)
+	case err != nil:
+		result = "retry"
+		logger.ERROR.Printf("Job #%d (%s) failed, will retry: %s", job.ID, job.Type, err)
+		updateErr = w.service.Fail(job, err)
+	default:
+		result = "done"
+		updateErr = w.service.Complete(job)
+	}
+
+	if updateErr != nil {
+		result = "error"
+		logger.ERROR.Printf("Job #%d (%s) not updated: %s", job.ID, job.Type, updateErr)
+	}
+	jobsProcessed.Inc(job.Type, result)
+}
+
+// jobContext returns a context of a job which is done when its lease
+// expires or the worker is stopped
+func jobContext(parent context.Context, job *Job) (context.Context, context.CancelFunc) {
+	if job.LeasedUntil.Valid {
+		return context.WithDeadline(parent, job.LeasedUntil.Time)
+	}
+	if job.VisibilityTimeout > 0 {
+		return context.WithTimeout(parent, time.Duration(job.VisibilityTimeout)*time.Second)
+	}
+	return context.WithCancel(parent)
+}
@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWorkerRunsJobs(t *testing.T) {
+	var (
+		serviceMock = new(ServiceMock)
+		doneJob     = &Job{Type: "test", Attempts: 1, MaxAttempts: 3, Payload: `{"alarm_id":1}`}
+		failedJob   = &Job{Type: "test", Attempts: 1, MaxAttempts: 3, Payload: `{"alarm_id":2}`}
+		deadJob     = &Job{Type: "other", Attempts: 3, MaxAttempts: 3}
+		jobErr      = errors.New("boom")
+	)
+	doneJob.ID, failedJob.ID, deadJob.ID = 1, 2, 3
+
+	serviceMock.On("Lease", []string{"test", "other"}, 5, mock.Anything).
+		Return([]*Job{doneJob, failedJob, deadJob}, nil).Once()
+	serviceMock.On("Lease", []string{"test", "other"}, mock.Anything, mock.Anything).
+		Return([]*Job{}, nil)
+	serviceMock.On("Complete", doneJob).Return(nil)
+	serviceMock.On("Fail", failedJob, jobErr).Return(nil)
+	serviceMock.On("Fail", deadJob, jobErr).Return(nil)
+
+	worker := NewWorker(serviceMock, 5)
+	worker.Register("test", func(ctx context.Context, job *Job) error {
+		payload := new(testPayload)
+		if err := job.DecodePayload(payload); err != nil {
+			return err
+		}
+		if payload.AlarmID == 2 {
+			return jobErr
+		}
+		return nil
+	})
+	worker.Register("other", func(ctx context.Context, job *Job) error {
+		return jobErr
+	})
+	worker.Start(5 * time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	assert.NoError(t, worker.Stop(context.Background()))
+	serviceMock.AssertExpectations(t)
+}
+
+func TestWorkerLeasesOnlyFreeSlots(t *testing.T) {
+	var (
+		serviceMock = new(ServiceMock)
+		job         = &Job{Type: "test", Attempts: 1, MaxAttempts: 3}
+		started     = make(chan struct{})
+		unblock     = make(chan struct{})
+	)
+
+	serviceMock.On("Lease", []string{"test"}, 1, mock.Anything).
+		Return([]*Job{job}, nil).Once()
+	serviceMock.On("Lease", []string{"test"}, 1, mock.Anything).
+		Return([]*Job{}, nil)
+	serviceMock.On("Complete", job).Return(nil)
+
+	worker := NewWorker(serviceMock, 1)
+	worker.Register("test", func(ctx context.Context, job *Job) error {
+		close(started)
+		<-unblock
+		return nil
+	})
+	worker.Start(5 * time.Millisecond)
+	<-started
+
+	// The only worker is busy, nothing else gets leased
+	time.Sleep(30 * time.Millisecond)
+	serviceMock.AssertNumberOfCalls(t, "Lease", 1)
+
+	// Stop waits for the running job
+	stopErr := make(chan error)
+	go func() {
+		stopErr <- worker.Stop(context.Background())
+	}()
+	close(unblock)
+	assert.NoError(t, <-stopErr)
+	serviceMock.AssertExpectations(t)
+}
+
+func TestWorkerStopTimeoutReleasesJobs(t *testing.T) {
+	var (
+		serviceMock = new(ServiceMock)
+		job         = &Job{Type: "test", Attempts: 1, MaxAttempts: 3}
+		started     = make(chan struct{})
+	)
+
+	serviceMock.On("Lease", []string{"test"}, 1, mock.Anything).
+		Return([]*Job{job}, nil).Once()
+	serviceMock.On("Release", job).Return(nil)
+
+	worker := NewWorker(serviceMock, 1)
+	worker.Register("test", func(ctx context.Context, job *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	worker.Start(5 * time.Millisecond)
+	<-started
+
+	// The running job is cancelled and returned to the queue
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, worker.Stop(ctx))
+	serviceMock.AssertExpectations(t)
+}
+
+func TestWorkerCancelsJobsWhenLeaseExpires(t *testing.T) {
+	var (
+		serviceMock = new(ServiceMock)
+		leasedUntil = time.Now().Add(20 * time.Millisecond)
+		job         = &Job{
+			Type:        "test",
+			Attempts:    1,
+			MaxAttempts: 3,
+			LeasedUntil: util.TimeOrNull(&leasedUntil),
+		}
+	)
+
+	serviceMock.On("Lease", []string{"test"}, 1, mock.Anything).
+		Return([]*Job{job}, nil).Once()
+	serviceMock.On("Lease", []string{"test"}, 1, mock.Anything).
+		Return([]*Job{}, nil)
+	serviceMock.On("Fail", job, context.DeadlineExceeded).Return(nil)
+
+	worker := NewWorker(serviceMock, 1)
+	worker.Register("test", func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	worker.Start(5 * time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+
+	// The job is retried instead of running past its lease
+	assert.NoError(t, worker.Stop(context.Background()))
+	serviceMock.AssertExpectations(t)
+}
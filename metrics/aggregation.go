@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// AggregationAvg is an average value
+	AggregationAvg = "avg"
+	// AggregationMin is a minimum value
+	AggregationMin = "min"
+	// AggregationMax is a maximum value
+	AggregationMax = "max"
+	// AggregationCount is a number of samples
+	AggregationCount = "count"
+	// AggregationStdDev is a sample standard deviation
+	AggregationStdDev = "stddev"
+	// AggregationP50 is the 50th percentile (median)
+	AggregationP50 = "p50"
+	// AggregationP90 is the 90th percentile
+	AggregationP90 = "p90"
+	// AggregationP95 is the 95th percentile
+	AggregationP95 = "p95"
+	// AggregationP99 is the 99th percentile
+	AggregationP99 = "p99"
+)
+
+// aggregationExpressions maps allowed aggregations to SQL expressions of
+// a column, it doubles as a whitelist as the expressions are inlined into queries
+var aggregationExpressions = map[string]string{
+	AggregationAvg:    "AVG(%s)",
+	AggregationMin:    "MIN(%s)",
+	AggregationMax:    "MAX(%s)",
+	AggregationCount:  "COUNT(%s)",
+	AggregationStdDev: "COALESCE(STDDEV_SAMP(%s), 0)",
+	AggregationP50:    "PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY %s)",
+	AggregationP90:    "PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY %s)",
+	AggregationP95:    "PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY %s)",
+	AggregationP99:    "PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY %s)",
+}
+
+// ResponseTimesAggregations returns aggregations of response times or one of
+// their phases over the whole time range (e.g. p95 response time for the last day)
+func (s *Service) ResponseTimesAggregations(referenceID int, phase string, aggregations []string, from, to *time.Time) (map[string]float64, error) {
+	if len(aggregations) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	// Older data may have been rolled up, aggregate daily rollups too
+	boundary, err := s.rollupBoundary(RollupPeriodDay, phase, from)
+	if err != nil {
+		return nil, err
+	}
+	if boundary != nil {
+		buckets, err := s.findResponseTimeBuckets(referenceIDsFilter(referenceID), RollupPeriodDay, from, to)
+		if err != nil {
+			return nil, err
+		}
+		total := new(responseTimeBucket)
+		for _, referenceBuckets := range buckets {
+			for _, bucket := range referenceBuckets {
+				total.merge(bucket)
+			}
+		}
+		result := make(map[string]float64, len(aggregations))
+		for _, aggregation := range aggregations {
+			result[aggregation] = total.value(aggregation)
+		}
+		return result, nil
+	}
+
+	query := s.responseTimesQuery(referenceIDsFilter(referenceID), from, to).
+		Select(aggregationsSelect(aggregations, phaseColumn(phase)))
+
+	values := make([]sql.NullFloat64, len(aggregations))
+	if err := query.Row().Scan(aggregationsDest(values)...); err != nil {
+		return nil, err
+	}
+
+	return aggregationsMap(aggregations, values), nil
+}
+
+// aggregationsSelect returns a select clause for aggregations of a column
+func aggregationsSelect(aggregations []string, column string) string {
+	expressions := make([]string, len(aggregations))
+	for i, aggregation := range aggregations {
+		expressions[i] = fmt.Sprintf(aggregationExpressions[aggregation], column)
+	}
+	return strings.Join(expressions, ", ")
+}
+
+// aggregationsDest returns scan destinations for aggregations
+func aggregationsDest(values []sql.NullFloat64) []interface{} {
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	return dest
+}
+
+// aggregationsMap converts scanned values to a map, aggregations of an empty
+// set of samples are NULL in Postgres and are returned as zeros
+func aggregationsMap(aggregations []string, values []sql.NullFloat64) map[string]float64 {
+	result := make(map[string]float64, len(aggregations))
+	for i, aggregation := range aggregations {
+		result[aggregation] = values[i].Float64
+	}
+	return result
+}
+
+// dateTruncExpression returns a SQL expression truncating the timestamp
+// to the beginning of a bucket, Postgres DATE_TRUNC does not support
+// 5 minute buckets so those are calculated from the hour
+func dateTruncExpression(dateTrunc string) string {
+	if dateTrunc == DateTruncFiveMinutes {
+		return "DATE_TRUNC('hour', timestamp at time zone 'Z') + " +
+			"FLOOR(DATE_PART('minute', timestamp at time zone 'Z') / 5) * INTERVAL '5 minutes'"
+	}
+	return fmt.Sprintf("DATE_TRUNC('%s', timestamp at time zone 'Z')", dateTrunc)
+}
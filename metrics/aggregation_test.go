@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregationsSelect(t *testing.T) {
+	assert.Equal(
+		t,
+		"MIN(value), PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY value)",
+		aggregationsSelect([]string{AggregationMin, AggregationP99}, "value"),
+	)
+	assert.Equal(
+		t,
+		"COUNT(first_byte), AVG(first_byte)",
+		aggregationsSelect([]string{AggregationCount, AggregationAvg}, phaseColumn(PhaseFirstByte)),
+	)
+}
+
+func TestDateTruncExpression(t *testing.T) {
+	assert.Equal(
+		t,
+		"DATE_TRUNC('week', timestamp at time zone 'Z')",
+		dateTruncExpression("week"),
+	)
+	assert.Contains(t, dateTruncExpression(DateTruncFiveMinutes), "INTERVAL '5 minutes'")
+}
+
+func (suite *MetricsTestSuite) TestResponseTimesAggregations() {
+	var (
+		today             = time.Date(2016, time.February, 9, 0, 0, 0, 0, time.UTC)
+		todaySubTableName = "metrics_response_times_2016_02_09"
+		responseTimes     []*ResponseTime
+		aggregations      map[string]float64
+		err               error
+	)
+
+	// Partition the response time table
+	err = suite.service.PartitionResponseTime(ResponseTimeParentTableName, today)
+	assert.NoError(suite.T(), err, "Partitioning table failed")
+
+	// Insert some test records
+	for i, value := range []int64{100, 200, 300, 400, 1000} {
+		testRecord := NewResponseTime(todaySubTableName, 1, today.Add(time.Duration(i)*time.Minute), value)
+		err := suite.db.Create(testRecord).Error
+		assert.NoError(suite.T(), err, "Inserting test data failed")
+	}
+
+	// Aggregations over the whole time range
+	aggregations, err = suite.service.ResponseTimesAggregations(
+		1,          // reference ID
+		PhaseTotal, // phase
+		[]string{AggregationMin, AggregationMax, AggregationCount, AggregationP50},
+		nil, // from
+		nil, // to
+	)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 100.0, aggregations[AggregationMin])
+		assert.Equal(suite.T(), 1000.0, aggregations[AggregationMax])
+		assert.Equal(suite.T(), 5.0, aggregations[AggregationCount])
+		assert.Equal(suite.T(), 300.0, aggregations[AggregationP50])
+	}
+
+	// Aggregations of an empty set should be zeros
+	aggregations, err = suite.service.ResponseTimesAggregations(
+		2,          // reference ID
+		PhaseTotal, // phase
+		[]string{AggregationAvg, AggregationStdDev},
+		nil, // from
+		nil, // to
+	)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 0.0, aggregations[AggregationAvg])
+		assert.Equal(suite.T(), 0.0, aggregations[AggregationStdDev])
+	}
+
+	// Aggregations of 5 minute buckets
+	responseTimes, err = suite.service.FindPaginatedResponseTimes(
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		1,          // reference ID
+		"5-minute", // date trunc
+		PhaseTotal, // phase
+		[]string{AggregationMax, AggregationCount}, // aggregations
+		nil, // from
+		nil, // to
+	)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 1, len(responseTimes)) {
+		assert.Equal(suite.T(), today.Unix(), responseTimes[0].Timestamp.Unix())
+		assert.Equal(suite.T(), int64(400), responseTimes[0].Value)
+		assert.Equal(suite.T(), 1000.0, responseTimes[0].Aggregations[AggregationMax])
+		assert.Equal(suite.T(), 5.0, responseTimes[0].Aggregations[AggregationCount])
+	}
+}
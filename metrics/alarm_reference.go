@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Alarms are the only references metrics are logged for at the moment.
+// The alarms package depends on this one, so rather than importing it the
+// few alarm columns needed for filtering and grouping are read directly.
+const (
+	alarmsTableName     = "alarm_alarms"
+	alarmTagsTableName  = "alarm_tags"
+	alarmAlarmTagsTable = "alarm_alarm_tags"
+)
+
+const (
+	// GroupByAlarm returns a separate series for each alarm
+	GroupByAlarm = "alarm"
+	// GroupByRegion aggregates alarms from the same region
+	GroupByRegion = "region"
+	// GroupByTag aggregates alarms with the same tag
+	GroupByTag = "tag"
+)
+
+var (
+	// AllowedGroupByMap ...
+	AllowedGroupByMap = map[string]bool{
+		GroupByAlarm:  true,
+		GroupByRegion: true,
+		GroupByTag:    true,
+	}
+
+	// ErrInvalidGroupBy ...
+	ErrInvalidGroupBy = errors.New("Invalid group_by value. Use one of: alarm, region, tag")
+	// ErrAlarmNotFound ...
+	ErrAlarmNotFound = errors.New("Alarm not found")
+)
+
+// alarmReference is the part of an alarm needed to query its metrics
+type alarmReference struct {
+	ID       uint
+	UserID   uint
+	RegionID string
+	Tags     []string
+}
+
+// findAlarmReferences returns alarms owned by any of the users, optionally
+// only the ones with given IDs, nil user IDs mean alarms of any user
+func (s *Service) findAlarmReferences(userIDs []uint, alarmIDs []uint) ([]*alarmReference, error) {
+	query := s.db.Table(alarmsTableName).
+		Select("id, user_id, region_id").
+		Where("deleted_at IS NULL")
+	if userIDs != nil {
+		query = query.Where("user_id IN (?)", userIDs)
+	}
+	if len(alarmIDs) > 0 {
+		query = query.Where("id IN (?)", alarmIDs)
+	}
+
+	rows, err := query.Order("id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		references []*alarmReference
+		ids        []uint
+		byID       = make(map[uint]*alarmReference)
+	)
+	for rows.Next() {
+		reference := new(alarmReference)
+		if err := rows.Scan(&reference.ID, &reference.UserID, &reference.RegionID); err != nil {
+			return nil, err
+		}
+		references = append(references, reference)
+		ids = append(ids, reference.ID)
+		byID[reference.ID] = reference
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// All explicitly requested alarms must have been found
+	if len(alarmIDs) > 0 && len(references) != len(uniqueIDs(alarmIDs)) {
+		return nil, ErrAlarmNotFound
+	}
+
+	if len(ids) == 0 {
+		return references, nil
+	}
+
+	// Fetch tags
+	tagRows, err := s.db.Table(alarmAlarmTagsTable).
+		Select(fmt.Sprintf("%s.alarm_id, %s.name", alarmAlarmTagsTable, alarmTagsTableName)).
+		Joins(fmt.Sprintf(
+			"inner join %s on %s.id = %s.tag_id",
+			alarmTagsTableName,
+			alarmTagsTableName,
+			alarmAlarmTagsTable,
+		)).
+		Where(fmt.Sprintf("%s.alarm_id IN (?)", alarmAlarmTagsTable), ids).
+		Order(fmt.Sprintf("%s.name", alarmTagsTableName)).
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var (
+			alarmID uint
+			name    string
+		)
+		if err := tagRows.Scan(&alarmID, &name); err != nil {
+			return nil, err
+		}
+		byID[alarmID].Tags = append(byID[alarmID].Tags, name)
+	}
+	if err := tagRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return references, nil
+}
+
+// groupAlarmReferences splits alarms into series groups, without grouping
+// all alarms are aggregated into a single series, alarms without any tag
+// are left out when grouping by tag
+func groupAlarmReferences(references []*alarmReference, groupBy string) []*SeriesGroup {
+	if groupBy == "" {
+		group := &SeriesGroup{Key: "all", ReferenceIDs: make([]uint, len(references))}
+		for i, reference := range references {
+			group.ReferenceIDs[i] = reference.ID
+		}
+		return []*SeriesGroup{group}
+	}
+
+	groups := make(map[string]*SeriesGroup)
+	var keys []string
+	add := func(key string, referenceID uint) {
+		if groups[key] == nil {
+			groups[key] = &SeriesGroup{Key: key}
+			keys = append(keys, key)
+		}
+		groups[key].ReferenceIDs = append(groups[key].ReferenceIDs, referenceID)
+	}
+
+	for _, reference := range references {
+		switch groupBy {
+		case GroupByAlarm:
+			add(fmt.Sprintf("%d", reference.ID), reference.ID)
+		case GroupByRegion:
+			add(reference.RegionID, reference.ID)
+		case GroupByTag:
+			for _, tag := range reference.Tags {
+				add(tag, reference.ID)
+			}
+		}
+	}
+
+	// Alarm groups keep the ID order, other groups are sorted by name
+	if groupBy != GroupByAlarm {
+		sort.Strings(keys)
+	}
+
+	result := make([]*SeriesGroup, len(keys))
+	for i, key := range keys {
+		result[i] = groups[key]
+	}
+	return result
+}
+
+// uniqueIDs removes duplicate IDs
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/jinzhu/gorm"
+)
+
+// CheckOutcomeSuccess is an outcome of a check which did not open an incident,
+// other checks have an outcome of the incident type (timeout, bad code etc)
+const CheckOutcomeSuccess = "success"
+
+// AllowedCheckOutcomesMap ...
+var AllowedCheckOutcomesMap = map[string]bool{
+	CheckOutcomeSuccess:   true,
+	incidenttypes.Slow:    true,
+	incidenttypes.Timeout: true,
+	incidenttypes.BadCode: true,
+	incidenttypes.Other:   true,
+}
+
+// LogCheckResult queues a check result to be written in a batch by the
+// buffered writer together with response times
+func (s *Service) LogCheckResult(timestamp time.Time, referenceID uint, region string, statusCode int, responseSize int64, outcome, errMsg string) error {
+	checkResultRecord := NewCheckResult(
+		CheckResultParentTableName,
+		referenceID,
+		timestamp,
+		region,
+		statusCode,
+		responseSize,
+		outcome,
+		errMsg,
+	)
+	return s.writer.Write(checkResultRecord)
+}
+
+// CheckResultsCount returns a total count of check result records
+func (s *Service) CheckResultsCount(referenceID int, outcome string, from, to *time.Time) (int, error) {
+	var count int
+	if err := s.checkResultsQuery(referenceID, outcome, from, to).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FindPaginatedCheckResults returns paginated check result records
+// Records can optionally be filtered by:
+// - reference_id
+// - outcome
+// - from
+// - to
+func (s *Service) FindPaginatedCheckResults(offset, limit int, orderBy string, referenceID int, outcome string, from, to *time.Time) ([]*CheckResult, error) {
+	var checkResults []*CheckResult
+
+	// Default ordering
+	if orderBy == "" {
+		orderBy = "timestamp"
+	}
+
+	// Retrieve paginated results from the database
+	err := s.checkResultsQuery(referenceID, outcome, from, to).
+		Offset(offset).
+		Limit(limit).
+		Order(orderBy).
+		Find(&checkResults).Error
+	if err != nil {
+		return checkResults, err
+	}
+
+	return checkResults, nil
+}
+
+// CheckResultCounts returns numbers of check results of references by
+// outcome, keyed by reference ID
+func (s *Service) CheckResultCounts(referenceIDs []uint) (map[uint]map[string]int, error) {
+	counts := make(map[uint]map[string]int)
+	if len(referenceIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := s.db.Model(new(CheckResult)).
+		Select("reference_id, outcome, COUNT(*)").
+		Where("reference_id IN (?)", referenceIDs).
+		Group("reference_id, outcome").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			referenceID int64
+			outcome     string
+			count       int
+		)
+		if err := rows.Scan(&referenceID, &outcome, &count); err != nil {
+			return nil, err
+		}
+		if _, ok := counts[uint(referenceID)]; !ok {
+			counts[uint(referenceID)] = make(map[string]int)
+		}
+		counts[uint(referenceID)][outcome] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// checkResultsQuery returns a common part of db query for
+// fetching check result records
+func (s *Service) checkResultsQuery(referenceID int, outcome string, from, to *time.Time) *gorm.DB {
+	// Basic query
+	query := s.db.Model(new(CheckResult))
+
+	// Optionally filter by reference ID
+	if referenceID > 0 {
+		query = query.Where("reference_id = ?", referenceID)
+	}
+
+	// Optionally filter by outcome
+	if outcome != "" {
+		query = query.Where("outcome = ?", outcome)
+	}
+
+	// Optionally limit timestamp to be greater or equal to
+	if from != nil {
+		query = query.Where("timestamp >= ?", from)
+	}
+
+	// Optionally limit timestamp to be less or equal to
+	if to != nil {
+		query = query.Where("timestamp <= ?", to)
+	}
+
+	return query
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/alarms/incidenttypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *MetricsTestSuite) TestCheckResults() {
+	var (
+		today             = time.Date(2016, time.February, 9, 0, 0, 0, 0, time.UTC)
+		todaySubTableName = "metrics_check_results_2016_02_09"
+		checkResults      []*CheckResult
+		count             int
+		err               error
+	)
+
+	// Partition the check results table
+	err = suite.service.PartitionResponseTime(CheckResultParentTableName, today)
+	assert.NoError(suite.T(), err, "Partitioning table failed")
+
+	// Log some check results
+	outcomes := []string{
+		CheckOutcomeSuccess,
+		incidenttypes.Timeout,
+		CheckOutcomeSuccess,
+		incidenttypes.BadCode,
+	}
+	for i, outcome := range outcomes {
+		err = suite.service.LogCheckResult(
+			today.Add(time.Duration(i)*time.Minute),
+			1,           // reference ID
+			"us-west-2", // region
+			200,         // status code
+			1024,        // response size
+			outcome,
+			"", // error
+		)
+		assert.NoError(suite.T(), err)
+	}
+	assert.NoError(suite.T(), suite.service.FlushResponseTimes())
+
+	// Check results should be routed to the partition
+	suite.db.Table(todaySubTableName).Count(&count)
+	assert.Equal(suite.T(), 4, count)
+
+	// Count all check results of the reference
+	count, err = suite.service.CheckResultsCount(1, "", nil, nil)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 4, count)
+	}
+
+	// Count filtered by outcome
+	count, err = suite.service.CheckResultsCount(1, CheckOutcomeSuccess, nil, nil)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 2, count)
+	}
+
+	// Paginated results filtered by outcome
+	checkResults, err = suite.service.FindPaginatedCheckResults(
+		0,                     // offset
+		25,                    // limit
+		"timestamp desc",      // order by
+		1,                     // reference ID
+		incidenttypes.BadCode, // outcome
+		nil,                   // from
+		nil,                   // to
+	)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 1, len(checkResults)) {
+		assert.Equal(suite.T(), today.Add(3*time.Minute).Unix(), checkResults[0].Timestamp.Unix())
+		assert.Equal(suite.T(), "us-west-2", checkResults[0].Region)
+		assert.Equal(suite.T(), 200, checkResults[0].StatusCode)
+		assert.Equal(suite.T(), int64(1024), checkResults[0].ResponseSize)
+	}
+
+	// Iterate over check results oldest first
+	var iterated []*CheckResult
+	err = suite.service.IterateCheckResults(1, "", nil, nil, func(checkResult *CheckResult) error {
+		iterated = append(iterated, checkResult)
+		return nil
+	})
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 4, len(iterated)) {
+		for i, checkResult := range iterated {
+			assert.Equal(suite.T(), today.Add(time.Duration(i)*time.Minute).Unix(), checkResult.Timestamp.Unix())
+			assert.Equal(suite.T(), outcomes[i], checkResult.Outcome)
+		}
+	}
+
+	// Iteration stops at the first error
+	iterated = nil
+	err = suite.service.IterateCheckResults(1, "", nil, nil, func(checkResult *CheckResult) error {
+		iterated = append(iterated, checkResult)
+		return errors.New("stop")
+	})
+	assert.EqualError(suite.T(), err, "stop")
+	assert.Equal(suite.T(), 1, len(iterated))
+
+	// Counts by outcome
+	counts, err := suite.service.CheckResultCounts([]uint{1, 2})
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), map[uint]map[string]int{
+			1: map[string]int{
+				CheckOutcomeSuccess:   2,
+				incidenttypes.Timeout: 1,
+				incidenttypes.BadCode: 1,
+			},
+		}, counts)
+	}
+
+	// Other references have no check results
+	count, err = suite.service.CheckResultsCount(2, "", nil, nil)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 0, count)
+	}
+}
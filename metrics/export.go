@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"time"
+)
+
+// IterateResponseTimes calls fn for every raw response time record in the
+// time range, oldest first. Rows are read from a database cursor one by
+// one so exports do not need to hold all records in memory, iteration
+// stops at the first error returned by fn
+func (s *Service) IterateResponseTimes(referenceID int, from, to *time.Time, fn func(*ResponseTime) error) error {
+	rows, err := s.responseTimesQuery(referenceIDsFilter(referenceID), from, to).
+		Order("timestamp").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		responseTime := new(ResponseTime)
+		if err := s.db.ScanRows(rows, responseTime); err != nil {
+			return err
+		}
+		if err := fn(responseTime); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// IterateCheckResults calls fn for every check result record in the time
+// range optionally filtered by outcome, oldest first. Rows are read from
+// a database cursor the same way as in IterateResponseTimes
+func (s *Service) IterateCheckResults(referenceID int, outcome string, from, to *time.Time, fn func(*CheckResult) error) error {
+	rows, err := s.checkResultsQuery(referenceID, outcome, from, to).
+		Order("timestamp").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		checkResult := new(CheckResult)
+		if err := s.db.ScanRows(rows, checkResult); err != nil {
+			return err
+		}
+		if err := fn(checkResult); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
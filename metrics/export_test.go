@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *MetricsTestSuite) TestIterateResponseTimes() {
+	var (
+		today             = time.Date(2016, time.February, 9, 0, 0, 0, 0, time.UTC)
+		todaySubTableName = "metrics_response_times_2016_02_09"
+		iterated          []*ResponseTime
+		err               error
+	)
+
+	// Partition the response time table
+	err = suite.service.PartitionResponseTime(ResponseTimeParentTableName, today)
+	assert.NoError(suite.T(), err, "Partitioning table failed")
+
+	// Insert some test records out of order
+	testRecords := []*ResponseTime{
+		NewResponseTime(todaySubTableName, 1, today.Add(2*time.Hour), 345),
+		NewResponseTime(todaySubTableName, 1, today, 123),
+		NewResponseTime(todaySubTableName, 2, today.Add(1*time.Hour), 234),
+		NewResponseTime(todaySubTableName, 1, today.Add(3*time.Hour), 456),
+	}
+	testRecords[0].Failed = true
+	for _, testRecord := range testRecords {
+		err := suite.db.Create(testRecord).Error
+		assert.NoError(suite.T(), err, "Inserting test data failed")
+	}
+
+	// Records of the reference are iterated oldest first
+	err = suite.service.IterateResponseTimes(1, nil, nil, func(responseTime *ResponseTime) error {
+		iterated = append(iterated, responseTime)
+		return nil
+	})
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 3, len(iterated)) {
+		assert.Equal(suite.T(), int64(123), iterated[0].Value)
+		assert.Equal(suite.T(), int64(345), iterated[1].Value)
+		assert.True(suite.T(), iterated[1].Failed)
+		assert.Equal(suite.T(), int64(456), iterated[2].Value)
+	}
+
+	// The last record of each reference
+	lastResponseTimes, err := suite.service.LastResponseTimes([]uint{1, 2, 3}, today)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 2, len(lastResponseTimes)) {
+		assert.Equal(suite.T(), int64(456), lastResponseTimes[1].Value)
+		assert.Equal(suite.T(), int64(234), lastResponseTimes[2].Value)
+	}
+
+	// Records older than since are ignored
+	lastResponseTimes, err = suite.service.LastResponseTimes([]uint{2}, today.Add(90*time.Minute))
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 0, len(lastResponseTimes))
+	}
+
+	// Filter by time range
+	var (
+		from = today.Add(30 * time.Minute)
+		to   = today.Add(150 * time.Minute)
+	)
+	iterated = nil
+	err = suite.service.IterateResponseTimes(0, &from, &to, func(responseTime *ResponseTime) error {
+		iterated = append(iterated, responseTime)
+		return nil
+	})
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 2, len(iterated)) {
+		assert.Equal(suite.T(), uint(2), iterated[0].ReferenceID)
+		assert.Equal(suite.T(), uint(1), iterated[1].ReferenceID)
+	}
+}
@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"github.com/RichardKnop/pinglist-api/prometheus"
+)
+
+var (
+	writerDropped = prometheus.NewCounterVec(
+		"pinglist_metrics_writer_dropped_total",
+		"Number of buffered metrics dropped after failed writes by table.",
+		"table",
+	)
+)
+
+// countDropped records buffered records the writer gave up on
+func countDropped(records []bufferedRecord) {
+	for _, record := range records {
+		writerDropped.Inc(record.TableName())
+	}
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/accounts/roles"
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/response"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/RichardKnop/pinglist-api/util"
+)
+
+const (
+	// DefaultSeriesPeriod is used when the from param is not set
+	DefaultSeriesPeriod = 24 * time.Hour
+	// DefaultSeriesDateTrunc is used when the date_trunc param is not set
+	DefaultSeriesDateTrunc = "hour"
+)
+
+var (
+	// ErrListResponseTimeSeriesPermission ...
+	ErrListResponseTimeSeriesPermission = errors.New("Need permission to list response time series")
+)
+
+// Handles calls to list response time series of several alarms
+// (GET /v1/metrics/response-times)
+func (s *Service) listResponseTimeSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the authenticated user from the request context
+	authenticatedUser, err := accounts.GetAuthenticatedUser(r)
+	if err != nil {
+		response.UnauthorizedError(w, err.Error())
+		return
+	}
+
+	// Optional filtering by user
+	user, err := s.GetAccountsService().GetUserFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Optional filtering by team
+	var team *teams.Team
+	if r.URL.Query().Get("team_id") != "" {
+		teamID, err := strconv.Atoi(r.URL.Query().Get("team_id"))
+		if err != nil {
+			response.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		team, err = s.teamsService.FindTeamByID(uint(teamID))
+		if err != nil {
+			response.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+
+	// Get alarm IDs and grouping
+	alarmIDs, groupBy, err := GetSeriesParamsFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Default to the authenticated user unless logged in as superuser
+	// and querying specific alarms
+	if user == nil && team == nil {
+		if authenticatedUser.Role.Name != roles.Superuser || len(alarmIDs) == 0 {
+			user = authenticatedUser
+		}
+	}
+
+	// Check permissions
+	if err := checkListResponseTimeSeriesPermissions(authenticatedUser, user, team); err != nil {
+		response.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Get other params, history is limited by the plan of the queried user
+	// or the team owner, superusers querying any alarms are not limited
+	var retention time.Duration
+	if team != nil {
+		retention = s.GetMetricsRetention(uint(team.OwnerID.Int64))
+	} else if user != nil {
+		retention = s.GetMetricsRetention(user.ID)
+	}
+	dateTrunc, from, to, err := GetParamsFromQueryString(r, retention)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if dateTrunc == "" {
+		dateTrunc = DefaultSeriesDateTrunc
+	}
+	phase, err := GetPhaseFromQueryString(r)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if from == nil {
+		end := time.Now()
+		if to != nil {
+			end = *to
+		}
+		start := end.Add(-DefaultSeriesPeriod)
+		from = &start
+	}
+
+	// Fetch alarms and group them
+	references, err := s.findAlarmReferences(seriesUserIDs(user, team), alarmIDs)
+	if err != nil {
+		if err == ErrAlarmNotFound {
+			response.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		logger.ERROR.Printf("Find alarm references error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	groups := groupAlarmReferences(references, groupBy)
+
+	// Fetch the series
+	series, err := s.FindResponseTimeSeries(groups, dateTrunc, phase, from, to)
+	if err != nil {
+		logger.ERROR.Printf("Find response time series error: %s", err)
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	listResponse, err := NewListResponseTimeSeriesResponse(
+		util.GetCurrentURL(r),
+		groupBy,
+		dateTrunc,
+		phase,
+		series,
+	)
+	if err != nil {
+		response.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Write JSON response
+	response.WriteJSON(w, listResponse, http.StatusOK)
+}
+
+// seriesUserIDs returns IDs of users whose alarms can be queried, nil means
+// alarms of any user (superusers querying specific alarms)
+func seriesUserIDs(user *accounts.User, team *teams.Team) []uint {
+	if team != nil {
+		userIDs := []uint{uint(team.OwnerID.Int64)}
+		for _, member := range team.Members {
+			userIDs = append(userIDs, member.ID)
+		}
+		return userIDs
+	}
+	if user != nil {
+		return []uint{user.ID}
+	}
+	return nil
+}
+
+func checkListResponseTimeSeriesPermissions(authenticatedUser *accounts.User, user *accounts.User, team *teams.Team) error {
+	// Superusers can list any response times
+	if authenticatedUser.Role.Name == roles.Superuser {
+		return nil
+	}
+
+	// Team owner and members can list response times of team alarms
+	if team != nil {
+		if uint(team.OwnerID.Int64) == authenticatedUser.ID {
+			return nil
+		}
+		for _, member := range team.Members {
+			if member.ID == authenticatedUser.ID {
+				return nil
+			}
+		}
+		return ErrListResponseTimeSeriesPermission
+	}
+
+	// Users can list their own response times
+	if user != nil && authenticatedUser.ID == user.ID {
+		return nil
+	}
+
+	// The user doesn't have the permission
+	return ErrListResponseTimeSeriesPermission
+}
@@ -2,9 +2,11 @@ package metrics
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/RichardKnop/pinglist-api/logger"
 	"github.com/RichardKnop/pinglist-api/migrations"
+	"github.com/RichardKnop/pinglist-api/util"
 	"github.com/jinzhu/gorm"
 )
 
@@ -14,6 +16,22 @@ func MigrateAll(db *gorm.DB) error {
 		return err
 	}
 
+	if err := migrate0002(db); err != nil {
+		return err
+	}
+
+	if err := migrate0003(db); err != nil {
+		return err
+	}
+
+	if err := migrate0004(db); err != nil {
+		return err
+	}
+
+	if err := migrate0005(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -50,3 +68,271 @@ func migrate0001(db *gorm.DB) error {
 
 	return nil
 }
+
+// Migrate0002 adds failed flag to response times and creates rollups table
+func migrate0002(db *gorm.DB) error {
+	migrationName := "metrics_add_rollups"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Add failed column to metrics_response_times table,
+	// existing sub tables inherit the new column automatically
+	if !db.Dialect().HasColumn(ResponseTimeParentTableName, "failed") {
+		sql := fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN failed BOOLEAN NOT NULL DEFAULT FALSE",
+			ResponseTimeParentTableName,
+		)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("Error adding failed column: %s", err)
+		}
+	}
+
+	// Create metrics_response_time_rollups table
+	if err := db.CreateTable(new(ResponseTimeRollup)).Error; err != nil {
+		return fmt.Errorf("Error creating metrics_response_time_rollups table: %s", err)
+	}
+	err := db.Model(new(ResponseTimeRollup)).AddUniqueIndex(
+		"idx_metrics_response_time_rollups_reference_period_timestamp",
+		"reference_id",
+		"period",
+		"timestamp",
+	).Error
+	if err != nil {
+		return fmt.Errorf("Error adding unique index on metrics_response_time_rollups: %s", err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0003 converts response times from table inheritance
+// to declarative partitioning
+func migrate0003(db *gorm.DB) error {
+	migrationName := "metrics_declarative_partitioning"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	var (
+		parentTableName = ResponseTimeParentTableName
+		legacyTableName = fmt.Sprintf("%s_legacy", parentTableName)
+		subTables       []*SubTable
+	)
+
+	// Fetch existing sub tables
+	err := db.Where("parent_table = ?", parentTableName).Order("name").Find(&subTables).Error
+	if err != nil {
+		return fmt.Errorf("Error fetching sub tables: %s", err)
+	}
+
+	// Begin a transaction
+	tx := db.Begin()
+
+	statements := []string{
+		// Rename the old parent table out of the way
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", parentTableName, legacyTableName),
+		// Create a new parent table partitioned by range of timestamp
+		fmt.Sprintf(
+			"CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS) PARTITION BY RANGE (timestamp)",
+			parentTableName,
+			legacyTableName,
+		),
+		// Create the default partition so late inserts never fail
+		fmt.Sprintf(
+			"CREATE TABLE %s PARTITION OF %s DEFAULT",
+			getDefaultPartitionName(parentTableName),
+			parentTableName,
+		),
+	}
+
+	// Detach sub tables from the old parent and attach them as partitions
+	for _, subTable := range subTables {
+		from, err := getSubTableDate(parentTableName, subTable.Name)
+		if err != nil {
+			tx.Rollback() // rollback the transaction
+			return fmt.Errorf("Error parsing sub table %s date: %s", subTable.Name, err)
+		}
+		statements = append(
+			statements,
+			fmt.Sprintf("ALTER TABLE %s NO INHERIT %s", subTable.Name, legacyTableName),
+			fmt.Sprintf(
+				"ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')",
+				parentTableName,
+				subTable.Name,
+				util.FormatTime(from),
+				util.FormatTime(from.Add(24*time.Hour)),
+			),
+		)
+	}
+
+	statements = append(
+		statements,
+		// Rows stored directly in the old parent table are routed to partitions
+		fmt.Sprintf(
+			"INSERT INTO %s (reference_id, timestamp, value, failed) SELECT reference_id, timestamp, value, failed FROM ONLY %s",
+			parentTableName,
+			legacyTableName,
+		),
+		fmt.Sprintf("DROP TABLE %s", legacyTableName),
+		// Indexes of partitioned tables are created on all partitions
+		fmt.Sprintf("CREATE INDEX idx_%s_reference_id ON %s (reference_id)", parentTableName, parentTableName),
+		fmt.Sprintf("CREATE INDEX idx_%s_timestamp ON %s (timestamp)", parentTableName, parentTableName),
+	)
+
+	for _, sql := range statements {
+		if err := tx.Exec(sql).Error; err != nil {
+			tx.Rollback() // rollback the transaction
+			return fmt.Errorf("Error partitioning %s table: %s", parentTableName, err)
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return fmt.Errorf("Error partitioning %s table: %s", parentTableName, err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0004 adds timing phases to response times
+func migrate0004(db *gorm.DB) error {
+	migrationName := "metrics_add_timing_phases"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Add a column per phase, columns added to a partitioned
+	// table are added to all of its partitions
+	for _, phase := range []string{PhaseDNS, PhaseConnect, PhaseTLS, PhaseFirstByte, PhaseTransfer} {
+		column := phaseColumn(phase)
+		if db.Dialect().HasColumn(ResponseTimeParentTableName, column) {
+			continue
+		}
+		sql := fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN %s BIGINT NOT NULL DEFAULT 0",
+			ResponseTimeParentTableName,
+			column,
+		)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("Error adding %s column: %s", column, err)
+		}
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
+
+// Migrate0005 creates a partitioned check results table
+func migrate0005(db *gorm.DB) error {
+	migrationName := "metrics_add_check_results"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	var (
+		parentTableName   = CheckResultParentTableName
+		templateTableName = fmt.Sprintf("%s_template", parentTableName)
+	)
+
+	// Begin a transaction
+	tx := db.Begin()
+
+	// Create a template table to copy columns from
+	if err := tx.CreateTable(&CheckResult{Table: templateTableName}).Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return fmt.Errorf("Error creating %s table: %s", templateTableName, err)
+	}
+
+	statements := []string{
+		// Create the parent table partitioned by range of timestamp
+		fmt.Sprintf(
+			"CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS) PARTITION BY RANGE (timestamp)",
+			parentTableName,
+			templateTableName,
+		),
+		fmt.Sprintf("DROP TABLE %s", templateTableName),
+		// Create the default partition so late inserts never fail
+		fmt.Sprintf(
+			"CREATE TABLE %s PARTITION OF %s DEFAULT",
+			getDefaultPartitionName(parentTableName),
+			parentTableName,
+		),
+		// Indexes of partitioned tables are created on all partitions
+		fmt.Sprintf(
+			"CREATE INDEX idx_%s_reference_id_timestamp ON %s (reference_id, timestamp)",
+			parentTableName,
+			parentTableName,
+		),
+	}
+
+	for _, sql := range statements {
+		if err := tx.Exec(sql).Error; err != nil {
+			tx.Rollback() // rollback the transaction
+			return fmt.Errorf("Error creating %s table: %s", parentTableName, err)
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return fmt.Errorf("Error creating %s table: %s", parentTableName, err)
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
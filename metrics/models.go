@@ -9,6 +9,16 @@ import (
 // ResponseTimeParentTableName defines request time parent table name
 const ResponseTimeParentTableName = "metrics_response_times"
 
+// CheckResultParentTableName defines check result parent table name
+const CheckResultParentTableName = "metrics_check_results"
+
+const (
+	// RollupPeriodHour is used for hourly rollups
+	RollupPeriodHour = "hour"
+	// RollupPeriodDay is used for daily rollups
+	RollupPeriodDay = "day"
+)
+
 // SubTable keeps track of all result sub tables
 type SubTable struct {
 	gorm.Model
@@ -21,13 +31,20 @@ func (t *SubTable) TableName() string {
 	return "metrics_sub_tables"
 }
 
-// ResponseTime represents a parent table used to vertically partition request times,
-// sub tables will inherit from this table and split data by day
+// ResponseTime represents a parent table used to partition request times by
+// range of timestamp, sub tables are partitions splitting data by day
 type ResponseTime struct {
-	ReferenceID uint      `sql:"index;not null"`
-	Timestamp   time.Time `sql:"index;not null"`
-	Value       int64     // request time in nanoseconds
-	Table       string    `sql:"-"` // ignore this field
+	ReferenceID  uint               `sql:"index;not null"`
+	Timestamp    time.Time          `sql:"index;not null"`
+	Value        int64              // request time in nanoseconds
+	Failed       bool               `sql:"default:false;not null"`
+	DNSLookup    int64              `sql:"default:0;not null"` // nanoseconds
+	Connect      int64              `sql:"default:0;not null"` // nanoseconds
+	TLSHandshake int64              `sql:"default:0;not null"` // nanoseconds
+	FirstByte    int64              `sql:"default:0;not null"` // nanoseconds
+	Transfer     int64              `sql:"default:0;not null"` // nanoseconds
+	Aggregations map[string]float64 `sql:"-"`                  // only set when aggregating by date_trunc
+	Table        string             `sql:"-"`                  // ignore this field
 }
 
 // TableName specifies table name
@@ -47,3 +64,118 @@ func NewResponseTime(table string, referenceID uint, timestamp time.Time, value
 		Table:       table,
 	}
 }
+
+// columns returns columns written by the buffered writer
+func (r *ResponseTime) columns() []string {
+	return []string{
+		"reference_id",
+		"timestamp",
+		"value",
+		"failed",
+		"dns_lookup",
+		"connect",
+		"tls_handshake",
+		"first_byte",
+		"transfer",
+	}
+}
+
+// values returns values written by the buffered writer
+func (r *ResponseTime) values() []interface{} {
+	return []interface{}{
+		r.ReferenceID,
+		r.Timestamp,
+		r.Value,
+		r.Failed,
+		r.DNSLookup,
+		r.Connect,
+		r.TLSHandshake,
+		r.FirstByte,
+		r.Transfer,
+	}
+}
+
+// ResponseTimeRollup is an hourly or daily summary of response times,
+// rollups are computed before sub tables are rotated and kept for long
+// term history, percentiles of coarser buckets are approximated
+type ResponseTimeRollup struct {
+	ReferenceID     uint      `sql:"not null"`
+	Period          string    `sql:"type:varchar(10);not null"`
+	Timestamp       time.Time `sql:"not null"`
+	SampleCount     int64     `sql:"not null"`
+	FailureCount    int64     `sql:"not null"`
+	ValueSum        float64   `sql:"not null"`
+	ValueSumSquares float64   `sql:"not null"`
+	MinValue        int64     `sql:"not null"`
+	MaxValue        int64     `sql:"not null"`
+	P50             float64   `sql:"not null"`
+	P90             float64   `sql:"not null"`
+	P95             float64   `sql:"not null"`
+	P99             float64   `sql:"not null"`
+}
+
+// TableName specifies table name
+func (r *ResponseTimeRollup) TableName() string {
+	return "metrics_response_time_rollups"
+}
+
+// CheckResult is a log entry of a single alarm check, check results are
+// partitioned by range of timestamp the same way as response times
+type CheckResult struct {
+	ReferenceID  uint      `sql:"not null"`
+	Timestamp    time.Time `sql:"not null"`
+	Region       string    `sql:"type:varchar(20);not null"`
+	StatusCode   int       `sql:"default:0;not null"` // zero if there was no response
+	ResponseSize int64     `sql:"default:0;not null"` // bytes
+	Outcome      string    `sql:"type:varchar(20);not null"`
+	Error        string    `sql:"type:text"`
+	Table        string    `sql:"-"` // ignore this field
+}
+
+// TableName specifies table name
+func (r *CheckResult) TableName() string {
+	if r.Table == "" {
+		return CheckResultParentTableName
+	}
+	return r.Table
+}
+
+// NewCheckResult creates new CheckResult instance
+func NewCheckResult(table string, referenceID uint, timestamp time.Time, region string, statusCode int, responseSize int64, outcome, errMsg string) *CheckResult {
+	return &CheckResult{
+		ReferenceID:  referenceID,
+		Timestamp:    timestamp,
+		Region:       region,
+		StatusCode:   statusCode,
+		ResponseSize: responseSize,
+		Outcome:      outcome,
+		Error:        errMsg,
+		Table:        table,
+	}
+}
+
+// columns returns columns written by the buffered writer
+func (r *CheckResult) columns() []string {
+	return []string{
+		"reference_id",
+		"timestamp",
+		"region",
+		"status_code",
+		"response_size",
+		"outcome",
+		"error",
+	}
+}
+
+// values returns values written by the buffered writer
+func (r *CheckResult) values() []interface{} {
+	return []interface{}{
+		r.ReferenceID,
+		r.Timestamp,
+		r.Region,
+		r.StatusCode,
+		r.ResponseSize,
+		r.Outcome,
+		r.Error,
+	}
+}
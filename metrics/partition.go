@@ -55,39 +55,48 @@ func (s *Service) PartitionResponseTime(parentTableName string, now time.Time) e
 	return nil
 }
 
-// createResponseTimeSubTable creates a new request time sub table inheriting
-// from the parent table with a check constraint to limit span of the data
-// to a period of time
+// createResponseTimeSubTable creates a new request time partition of the
+// parent table for a period of time, rows which have already landed
+// in the default partition are moved to the new partition
 func (s *Service) createResponseTimeSubTable(parentTableName, subTableName string, from, to time.Time) (*SubTable, error) {
 	// Begin a transaction
 	tx := s.db.Begin()
 
-	// Let's create a new request time sub table
-	ResponseTimeTable := &ResponseTime{Table: subTableName}
-	if err := tx.CreateTable(ResponseTimeTable).Error; err != nil {
+	var sql string
+
+	// Create the partition as a standalone table first
+	sql = fmt.Sprintf(
+		"CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS)",
+		subTableName,
+		parentTableName,
+	)
+	if err := tx.Exec(sql).Error; err != nil {
 		tx.Rollback() // rollback the transaction
 		return nil, err
 	}
 
-	var sql string
-
-	// Add CHECK CONSTRAINT to limit the sub table's span to just one day
+	// Move late inserts from the default partition, otherwise attaching
+	// the partition would fail as the range would overlap with them
 	sql = fmt.Sprintf(
-		"ALTER TABLE %s ADD CONSTRAINT timestamp_check CHECK (timestamp >= '%s' AND timestamp < '%s') NO INHERIT",
-		subTableName,
+		"WITH moved AS (DELETE FROM %s WHERE timestamp >= '%s' AND timestamp < '%s' RETURNING *) INSERT INTO %s SELECT * FROM moved",
+		getDefaultPartitionName(parentTableName),
 		util.FormatTime(from),
 		util.FormatTime(to),
+		subTableName,
 	)
 	if err := tx.Exec(sql).Error; err != nil {
 		tx.Rollback() // rollback the transaction
 		return nil, err
 	}
 
-	// Inherit from the parent table
+	// Attach as a partition of the parent table, indexes are created
+	// automatically based on the parent table indexes
 	sql = fmt.Sprintf(
-		"ALTER TABLE %s INHERIT %s",
-		subTableName,
+		"ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')",
 		parentTableName,
+		subTableName,
+		util.FormatTime(from),
+		util.FormatTime(to),
 	)
 	if err := tx.Exec(sql).Error; err != nil {
 		tx.Rollback() // rollback the transaction
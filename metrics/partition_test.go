@@ -148,3 +148,40 @@ func (suite *MetricsTestSuite) TestCreateResponseTimeSubTable() {
 	assert.Equal(suite.T(), int64(321), ResponseTimes[0].Value)
 	assert.Equal(suite.T(), int64(432), ResponseTimes[1].Value)
 }
+
+func (suite *MetricsTestSuite) TestLateInsertsMovedFromDefaultPartition() {
+	var (
+		today             = time.Date(2016, time.February, 9, 0, 0, 0, 0, time.UTC)
+		todaySubTableName = "metrics_response_times_2016_02_09"
+		defaultPartition  = getDefaultPartitionName(ResponseTimeParentTableName)
+		count             int
+		err               error
+	)
+
+	// There is no partition yet but the insert should not fail
+	err = suite.service.LogResponseTime(today.Add(time.Hour), 1, 123, false, nil)
+	assert.NoError(suite.T(), err)
+	err = suite.service.FlushResponseTimes()
+	assert.NoError(suite.T(), err)
+
+	// The row should have landed in the default partition
+	suite.db.Table(defaultPartition).Count(&count)
+	assert.Equal(suite.T(), 1, count)
+
+	// Partitioning should move the row to the new partition
+	err = suite.service.PartitionResponseTime(ResponseTimeParentTableName, today)
+	assert.NoError(suite.T(), err, "Partitioning table failed")
+
+	suite.db.Table(defaultPartition).Count(&count)
+	assert.Equal(suite.T(), 0, count)
+	suite.db.Table(todaySubTableName).Count(&count)
+	assert.Equal(suite.T(), 1, count)
+
+	// New rows are routed to the partition
+	err = suite.service.LogResponseTime(today.Add(2*time.Hour), 1, 234, false, nil)
+	assert.NoError(suite.T(), err)
+	err = suite.service.FlushResponseTimes()
+	assert.NoError(suite.T(), err)
+	suite.db.Table(todaySubTableName).Count(&count)
+	assert.Equal(suite.T(), 2, count)
+}
@@ -3,22 +3,44 @@ package metrics
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/RichardKnop/pinglist-api/pagination"
 )
 
+// DateTruncFiveMinutes is not supported by Postgres DATE_TRUNC natively
+const DateTruncFiveMinutes = "5-minute"
+
 // AllowedDateTruncMap ...
 var AllowedDateTruncMap = map[string]bool{
-	"hour": true,
-	"day":  true,
+	"minute":             true,
+	DateTruncFiveMinutes: true,
+	"hour":               true,
+	"day":                true,
+	"week":               true,
+	"month":              true,
 }
 
 // ErrInvalidDateTrunc ...
-var ErrInvalidDateTrunc = errors.New("Invalid date_trunc value. Use one of: hour, day")
+var ErrInvalidDateTrunc = errors.New("Invalid date_trunc value. Use one of: minute, 5-minute, hour, day, week, month")
+
+// ErrInvalidAggregation ...
+var ErrInvalidAggregation = errors.New("Invalid aggregations value. Use a comma separated list of: avg, min, max, count, stddev, p50, p90, p95, p99")
+
+// ErrInvalidPhase ...
+var ErrInvalidPhase = errors.New("Invalid phase value. Use one of: total, dns, connect, tls, ttfb, transfer")
+
+// ErrInvalidOutcome ...
+var ErrInvalidOutcome = errors.New("Invalid outcome value. Use one of: success, slow, timeout, bad code, other")
 
-// GetParamsFromQueryString parses querystring and returns params
-func GetParamsFromQueryString(r *http.Request) (string, *time.Time, *time.Time, error) {
+// ErrInvalidAlarmID ...
+var ErrInvalidAlarmID = errors.New("Invalid alarm_id value")
+
+// GetParamsFromQueryString parses querystring and returns params,
+// from is clamped to the retention period unless retention is zero
+func GetParamsFromQueryString(r *http.Request, retention time.Duration) (string, *time.Time, *time.Time, error) {
 	var (
 		dateTrunc string
 		from, to  *time.Time
@@ -30,6 +52,14 @@ func GetParamsFromQueryString(r *http.Request) (string, *time.Time, *time.Time,
 		return "", nil, nil, err
 	}
 
+	// Older metrics are not available for the user's plan
+	if retention > 0 && from != nil {
+		minFrom := time.Now().Add(-retention)
+		if from.Before(minFrom) {
+			from = &minFrom
+		}
+	}
+
 	// Get "date_trunc" param from the querystring
 	if r.URL.Query().Get("date_trunc") != "" {
 		dateTrunc = r.URL.Query().Get("date_trunc")
@@ -40,3 +70,76 @@ func GetParamsFromQueryString(r *http.Request) (string, *time.Time, *time.Time,
 
 	return dateTrunc, from, to, nil
 }
+
+// GetAggregationsFromQueryString parses a comma separated list
+// of aggregations from querystring (e.g. aggregations=p50,p95,max)
+func GetAggregationsFromQueryString(r *http.Request) ([]string, error) {
+	if r.URL.Query().Get("aggregations") == "" {
+		return nil, nil
+	}
+
+	var (
+		aggregations []string
+		seen         = make(map[string]bool)
+	)
+	for _, aggregation := range strings.Split(r.URL.Query().Get("aggregations"), ",") {
+		aggregation = strings.ToLower(strings.TrimSpace(aggregation))
+		if _, ok := aggregationExpressions[aggregation]; !ok {
+			return nil, ErrInvalidAggregation
+		}
+		if seen[aggregation] {
+			continue
+		}
+		seen[aggregation] = true
+		aggregations = append(aggregations, aggregation)
+	}
+
+	return aggregations, nil
+}
+
+// GetPhaseFromQueryString parses a phase of response times from querystring,
+// total response time is returned when the phase is not specified
+func GetPhaseFromQueryString(r *http.Request) (string, error) {
+	phase := r.URL.Query().Get("phase")
+	if phase == "" {
+		return PhaseTotal, nil
+	}
+	if _, ok := phaseColumns[phase]; !ok {
+		return "", ErrInvalidPhase
+	}
+	return phase, nil
+}
+
+// GetOutcomeFromQueryString parses an optional check outcome from querystring
+func GetOutcomeFromQueryString(r *http.Request) (string, error) {
+	outcome := r.URL.Query().Get("outcome")
+	if outcome != "" && !AllowedCheckOutcomesMap[outcome] {
+		return "", ErrInvalidOutcome
+	}
+	return outcome, nil
+}
+
+// GetSeriesParamsFromQueryString parses alarm IDs (either repeated alarm_id
+// params or a comma separated list) and group_by from querystring
+func GetSeriesParamsFromQueryString(r *http.Request) ([]uint, string, error) {
+	var alarmIDs []uint
+	for _, value := range r.URL.Query()["alarm_id"] {
+		for _, part := range strings.Split(value, ",") {
+			if part == "" {
+				continue
+			}
+			alarmID, err := strconv.Atoi(part)
+			if err != nil || alarmID < 1 {
+				return nil, "", ErrInvalidAlarmID
+			}
+			alarmIDs = append(alarmIDs, uint(alarmID))
+		}
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy != "" && !AllowedGroupByMap[groupBy] {
+		return nil, "", ErrInvalidGroupBy
+	}
+
+	return alarmIDs, groupBy, nil
+}
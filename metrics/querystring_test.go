@@ -21,7 +21,7 @@ func TestGetParamsFromQueryString(t *testing.T) {
 	// Let's try without any params first
 	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar", nil)
 	assert.NoError(t, err, "Request setup should not get an error")
-	dateTrunc, from, to, err = GetParamsFromQueryString(r)
+	dateTrunc, from, to, err = GetParamsFromQueryString(r, 0)
 
 	// Check error is nil and correct values were returned
 	if assert.NoError(t, err) {
@@ -45,7 +45,7 @@ func TestGetParamsFromQueryString(t *testing.T) {
 		nil,
 	)
 	assert.NoError(t, err, "Request setup should not get an error")
-	dateTrunc, from, to, err = GetParamsFromQueryString(r)
+	dateTrunc, from, to, err = GetParamsFromQueryString(r, 0)
 
 	// Check error is nil and correct values were returned
 	if assert.NoError(t, err) {
@@ -57,7 +57,7 @@ func TestGetParamsFromQueryString(t *testing.T) {
 	// Let's try with invalid date_trunc
 	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?date_trunc=bogus", nil)
 	assert.NoError(t, err, "Request setup should not get an error")
-	dateTrunc, from, to, err = GetParamsFromQueryString(r)
+	dateTrunc, from, to, err = GetParamsFromQueryString(r, 0)
 
 	// Check correct error and zero values were returned
 	if assert.Error(t, err) {
@@ -67,3 +67,183 @@ func TestGetParamsFromQueryString(t *testing.T) {
 		assert.Nil(t, to)
 	}
 }
+
+func TestGetSeriesParamsFromQueryString(t *testing.T) {
+	var (
+		r        *http.Request
+		alarmIDs []uint
+		groupBy  string
+		err      error
+	)
+
+	// Let's try without any params first
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	alarmIDs, groupBy, err = GetSeriesParamsFromQueryString(r)
+
+	// Check error is nil and zero values were returned
+	if assert.NoError(t, err) {
+		assert.Nil(t, alarmIDs)
+		assert.Equal(t, "", groupBy)
+	}
+
+	// Repeated and comma separated alarm IDs can be combined
+	r, err = http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/foobar?alarm_id=1,2&alarm_id=5&group_by=region",
+		nil,
+	)
+	assert.NoError(t, err, "Request setup should not get an error")
+	alarmIDs, groupBy, err = GetSeriesParamsFromQueryString(r)
+
+	// Check error is nil and correct values were returned
+	if assert.NoError(t, err) {
+		assert.Equal(t, []uint{1, 2, 5}, alarmIDs)
+		assert.Equal(t, GroupByRegion, groupBy)
+	}
+
+	// Let's try with invalid alarm ID
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?alarm_id=bogus", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	_, _, err = GetSeriesParamsFromQueryString(r)
+	assert.Equal(t, ErrInvalidAlarmID, err)
+
+	// Let's try with invalid group_by
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?group_by=bogus", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	_, _, err = GetSeriesParamsFromQueryString(r)
+	assert.Equal(t, ErrInvalidGroupBy, err)
+}
+
+func TestGetAggregationsFromQueryString(t *testing.T) {
+	var (
+		r            *http.Request
+		aggregations []string
+		err          error
+	)
+
+	// Let's try without any params first
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	aggregations, err = GetAggregationsFromQueryString(r)
+
+	// Check error is nil and no aggregations were returned
+	if assert.NoError(t, err) {
+		assert.Nil(t, aggregations)
+	}
+
+	// Aggregations are normalised and duplicates ignored
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?aggregations=P95,max,p95,stddev", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	aggregations, err = GetAggregationsFromQueryString(r)
+
+	// Check error is nil and correct values were returned
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{AggregationP95, AggregationMax, AggregationStdDev}, aggregations)
+	}
+
+	// Let's try with invalid aggregation
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?aggregations=p50,p42", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	aggregations, err = GetAggregationsFromQueryString(r)
+
+	// Check correct error and zero value were returned
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrInvalidAggregation, err)
+		assert.Nil(t, aggregations)
+	}
+}
+
+func TestGetParamsFromQueryStringDateTruncs(t *testing.T) {
+	for _, dateTrunc := range []string{"minute", "5-minute", "hour", "day", "week", "month"} {
+		r, err := http.NewRequest("GET", "http://1.2.3.4/v1/foobar?date_trunc="+dateTrunc, nil)
+		assert.NoError(t, err, "Request setup should not get an error")
+		actual, _, _, err := GetParamsFromQueryString(r, 0)
+		if assert.NoError(t, err) {
+			assert.Equal(t, dateTrunc, actual)
+		}
+	}
+}
+
+func TestGetParamsFromQueryStringRetention(t *testing.T) {
+	r, err := http.NewRequest(
+		"GET",
+		"http://1.2.3.4/v1/foobar?from=2016-02-08T00:00:00Z&to=2016-03-08T00:00:00Z",
+		nil,
+	)
+	assert.NoError(t, err, "Request setup should not get an error")
+
+	// From should be clamped to the retention period
+	before := time.Now().Add(-24 * time.Hour)
+	_, from, to, err := GetParamsFromQueryString(r, 24*time.Hour)
+	if assert.NoError(t, err) {
+		assert.False(t, from.Before(before))
+		assert.Equal(t, "2016-03-08T00:00:00Z", util.FormatTime(*to))
+	}
+}
+
+func TestGetPhaseFromQueryString(t *testing.T) {
+	var (
+		r     *http.Request
+		phase string
+		err   error
+	)
+
+	// Total response time is the default
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	phase, err = GetPhaseFromQueryString(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, PhaseTotal, phase)
+	}
+
+	// Let's try with a valid phase
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?phase=ttfb", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	phase, err = GetPhaseFromQueryString(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, PhaseFirstByte, phase)
+	}
+
+	// Let's try with invalid phase
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?phase=value", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	phase, err = GetPhaseFromQueryString(r)
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrInvalidPhase, err)
+		assert.Equal(t, "", phase)
+	}
+}
+
+func TestGetOutcomeFromQueryString(t *testing.T) {
+	var (
+		r       *http.Request
+		outcome string
+		err     error
+	)
+
+	// No outcome means no filtering
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	outcome, err = GetOutcomeFromQueryString(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", outcome)
+	}
+
+	// Let's try with a valid outcome
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?outcome=bad+code", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	outcome, err = GetOutcomeFromQueryString(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "bad code", outcome)
+	}
+
+	// Let's try with invalid outcome
+	r, err = http.NewRequest("GET", "http://1.2.3.4/v1/foobar?outcome=failed", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	outcome, err = GetOutcomeFromQueryString(r)
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrInvalidOutcome, err)
+		assert.Equal(t, "", outcome)
+	}
+}
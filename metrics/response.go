@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/RichardKnop/jsonhal"
@@ -10,8 +11,35 @@ import (
 // MetricResponse ...
 type MetricResponse struct {
 	jsonhal.Hal
-	Timestamp string `json:"timestamp"`
-	Value     int64  `json:"value"`
+	Timestamp    string             `json:"timestamp"`
+	Value        int64              `json:"value"`
+	Aggregations map[string]float64 `json:"aggregations,omitempty"`
+	Timings      *TimingsResponse   `json:"timings,omitempty"`
+}
+
+// TimingsResponse ...
+type TimingsResponse struct {
+	DNSLookup    int64 `json:"dns_lookup"`
+	Connect      int64 `json:"connect"`
+	TLSHandshake int64 `json:"tls_handshake"`
+	FirstByte    int64 `json:"first_byte"`
+	Transfer     int64 `json:"transfer"`
+}
+
+// NewTimingsResponse creates new TimingsResponse instance, nil is returned
+// for aggregated response times and samples recorded without timings
+func NewTimingsResponse(responseTime *ResponseTime) *TimingsResponse {
+	response := &TimingsResponse{
+		DNSLookup:    responseTime.DNSLookup,
+		Connect:      responseTime.Connect,
+		TLSHandshake: responseTime.TLSHandshake,
+		FirstByte:    responseTime.FirstByte,
+		Transfer:     responseTime.Transfer,
+	}
+	if *response == (TimingsResponse{}) {
+		return nil
+	}
+	return response
 }
 
 // NewMetricResponse creates new MetricResponse instance
@@ -21,3 +49,103 @@ func NewMetricResponse(timestamp time.Time, value int64) (*MetricResponse, error
 		Value:     value,
 	}, nil
 }
+
+// CheckResultResponse ...
+type CheckResultResponse struct {
+	jsonhal.Hal
+	Timestamp    string `json:"timestamp"`
+	Region       string `json:"region"`
+	StatusCode   int    `json:"status_code"`
+	ResponseSize int64  `json:"response_size"`
+	Outcome      string `json:"outcome"`
+	Error        string `json:"error"`
+}
+
+// NewCheckResultResponse creates new CheckResultResponse instance
+func NewCheckResultResponse(checkResult *CheckResult) (*CheckResultResponse, error) {
+	return &CheckResultResponse{
+		Timestamp:    util.FormatTime(checkResult.Timestamp),
+		Region:       checkResult.Region,
+		StatusCode:   checkResult.StatusCode,
+		ResponseSize: checkResult.ResponseSize,
+		Outcome:      checkResult.Outcome,
+		Error:        checkResult.Error,
+	}, nil
+}
+
+// SeriesResponse ...
+type SeriesResponse struct {
+	jsonhal.Hal
+	Key      string `json:"key"`
+	AlarmIDs []uint `json:"alarm_ids"`
+}
+
+// ListResponseTimeSeriesResponse ...
+type ListResponseTimeSeriesResponse struct {
+	jsonhal.Hal
+	GroupBy   string `json:"group_by"`
+	DateTrunc string `json:"date_trunc"`
+	Phase     string `json:"phase"`
+}
+
+// NewListResponseTimeSeriesResponse creates new ListResponseTimeSeriesResponse instance
+func NewListResponseTimeSeriesResponse(self, groupBy, dateTrunc, phase string, series []*Series) (*ListResponseTimeSeriesResponse, error) {
+	response := &ListResponseTimeSeriesResponse{
+		GroupBy:   groupBy,
+		DateTrunc: dateTrunc,
+		Phase:     phase,
+	}
+
+	// Set the self link
+	response.SetLink("self", self, "")
+
+	// Create slice of series responses
+	seriesResponses := make([]*SeriesResponse, len(series))
+	for i, s := range series {
+		seriesResponse := &SeriesResponse{
+			Key:      s.Key,
+			AlarmIDs: s.ReferenceIDs,
+		}
+		if seriesResponse.AlarmIDs == nil {
+			seriesResponse.AlarmIDs = []uint{}
+		}
+
+		// Link single alarm series to the alarm
+		if len(s.ReferenceIDs) == 1 {
+			seriesResponse.SetLink(
+				"alarm", // name
+				fmt.Sprintf("/v1/alarms/%d", s.ReferenceIDs[0]), // href
+				"", // title
+			)
+		}
+
+		// Create slice of metric responses
+		metricResponses := make([]*MetricResponse, len(s.ResponseTimes))
+		for j, responseTime := range s.ResponseTimes {
+			metricResponse, err := NewMetricResponse(
+				responseTime.Timestamp,
+				responseTime.Value,
+			)
+			if err != nil {
+				return nil, err
+			}
+			metricResponses[j] = metricResponse
+		}
+
+		// Set embedded response times
+		seriesResponse.SetEmbedded(
+			"response_times",
+			jsonhal.Embedded(metricResponses),
+		)
+
+		seriesResponses[i] = seriesResponse
+	}
+
+	// Set embedded series
+	response.SetEmbedded(
+		"series",
+		jsonhal.Embedded(seriesResponses),
+	)
+
+	return response, nil
+}
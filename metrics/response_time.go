@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
@@ -8,28 +9,45 @@ import (
 	"github.com/jinzhu/gorm"
 )
 
-// LogResponseTime logs request time metric
-func (s *Service) LogResponseTime(timestamp time.Time, referenceID uint, value int64) error {
+// LogResponseTime queues request time metric to be written in a batch by
+// the buffered writer so checks don't have to wait for the database
+func (s *Service) LogResponseTime(timestamp time.Time, referenceID uint, value int64, failed bool, timings *Timings) error {
 	ResponseTimeRecord := NewResponseTime(
-		getSubTableName(ResponseTimeParentTableName, timestamp),
+		ResponseTimeParentTableName,
 		referenceID,
 		timestamp,
 		value,
 	)
-	return s.db.Create(ResponseTimeRecord).Error
+	ResponseTimeRecord.Failed = failed
+	ResponseTimeRecord.setTimings(timings)
+	return s.writer.Write(ResponseTimeRecord)
 }
 
 // ResponseTimesCount returns a total count of response time records
-func (s *Service) ResponseTimesCount(referenceID int, dateTrunc string, from, to *time.Time) (int, error) {
+func (s *Service) ResponseTimesCount(referenceID int, dateTrunc, phase string, from, to *time.Time) (int, error) {
 	var count int
 
 	// Get the pagination query
-	query := s.responseTimesQuery(referenceID, from, to)
+	query := s.responseTimesQuery(referenceIDsFilter(referenceID), from, to)
 
 	// Are we aggregating data based on some time period (e.g. hourly / daily averages)?
 	if dateTrunc != "" {
+		// Older data may have been rolled up, count buckets of rollups too
+		boundary, err := s.rollupBoundary(dateTrunc, phase, from)
+		if err != nil {
+			return 0, err
+		}
+		if boundary != nil {
+			bucketsSQL, args := responseTimeBucketsSQL(referenceIDsFilter(referenceID), dateTrunc, from, to)
+			countSQL := fmt.Sprintf("SELECT COUNT(DISTINCT t) FROM (%s) buckets", bucketsSQL)
+			if err := s.db.Raw(countSQL, args...).Row().Scan(&count); err != nil {
+				return 0, err
+			}
+			return count, nil
+		}
+
 		query = query.
-			Select(fmt.Sprintf("COUNT(DISTINCT(date_trunc('%s', timestamp at time zone 'Z')))", dateTrunc))
+			Select(fmt.Sprintf("COUNT(DISTINCT(%s))", dateTruncExpression(dateTrunc)))
 		if err := query.Row().Scan(&count); err != nil {
 			return 0, err
 		}
@@ -48,22 +66,39 @@ func (s *Service) ResponseTimesCount(referenceID int, dateTrunc string, from, to
 // - date_trunc (day, hour etc)
 // - from
 // - to
-func (s *Service) FindPaginatedResponseTimes(offset, limit int, orderBy string, referenceID int, dateTrunc string, from, to *time.Time) ([]*ResponseTime, error) {
+// When aggregating by date_trunc, the value is an average and further
+// aggregations (percentiles, min, max etc) can be requested, the value
+// can be either the total response time or one of its phases (dns, ttfb etc)
+func (s *Service) FindPaginatedResponseTimes(offset, limit int, orderBy string, referenceID int, dateTrunc, phase string, aggregations []string, from, to *time.Time) ([]*ResponseTime, error) {
 	var responseTimes []*ResponseTime
 
 	// Get the pagination query
-	query := s.responseTimesQuery(referenceID, from, to)
+	query := s.responseTimesQuery(referenceIDsFilter(referenceID), from, to)
 
 	// Default ordering
 	if orderBy == "" {
 		orderBy = "timestamp"
 	}
 
+	// Older data may have been rolled up, read rollups transparently
+	if dateTrunc != "" {
+		boundary, err := s.rollupBoundary(dateTrunc, phase, from)
+		if err != nil {
+			return responseTimes, err
+		}
+		if boundary != nil {
+			return s.findPaginatedResponseTimeRollups(offset, limit, orderBy, referenceID, dateTrunc, aggregations, from, to)
+		}
+	}
+
 	// Are we aggregating data based on some time period (e.g. hourly / daily averages)?
 	if dateTrunc != "" {
-		query = query.
-			Select("DATE_TRUNC(?, timestamp at time zone 'Z') t, AVG(value) avg", dateTrunc).
-			Group("t")
+		column := phaseColumn(phase)
+		selectClause := fmt.Sprintf("%s t, AVG(%s) avg", dateTruncExpression(dateTrunc), column)
+		if len(aggregations) > 0 {
+			selectClause = fmt.Sprintf("%s, %s", selectClause, aggregationsSelect(aggregations, column))
+		}
+		query = query.Select(selectClause).Group("t")
 		// This is needed because if we use "timestamp" in ORDER BY clause,
 		// since timestamp is not present in our aggregate function there is an error:
 		// ERROR:  column "metrics_response_times.timestamp" must appear in the GROUP BY clause or be used in an aggregate function
@@ -78,6 +113,9 @@ func (s *Service) FindPaginatedResponseTimes(offset, limit int, orderBy string,
 		if err := query.Find(&responseTimes).Error; err != nil {
 			return responseTimes, err
 		}
+		for _, responseTime := range responseTimes {
+			responseTime.Value = responseTime.phaseValue(phase)
+		}
 		return responseTimes, nil
 	}
 
@@ -93,32 +131,104 @@ func (s *Service) FindPaginatedResponseTimes(offset, limit int, orderBy string,
 		var (
 			timestamp time.Time
 			value     float64
+			values    = make([]sql.NullFloat64, len(aggregations))
 		)
 
 		// Scan the data into our vars
-		if err := rows.Scan(&timestamp, &value); err != nil {
+		dest := append([]interface{}{&timestamp, &value}, aggregationsDest(values)...)
+		if err := rows.Scan(dest...); err != nil {
 			return responseTimes, err
 		}
 
 		// Append correct object to our return slice
-		responseTimes = append(responseTimes, &ResponseTime{
+		responseTime := &ResponseTime{
 			Timestamp: timestamp,
 			Value:     int64(value),
-		})
+		}
+		if len(aggregations) > 0 {
+			responseTime.Aggregations = aggregationsMap(aggregations, values)
+		}
+		responseTimes = append(responseTimes, responseTime)
 	}
 
 	return responseTimes, nil
 }
 
+// LastResponseTimes returns the most recent response time record of each
+// reference logged since the given time, keyed by reference ID
+func (s *Service) LastResponseTimes(referenceIDs []uint, since time.Time) (map[uint]*ResponseTime, error) {
+	lastResponseTimes := make(map[uint]*ResponseTime)
+	if len(referenceIDs) == 0 {
+		return lastResponseTimes, nil
+	}
+
+	var responseTimes []*ResponseTime
+	err := s.responseTimesQuery(referenceIDs, &since, nil).
+		Select("DISTINCT ON (reference_id) *").
+		Order("reference_id, timestamp desc").
+		Find(&responseTimes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, responseTime := range responseTimes {
+		lastResponseTimes[responseTime.ReferenceID] = responseTime
+	}
+
+	return lastResponseTimes, nil
+}
+
+// findPaginatedResponseTimeRollups returns paginated aggregated response
+// times combining rollups with raw data, buckets are merged and paginated
+// in the database
+func (s *Service) findPaginatedResponseTimeRollups(offset, limit int, orderBy string, referenceID int, dateTrunc string, aggregations []string, from, to *time.Time) ([]*ResponseTime, error) {
+	responseTimes := make([]*ResponseTime, 0)
+
+	bucketsSQL, args := responseTimeBucketsSQL(referenceIDsFilter(referenceID), dateTrunc, from, to)
+	query := mergedResponseTimeBucketsSQL(bucketsSQL)
+
+	// Order, offset and limit
+	if strings.Contains(strings.ToLower(orderBy), "desc") {
+		query += " ORDER BY t DESC"
+	} else {
+		query += " ORDER BY t"
+	}
+	query += " OFFSET ?"
+	args = append(args, offset)
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var timestamp time.Time
+		bucket, err := scanResponseTimeBucket(rows, &timestamp)
+		if err != nil {
+			return nil, err
+		}
+		responseTimes = append(responseTimes, bucket.responseTime(timestamp.UTC(), aggregations))
+	}
+
+	return responseTimes, rows.Err()
+}
+
 // responseTimesQuery returns a common part of db query for
 // fetching response time records
-func (s *Service) responseTimesQuery(referenceID int, from, to *time.Time) *gorm.DB {
+func (s *Service) responseTimesQuery(referenceIDs []uint, from, to *time.Time) *gorm.DB {
 	// Basic query
 	query := s.db.Model(new(ResponseTime))
 
-	// Optionally filter by reference ID
-	if referenceID > 0 {
-		query = query.Where("reference_id = ?", referenceID)
+	// Optionally filter by reference IDs
+	if len(referenceIDs) == 1 {
+		query = query.Where("reference_id = ?", referenceIDs[0])
+	} else if len(referenceIDs) > 1 {
+		query = query.Where("reference_id IN (?)", referenceIDs)
 	}
 
 	// Optionally limit timestamp to be greater or equal to
@@ -133,3 +243,11 @@ func (s *Service) responseTimesQuery(referenceID int, from, to *time.Time) *gorm
 
 	return query
 }
+
+// referenceIDsFilter converts an optional reference ID to a filter
+func referenceIDsFilter(referenceID int) []uint {
+	if referenceID > 0 {
+		return []uint{uint(referenceID)}
+	}
+	return nil
+}
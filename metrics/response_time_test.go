@@ -40,10 +40,11 @@ func (suite *MetricsTestSuite) TestPaginatedResponseTimesCount() {
 
 	// No filtering at all
 	count, err = suite.service.ResponseTimesCount(
-		0,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		0,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 6, count)
@@ -51,10 +52,11 @@ func (suite *MetricsTestSuite) TestPaginatedResponseTimesCount() {
 
 	// Filter by a valid reference ID
 	count, err = suite.service.ResponseTimesCount(
-		1,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		1,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 4, count)
@@ -62,10 +64,11 @@ func (suite *MetricsTestSuite) TestPaginatedResponseTimesCount() {
 
 	// Filter by a valid reference ID
 	count, err = suite.service.ResponseTimesCount(
-		2,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		2,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 2, count)
@@ -73,10 +76,11 @@ func (suite *MetricsTestSuite) TestPaginatedResponseTimesCount() {
 
 	// Filter by a bogus reference ID
 	count, err = suite.service.ResponseTimesCount(
-		3,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		3,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 0, count)
@@ -86,10 +90,11 @@ func (suite *MetricsTestSuite) TestPaginatedResponseTimesCount() {
 	from = yesterday.Add(2 * time.Hour)
 	to = today
 	count, err = suite.service.ResponseTimesCount(
-		0,     // reference ID
-		"",    // date trunc
-		&from, // from
-		&to,   // to
+		0,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		&from,      // from
+		&to,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 2, count)
@@ -97,10 +102,11 @@ func (suite *MetricsTestSuite) TestPaginatedResponseTimesCount() {
 
 	// Filter by "date_trunc" timestamp
 	count, err = suite.service.ResponseTimesCount(
-		0,     // reference ID
-		"day", // date trunc
-		nil,   // from
-		nil,   // to
+		0,          // reference ID
+		"day",      // date trunc
+		PhaseTotal, // phase
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 2, count)
@@ -140,13 +146,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 
 	// No filtering at all
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		0,   // offset
-		25,  // limit
-		"",  // order by
-		0,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		0,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 6, len(responseTimes))
@@ -154,13 +162,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 
 	// Filter by a valid reference ID
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		0,   // offset
-		25,  // limit
-		"",  // order by
-		1,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		1,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 4, len(responseTimes))
@@ -172,13 +182,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 
 	// Filter by a valid reference ID
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		0,   // offset
-		25,  // limit
-		"",  // order by
-		2,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		2,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 2, len(responseTimes))
@@ -188,13 +200,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 
 	// Filter by a bogus reference ID
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		0,   // offset
-		25,  // limit
-		"",  // order by
-		3,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		3,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 0, len(responseTimes))
@@ -204,13 +218,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 	from = yesterday.Add(2 * time.Hour)
 	to = today
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		0,     // offset
-		25,    // limit
-		"",    // order by
-		0,     // reference ID
-		"",    // date trunc
-		&from, // from
-		&to,   // to
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		0,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		&from,      // from
+		&to,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 2, len(responseTimes))
@@ -221,13 +237,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 
 	// Filter by "date_trunc" timestamp
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		0,     // offset
-		25,    // limit
-		"",    // order by
-		0,     // reference ID
-		"day", // date trunc
-		nil,   // from
-		nil,   // to
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		0,          // reference ID
+		"day",      // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 2, len(responseTimes))
@@ -247,13 +265,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 
 	// This should return all records
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		0,   // offset
-		25,  // limit
-		"",  // order by
-		0,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		0,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 6, len(responseTimes))
@@ -272,6 +292,8 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 		"timestamp desc", // order by
 		0,                // reference ID
 		"",               // date trunc
+		PhaseTotal,       // phase
+		nil,              // aggregations
 		nil,              // from
 		nil,              // to
 	)
@@ -287,13 +309,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 
 	// Test offset
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		3,   // offset
-		25,  // limit
-		"",  // order by
-		0,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		3,          // offset
+		25,         // limit
+		"",         // order by
+		0,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 3, len(responseTimes))
@@ -304,13 +328,15 @@ func (suite *MetricsTestSuite) TestFindPaginatedResponseTimes() {
 
 	// Test limit
 	responseTimes, err = suite.service.FindPaginatedResponseTimes(
-		2,   // offset
-		1,   // limit
-		"",  // order by
-		0,   // reference ID
-		"",  // date trunc
-		nil, // from
-		nil, // to
+		2,          // offset
+		1,          // limit
+		"",         // order by
+		0,          // reference ID
+		"",         // date trunc
+		PhaseTotal, // phase
+		nil,        // aggregations
+		nil,        // from
+		nil,        // to
 	)
 	if assert.Nil(suite.T(), err) {
 		assert.Equal(suite.T(), 1, len(responseTimes))
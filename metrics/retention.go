@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/RichardKnop/pinglist-api/subscriptions"
+)
+
+const (
+	// FreeTierMetricsRetention is used for users without an active subscription
+	FreeTierMetricsRetention = RotateAfterHours / 24 // days
+	// RollupRetention is how long hourly and daily rollups are kept, they
+	// outlive raw data to keep long term history
+	RollupRetention = 2 * 365 // days
+)
+
+// GetMetricsRetention returns how long metrics of a user are kept, team
+// members inherit the team owner's plan the same way as alarm limits do
+func (s *Service) GetMetricsRetention(userID uint) time.Duration {
+	var (
+		retention    = uint(FreeTierMetricsRetention)
+		subscription *subscriptions.Subscription
+	)
+
+	// Fetch the user team
+	team, _ := s.teamsService.FindTeamByMemberID(userID)
+
+	// If the user is member of a team, look for a team owner subscription
+	if team != nil {
+		subscription, _ = s.subscriptionsService.FindActiveSubscriptionByUserID(team.Owner.ID)
+	}
+
+	// No subscription found yet, look for this user's subscription
+	if subscription == nil {
+		subscription, _ = s.subscriptionsService.FindActiveSubscriptionByUserID(userID)
+	}
+
+	// If subscription found, take the retention from the plan
+	if subscription != nil && subscription.Plan.MetricsRetention > 0 {
+		retention = subscription.Plan.MetricsRetention
+	}
+
+	return time.Duration(retention) * 24 * time.Hour
+}
+
+// findMetricsRetentions returns how long metrics of each of the users are
+// kept the same way as GetMetricsRetention but with a constant number of
+// queries, a failed lookup is returned rather than falling back to the free
+// tier so paid metrics never get purged early
+func (s *Service) findMetricsRetentions(userIDs []uint) (map[uint]time.Duration, error) {
+	// Fetch owners of teams the users are members of
+	ownerIDs, err := s.teamsService.FindTeamOwnerIDsByMemberIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch plan retentions of the users and team owners
+	lookupIDs := append([]uint{}, userIDs...)
+	for _, ownerID := range ownerIDs {
+		lookupIDs = append(lookupIDs, ownerID)
+	}
+	planRetentions, err := s.subscriptionsService.FindActiveMetricsRetentions(lookupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	retentions := make(map[uint]time.Duration, len(userIDs))
+	for _, userID := range userIDs {
+		retention := uint(FreeTierMetricsRetention)
+
+		// Look for a team owner subscription first, then for this user's one
+		planRetention, ok := uint(0), false
+		if ownerID, isMember := ownerIDs[userID]; isMember {
+			planRetention, ok = planRetentions[ownerID]
+		}
+		if !ok {
+			planRetention, ok = planRetentions[userID]
+		}
+
+		// If subscription found, take the retention from the plan
+		if ok && planRetention > 0 {
+			retention = planRetention
+		}
+
+		retentions[userID] = time.Duration(retention) * 24 * time.Hour
+	}
+
+	return retentions, nil
+}
+
+// PurgeExpiredResponseTimes deletes response times and check results older
+// than retention of alarm owners' plans, response times are rolled up before
+// being deleted and rollups are kept for RollupRetention or the plan's
+// retention if longer. Metrics of deleted alarms are kept for the free tier
+// retention.
+func (s *Service) PurgeExpiredResponseTimes(now time.Time) error {
+	// Fetch all alarms
+	references, err := s.findAlarmReferences(nil, nil)
+	if err != nil {
+		return err
+	}
+
+	// Fetch retentions of all alarm owners at once
+	var (
+		userIDs = make([]uint, 0)
+		seen    = make(map[uint]bool)
+	)
+	for _, reference := range references {
+		if !seen[reference.UserID] {
+			seen[reference.UserID] = true
+			userIDs = append(userIDs, reference.UserID)
+		}
+	}
+	userRetentions, err := s.findMetricsRetentions(userIDs)
+	if err != nil {
+		return err
+	}
+
+	// Group alarms by retention of their owners
+	var (
+		byRetention  = make(map[time.Duration][]uint)
+		referenceIDs = make([]uint, 0, len(references))
+	)
+	for _, reference := range references {
+		retention := userRetentions[reference.UserID]
+		byRetention[retention] = append(byRetention[retention], reference.ID)
+		referenceIDs = append(referenceIDs, reference.ID)
+	}
+
+	// Purge metrics of existing alarms
+	rollupRetention := time.Duration(RollupRetention) * 24 * time.Hour
+	for retention, ids := range byRetention {
+		rollupsBefore := purgeBefore(now, rollupRetention)
+		if retention > rollupRetention {
+			rollupsBefore = purgeBefore(now, retention)
+		}
+		if err := s.purgeResponseTimes(ids, purgeBefore(now, retention), rollupsBefore); err != nil {
+			return err
+		}
+	}
+
+	// Purge metrics of deleted alarms
+	freeTierRetention := time.Duration(FreeTierMetricsRetention) * 24 * time.Hour
+	if err := s.purgeDeletedResponseTimes(referenceIDs, purgeBefore(now, freeTierRetention)); err != nil {
+		return err
+	}
+
+	logger.INFO.Printf("Purged expired metrics of %d alarms", len(referenceIDs))
+
+	return nil
+}
+
+// purgeBefore returns the start of the day after which metrics are kept,
+// purging whole days makes sure a bucket gets rolled up only once
+func purgeBefore(now time.Time, retention time.Duration) time.Time {
+	return now.Add(-retention).UTC().Truncate(24 * time.Hour)
+}
+
+// purgeResponseTimes rolls up and deletes response times and deletes check
+// results of references older than a given time, rollups are deleted when
+// older than rollupsBefore
+func (s *Service) purgeResponseTimes(referenceIDs []uint, before, rollupsBefore time.Time) error {
+	// Nothing to purge
+	if len(referenceIDs) == 0 {
+		return nil
+	}
+
+	// Begin a transaction
+	tx := s.db.Begin()
+
+	// Roll up response times first so long term history is kept
+	err := rollupResponseTimes(
+		tx,
+		ResponseTimeParentTableName,
+		"reference_id IN (?) AND timestamp < ?",
+		referenceIDs,
+		before,
+	)
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Deleting from the parent table deletes from sub tables as well
+	for _, model := range []interface{}{new(ResponseTime), new(CheckResult)} {
+		err := tx.Where("reference_id IN (?) AND timestamp < ?", referenceIDs, before).
+			Delete(model).Error
+		if err != nil {
+			tx.Rollback() // rollback the transaction
+			return err
+		}
+	}
+
+	// Rollups have their own retention
+	err = tx.Where("reference_id IN (?) AND timestamp < ?", referenceIDs, rollupsBefore).
+		Delete(new(ResponseTimeRollup)).Error
+	if err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback() // rollback the transaction
+		return err
+	}
+
+	return nil
+}
+
+// purgeDeletedResponseTimes deletes response times, rollups and check
+// results of all references except the given ones older than a given time
+func (s *Service) purgeDeletedResponseTimes(referenceIDs []uint, before time.Time) error {
+	for _, model := range []interface{}{new(ResponseTime), new(ResponseTimeRollup), new(CheckResult)} {
+		query := s.db.Where("timestamp < ?", before)
+		if len(referenceIDs) > 0 {
+			query = query.Where("reference_id NOT IN (?)", referenceIDs)
+		}
+		// Deleting from the parent table deletes from sub tables as well
+		if err := query.Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/accounts"
+	"github.com/RichardKnop/pinglist-api/subscriptions"
+	"github.com/RichardKnop/pinglist-api/teams"
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *MetricsTestSuite) TestGetMetricsRetention() {
+	var (
+		freeTierRetention = time.Duration(FreeTierMetricsRetention) * 24 * time.Hour
+		owner             = suite.users[0]
+		member            = suite.users[1]
+		team              = teams.NewTeam(owner, []*accounts.User{member}, "Test Team")
+		subscription      = &subscriptions.Subscription{
+			Plan: &subscriptions.Plan{MetricsRetention: 365},
+		}
+	)
+
+	// Users without a subscription get the free tier retention
+	suite.teamsServiceMock.On("FindTeamByMemberID", owner.ID).
+		Return(nil, teams.ErrTeamNotFound).Once()
+	suite.subscriptionsServiceMock.On("FindActiveSubscriptionByUserID", owner.ID).
+		Return(nil, subscriptions.ErrUserHasNoActiveSubscription).Once()
+	assert.Equal(suite.T(), freeTierRetention, suite.service.GetMetricsRetention(owner.ID))
+
+	// Team members inherit the team owner's plan
+	suite.teamsServiceMock.On("FindTeamByMemberID", member.ID).
+		Return(team, nil).Once()
+	suite.subscriptionsServiceMock.On("FindActiveSubscriptionByUserID", owner.ID).
+		Return(subscription, nil).Once()
+	assert.Equal(suite.T(), 365*24*time.Hour, suite.service.GetMetricsRetention(member.ID))
+
+	// Check that the mock object expectations were met
+	suite.teamsServiceMock.AssertExpectations(suite.T())
+	suite.subscriptionsServiceMock.AssertExpectations(suite.T())
+}
+
+func (suite *MetricsTestSuite) TestFindMetricsRetentions() {
+	var (
+		freeTierRetention = time.Duration(FreeTierMetricsRetention) * 24 * time.Hour
+		owner             = suite.users[0]
+		member            = suite.users[1]
+		userIDs           = []uint{owner.ID, member.ID}
+	)
+
+	// Team members inherit the team owner's plan, all users are looked up at once
+	suite.teamsServiceMock.On("FindTeamOwnerIDsByMemberIDs", userIDs).
+		Return(map[uint]uint{member.ID: owner.ID}, nil).Once()
+	suite.subscriptionsServiceMock.On("FindActiveMetricsRetentions", []uint{owner.ID, member.ID, owner.ID}).
+		Return(map[uint]uint{owner.ID: 365}, nil).Once()
+	retentions, err := suite.service.findMetricsRetentions(userIDs)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), map[uint]time.Duration{
+			owner.ID:  365 * 24 * time.Hour,
+			member.ID: 365 * 24 * time.Hour,
+		}, retentions)
+	}
+
+	// Users without a subscription get the free tier retention
+	suite.teamsServiceMock.On("FindTeamOwnerIDsByMemberIDs", userIDs).
+		Return(map[uint]uint{}, nil).Once()
+	suite.subscriptionsServiceMock.On("FindActiveMetricsRetentions", userIDs).
+		Return(map[uint]uint{member.ID: 180}, nil).Once()
+	retentions, err = suite.service.findMetricsRetentions(userIDs)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), map[uint]time.Duration{
+			owner.ID:  freeTierRetention,
+			member.ID: 180 * 24 * time.Hour,
+		}, retentions)
+	}
+
+	// A failed lookup is returned rather than purging paid metrics
+	suite.teamsServiceMock.On("FindTeamOwnerIDsByMemberIDs", userIDs).
+		Return(map[uint]uint{}, nil).Once()
+	suite.subscriptionsServiceMock.On("FindActiveMetricsRetentions", userIDs).
+		Return(nil, errors.New("connection refused")).Once()
+	_, err = suite.service.findMetricsRetentions(userIDs)
+	assert.Error(suite.T(), err)
+
+	// Check that the mock object expectations were met
+	suite.teamsServiceMock.AssertExpectations(suite.T())
+	suite.subscriptionsServiceMock.AssertExpectations(suite.T())
+}
+
+func (suite *MetricsTestSuite) TestPurgeResponseTimes() {
+	var (
+		today             = time.Date(2016, time.February, 9, 0, 0, 0, 0, time.UTC)
+		todaySubTableName = "metrics_response_times_2016_02_09"
+		count             int
+		err               error
+	)
+
+	// Partition the response time table
+	err = suite.service.PartitionResponseTime(ResponseTimeParentTableName, today)
+	assert.NoError(suite.T(), err, "Partitioning table failed")
+
+	// Insert some test records
+	testRecords := []*ResponseTime{
+		NewResponseTime(todaySubTableName, 1, today, 100),
+		NewResponseTime(todaySubTableName, 1, today.Add(12*time.Hour), 200),
+		NewResponseTime(todaySubTableName, 2, today, 300),
+		NewResponseTime(todaySubTableName, 3, today, 400),
+	}
+	for _, testRecord := range testRecords {
+		err := suite.db.Create(testRecord).Error
+		assert.NoError(suite.T(), err, "Inserting test data failed")
+	}
+	err = suite.db.Create(&ResponseTimeRollup{
+		ReferenceID: 1,
+		Period:      RollupPeriodDay,
+		Timestamp:   today.Add(-24 * time.Hour),
+		SampleCount: 1,
+	}).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// Purge older metrics of reference 1, response times get rolled up
+	err = suite.service.purgeResponseTimes([]uint{1}, today.Add(24*time.Hour), today)
+	assert.NoError(suite.T(), err)
+
+	suite.db.Model(new(ResponseTime)).Count(&count)
+	assert.Equal(suite.T(), 2, count)
+
+	// The old rollup is deleted, a daily and two hourly rollups are created
+	var rollups []*ResponseTimeRollup
+	err = suite.db.Order("period, timestamp").Find(&rollups).Error
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 3, len(rollups)) {
+		assert.Equal(suite.T(), RollupPeriodDay, rollups[0].Period)
+		assert.Equal(suite.T(), today.Unix(), rollups[0].Timestamp.Unix())
+		assert.Equal(suite.T(), int64(2), rollups[0].SampleCount)
+		assert.Equal(suite.T(), RollupPeriodHour, rollups[1].Period)
+		assert.Equal(suite.T(), RollupPeriodHour, rollups[2].Period)
+	}
+
+	// Purge older metrics of all references except 2
+	err = suite.service.purgeDeletedResponseTimes([]uint{2}, today.Add(24*time.Hour))
+	assert.NoError(suite.T(), err)
+
+	suite.db.Model(new(ResponseTime)).Count(&count)
+	assert.Equal(suite.T(), 1, count)
+	suite.db.Model(new(ResponseTimeRollup)).Count(&count)
+	assert.Equal(suite.T(), 0, count)
+}
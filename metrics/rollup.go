@@ -0,0 +1,331 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// rollupPercentiles lists percentiles kept in rollups
+var rollupPercentiles = []string{
+	AggregationP50,
+	AggregationP90,
+	AggregationP95,
+	AggregationP99,
+}
+
+// responseTimeBucket is a partial aggregate of response times, buckets read
+// from rollups and from raw data can be merged together
+type responseTimeBucket struct {
+	count       int64
+	failures    int64
+	sum         float64
+	sumSquares  float64
+	min         float64
+	max         float64
+	percentiles map[string]float64
+}
+
+// merge adds another bucket to this one, percentiles can't be merged
+// exactly so they are approximated by an average weighted by sample count
+func (b *responseTimeBucket) merge(other *responseTimeBucket) {
+	if other.count == 0 {
+		return
+	}
+	if b.count == 0 {
+		b.min, b.max = other.min, other.max
+	}
+	if b.percentiles == nil {
+		b.percentiles = make(map[string]float64, len(rollupPercentiles))
+	}
+	for _, p := range rollupPercentiles {
+		b.percentiles[p] = (b.percentiles[p]*float64(b.count) + other.percentiles[p]*float64(other.count)) /
+			float64(b.count+other.count)
+	}
+	b.count += other.count
+	b.failures += other.failures
+	b.sum += other.sum
+	b.sumSquares += other.sumSquares
+	b.min = math.Min(b.min, other.min)
+	b.max = math.Max(b.max, other.max)
+}
+
+// value returns the aggregated value of the bucket
+func (b *responseTimeBucket) value(aggregation string) float64 {
+	if b.count == 0 {
+		return 0
+	}
+	switch aggregation {
+	case AggregationAvg:
+		return b.sum / float64(b.count)
+	case AggregationMin:
+		return b.min
+	case AggregationMax:
+		return b.max
+	case AggregationCount:
+		return float64(b.count)
+	case AggregationStdDev:
+		if b.count < 2 {
+			return 0
+		}
+		variance := (b.sumSquares - b.sum*b.sum/float64(b.count)) / float64(b.count-1)
+		return math.Sqrt(math.Max(variance, 0))
+	}
+	return b.percentiles[aggregation]
+}
+
+// responseTime converts the bucket to a ResponseTime object
+func (b *responseTimeBucket) responseTime(timestamp time.Time, aggregations []string) *ResponseTime {
+	responseTime := &ResponseTime{
+		Timestamp: timestamp,
+		Value:     int64(b.value(AggregationAvg)),
+	}
+	if len(aggregations) > 0 {
+		responseTime.Aggregations = make(map[string]float64, len(aggregations))
+		for _, aggregation := range aggregations {
+			responseTime.Aggregations[aggregation] = b.value(aggregation)
+		}
+	}
+	return responseTime
+}
+
+// rollupResponseTimes computes hourly and daily rollups of response times
+// in a table matching an optional condition, the rolled up rows have to be
+// deleted in the same transaction so they are never counted twice. Rows of
+// a period which has been rolled up already, e.g. late inserts, are merged
+// into the existing rollup the same way responseTimeBucket.merge does
+func rollupResponseTimes(db *gorm.DB, tableName, condition string, args ...interface{}) error {
+	where := ""
+	if condition != "" {
+		where = " WHERE " + condition
+	}
+	mergePercentiles := make([]string, len(rollupPercentiles))
+	for i, p := range rollupPercentiles {
+		mergePercentiles[i] = fmt.Sprintf(
+			"%s = (r.%s * r.sample_count + EXCLUDED.%s * EXCLUDED.sample_count) / "+
+				"(r.sample_count + EXCLUDED.sample_count)",
+			p, p, p,
+		)
+	}
+	for _, period := range []string{RollupPeriodHour, RollupPeriodDay} {
+		sql := fmt.Sprintf(
+			"INSERT INTO %s AS r (reference_id, period, timestamp, sample_count, failure_count, "+
+				"value_sum, value_sum_squares, min_value, max_value, p50, p90, p95, p99) "+
+				"SELECT reference_id, '%s', DATE_TRUNC('%s', timestamp at time zone 'Z') at time zone 'Z' t, "+
+				"COUNT(*), SUM(CASE WHEN failed THEN 1 ELSE 0 END), SUM(value), SUM(value::float8 * value), "+
+				"MIN(value), MAX(value), %s FROM %s%s GROUP BY reference_id, t "+
+				"ON CONFLICT (reference_id, period, timestamp) DO UPDATE SET %s, "+
+				"sample_count = r.sample_count + EXCLUDED.sample_count, "+
+				"failure_count = r.failure_count + EXCLUDED.failure_count, "+
+				"value_sum = r.value_sum + EXCLUDED.value_sum, "+
+				"value_sum_squares = r.value_sum_squares + EXCLUDED.value_sum_squares, "+
+				"min_value = LEAST(r.min_value, EXCLUDED.min_value), "+
+				"max_value = GREATEST(r.max_value, EXCLUDED.max_value)",
+			new(ResponseTimeRollup).TableName(),
+			period,
+			period,
+			aggregationsSelect(rollupPercentiles, "value"),
+			tableName,
+			where,
+			strings.Join(mergePercentiles, ", "),
+		)
+		if err := db.Exec(sql, args...).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollupBoundary returns a time before which raw response times may have
+// been purged or rotated away and rollups have to be read as well, nil is
+// returned when the queried range is fully covered by raw data
+func (s *Service) rollupBoundary(dateTrunc, phase string, from *time.Time) (*time.Time, error) {
+	// Minute buckets are too fine to be served from rollups
+	if dateTrunc == "minute" || dateTrunc == DateTruncFiveMinutes {
+		return nil, nil
+	}
+
+	// Only total response times are rolled up, timing phases
+	// are available for as long as raw data is kept
+	if phase != "" && phase != PhaseTotal {
+		return nil, nil
+	}
+
+	// Response times of free tier users are rolled up and purged first
+	boundary := purgeBefore(time.Now(), time.Duration(FreeTierMetricsRetention)*24*time.Hour)
+
+	// The oldest sub table which has not been rotated yet
+	subTable := new(SubTable)
+	notFound := s.db.Where("parent_table = ?", ResponseTimeParentTableName).
+		Order("name").First(subTable).RecordNotFound()
+	if !notFound {
+		day, err := getSubTableDate(ResponseTimeParentTableName, subTable.Name)
+		if err != nil {
+			return nil, err
+		}
+		if day.Before(boundary) {
+			boundary = day
+		}
+	}
+
+	if from != nil && !from.Before(boundary) {
+		return nil, nil
+	}
+	return &boundary, nil
+}
+
+// findResponseTimeBuckets returns buckets per reference and truncated
+// timestamp combining rollups with raw data
+func (s *Service) findResponseTimeBuckets(referenceIDs []uint, dateTrunc string, from, to *time.Time) (map[uint]map[time.Time]*responseTimeBucket, error) {
+	buckets := make(map[uint]map[time.Time]*responseTimeBucket)
+	bucketsSQL, args := responseTimeBucketsSQL(referenceIDs, dateTrunc, from, to)
+	if err := scanResponseTimeBuckets(s.db.Raw(bucketsSQL, args...), buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// responseTimeBucketsSQL returns a query selecting partial buckets per
+// reference and truncated timestamp, summaries of purged and rotated data
+// are combined with raw data using UNION ALL so buckets can be further
+// merged, ordered and paginated in the database. Raw rows are deleted when
+// rolled up so both can be read over the whole range without overlapping.
+func responseTimeBucketsSQL(referenceIDs []uint, dateTrunc string, from, to *time.Time) (string, []interface{}) {
+	// Hourly rollups are only needed for hourly buckets
+	period := RollupPeriodDay
+	if dateTrunc == RollupPeriodHour {
+		period = RollupPeriodHour
+	}
+
+	// Common filters of both queries
+	var (
+		where []string
+		args  []interface{}
+	)
+	if len(referenceIDs) > 0 {
+		where = append(where, "reference_id IN (?)")
+		args = append(args, referenceIDs)
+	}
+	if from != nil {
+		where = append(where, "timestamp >= ?")
+		args = append(args, *from)
+	}
+	if to != nil {
+		where = append(where, "timestamp <= ?")
+		args = append(args, *to)
+	}
+
+	// Summaries of purged and rotated data
+	rollupsWhere := append([]string{"period = ?"}, where...)
+	rollupsArgs := append([]interface{}{period}, args...)
+	percentiles := make([]string, len(rollupPercentiles))
+	for i, p := range rollupPercentiles {
+		percentiles[i] = fmt.Sprintf("SUM(%s * sample_count) / SUM(sample_count) %s", p, p)
+	}
+	rollupsSQL := fmt.Sprintf(
+		"SELECT reference_id, %s t, SUM(sample_count) sample_count, SUM(failure_count) failure_count, "+
+			"SUM(value_sum) value_sum, SUM(value_sum_squares) value_sum_squares, "+
+			"MIN(min_value) min_value, MAX(max_value) max_value, %s "+
+			"FROM %s WHERE %s GROUP BY reference_id, t",
+		dateTruncExpression(dateTrunc),
+		strings.Join(percentiles, ", "),
+		new(ResponseTimeRollup).TableName(),
+		strings.Join(rollupsWhere, " AND "),
+	)
+
+	// Raw data which has not been rolled up yet
+	rawWhere := ""
+	if len(where) > 0 {
+		rawWhere = " WHERE " + strings.Join(where, " AND ")
+	}
+	rawSQL := fmt.Sprintf(
+		"SELECT reference_id, %s t, COUNT(*), SUM(CASE WHEN failed THEN 1 ELSE 0 END), SUM(value), "+
+			"SUM(value::float8 * value), MIN(value), MAX(value), %s "+
+			"FROM %s%s GROUP BY reference_id, t",
+		dateTruncExpression(dateTrunc),
+		aggregationsSelect(rollupPercentiles, "value"),
+		ResponseTimeParentTableName,
+		rawWhere,
+	)
+
+	return rollupsSQL + " UNION ALL " + rawSQL, append(rollupsArgs, args...)
+}
+
+// mergedResponseTimeBucketsSQL returns a query merging partial buckets of
+// all references by truncated timestamp, percentiles are approximated by
+// an average weighted by sample count same as when merging in memory
+func mergedResponseTimeBucketsSQL(bucketsSQL string) string {
+	percentiles := make([]string, len(rollupPercentiles))
+	for i, p := range rollupPercentiles {
+		percentiles[i] = fmt.Sprintf("SUM(%s * sample_count) / SUM(sample_count)", p)
+	}
+	return fmt.Sprintf(
+		"SELECT t, SUM(sample_count), SUM(failure_count), SUM(value_sum), SUM(value_sum_squares), "+
+			"MIN(min_value), MAX(max_value), %s FROM (%s) buckets GROUP BY t",
+		strings.Join(percentiles, ", "),
+		bucketsSQL,
+	)
+}
+
+// scanResponseTimeBuckets runs the query and merges results into buckets
+func scanResponseTimeBuckets(query *gorm.DB, buckets map[uint]map[time.Time]*responseTimeBucket) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			referenceID uint
+			timestamp   time.Time
+		)
+		bucket, err := scanResponseTimeBucket(rows, &referenceID, &timestamp)
+		if err != nil {
+			return err
+		}
+
+		timestamp = timestamp.UTC()
+		if buckets[referenceID] == nil {
+			buckets[referenceID] = make(map[time.Time]*responseTimeBucket)
+		}
+		if buckets[referenceID][timestamp] == nil {
+			buckets[referenceID][timestamp] = new(responseTimeBucket)
+		}
+		buckets[referenceID][timestamp].merge(bucket)
+	}
+
+	return rows.Err()
+}
+
+// scanResponseTimeBucket scans a row into the leading destinations followed
+// by a bucket (count, failures, sum, sum of squares, min, max, percentiles)
+func scanResponseTimeBucket(rows *sql.Rows, leading ...interface{}) (*responseTimeBucket, error) {
+	var (
+		bucket      = &responseTimeBucket{percentiles: make(map[string]float64)}
+		percentiles = make([]float64, len(rollupPercentiles))
+	)
+	dest := append(
+		leading,
+		&bucket.count,
+		&bucket.failures,
+		&bucket.sum,
+		&bucket.sumSquares,
+		&bucket.min,
+		&bucket.max,
+	)
+	for i := range percentiles {
+		dest = append(dest, &percentiles[i])
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	for i, p := range rollupPercentiles {
+		bucket.percentiles[p] = percentiles[i]
+	}
+	return bucket, nil
+}
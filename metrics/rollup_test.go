@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseTimeBucketMerge(t *testing.T) {
+	bucket := new(responseTimeBucket)
+
+	// Merging an empty bucket is a no-op
+	bucket.merge(new(responseTimeBucket))
+	assert.Equal(t, 0.0, bucket.value(AggregationAvg))
+
+	// Samples 100 and 300
+	bucket.merge(&responseTimeBucket{
+		count:       2,
+		failures:    1,
+		sum:         400,
+		sumSquares:  100000,
+		min:         100,
+		max:         300,
+		percentiles: map[string]float64{AggregationP50: 200},
+	})
+	// Samples 200, 200, 500 and 700
+	bucket.merge(&responseTimeBucket{
+		count:       4,
+		sum:         1600,
+		sumSquares:  820000,
+		min:         200,
+		max:         700,
+		percentiles: map[string]float64{AggregationP50: 350},
+	})
+
+	assert.Equal(t, int64(6), bucket.count)
+	assert.Equal(t, int64(1), bucket.failures)
+	assert.Equal(t, 6.0, bucket.value(AggregationCount))
+	assert.Equal(t, 100.0, bucket.value(AggregationMin))
+	assert.Equal(t, 700.0, bucket.value(AggregationMax))
+	assert.InDelta(t, 333.33, bucket.value(AggregationAvg), 0.01)
+	// Sample standard deviation of 100, 300, 200, 200, 500, 700
+	assert.InDelta(t, 225.09, bucket.value(AggregationStdDev), 0.01)
+	// Percentiles are weighted by sample count
+	assert.Equal(t, 300.0, bucket.value(AggregationP50))
+}
+
+func (suite *MetricsTestSuite) TestRollupsAfterRotation() {
+	var (
+		today             = time.Now().UTC().Truncate(24 * time.Hour)
+		oldDay            = today.Add(-time.Duration(RotateAfterHours+48) * time.Hour)
+		oldSubTableName   = getSubTableName(ResponseTimeParentTableName, oldDay)
+		todaySubTableName = getSubTableName(ResponseTimeParentTableName, today)
+		from              = oldDay
+		responseTimes     []*ResponseTime
+		count             int
+		subTable          *SubTable
+		rollups           []*ResponseTimeRollup
+		err               error
+	)
+
+	// Create an old sub table which will be rotated
+	subTable, err = suite.service.createResponseTimeSubTable(
+		ResponseTimeParentTableName,
+		oldSubTableName,
+		oldDay,
+		oldDay.Add(24*time.Hour),
+	)
+	assert.NoError(suite.T(), err, "Creating sub table failed")
+	subTable.CreatedAt = oldDay
+	err = suite.db.Save(subTable).Error
+	assert.NoError(suite.T(), err, "Updating created_at failed")
+
+	// And a sub table for today
+	err = suite.service.PartitionResponseTime(ResponseTimeParentTableName, today)
+	assert.NoError(suite.T(), err, "Partitioning table failed")
+
+	// Insert some test records
+	testRecords := []*ResponseTime{
+		NewResponseTime(oldSubTableName, 1, oldDay, 100),
+		NewResponseTime(oldSubTableName, 1, oldDay.Add(10*time.Minute), 300),
+		NewResponseTime(oldSubTableName, 1, oldDay.Add(2*time.Hour), 500),
+		NewResponseTime(todaySubTableName, 1, today, 1000),
+	}
+	testRecords[2].Failed = true
+	for _, testRecord := range testRecords {
+		err := suite.db.Create(testRecord).Error
+		assert.NoError(suite.T(), err, "Inserting test data failed")
+	}
+
+	// Rotate the old sub table away
+	suite.subscriptionsServiceMock.On("MaxMetricsRetention").Return(uint(0), nil)
+	err = suite.service.RotateSubTables()
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), suite.db.HasTable(oldSubTableName))
+
+	// Hourly and daily rollups should have been created
+	err = suite.db.Order("period, timestamp").Find(&rollups).Error
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 3, len(rollups)) {
+		assert.Equal(suite.T(), RollupPeriodDay, rollups[0].Period)
+		assert.Equal(suite.T(), oldDay.Unix(), rollups[0].Timestamp.Unix())
+		assert.Equal(suite.T(), int64(3), rollups[0].SampleCount)
+		assert.Equal(suite.T(), int64(1), rollups[0].FailureCount)
+		assert.Equal(suite.T(), int64(100), rollups[0].MinValue)
+		assert.Equal(suite.T(), int64(500), rollups[0].MaxValue)
+		assert.Equal(suite.T(), RollupPeriodHour, rollups[1].Period)
+		assert.Equal(suite.T(), int64(2), rollups[1].SampleCount)
+		assert.Equal(suite.T(), RollupPeriodHour, rollups[2].Period)
+		assert.Equal(suite.T(), int64(1), rollups[2].SampleCount)
+	}
+
+	// Daily results combine rollups with raw data transparently
+	responseTimes, err = suite.service.FindPaginatedResponseTimes(
+		0,          // offset
+		25,         // limit
+		"",         // order by
+		1,          // reference ID
+		"day",      // date trunc
+		PhaseTotal, // phase
+		[]string{AggregationCount, AggregationMax}, // aggregations
+		&from, // from
+		nil,   // to
+	)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 2, len(responseTimes)) {
+		assert.Equal(suite.T(), oldDay.Unix(), responseTimes[0].Timestamp.Unix())
+		assert.Equal(suite.T(), int64(300), responseTimes[0].Value)
+		assert.Equal(suite.T(), 3.0, responseTimes[0].Aggregations[AggregationCount])
+		assert.Equal(suite.T(), 500.0, responseTimes[0].Aggregations[AggregationMax])
+		assert.Equal(suite.T(), today.Unix(), responseTimes[1].Timestamp.Unix())
+		assert.Equal(suite.T(), int64(1000), responseTimes[1].Value)
+	}
+
+	// Pagination is done in the database
+	responseTimes, err = suite.service.FindPaginatedResponseTimes(
+		1,                // offset
+		1,                // limit
+		"timestamp desc", // order by
+		1,                // reference ID
+		"day",            // date trunc
+		PhaseTotal,       // phase
+		nil,              // aggregations
+		&from,            // from
+		nil,              // to
+	)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 1, len(responseTimes)) {
+		assert.Equal(suite.T(), oldDay.Unix(), responseTimes[0].Timestamp.Unix())
+		assert.Equal(suite.T(), int64(300), responseTimes[0].Value)
+	}
+
+	// Hourly buckets are read from hourly rollups
+	count, err = suite.service.ResponseTimesCount(
+		1,          // reference ID
+		"hour",     // date trunc
+		PhaseTotal, // phase
+		&from,      // from
+		nil,        // to
+	)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 3, count)
+	}
+}
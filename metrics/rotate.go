@@ -1,24 +1,33 @@
 package metrics
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/RichardKnop/pinglist-api/logger"
 )
 
-// RotateAfterHours defines how long to wait before sub tables are rotated away
+// RotateAfterHours defines the minimum time before sub tables are rotated
+// away, rotation waits for the longest metrics retention of any plan and
+// shorter retentions are enforced by purging by reference ID
 const RotateAfterHours = 30 * 24 // 30 days
 
-// RotateSubTables deletes sub tables older than RotateAfterHours hours
+// RotateSubTables deletes sub tables older than the longest metrics
+// retention, hourly and daily rollups of response times are computed
+// before a sub table is dropped or late rows of the default partition
+// are deleted
 func (s *Service) RotateSubTables() error {
 	var (
-		err             error
-		rotateAfterDate = time.Now().Add(
-			-time.Duration(RotateAfterHours) * time.Hour,
-		)
+		err       error
 		subTables []*SubTable
 	)
 
+	rotateAfter, err := s.rotateAfter()
+	if err != nil {
+		return err
+	}
+	rotateAfterDate := time.Now().Add(-rotateAfter)
+
 	// Fetch result sub table records we want to rotate
 	err = s.db.Where("created_at < ?", rotateAfterDate.UTC()).
 		Find(&subTables).Error
@@ -29,8 +38,16 @@ func (s *Service) RotateSubTables() error {
 	// Begin a transaction
 	tx := s.db.Begin()
 
-	// Delete old result sub tables
+	// Roll up and delete old result sub tables
 	for _, subTable := range subTables {
+		if subTable.ParentTable == ResponseTimeParentTableName {
+			if err := rollupResponseTimes(tx, subTable.Name, ""); err != nil {
+				tx.Rollback() // rollback the transaction
+				return err
+			}
+			logger.INFO.Printf("Rolled up sub table: %s", subTable.Name)
+		}
+
 		if err := tx.DropTable(subTable.Name).Error; err != nil {
 			tx.Rollback() // rollback the transaction
 			return err
@@ -39,13 +56,34 @@ func (s *Service) RotateSubTables() error {
 	}
 
 	// Delete old sub table records
-	err = s.db.Where("created_at < ?", rotateAfterDate.UTC()).
+	err = tx.Where("created_at < ?", rotateAfterDate.UTC()).
 		Delete(new(SubTable)).Error
 	if err != nil {
 		tx.Rollback() // rollback the transaction
 		return err
 	}
 
+	// Roll up and delete old late inserts which never got their own partition
+	for _, parentTableName := range []string{ResponseTimeParentTableName, CheckResultParentTableName} {
+		defaultPartitionName := getDefaultPartitionName(parentTableName)
+		if parentTableName == ResponseTimeParentTableName {
+			err = rollupResponseTimes(tx, defaultPartitionName, "timestamp < ?", rotateAfterDate.UTC())
+			if err != nil {
+				tx.Rollback() // rollback the transaction
+				return err
+			}
+		}
+
+		err = tx.Exec(
+			fmt.Sprintf("DELETE FROM %s WHERE timestamp < ?", defaultPartitionName),
+			rotateAfterDate.UTC(),
+		).Error
+		if err != nil {
+			tx.Rollback() // rollback the transaction
+			return err
+		}
+	}
+
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback() // rollback the transaction
@@ -54,3 +92,19 @@ func (s *Service) RotateSubTables() error {
 
 	return nil
 }
+
+// rotateAfter returns how old sub tables have to be to get rotated, which
+// is the longest metrics retention of any plan but at least RotateAfterHours
+func (s *Service) rotateAfter() (time.Duration, error) {
+	rotateAfter := time.Duration(RotateAfterHours) * time.Hour
+
+	days, err := s.subscriptionsService.MaxMetricsRetention()
+	if err != nil {
+		return 0, err
+	}
+	if planRetention := time.Duration(days) * 24 * time.Hour; planRetention > rotateAfter {
+		rotateAfter = planRetention
+	}
+
+	return rotateAfter, nil
+}
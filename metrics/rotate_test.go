@@ -39,6 +39,9 @@ func (suite *MetricsTestSuite) TestRotateSubTables() {
 	suite.service.db.Model(new(SubTable)).Count(&count)
 	assert.Equal(suite.T(), 2, count)
 
+	// No plan keeps metrics longer than the free tier
+	suite.subscriptionsServiceMock.On("MaxMetricsRetention").Return(uint(0), nil)
+
 	// Let's rotate the sub tables
 	err = suite.service.RotateSubTables()
 
@@ -59,3 +62,113 @@ func (suite *MetricsTestSuite) TestRotateSubTables() {
 	suite.service.db.Model(new(SubTable)).Count(&count)
 	assert.Equal(suite.T(), 1, count)
 }
+
+func (suite *MetricsTestSuite) TestRotateSubTablesKeepsPaidRetention() {
+	var (
+		now             = time.Now().UTC()
+		oldDay          = now.Truncate(24 * time.Hour).Add(-60 * 24 * time.Hour)
+		oldSubTableName = getSubTableName(ResponseTimeParentTableName, oldDay)
+		count           int
+	)
+
+	// Create a 60 days old sub table
+	subTable, err := suite.service.createResponseTimeSubTable(
+		ResponseTimeParentTableName,
+		oldSubTableName,
+		oldDay,
+		oldDay.Add(24*time.Hour),
+	)
+	assert.NoError(suite.T(), err, "Creating sub table failed")
+	subTable.CreatedAt = oldDay
+	err = suite.db.Save(subTable).Error
+	assert.NoError(suite.T(), err, "Updating created_at failed")
+
+	// Insert test records of a paid user's alarm and a free user's alarm
+	testRecords := []*ResponseTime{
+		NewResponseTime(oldSubTableName, 1, oldDay, 100),
+		NewResponseTime(oldSubTableName, 2, oldDay, 200),
+	}
+	for _, testRecord := range testRecords {
+		err := suite.db.Create(testRecord).Error
+		assert.NoError(suite.T(), err, "Inserting test data failed")
+	}
+
+	// The longest plan retention is one year
+	suite.subscriptionsServiceMock.On("MaxMetricsRetention").Return(uint(365), nil)
+
+	// The sub table is kept
+	err = suite.service.RotateSubTables()
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), suite.db.HasTable(oldSubTableName))
+
+	// Purging the free user's metrics leaves the paid user's data alone
+	freeTierRetention := time.Duration(FreeTierMetricsRetention) * 24 * time.Hour
+	err = suite.service.purgeResponseTimes(
+		[]uint{2},
+		purgeBefore(now, freeTierRetention),
+		purgeBefore(now, time.Duration(RollupRetention)*24*time.Hour),
+	)
+	assert.NoError(suite.T(), err)
+
+	suite.db.Model(new(ResponseTime)).Where("reference_id = ?", 1).Count(&count)
+	assert.Equal(suite.T(), 1, count)
+	suite.db.Model(new(ResponseTime)).Where("reference_id = ?", 2).Count(&count)
+	assert.Equal(suite.T(), 0, count)
+
+	// Check that the mock object expectations were met
+	suite.subscriptionsServiceMock.AssertExpectations(suite.T())
+}
+
+func (suite *MetricsTestSuite) TestRotateSubTablesRollsUpLateRows() {
+	var (
+		now    = time.Now().UTC()
+		oldDay = now.Truncate(24 * time.Hour).Add(-40 * 24 * time.Hour)
+		count  int
+	)
+
+	// No sub table holds the day, a late insert lands in the default partition
+	err := suite.db.Create(NewResponseTime(ResponseTimeParentTableName, 1, oldDay, 300)).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+	suite.db.Table(getDefaultPartitionName(ResponseTimeParentTableName)).Count(&count)
+	assert.Equal(suite.T(), 1, count)
+
+	// The day has been rolled up from its sub table already
+	err = suite.db.Create(&ResponseTimeRollup{
+		ReferenceID: 1,
+		Period:      RollupPeriodDay,
+		Timestamp:   oldDay,
+		SampleCount: 1,
+		ValueSum:    100,
+		MinValue:    100,
+		MaxValue:    100,
+		P50:         100,
+	}).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// No plan keeps metrics longer than the free tier
+	suite.subscriptionsServiceMock.On("MaxMetricsRetention").Return(uint(0), nil)
+
+	err = suite.service.RotateSubTables()
+	assert.NoError(suite.T(), err)
+
+	// The late row is deleted
+	suite.db.Table(getDefaultPartitionName(ResponseTimeParentTableName)).Count(&count)
+	assert.Equal(suite.T(), 0, count)
+
+	// But it is merged into the daily rollup and gets an hourly one
+	var rollups []*ResponseTimeRollup
+	err = suite.db.Order("period, timestamp").Find(&rollups).Error
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 2, len(rollups)) {
+		assert.Equal(suite.T(), RollupPeriodDay, rollups[0].Period)
+		assert.Equal(suite.T(), int64(2), rollups[0].SampleCount)
+		assert.Equal(suite.T(), float64(400), rollups[0].ValueSum)
+		assert.Equal(suite.T(), int64(100), rollups[0].MinValue)
+		assert.Equal(suite.T(), int64(300), rollups[0].MaxValue)
+		assert.Equal(suite.T(), float64(200), rollups[0].P50)
+		assert.Equal(suite.T(), RollupPeriodHour, rollups[1].Period)
+		assert.Equal(suite.T(), int64(1), rollups[1].SampleCount)
+	}
+
+	// Check that the mock object expectations were met
+	suite.subscriptionsServiceMock.AssertExpectations(suite.T())
+}
@@ -1,19 +1,29 @@
 package metrics
 
 import (
+	"github.com/RichardKnop/pinglist-api/accounts"
 	"github.com/RichardKnop/pinglist-api/routes"
 	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
 )
 
-// RegisterRoutes registers route handlers for the alarms service
+// RegisterRoutes registers route handlers for the metrics service
 func RegisterRoutes(router *mux.Router, service ServiceInterface) {
 	subRouter := router.PathPrefix("/v1").Subrouter()
 	routes.AddRoutes(newRoutes(service), subRouter)
 }
 
-// newRoutes returns []routes.Route slice for the alarms service
+// newRoutes returns []routes.Route slice for the metrics service
 func newRoutes(service ServiceInterface) []routes.Route {
 	return []routes.Route{
-	// TODO
+		routes.Route{
+			Name:        "list_response_time_series",
+			Method:      "GET",
+			Pattern:     "/metrics/response-times",
+			HandlerFunc: service.listResponseTimeSeriesHandler,
+			Middlewares: []negroni.Handler{
+				accounts.NewUserAuthMiddleware(service.GetAccountsService()),
+			},
+		},
 	}
 }
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SeriesGroup is a named set of references whose response times are
+// aggregated into a single series
+type SeriesGroup struct {
+	Key          string
+	ReferenceIDs []uint
+}
+
+// Series is a response time series of a group
+type Series struct {
+	Key           string
+	ReferenceIDs  []uint
+	ResponseTimes []*ResponseTime
+}
+
+// seriesBucket keeps a running total of a time bucket so averages can be
+// combined across references without losing weight
+type seriesBucket struct {
+	sum   float64
+	count int64
+}
+
+// FindResponseTimeSeries returns one aggregated response time series per
+// group, references can belong to several groups (e.g. alarms with more
+// than one tag), values are averages weighted by number of samples
+func (s *Service) FindResponseTimeSeries(groups []*SeriesGroup, dateTrunc, phase string, from, to *time.Time) ([]*Series, error) {
+	// Collect unique reference IDs
+	var referenceIDs []uint
+	seen := make(map[uint]bool)
+	for _, group := range groups {
+		for _, referenceID := range group.ReferenceIDs {
+			if !seen[referenceID] {
+				seen[referenceID] = true
+				referenceIDs = append(referenceIDs, referenceID)
+			}
+		}
+	}
+
+	// Nothing to query
+	if len(referenceIDs) == 0 {
+		return combineSeries(groups, nil), nil
+	}
+
+	// Older data may have been rolled up, read rollups transparently
+	boundary, err := s.rollupBoundary(dateTrunc, phase, from)
+	if err != nil {
+		return nil, err
+	}
+	if boundary != nil {
+		rollupBuckets, err := s.findResponseTimeBuckets(referenceIDs, dateTrunc, from, to)
+		if err != nil {
+			return nil, err
+		}
+		buckets := make(map[uint]map[time.Time]*seriesBucket, len(rollupBuckets))
+		for referenceID, referenceBuckets := range rollupBuckets {
+			buckets[referenceID] = make(map[time.Time]*seriesBucket, len(referenceBuckets))
+			for timestamp, bucket := range referenceBuckets {
+				buckets[referenceID][timestamp] = &seriesBucket{sum: bucket.sum, count: bucket.count}
+			}
+		}
+		return combineSeries(groups, buckets), nil
+	}
+
+	// Aggregate per reference and time bucket, groups are combined later
+	rows, err := s.responseTimesQuery(referenceIDs, from, to).
+		Select(fmt.Sprintf(
+			"reference_id, %s t, SUM(%s), COUNT(*)",
+			dateTruncExpression(dateTrunc),
+			phaseColumn(phase),
+		)).
+		Group("reference_id, t").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make(map[uint]map[time.Time]*seriesBucket)
+	for rows.Next() {
+		var (
+			referenceID uint
+			timestamp   time.Time
+			sum         float64
+			count       int64
+		)
+		if err := rows.Scan(&referenceID, &timestamp, &sum, &count); err != nil {
+			return nil, err
+		}
+		if buckets[referenceID] == nil {
+			buckets[referenceID] = make(map[time.Time]*seriesBucket)
+		}
+		buckets[referenceID][timestamp] = &seriesBucket{sum: sum, count: count}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return combineSeries(groups, buckets), nil
+}
+
+// combineSeries merges per reference buckets into series of groups ordered
+// by timestamp
+func combineSeries(groups []*SeriesGroup, buckets map[uint]map[time.Time]*seriesBucket) []*Series {
+	series := make([]*Series, len(groups))
+
+	for i, group := range groups {
+		combined := make(map[time.Time]*seriesBucket)
+		for _, referenceID := range group.ReferenceIDs {
+			for timestamp, bucket := range buckets[referenceID] {
+				if combined[timestamp] == nil {
+					combined[timestamp] = new(seriesBucket)
+				}
+				combined[timestamp].sum += bucket.sum
+				combined[timestamp].count += bucket.count
+			}
+		}
+
+		responseTimes := make([]*ResponseTime, 0, len(combined))
+		for timestamp, bucket := range combined {
+			responseTimes = append(responseTimes, &ResponseTime{
+				Timestamp: timestamp,
+				Value:     int64(bucket.sum / float64(bucket.count)),
+			})
+		}
+		sort.Slice(responseTimes, func(a, b int) bool {
+			return responseTimes[a].Timestamp.Before(responseTimes[b].Timestamp)
+		})
+
+		series[i] = &Series{
+			Key:           group.Key,
+			ReferenceIDs:  group.ReferenceIDs,
+			ResponseTimes: responseTimes,
+		}
+	}
+
+	return series
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupAlarmReferences(t *testing.T) {
+	references := []*alarmReference{
+		{ID: 1, RegionID: "us-west-2", Tags: []string{"api", "prod"}},
+		{ID: 2, RegionID: "eu-west-1", Tags: []string{"prod"}},
+		{ID: 3, RegionID: "us-west-2"},
+	}
+
+	// No grouping
+	groups := groupAlarmReferences(references, "")
+	if assert.Equal(t, 1, len(groups)) {
+		assert.Equal(t, "all", groups[0].Key)
+		assert.Equal(t, []uint{1, 2, 3}, groups[0].ReferenceIDs)
+	}
+
+	// Group by alarm
+	groups = groupAlarmReferences(references, GroupByAlarm)
+	if assert.Equal(t, 3, len(groups)) {
+		assert.Equal(t, "1", groups[0].Key)
+		assert.Equal(t, []uint{1}, groups[0].ReferenceIDs)
+		assert.Equal(t, "3", groups[2].Key)
+	}
+
+	// Group by region
+	groups = groupAlarmReferences(references, GroupByRegion)
+	if assert.Equal(t, 2, len(groups)) {
+		assert.Equal(t, "eu-west-1", groups[0].Key)
+		assert.Equal(t, []uint{2}, groups[0].ReferenceIDs)
+		assert.Equal(t, "us-west-2", groups[1].Key)
+		assert.Equal(t, []uint{1, 3}, groups[1].ReferenceIDs)
+	}
+
+	// Group by tag, untagged alarms are left out
+	groups = groupAlarmReferences(references, GroupByTag)
+	if assert.Equal(t, 2, len(groups)) {
+		assert.Equal(t, "api", groups[0].Key)
+		assert.Equal(t, []uint{1}, groups[0].ReferenceIDs)
+		assert.Equal(t, "prod", groups[1].Key)
+		assert.Equal(t, []uint{1, 2}, groups[1].ReferenceIDs)
+	}
+}
+
+func TestCombineSeries(t *testing.T) {
+	var (
+		first  = time.Date(2016, time.February, 9, 0, 0, 0, 0, time.UTC)
+		second = first.Add(time.Hour)
+	)
+
+	buckets := map[uint]map[time.Time]*seriesBucket{
+		1: {
+			second: {sum: 300, count: 1},
+			first:  {sum: 200, count: 2},
+		},
+		2: {
+			first: {sum: 400, count: 2},
+		},
+	}
+	groups := []*SeriesGroup{
+		{Key: "all", ReferenceIDs: []uint{1, 2}},
+		{Key: "empty", ReferenceIDs: []uint{3}},
+	}
+
+	series := combineSeries(groups, buckets)
+	if assert.Equal(t, 2, len(series)) {
+		// Averages are weighted by number of samples and ordered by time
+		assert.Equal(t, "all", series[0].Key)
+		if assert.Equal(t, 2, len(series[0].ResponseTimes)) {
+			assert.Equal(t, first, series[0].ResponseTimes[0].Timestamp)
+			assert.Equal(t, int64(150), series[0].ResponseTimes[0].Value)
+			assert.Equal(t, second, series[0].ResponseTimes[1].Timestamp)
+			assert.Equal(t, int64(300), series[0].ResponseTimes[1].Value)
+		}
+
+		assert.Equal(t, "empty", series[1].Key)
+		assert.Equal(t, 0, len(series[1].ResponseTimes))
+	}
+}
+
+func (suite *MetricsTestSuite) TestFindResponseTimeSeries() {
+	var (
+		today             = time.Date(2016, time.February, 9, 0, 0, 0, 0, time.UTC)
+		todaySubTableName = "metrics_response_times_2016_02_09"
+		err               error
+	)
+
+	// Partition the response time table
+	err = suite.service.PartitionResponseTime(ResponseTimeParentTableName, today)
+	assert.NoError(suite.T(), err, "Partitioning table failed")
+
+	// Insert some test records
+	testRecords := []*ResponseTime{
+		NewResponseTime(todaySubTableName, 1, today, 100),
+		NewResponseTime(todaySubTableName, 1, today.Add(10*time.Minute), 200),
+		NewResponseTime(todaySubTableName, 2, today.Add(20*time.Minute), 600),
+		NewResponseTime(todaySubTableName, 2, today.Add(1*time.Hour), 400),
+		NewResponseTime(todaySubTableName, 3, today.Add(1*time.Hour), 999),
+	}
+	for _, testRecord := range testRecords {
+		err := suite.db.Create(testRecord).Error
+		assert.NoError(suite.T(), err, "Inserting test data failed")
+	}
+
+	from := today
+	series, err := suite.service.FindResponseTimeSeries(
+		[]*SeriesGroup{
+			{Key: "1", ReferenceIDs: []uint{1}},
+			{Key: "all", ReferenceIDs: []uint{1, 2}},
+		},
+		"hour",     // date trunc
+		PhaseTotal, // phase
+		&from,      // from
+		nil,        // to
+	)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 2, len(series)) {
+		if assert.Equal(suite.T(), 1, len(series[0].ResponseTimes)) {
+			assert.Equal(suite.T(), int64(150), series[0].ResponseTimes[0].Value)
+		}
+		if assert.Equal(suite.T(), 2, len(series[1].ResponseTimes)) {
+			assert.Equal(suite.T(), int64(300), series[1].ResponseTimes[0].Value)
+			assert.Equal(suite.T(), int64(400), series[1].ResponseTimes[1].Value)
+		}
+	}
+}
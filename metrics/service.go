@@ -1,24 +1,39 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/RichardKnop/pinglist-api/accounts"
 	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/RichardKnop/pinglist-api/subscriptions"
+	"github.com/RichardKnop/pinglist-api/teams"
 	"github.com/jinzhu/gorm"
 )
 
 // Service struct keeps config and db objects to avoid passing them around
 type Service struct {
-	cnf             *config.Config
-	db              *gorm.DB
-	accountsService accounts.ServiceInterface
+	cnf                  *config.Config
+	db                   *gorm.DB
+	accountsService      accounts.ServiceInterface
+	subscriptionsService subscriptions.ServiceInterface
+	teamsService         teams.ServiceInterface
+	writer               *Writer
 }
 
 // NewService starts a new Service instance
-func NewService(cnf *config.Config, db *gorm.DB, accountsService accounts.ServiceInterface) *Service {
+func NewService(cnf *config.Config, db *gorm.DB, accountsService accounts.ServiceInterface, subscriptionsService subscriptions.ServiceInterface, teamsService teams.ServiceInterface) *Service {
 	return &Service{
-		cnf:             cnf,
-		db:              db,
-		accountsService: accountsService,
+		cnf:                  cnf,
+		db:                   db,
+		accountsService:      accountsService,
+		subscriptionsService: subscriptionsService,
+		teamsService:         teamsService,
+		writer: NewWriter(
+			db,
+			cnf.Metrics.BufferSize,
+			cnf.Metrics.BatchSize,
+			time.Duration(cnf.Metrics.FlushInterval)*time.Millisecond,
+		),
 	}
 }
 
@@ -26,3 +41,13 @@ func NewService(cnf *config.Config, db *gorm.DB, accountsService accounts.Servic
 func (s *Service) GetAccountsService() accounts.ServiceInterface {
 	return s.accountsService
 }
+
+// FlushResponseTimes writes buffered response times to the database
+func (s *Service) FlushResponseTimes() error {
+	return s.writer.Flush()
+}
+
+// Close flushes buffered response times and stops the writer
+func (s *Service) Close() error {
+	return s.writer.Close()
+}
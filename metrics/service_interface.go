@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/RichardKnop/pinglist-api/accounts"
@@ -12,7 +13,23 @@ type ServiceInterface interface {
 	GetAccountsService() accounts.ServiceInterface
 	PartitionResponseTime(parentTableName string, now time.Time) error
 	RotateSubTables() error
-	LogResponseTime(timestamp time.Time, referenceID uint, value int64) error
-	ResponseTimesCount(referenceID int, dateTrunc string, from, to *time.Time) (int, error)
-	FindPaginatedResponseTimes(offset, limit int, orderBy string, referenceID int, dateTrunc string, from, to *time.Time) ([]*ResponseTime, error)
+	LogResponseTime(timestamp time.Time, referenceID uint, value int64, failed bool, timings *Timings) error
+	FlushResponseTimes() error
+	LogCheckResult(timestamp time.Time, referenceID uint, region string, statusCode int, responseSize int64, outcome, errMsg string) error
+	CheckResultsCount(referenceID int, outcome string, from, to *time.Time) (int, error)
+	FindPaginatedCheckResults(offset, limit int, orderBy string, referenceID int, outcome string, from, to *time.Time) ([]*CheckResult, error)
+	CheckResultCounts(referenceIDs []uint) (map[uint]map[string]int, error)
+	IterateCheckResults(referenceID int, outcome string, from, to *time.Time, fn func(*CheckResult) error) error
+	Close() error
+	ResponseTimesCount(referenceID int, dateTrunc, phase string, from, to *time.Time) (int, error)
+	FindPaginatedResponseTimes(offset, limit int, orderBy string, referenceID int, dateTrunc, phase string, aggregations []string, from, to *time.Time) ([]*ResponseTime, error)
+	LastResponseTimes(referenceIDs []uint, since time.Time) (map[uint]*ResponseTime, error)
+	IterateResponseTimes(referenceID int, from, to *time.Time, fn func(*ResponseTime) error) error
+	ResponseTimesAggregations(referenceID int, phase string, aggregations []string, from, to *time.Time) (map[string]float64, error)
+	FindResponseTimeSeries(groups []*SeriesGroup, dateTrunc, phase string, from, to *time.Time) ([]*Series, error)
+	GetMetricsRetention(userID uint) time.Duration
+	PurgeExpiredResponseTimes(now time.Time) error
+
+	// Needed for the newRoutes to be able to register handlers
+	listResponseTimeSeriesHandler(w http.ResponseWriter, r *http.Request)
 }
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/RichardKnop/pinglist-api/accounts"
@@ -55,12 +56,12 @@ func (_m *ServiceMock) RotateSubTables() error {
 }
 
 // LogResponseTime ...
-func (_m *ServiceMock) LogResponseTime(timestamp time.Time, referenceID uint, value int64) error {
-	ret := _m.Called(timestamp, referenceID, value)
+func (_m *ServiceMock) LogResponseTime(timestamp time.Time, referenceID uint, value int64, failed bool, timings *Timings) error {
+	ret := _m.Called(timestamp, referenceID, value, failed, timings)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(time.Time, uint, int64) error); ok {
-		r0 = rf(timestamp, referenceID, value)
+	if rf, ok := ret.Get(0).(func(time.Time, uint, int64, bool, *Timings) error); ok {
+		r0 = rf(timestamp, referenceID, value, failed, timings)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -68,20 +69,143 @@ func (_m *ServiceMock) LogResponseTime(timestamp time.Time, referenceID uint, va
 	return r0
 }
 
-// ResponseTimesCount ...
-func (_m *ServiceMock) ResponseTimesCount(referenceID int, dateTrunc string, from *time.Time, to *time.Time) (int, error) {
-	ret := _m.Called(referenceID, dateTrunc, from, to)
+// FlushResponseTimes ...
+func (_m *ServiceMock) FlushResponseTimes() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LogCheckResult ...
+func (_m *ServiceMock) LogCheckResult(timestamp time.Time, referenceID uint, region string, statusCode int, responseSize int64, outcome string, errMsg string) error {
+	ret := _m.Called(timestamp, referenceID, region, statusCode, responseSize, outcome, errMsg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Time, uint, string, int, int64, string, string) error); ok {
+		r0 = rf(timestamp, referenceID, region, statusCode, responseSize, outcome, errMsg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CheckResultsCount ...
+func (_m *ServiceMock) CheckResultsCount(referenceID int, outcome string, from *time.Time, to *time.Time) (int, error) {
+	ret := _m.Called(referenceID, outcome, from, to)
 
 	var r0 int
 	if rf, ok := ret.Get(0).(func(int, string, *time.Time, *time.Time) int); ok {
-		r0 = rf(referenceID, dateTrunc, from, to)
+		r0 = rf(referenceID, outcome, from, to)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
 	if rf, ok := ret.Get(1).(func(int, string, *time.Time, *time.Time) error); ok {
-		r1 = rf(referenceID, dateTrunc, from, to)
+		r1 = rf(referenceID, outcome, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindPaginatedCheckResults ...
+func (_m *ServiceMock) FindPaginatedCheckResults(offset int, limit int, orderBy string, referenceID int, outcome string, from *time.Time, to *time.Time) ([]*CheckResult, error) {
+	ret := _m.Called(offset, limit, orderBy, referenceID, outcome, from, to)
+
+	var r0 []*CheckResult
+	if rf, ok := ret.Get(0).(func(int, int, string, int, string, *time.Time, *time.Time) []*CheckResult); ok {
+		r0 = rf(offset, limit, orderBy, referenceID, outcome, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*CheckResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, int, string, int, string, *time.Time, *time.Time) error); ok {
+		r1 = rf(offset, limit, orderBy, referenceID, outcome, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckResultCounts ...
+func (_m *ServiceMock) CheckResultCounts(referenceIDs []uint) (map[uint]map[string]int, error) {
+	ret := _m.Called(referenceIDs)
+
+	var r0 map[uint]map[string]int
+	if rf, ok := ret.Get(0).(func([]uint) map[uint]map[string]int); ok {
+		r0 = rf(referenceIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]map[string]int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]uint) error); ok {
+		r1 = rf(referenceIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IterateCheckResults ...
+func (_m *ServiceMock) IterateCheckResults(referenceID int, outcome string, from *time.Time, to *time.Time, fn func(*CheckResult) error) error {
+	ret := _m.Called(referenceID, outcome, from, to, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, string, *time.Time, *time.Time, func(*CheckResult) error) error); ok {
+		r0 = rf(referenceID, outcome, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Close ...
+func (_m *ServiceMock) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResponseTimesCount ...
+func (_m *ServiceMock) ResponseTimesCount(referenceID int, dateTrunc string, phase string, from *time.Time, to *time.Time) (int, error) {
+	ret := _m.Called(referenceID, dateTrunc, phase, from, to)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(int, string, string, *time.Time, *time.Time) int); ok {
+		r0 = rf(referenceID, dateTrunc, phase, from, to)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, string, string, *time.Time, *time.Time) error); ok {
+		r1 = rf(referenceID, dateTrunc, phase, from, to)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,12 +214,12 @@ func (_m *ServiceMock) ResponseTimesCount(referenceID int, dateTrunc string, fro
 }
 
 // FindPaginatedResponseTimes ...
-func (_m *ServiceMock) FindPaginatedResponseTimes(offset int, limit int, orderBy string, referenceID int, dateTrunc string, from *time.Time, to *time.Time) ([]*ResponseTime, error) {
-	ret := _m.Called(offset, limit, orderBy, referenceID, dateTrunc, from, to)
+func (_m *ServiceMock) FindPaginatedResponseTimes(offset int, limit int, orderBy string, referenceID int, dateTrunc string, phase string, aggregations []string, from *time.Time, to *time.Time) ([]*ResponseTime, error) {
+	ret := _m.Called(offset, limit, orderBy, referenceID, dateTrunc, phase, aggregations, from, to)
 
 	var r0 []*ResponseTime
-	if rf, ok := ret.Get(0).(func(int, int, string, int, string, *time.Time, *time.Time) []*ResponseTime); ok {
-		r0 = rf(offset, limit, orderBy, referenceID, dateTrunc, from, to)
+	if rf, ok := ret.Get(0).(func(int, int, string, int, string, string, []string, *time.Time, *time.Time) []*ResponseTime); ok {
+		r0 = rf(offset, limit, orderBy, referenceID, dateTrunc, phase, aggregations, from, to)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*ResponseTime)
@@ -103,11 +227,127 @@ func (_m *ServiceMock) FindPaginatedResponseTimes(offset int, limit int, orderBy
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int, string, int, string, *time.Time, *time.Time) error); ok {
-		r1 = rf(offset, limit, orderBy, referenceID, dateTrunc, from, to)
+	if rf, ok := ret.Get(1).(func(int, int, string, int, string, string, []string, *time.Time, *time.Time) error); ok {
+		r1 = rf(offset, limit, orderBy, referenceID, dateTrunc, phase, aggregations, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LastResponseTimes ...
+func (_m *ServiceMock) LastResponseTimes(referenceIDs []uint, since time.Time) (map[uint]*ResponseTime, error) {
+	ret := _m.Called(referenceIDs, since)
+
+	var r0 map[uint]*ResponseTime
+	if rf, ok := ret.Get(0).(func([]uint, time.Time) map[uint]*ResponseTime); ok {
+		r0 = rf(referenceIDs, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]*ResponseTime)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]uint, time.Time) error); ok {
+		r1 = rf(referenceIDs, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IterateResponseTimes ...
+func (_m *ServiceMock) IterateResponseTimes(referenceID int, from *time.Time, to *time.Time, fn func(*ResponseTime) error) error {
+	ret := _m.Called(referenceID, from, to, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, *time.Time, *time.Time, func(*ResponseTime) error) error); ok {
+		r0 = rf(referenceID, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResponseTimesAggregations ...
+func (_m *ServiceMock) ResponseTimesAggregations(referenceID int, phase string, aggregations []string, from *time.Time, to *time.Time) (map[string]float64, error) {
+	ret := _m.Called(referenceID, phase, aggregations, from, to)
+
+	var r0 map[string]float64
+	if rf, ok := ret.Get(0).(func(int, string, []string, *time.Time, *time.Time) map[string]float64); ok {
+		r0 = rf(referenceID, phase, aggregations, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]float64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, string, []string, *time.Time, *time.Time) error); ok {
+		r1 = rf(referenceID, phase, aggregations, from, to)
 	} else {
 		r1 = ret.Error(1)
 	}
 
 	return r0, r1
 }
+
+// FindResponseTimeSeries ...
+func (_m *ServiceMock) FindResponseTimeSeries(groups []*SeriesGroup, dateTrunc string, phase string, from *time.Time, to *time.Time) ([]*Series, error) {
+	ret := _m.Called(groups, dateTrunc, phase, from, to)
+
+	var r0 []*Series
+	if rf, ok := ret.Get(0).(func([]*SeriesGroup, string, string, *time.Time, *time.Time) []*Series); ok {
+		r0 = rf(groups, dateTrunc, phase, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*Series)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]*SeriesGroup, string, string, *time.Time, *time.Time) error); ok {
+		r1 = rf(groups, dateTrunc, phase, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMetricsRetention ...
+func (_m *ServiceMock) GetMetricsRetention(userID uint) time.Duration {
+	ret := _m.Called(userID)
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func(uint) time.Duration); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	return r0
+}
+
+// PurgeExpiredResponseTimes ...
+func (_m *ServiceMock) PurgeExpiredResponseTimes(now time.Time) error {
+	ret := _m.Called(now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Time) error); ok {
+		r0 = rf(now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// listResponseTimeSeriesHandler ...
+func (_m *ServiceMock) listResponseTimeSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
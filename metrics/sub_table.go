@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -9,3 +10,14 @@ import (
 func getSubTableName(parentName string, now time.Time) string {
 	return fmt.Sprintf("%s_%s", parentName, now.UTC().Format("2006_01_02"))
 }
+
+// getDefaultPartitionName returns name of the partition catching rows
+// which don't fall into any other partition
+func getDefaultPartitionName(parentName string) string {
+	return fmt.Sprintf("%s_default", parentName)
+}
+
+// getSubTableDate parses the date a sub table holds data for from its name
+func getSubTableDate(parentName, subTableName string) (time.Time, error) {
+	return time.Parse("2006_01_02", strings.TrimPrefix(subTableName, parentName+"_"))
+}
@@ -34,3 +34,13 @@ func TestGetSubTableName(t *testing.T) {
 	)
 	assert.Equal(t, expected, actual)
 }
+
+func TestGetSubTableDate(t *testing.T) {
+	day, err := getSubTableDate("parent_table", "parent_table_2016_12_27")
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Date(2016, time.December, 27, 0, 0, 0, 0, time.UTC), day)
+	}
+
+	_, err = getSubTableDate("parent_table", "parent_table_bogus")
+	assert.Error(t, err)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/RichardKnop/pinglist-api/config"
 	"github.com/RichardKnop/pinglist-api/database"
 	"github.com/RichardKnop/pinglist-api/oauth"
+	"github.com/RichardKnop/pinglist-api/subscriptions"
+	"github.com/RichardKnop/pinglist-api/teams"
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/suite"
@@ -36,14 +38,16 @@ var testMigrations = []func(*gorm.DB) error{
 // AlarmsTestSuite needs to be exported so the tests run
 type MetricsTestSuite struct {
 	suite.Suite
-	cnf                 *config.Config
-	db                  *gorm.DB
-	oauthServiceMock    *oauth.ServiceMock
-	accountsServiceMock *accounts.ServiceMock
-	service             *Service
-	accounts            []*accounts.Account
-	users               []*accounts.User
-	router              *mux.Router
+	cnf                      *config.Config
+	db                       *gorm.DB
+	oauthServiceMock         *oauth.ServiceMock
+	accountsServiceMock      *accounts.ServiceMock
+	subscriptionsServiceMock *subscriptions.ServiceMock
+	teamsServiceMock         *teams.ServiceMock
+	service                  *Service
+	accounts                 []*accounts.Account
+	users                    []*accounts.User
+	router                   *mux.Router
 }
 
 // The SetupSuite method will be run by testify once, at the very
@@ -85,12 +89,16 @@ func (suite *MetricsTestSuite) SetupSuite() {
 	// Initialise mocks
 	suite.oauthServiceMock = new(oauth.ServiceMock)
 	suite.accountsServiceMock = new(accounts.ServiceMock)
+	suite.subscriptionsServiceMock = new(subscriptions.ServiceMock)
+	suite.teamsServiceMock = new(teams.ServiceMock)
 
 	// Initialise the service
 	suite.service = NewService(
 		suite.cnf,
 		suite.db,
 		suite.accountsServiceMock,
+		suite.subscriptionsServiceMock,
+		suite.teamsServiceMock,
 	)
 
 	// Register routes
@@ -107,6 +115,8 @@ func (suite *MetricsTestSuite) TearDownSuite() {
 // The SetupTest method will be run before every test in the suite.
 func (suite *MetricsTestSuite) SetupTest() {
 	suite.db.Unscoped().Delete(new(ResponseTime))
+	suite.db.Unscoped().Delete(new(ResponseTimeRollup))
+	suite.db.Unscoped().Delete(new(CheckResult))
 
 	// Delete sub tables
 	var subTables []*SubTable
@@ -125,6 +135,10 @@ func (suite *MetricsTestSuite) SetupTest() {
 	suite.oauthServiceMock.Calls = suite.oauthServiceMock.Calls[:0]
 	suite.accountsServiceMock.ExpectedCalls = suite.accountsServiceMock.ExpectedCalls[:0]
 	suite.accountsServiceMock.Calls = suite.accountsServiceMock.Calls[:0]
+	suite.subscriptionsServiceMock.ExpectedCalls = suite.subscriptionsServiceMock.ExpectedCalls[:0]
+	suite.subscriptionsServiceMock.Calls = suite.subscriptionsServiceMock.Calls[:0]
+	suite.teamsServiceMock.ExpectedCalls = suite.teamsServiceMock.ExpectedCalls[:0]
+	suite.teamsServiceMock.Calls = suite.teamsServiceMock.Calls[:0]
 }
 
 // The TearDownTest method will be run after every test in the suite.
@@ -0,0 +1,74 @@
+package metrics
+
+const (
+	// PhaseTotal is the total response time
+	PhaseTotal = "total"
+	// PhaseDNS is the DNS lookup
+	PhaseDNS = "dns"
+	// PhaseConnect is the TCP connect
+	PhaseConnect = "connect"
+	// PhaseTLS is the TLS handshake
+	PhaseTLS = "tls"
+	// PhaseFirstByte is the time from writing the request to the first response byte
+	PhaseFirstByte = "ttfb"
+	// PhaseTransfer is the time it took to read the response body
+	PhaseTransfer = "transfer"
+)
+
+// phaseColumns maps allowed phases to columns, it doubles as
+// a whitelist as the column names are inlined into queries
+var phaseColumns = map[string]string{
+	PhaseTotal:     "value",
+	PhaseDNS:       "dns_lookup",
+	PhaseConnect:   "connect",
+	PhaseTLS:       "tls_handshake",
+	PhaseFirstByte: "first_byte",
+	PhaseTransfer:  "transfer",
+}
+
+// Timings holds durations of phases of a request in nanoseconds,
+// phases which did not happen (e.g. reused connection) are zero
+type Timings struct {
+	DNSLookup    int64
+	Connect      int64
+	TLSHandshake int64
+	FirstByte    int64
+	Transfer     int64
+}
+
+// phaseColumn returns a column storing values of a phase
+func phaseColumn(phase string) string {
+	if column, ok := phaseColumns[phase]; ok {
+		return column
+	}
+	return phaseColumns[PhaseTotal]
+}
+
+// setTimings copies timings to the response time record
+func (r *ResponseTime) setTimings(timings *Timings) {
+	if timings == nil {
+		return
+	}
+	r.DNSLookup = timings.DNSLookup
+	r.Connect = timings.Connect
+	r.TLSHandshake = timings.TLSHandshake
+	r.FirstByte = timings.FirstByte
+	r.Transfer = timings.Transfer
+}
+
+// phaseValue returns a value of a phase of the response time record
+func (r *ResponseTime) phaseValue(phase string) int64 {
+	switch phase {
+	case PhaseDNS:
+		return r.DNSLookup
+	case PhaseConnect:
+		return r.Connect
+	case PhaseTLS:
+		return r.TLSHandshake
+	case PhaseFirstByte:
+		return r.FirstByte
+	case PhaseTransfer:
+		return r.Transfer
+	}
+	return r.Value
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTimingsResponse(t *testing.T) {
+	// Aggregated response times don't have timings
+	assert.Nil(t, NewTimingsResponse(&ResponseTime{Value: 123}))
+
+	// Phases which did not happen are zeros
+	responseTime := &ResponseTime{Value: 123}
+	responseTime.setTimings(&Timings{FirstByte: 100, Transfer: 20})
+	assert.Equal(t, &TimingsResponse{FirstByte: 100, Transfer: 20}, NewTimingsResponse(responseTime))
+	assert.Equal(t, int64(100), responseTime.phaseValue(PhaseFirstByte))
+	assert.Equal(t, int64(123), responseTime.phaseValue(PhaseTotal))
+}
+
+func (suite *MetricsTestSuite) TestResponseTimePhases() {
+	var (
+		today             = time.Date(2016, time.February, 9, 0, 0, 0, 0, time.UTC)
+		todaySubTableName = "metrics_response_times_2016_02_09"
+		responseTimes     []*ResponseTime
+		aggregations      map[string]float64
+		err               error
+	)
+
+	// Partition the response time table
+	err = suite.service.PartitionResponseTime(ResponseTimeParentTableName, today)
+	assert.NoError(suite.T(), err, "Partitioning table failed")
+
+	// Insert some test records with timings
+	for i, firstByte := range []int64{10, 20, 30} {
+		testRecord := NewResponseTime(todaySubTableName, 1, today.Add(time.Duration(i)*time.Minute), 100)
+		testRecord.setTimings(&Timings{DNSLookup: 5, FirstByte: firstByte})
+		err := suite.db.Create(testRecord).Error
+		assert.NoError(suite.T(), err, "Inserting test data failed")
+	}
+
+	// Raw samples should return the phase as the value
+	responseTimes, err = suite.service.FindPaginatedResponseTimes(
+		0,              // offset
+		25,             // limit
+		"",             // order by
+		1,              // reference ID
+		"",             // date trunc
+		PhaseFirstByte, // phase
+		nil,            // aggregations
+		nil,            // from
+		nil,            // to
+	)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 3, len(responseTimes)) {
+		assert.Equal(suite.T(), int64(10), responseTimes[0].Value)
+		assert.Equal(suite.T(), int64(5), responseTimes[0].DNSLookup)
+		assert.Equal(suite.T(), int64(10), NewTimingsResponse(responseTimes[0]).FirstByte)
+	}
+
+	// Aggregated buckets of the phase
+	responseTimes, err = suite.service.FindPaginatedResponseTimes(
+		0,                        // offset
+		25,                       // limit
+		"",                       // order by
+		1,                        // reference ID
+		"hour",                   // date trunc
+		PhaseFirstByte,           // phase
+		[]string{AggregationMax}, // aggregations
+		nil,                      // from
+		nil,                      // to
+	)
+	if assert.NoError(suite.T(), err) && assert.Equal(suite.T(), 1, len(responseTimes)) {
+		assert.Equal(suite.T(), int64(20), responseTimes[0].Value)
+		assert.Equal(suite.T(), 30.0, responseTimes[0].Aggregations[AggregationMax])
+	}
+
+	// Aggregations of the phase over the whole time range
+	aggregations, err = suite.service.ResponseTimesAggregations(
+		1,        // reference ID
+		PhaseDNS, // phase
+		[]string{AggregationAvg, AggregationCount},
+		nil, // from
+		nil, // to
+	)
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 5.0, aggregations[AggregationAvg])
+		assert.Equal(suite.T(), 3.0, aggregations[AggregationCount])
+	}
+}
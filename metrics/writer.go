@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// DefaultWriterBufferSize is used when buffer size is not configured
+	DefaultWriterBufferSize = 10000
+	// DefaultWriterBatchSize is used when batch size is not configured
+	DefaultWriterBatchSize = 500
+	// DefaultWriterFlushInterval is used when flush interval is not configured
+	DefaultWriterFlushInterval = time.Second
+	// maxWriterBatchSize keeps number of query params under Postgres limit
+	maxWriterBatchSize = 5000
+	// maxWriterAttempts is how many times failed records are written before
+	// they are dropped
+	maxWriterAttempts = 10
+	// maxWriterRetryBackoff caps the delay between retries of failed records
+	maxWriterRetryBackoff = time.Minute
+)
+
+var (
+	// ErrWriterClosed ...
+	ErrWriterClosed = errors.New("Metrics writer is closed")
+)
+
+// bufferedRecord is a row which can be inserted by the Writer
+type bufferedRecord interface {
+	TableName() string
+	columns() []string
+	values() []interface{}
+}
+
+// Writer buffers records (response times, check results) in memory and
+// inserts them in batches when either the batch size or the flush interval
+// is reached, writes block when the buffer is full until there is space again.
+// Records which fail to be written are retried with a backoff, at most buffer
+// size of them are kept, the oldest ones get dropped first
+type Writer struct {
+	db            *gorm.DB
+	bufferSize    int
+	batchSize     int
+	flushInterval time.Duration
+	buffer        chan bufferedRecord
+	flushRequests chan chan error
+	done          chan struct{}
+	mutex         sync.RWMutex
+	closed        bool
+}
+
+// NewWriter starts a new Writer instance
+func NewWriter(db *gorm.DB, bufferSize, batchSize int, flushInterval time.Duration) *Writer {
+	if bufferSize <= 0 {
+		bufferSize = DefaultWriterBufferSize
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultWriterBatchSize
+	}
+	if batchSize > maxWriterBatchSize {
+		batchSize = maxWriterBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = DefaultWriterFlushInterval
+	}
+
+	w := &Writer{
+		db:            db,
+		bufferSize:    bufferSize,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		buffer:        make(chan bufferedRecord, bufferSize),
+		flushRequests: make(chan chan error),
+		done:          make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write adds a record to the buffer, it blocks when the buffer is full
+func (w *Writer) Write(record bufferedRecord) error {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+	w.buffer <- record
+	return nil
+}
+
+// Flush writes all buffered records to the database
+func (w *Writer) Flush() error {
+	w.mutex.RLock()
+	if w.closed {
+		w.mutex.RUnlock()
+		return ErrWriterClosed
+	}
+	result := make(chan error)
+	w.flushRequests <- result
+	w.mutex.RUnlock()
+
+	return <-result
+}
+
+// Close stops accepting new records and flushes the buffer
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.buffer)
+	w.mutex.Unlock()
+
+	<-w.done
+	return nil
+}
+
+// run collects records into batches until the buffer is closed
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	var (
+		batch   = make([]bufferedRecord, 0, w.batchSize)
+		retries = new(writerRetries)
+	)
+	for {
+		select {
+		case record, ok := <-w.buffer:
+			if !ok {
+				w.write(retries, batch, true)
+				countDropped(retries.records)
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= w.batchSize {
+				w.write(retries, batch, false)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.write(retries, batch, false)
+			batch = batch[:0]
+		case result := <-w.flushRequests:
+			// Take everything buffered so far
+			for i := len(w.buffer); i > 0; i-- {
+				batch = append(batch, <-w.buffer)
+			}
+			result <- w.write(retries, batch, true)
+			batch = batch[:0]
+		}
+	}
+}
+
+// writerRetries keeps records which failed to be written until their retry
+type writerRetries struct {
+	records  []bufferedRecord
+	attempts int
+	retryAt  time.Time
+}
+
+// write inserts the batch together with records which failed before, while
+// retries back off the batch just joins them unless forced to be written
+func (w *Writer) write(retries *writerRetries, batch []bufferedRecord, force bool) error {
+	if len(retries.records) == 0 && len(batch) == 0 {
+		return nil
+	}
+	records := make([]bufferedRecord, 0, len(retries.records)+len(batch))
+	records = append(records, retries.records...)
+	records = append(records, batch...)
+
+	if len(retries.records) > 0 && !force && time.Now().Before(retries.retryAt) {
+		retries.records = w.boundRetries(records)
+		return nil
+	}
+
+	failed, err := w.insert(records)
+	if err == nil {
+		retries.records, retries.attempts = nil, 0
+		return nil
+	}
+
+	// Give up on records which keep failing
+	retries.attempts++
+	if retries.attempts >= maxWriterAttempts {
+		logger.ERROR.Printf("Dropping %d records after %d failed writes", len(failed), retries.attempts)
+		countDropped(failed)
+		retries.records, retries.attempts = nil, 0
+		return err
+	}
+
+	// Retry with an exponential backoff
+	backoff := w.flushInterval << uint(retries.attempts)
+	if backoff > maxWriterRetryBackoff {
+		backoff = maxWriterRetryBackoff
+	}
+	retries.records = w.boundRetries(failed)
+	retries.retryAt = time.Now().Add(backoff)
+	return err
+}
+
+// boundRetries drops the oldest records so no more than buffer size
+// of them wait for a retry
+func (w *Writer) boundRetries(records []bufferedRecord) []bufferedRecord {
+	if len(records) <= w.bufferSize {
+		return records
+	}
+	dropped := len(records) - w.bufferSize
+	logger.ERROR.Printf("Dropping %d records, too many failed writes to retry", dropped)
+	countDropped(records[:dropped])
+	return append([]bufferedRecord(nil), records[dropped:]...)
+}
+
+// insert writes a batch of records using multi row inserts per table and
+// returns records which failed to be written
+func (w *Writer) insert(batch []bufferedRecord) ([]bufferedRecord, error) {
+	// Group records by table keeping the order of tables
+	var (
+		tables  []string
+		byTable = make(map[string][]bufferedRecord)
+	)
+	for _, record := range batch {
+		table := record.TableName()
+		if _, ok := byTable[table]; !ok {
+			tables = append(tables, table)
+		}
+		byTable[table] = append(byTable[table], record)
+	}
+
+	var (
+		failed   []bufferedRecord
+		firstErr error
+	)
+	for _, table := range tables {
+		remaining, err := w.insertTable(table, byTable[table])
+		if err != nil {
+			failed = append(failed, remaining...)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return failed, firstErr
+}
+
+// insertTable writes records of a single table in chunks of batch size, on
+// error the records which have not been written are returned
+func (w *Writer) insertTable(table string, records []bufferedRecord) ([]bufferedRecord, error) {
+	columns := records[0].columns()
+	placeholders := "(?" + strings.Repeat(", ?", len(columns)-1) + ")"
+
+	for len(records) > 0 {
+		chunk := records
+		if len(chunk) > w.batchSize {
+			chunk = chunk[:w.batchSize]
+		}
+
+		var (
+			values = make([]string, len(chunk))
+			args   = make([]interface{}, 0, len(chunk)*len(columns))
+		)
+		for i, record := range chunk {
+			values[i] = placeholders
+			args = append(args, record.values()...)
+		}
+		sql := fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES %s",
+			table,
+			strings.Join(columns, ", "),
+			strings.Join(values, ", "),
+		)
+		if err := w.db.Exec(sql, args...).Error; err != nil {
+			logger.ERROR.Printf("Writing %d records to %s failed: %s", len(chunk), table, err)
+			return records, err
+		}
+		records = records[len(chunk):]
+	}
+	return nil, nil
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *MetricsTestSuite) TestWriterFlushesFullBatches() {
+	var (
+		writer = NewWriter(suite.db, 10, 2, time.Hour)
+		now    = time.Now().UTC()
+		count  int
+		err    error
+	)
+
+	// The first sample is kept in the buffer
+	err = writer.Write(NewResponseTime(ResponseTimeParentTableName, 1, now, 123))
+	assert.NoError(suite.T(), err)
+	suite.db.Model(new(ResponseTime)).Count(&count)
+	assert.Equal(suite.T(), 0, count)
+
+	// Filling the batch writes it
+	err = writer.Write(NewResponseTime(ResponseTimeParentTableName, 1, now, 234))
+	assert.NoError(suite.T(), err)
+	err = writer.Write(NewResponseTime(ResponseTimeParentTableName, 1, now, 345))
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), writer.Flush())
+	suite.db.Model(new(ResponseTime)).Count(&count)
+	assert.Equal(suite.T(), 3, count)
+
+	// Closing the writer flushes the rest of the buffer
+	err = writer.Write(NewResponseTime(ResponseTimeParentTableName, 2, now, 456))
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), writer.Close())
+	suite.db.Model(new(ResponseTime)).Count(&count)
+	assert.Equal(suite.T(), 4, count)
+
+	// Closed writer does not accept new samples
+	err = writer.Write(NewResponseTime(ResponseTimeParentTableName, 2, now, 567))
+	assert.Equal(suite.T(), ErrWriterClosed, err)
+	assert.Equal(suite.T(), ErrWriterClosed, writer.Flush())
+}
+
+func (suite *MetricsTestSuite) TestWriterFlushesOnInterval() {
+	var (
+		writer = NewWriter(suite.db, 10, 100, 10*time.Millisecond)
+		now    = time.Now().UTC()
+		count  int
+	)
+	defer writer.Close()
+
+	err := writer.Write(NewResponseTime(ResponseTimeParentTableName, 1, now, 123))
+	assert.NoError(suite.T(), err)
+
+	// The ticker should write the incomplete batch
+	for i := 0; i < 100 && count == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		suite.db.Model(new(ResponseTime)).Count(&count)
+	}
+	assert.Equal(suite.T(), 1, count)
+}
+
+// writerTestRecord is written to a table the test creates and drops
+type writerTestRecord struct {
+	value int64
+}
+
+func (r *writerTestRecord) TableName() string {
+	return "metrics_writer_test_records"
+}
+
+func (r *writerTestRecord) columns() []string {
+	return []string{"value"}
+}
+
+func (r *writerTestRecord) values() []interface{} {
+	return []interface{}{r.value}
+}
+
+func (suite *MetricsTestSuite) TestWriterRetriesFailedBatches() {
+	var (
+		writer = NewWriter(suite.db, 2, 10, time.Hour)
+		record = new(writerTestRecord)
+		count  int
+	)
+	defer suite.db.Exec("DROP TABLE IF EXISTS metrics_writer_test_records")
+
+	droppedRecords := func() float64 {
+		for _, family := range writerDropped.Collect() {
+			for _, sample := range family.Samples {
+				if sample.Labels["table"] == record.TableName() {
+					return sample.Value
+				}
+			}
+		}
+		return 0
+	}
+	droppedBefore := droppedRecords()
+
+	// The table is missing so writes fail, only buffer size of records
+	// are kept for a retry and the oldest one is dropped
+	for i := 1; i <= 3; i++ {
+		assert.NoError(suite.T(), writer.Write(&writerTestRecord{value: int64(i)}))
+	}
+	assert.Error(suite.T(), writer.Flush())
+	assert.Equal(suite.T(), float64(1), droppedRecords()-droppedBefore)
+
+	// Once the database is back the kept records get written
+	err := suite.db.Exec("CREATE TABLE metrics_writer_test_records (value bigint)").Error
+	assert.NoError(suite.T(), err, "Creating test table failed")
+	assert.NoError(suite.T(), writer.Flush())
+	suite.db.Table(record.TableName()).Count(&count)
+	assert.Equal(suite.T(), 2, count)
+	assert.NoError(suite.T(), writer.Close())
+	assert.Equal(suite.T(), float64(1), droppedRecords()-droppedBefore)
+}
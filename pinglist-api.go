@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/RichardKnop/pinglist-api/agent"
 	"github.com/RichardKnop/pinglist-api/cmd"
 	"github.com/urfave/cli"
 )
@@ -74,6 +75,41 @@ func main() {
 				return cmd.RunAll()
 			},
 		},
+		{
+			Name:  "agent",
+			Usage: "run a region agent checking alarms of its region",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "api-url",
+					Usage:  "URL of the API",
+					EnvVar: "PINGLIST_API_URL",
+				},
+				cli.StringFlag{
+					Name:   "client-id",
+					Usage:  "client ID of the region's agent account",
+					EnvVar: "PINGLIST_AGENT_CLIENT_ID",
+				},
+				cli.StringFlag{
+					Name:   "client-secret",
+					Usage:  "client secret of the region's agent account",
+					EnvVar: "PINGLIST_AGENT_CLIENT_SECRET",
+				},
+				cli.IntFlag{
+					Name:   "workers",
+					Usage:  "number of concurrent checks",
+					Value:  agent.DefaultWorkers,
+					EnvVar: "PINGLIST_AGENT_WORKERS",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return cmd.RunAgent(
+					c.String("api-url"),
+					c.String("client-id"),
+					c.String("client-secret"),
+					c.Int("workers"),
+				)
+			},
+		},
 	}
 
 	// Run the CLI app
@@ -0,0 +1,122 @@
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentType of the text exposition format
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+	// TypeCounter is a cumulative metric which only goes up
+	TypeCounter = "counter"
+	// TypeGauge is a metric which can go up and down
+	TypeGauge = "gauge"
+	// TypeHistogram is a metric with observations counted in buckets
+	TypeHistogram = "histogram"
+)
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// Labels are name / value pairs identifying a sample
+type Labels map[string]string
+
+// Sample is a single value of a metric family, the suffix is appended to
+// the family name (e.g. _bucket, _sum and _count of histograms)
+type Sample struct {
+	Suffix string
+	Labels Labels
+	Value  float64
+}
+
+// Family is a group of samples of the same metric
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []*Sample
+}
+
+// NewFamily creates new Family instance
+func NewFamily(name, help, metricType string) *Family {
+	return &Family{
+		Name:    name,
+		Help:    help,
+		Type:    metricType,
+		Samples: make([]*Sample, 0),
+	}
+}
+
+// Add adds a sample to the family
+func (f *Family) Add(value float64, labels Labels) {
+	f.Samples = append(f.Samples, &Sample{Labels: labels, Value: value})
+}
+
+// AddWithSuffix adds a sample with a name suffix to the family
+func (f *Family) AddWithSuffix(suffix string, value float64, labels Labels) {
+	f.Samples = append(f.Samples, &Sample{Suffix: suffix, Labels: labels, Value: value})
+}
+
+// WriteText writes metric families in the Prometheus text exposition format
+// (https://prometheus.io/docs/instrumenting/exposition_formats/), families
+// without samples are skipped, labels are written sorted by name
+func WriteText(w io.Writer, families []*Family) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range families {
+		if len(family.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n", family.Name, helpReplacer.Replace(family.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", family.Name, family.Type)
+		for _, sample := range family.Samples {
+			bw.WriteString(family.Name)
+			bw.WriteString(sample.Suffix)
+			writeLabels(bw, sample.Labels)
+			bw.WriteByte(' ')
+			bw.WriteString(FormatValue(sample.Value))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// FormatValue formats a sample value, including the special values
+func FormatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func writeLabels(bw *bufio.Writer, labels Labels) {
+	if len(labels) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		fmt.Fprintf(bw, `%s="%s"`, name, labelValueReplacer.Replace(labels[name]))
+	}
+	bw.WriteByte('}')
+}
@@ -0,0 +1,49 @@
+package prometheus
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteText(t *testing.T) {
+	gauge := NewFamily("test_state", "State of a thing,\nmultiline", TypeGauge)
+	gauge.Add(1, Labels{"region": "us-west-2", "id": "1"})
+	gauge.Add(0.25, Labels{"id": "2", "endpoint": `http://foo/"bar"\`})
+
+	// Families without samples are skipped
+	empty := NewFamily("test_empty", "Nothing", TypeCounter)
+
+	histogram := NewFamily("test_duration_seconds", "Durations", TypeHistogram)
+	histogram.AddWithSuffix("_bucket", 1, Labels{"le": "0.5"})
+	histogram.AddWithSuffix("_bucket", 2, Labels{"le": "+Inf"})
+	histogram.AddWithSuffix("_sum", 1.5, nil)
+	histogram.AddWithSuffix("_count", 2, nil)
+
+	var buf bytes.Buffer
+	err := WriteText(&buf, []*Family{gauge, empty, histogram})
+	assert.NoError(t, err)
+
+	expected := "# HELP test_state State of a thing,\\nmultiline\n" +
+		"# TYPE test_state gauge\n" +
+		"test_state{id=\"1\",region=\"us-west-2\"} 1\n" +
+		"test_state{endpoint=\"http://foo/\\\"bar\\\"\\\\\",id=\"2\"} 0.25\n" +
+		"# HELP test_duration_seconds Durations\n" +
+		"# TYPE test_duration_seconds histogram\n" +
+		"test_duration_seconds_bucket{le=\"0.5\"} 1\n" +
+		"test_duration_seconds_bucket{le=\"+Inf\"} 2\n" +
+		"test_duration_seconds_sum 1.5\n" +
+		"test_duration_seconds_count 2\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "42", FormatValue(42))
+	assert.Equal(t, "0.001", FormatValue(0.001))
+	assert.Equal(t, "1e+21", FormatValue(1e21))
+	assert.Equal(t, "NaN", FormatValue(math.NaN()))
+	assert.Equal(t, "+Inf", FormatValue(math.Inf(1)))
+	assert.Equal(t, "-Inf", FormatValue(math.Inf(-1)))
+}
@@ -0,0 +1,74 @@
+package prometheus
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/RichardKnop/pinglist-api/logger"
+)
+
+// Collector returns metric families when the registry is gathered
+type Collector interface {
+	Collect() []*Family
+}
+
+// CollectorFunc adapts an ordinary function to the Collector interface
+type CollectorFunc func() []*Family
+
+// Collect calls f()
+func (f CollectorFunc) Collect() []*Family {
+	return f()
+}
+
+// Registry holds collectors exposed by the process
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+// DefaultRegistry is used by the package level NewCounterVec,
+// NewGaugeVec and NewHistogramVec helpers
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates new Registry instance
+func NewRegistry() *Registry {
+	return &Registry{collectors: make([]Collector, 0)}
+}
+
+// Register adds a collector to the registry
+func (r *Registry) Register(collector Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collector)
+}
+
+// Gather collects metric families from all registered collectors sorted by name
+func (r *Registry) Gather() []*Family {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	families := make([]*Family, 0, len(r.collectors))
+	for _, collector := range r.collectors {
+		families = append(families, collector.Collect()...)
+	}
+	sort.SliceStable(families, func(i, j int) bool {
+		return families[i].Name < families[j].Name
+	})
+	return families
+}
+
+// Handler returns a http.Handler writing gathered families in the text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := WriteText(w, r.Gather()); err != nil {
+			logger.ERROR.Printf("Write metrics error: %s", err)
+		}
+	})
+}
+
+// Register adds a collector to the default registry
+func Register(collector Collector) {
+	DefaultRegistry.Register(collector)
+}
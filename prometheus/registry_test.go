@@ -0,0 +1,74 @@
+package prometheus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	requests := NewCounterVec("test_requests_total", "Requests", "route", "code")
+	requests.Inc("get_alarm", "200")
+	requests.Inc("get_alarm", "200")
+	requests.Add(3, "list_alarms", "500")
+	requests.Add(-1, "list_alarms", "500") // counters never go down
+	registry.Register(requests)
+
+	inUse := NewGaugeVec("test_in_use", "In use")
+	inUse.Set(5)
+	inUse.Add(-2)
+	registry.Register(inUse)
+
+	duration := NewHistogramVec("test_duration_seconds", "Durations", []float64{1, 0.1}, "job")
+	duration.Observe(0.05, "check")
+	duration.Observe(0.5, "check")
+	duration.Observe(2, "check")
+	registry.Register(duration)
+
+	registry.Register(CollectorFunc(func() []*Family {
+		family := NewFamily("test_custom", "Custom", TypeGauge)
+		family.Add(1, nil)
+		return []*Family{family}
+	}))
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteText(&buf, registry.Gather()))
+
+	expected := "# HELP test_custom Custom\n" +
+		"# TYPE test_custom gauge\n" +
+		"test_custom 1\n" +
+		"# HELP test_duration_seconds Durations\n" +
+		"# TYPE test_duration_seconds histogram\n" +
+		"test_duration_seconds_bucket{job=\"check\",le=\"0.1\"} 1\n" +
+		"test_duration_seconds_bucket{job=\"check\",le=\"1\"} 2\n" +
+		"test_duration_seconds_bucket{job=\"check\",le=\"+Inf\"} 3\n" +
+		"test_duration_seconds_sum{job=\"check\"} 2.55\n" +
+		"test_duration_seconds_count{job=\"check\"} 3\n" +
+		"# HELP test_in_use In use\n" +
+		"# TYPE test_in_use gauge\n" +
+		"test_in_use 3\n" +
+		"# HELP test_requests_total Requests\n" +
+		"# TYPE test_requests_total counter\n" +
+		"test_requests_total{code=\"200\",route=\"get_alarm\"} 2\n" +
+		"test_requests_total{code=\"500\",route=\"list_alarms\"} 3\n"
+	assert.Equal(t, expected, buf.String())
+
+	// Wrong number of label values is a programming error
+	assert.Panics(t, func() {
+		requests.Inc("get_alarm")
+	})
+
+	// Handler serves the text format
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "http://1.2.3.4/metrics", nil)
+	assert.NoError(t, err)
+	registry.Handler().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, expected, w.Body.String())
+}
@@ -0,0 +1,219 @@
+package prometheus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram buckets suitable for request latencies in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// labelSeparator joins label values into a map key, it cannot appear in UTF-8 text
+const labelSeparator = "\xff"
+
+type metricVec struct {
+	mu         sync.Mutex
+	name       string
+	help       string
+	labelNames []string
+}
+
+func (v *metricVec) key(labelValues []string) string {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf(
+			"%s: expected %d label values, got %d",
+			v.name,
+			len(v.labelNames),
+			len(labelValues),
+		))
+	}
+	return strings.Join(labelValues, labelSeparator)
+}
+
+func (v *metricVec) labels(key string) Labels {
+	labels := make(Labels, len(v.labelNames))
+	if len(v.labelNames) == 0 {
+		return labels
+	}
+	for i, value := range strings.Split(key, labelSeparator) {
+		labels[v.labelNames[i]] = value
+	}
+	return labels
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a counter partitioned by label values
+type CounterVec struct {
+	metricVec
+	values map[string]float64
+}
+
+// NewCounterVec creates a CounterVec registered with the default registry
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		metricVec: metricVec{name: name, help: help, labelNames: labelNames},
+		values:    make(map[string]float64),
+	}
+	Register(c)
+	return c
+}
+
+// Inc increments the counter by one
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter by a non negative value
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+	key := c.key(labelValues)
+	c.mu.Lock()
+	c.values[key] += value
+	c.mu.Unlock()
+}
+
+// Collect returns the counter family
+func (c *CounterVec) Collect() []*Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	family := NewFamily(c.name, c.help, TypeCounter)
+	for _, key := range sortedKeys(c.values) {
+		family.Add(c.values[key], c.labels(key))
+	}
+	return []*Family{family}
+}
+
+// GaugeVec is a gauge partitioned by label values
+type GaugeVec struct {
+	metricVec
+	values map[string]float64
+}
+
+// NewGaugeVec creates a GaugeVec registered with the default registry
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{
+		metricVec: metricVec{name: name, help: help, labelNames: labelNames},
+		values:    make(map[string]float64),
+	}
+	Register(g)
+	return g
+}
+
+// Set sets the gauge to a value
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	g.values[key] = value
+	g.mu.Unlock()
+}
+
+// Add adds a value (which can be negative) to the gauge
+func (g *GaugeVec) Add(value float64, labelValues ...string) {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	g.values[key] += value
+	g.mu.Unlock()
+}
+
+// Collect returns the gauge family
+func (g *GaugeVec) Collect() []*Family {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	family := NewFamily(g.name, g.help, TypeGauge)
+	for _, key := range sortedKeys(g.values) {
+		family.Add(g.values[key], g.labels(key))
+	}
+	return []*Family{family}
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec is a histogram partitioned by label values
+type HistogramVec struct {
+	metricVec
+	buckets    []float64
+	histograms map[string]*histogram
+}
+
+// NewHistogramVec creates a HistogramVec registered with the default
+// registry, nil buckets mean DefaultBuckets
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{
+		metricVec:  metricVec{name: name, help: help, labelNames: labelNames},
+		buckets:    buckets,
+		histograms: make(map[string]*histogram),
+	}
+	Register(h)
+	return h
+}
+
+// Observe adds a single observation to the histogram
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist, ok := h.histograms[key]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.histograms[key] = hist
+	}
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += value
+}
+
+// Collect returns the histogram family with cumulative buckets
+func (h *HistogramVec) Collect() []*Family {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.histograms))
+	for key := range h.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	family := NewFamily(h.name, h.help, TypeHistogram)
+	for _, key := range keys {
+		hist := h.histograms[key]
+		for i, upperBound := range h.buckets {
+			labels := h.labels(key)
+			labels["le"] = FormatValue(upperBound)
+			family.AddWithSuffix("_bucket", float64(hist.counts[i]), labels)
+		}
+		labels := h.labels(key)
+		labels["le"] = "+Inf"
+		family.AddWithSuffix("_bucket", float64(hist.count), labels)
+		family.AddWithSuffix("_sum", hist.sum, h.labels(key))
+		family.AddWithSuffix("_count", float64(hist.count), h.labels(key))
+	}
+	return []*Family{family}
+}
@@ -0,0 +1,136 @@
+package response
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ExportFormatCSV is a comma separated values export with a header row
+	ExportFormatCSV = "csv"
+	// ExportFormatNDJSON is a newline delimited JSON export, one object per line
+	ExportFormatNDJSON = "ndjson"
+	// exportFlushInterval is a number of records written between flushes
+	exportFlushInterval = 500
+)
+
+var (
+	// ErrInvalidExportFormat ...
+	ErrInvalidExportFormat = errors.New("Invalid format value. Use one of: csv, ndjson")
+
+	exportContentTypes = map[string]string{
+		ExportFormatCSV:    "text/csv; charset=utf-8",
+		ExportFormatNDJSON: "application/x-ndjson",
+	}
+	exportMediaTypes = map[string]string{
+		"text/csv":             ExportFormatCSV,
+		"application/x-ndjson": ExportFormatNDJSON,
+		"application/ndjson":   ExportFormatNDJSON,
+	}
+)
+
+// ExportRecord is a single row of an export, NDJSON exports marshal the
+// record itself while CSV exports write the values of CSVRecord
+type ExportRecord interface {
+	CSVRecord() []string
+}
+
+// GetExportFormat negotiates an export format, the format query string
+// parameter takes precedence over the Accept header, CSV is the default
+func GetExportFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := exportContentTypes[format]; !ok {
+			return "", ErrInvalidExportFormat
+		}
+		return format, nil
+	}
+
+	for _, mediaType := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType = strings.TrimSpace(strings.Split(mediaType, ";")[0])
+		if format, ok := exportMediaTypes[strings.ToLower(mediaType)]; ok {
+			return format, nil
+		}
+	}
+
+	return ExportFormatCSV, nil
+}
+
+// ExportWriter streams export records to the response, records are
+// flushed to the client periodically so large exports are never buffered
+// in memory. Once the first record has been written the status code can
+// no longer change, errors can only be handled by ending the response
+type ExportWriter struct {
+	w           http.ResponseWriter
+	format      string
+	csvWriter   *csv.Writer
+	jsonEncoder *json.Encoder
+	written     int
+}
+
+// NewExportWriter writes headers of an export response and returns a writer
+// for its records, CSV exports start with the header row
+func NewExportWriter(w http.ResponseWriter, format, filename string, csvHeader []string) (*ExportWriter, error) {
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return nil, ErrInvalidExportFormat
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=\"%s.%s\"",
+		filename,
+		format,
+	))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	exportWriter := &ExportWriter{w: w, format: format}
+	if format == ExportFormatNDJSON {
+		exportWriter.jsonEncoder = json.NewEncoder(w)
+		return exportWriter, nil
+	}
+
+	exportWriter.csvWriter = csv.NewWriter(w)
+	if err := exportWriter.csvWriter.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	return exportWriter, nil
+}
+
+// Write writes a single record
+func (ew *ExportWriter) Write(record ExportRecord) error {
+	var err error
+	if ew.format == ExportFormatNDJSON {
+		err = ew.jsonEncoder.Encode(record)
+	} else {
+		err = ew.csvWriter.Write(record.CSVRecord())
+	}
+	if err != nil {
+		return err
+	}
+
+	ew.written++
+	if ew.written%exportFlushInterval == 0 {
+		return ew.Flush()
+	}
+	return nil
+}
+
+// Flush sends records written so far to the client
+func (ew *ExportWriter) Flush() error {
+	if ew.csvWriter != nil {
+		ew.csvWriter.Flush()
+		if err := ew.csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := ew.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
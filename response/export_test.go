@@ -0,0 +1,90 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testExportRecord struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (r *testExportRecord) CSVRecord() []string {
+	return []string{strconv.Itoa(r.ID), r.Name}
+}
+
+func TestGetExportFormat(t *testing.T) {
+	var (
+		r      *http.Request
+		format string
+		err    error
+	)
+
+	// CSV by default
+	r, err = http.NewRequest("GET", "http://1.2.3.4/export", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	r.Header.Set("Accept", "*/*")
+	format, err = GetExportFormat(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ExportFormatCSV, format)
+	}
+
+	// Accept header
+	r.Header.Set("Accept", "application/json;q=0.9, application/x-ndjson")
+	format, err = GetExportFormat(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ExportFormatNDJSON, format)
+	}
+
+	// Query string takes precedence over the Accept header
+	r, err = http.NewRequest("GET", "http://1.2.3.4/export?format=csv", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	r.Header.Set("Accept", "application/x-ndjson")
+	format, err = GetExportFormat(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ExportFormatCSV, format)
+	}
+
+	// Invalid format
+	r, err = http.NewRequest("GET", "http://1.2.3.4/export?format=xml", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	_, err = GetExportFormat(r)
+	assert.Equal(t, ErrInvalidExportFormat, err)
+}
+
+func TestExportWriterCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	exportWriter, err := NewExportWriter(w, ExportFormatCSV, "things", []string{"id", "name"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, exportWriter.Write(&testExportRecord{ID: 1, Name: "foo"}))
+	assert.NoError(t, exportWriter.Write(&testExportRecord{ID: 2, Name: "bar, \"qux\""}))
+	assert.NoError(t, exportWriter.Flush())
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=\"things.csv\"", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,name\n1,foo\n2,\"bar, \"\"qux\"\"\"\n", w.Body.String())
+}
+
+func TestExportWriterNDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	exportWriter, err := NewExportWriter(w, ExportFormatNDJSON, "things", []string{"id", "name"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, exportWriter.Write(&testExportRecord{ID: 1, Name: "foo"}))
+	assert.NoError(t, exportWriter.Write(&testExportRecord{ID: 2, Name: "bar"}))
+	assert.NoError(t, exportWriter.Flush())
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=\"things.ndjson\"", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "{\"id\":1,\"name\":\"foo\"}\n{\"id\":2,\"name\":\"bar\"}\n", w.Body.String())
+}
@@ -1,9 +1,12 @@
 package response
 
 import (
+	"crypto/sha1"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
 const realm = "pinglist-api"
@@ -17,6 +20,24 @@ func WriteJSON(w http.ResponseWriter, v interface{}, code int) {
 	json.NewEncoder(w).Encode(v)
 }
 
+// WriteCacheable writes a response which can be cached by clients and
+// proxies for maxAge, the ETag is a hash of the body so conditional requests
+// with a matching If-None-Match header get a 304 not modified response
+func WriteCacheable(w http.ResponseWriter, r *http.Request, contentType string, body []byte, maxAge time.Duration) {
+	etag := fmt.Sprintf("\"%x\"", sha1.Sum(body))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
 // NoContent writes a 204 no content response
 func NoContent(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNoContent)
@@ -37,3 +58,14 @@ func UnauthorizedError(w http.ResponseWriter, err string) {
 	w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=%s", realm))
 	Error(w, err, http.StatusUnauthorized)
 }
+
+// etagMatches checks whether an If-None-Match header value matches the etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
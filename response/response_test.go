@@ -2,9 +2,12 @@ package response
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -42,3 +45,36 @@ func TestError(t *testing.T) {
 	expected := "{\"error\":\"something went wrong\"}"
 	assert.Equal(t, expected, strings.TrimSpace(w.Body.String()))
 }
+
+func TestWriteCacheable(t *testing.T) {
+	body := []byte("<svg></svg>")
+
+	r, err := http.NewRequest("GET", "http://1.2.3.4/badge.svg", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	w := httptest.NewRecorder()
+	WriteCacheable(w, r, "image/svg+xml", body, time.Minute)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	assert.Equal(t, string(body), w.Body.String())
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, "\"9ed5d9c55c348ab8df6e84baeabeb87e729730ba\"", etag)
+
+	// Conditional request with the same ETag
+	r.Header.Set("If-None-Match", fmt.Sprintf("\"bogus\", %s", etag))
+	w = httptest.NewRecorder()
+	WriteCacheable(w, r, "image/svg+xml", body, time.Minute)
+
+	assert.Equal(t, 304, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Equal(t, "", w.Body.String())
+
+	// Conditional request with a stale ETag
+	r.Header.Set("If-None-Match", "\"bogus\"")
+	w = httptest.NewRecorder()
+	WriteCacheable(w, r, "image/svg+xml", body, time.Minute)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, string(body), w.Body.String())
+}
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/prometheus"
+	"github.com/urfave/negroni"
+)
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(
+		"pinglist_http_requests_total",
+		"Number of HTTP requests by route name, method and status code.",
+		"route", "method", "code",
+	)
+	httpRequestDuration = prometheus.NewHistogramVec(
+		"pinglist_http_request_duration_seconds",
+		"Latency of HTTP requests by route name.",
+		nil,
+		"route",
+	)
+)
+
+// instrument wraps a route handler so requests are counted and timed under
+// the route name, the negroni response writer keeps Flush working for
+// streamed responses
+func instrument(name string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := negroni.NewResponseWriter(w)
+
+		handler.ServeHTTP(rw, r)
+
+		status := rw.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		httpRequestsTotal.Inc(name, r.Method, strconv.Itoa(status))
+		httpRequestDuration.Observe(time.Since(start).Seconds(), name)
+	})
+}
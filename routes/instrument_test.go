@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RichardKnop/pinglist-api/prometheus"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrument(t *testing.T) {
+	router := mux.NewRouter()
+
+	AddRoutes([]Route{
+		Route{
+			Name:    "instrumented_route",
+			Method:  "POST",
+			Pattern: "/instrumented",
+			HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.(http.Flusher).Flush()
+			},
+		},
+	}, router)
+
+	r, err := http.NewRequest("POST", "http://1.2.3.4/instrumented", nil)
+	assert.NoError(t, err, "Request setup should not get an error")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.True(t, w.Flushed)
+
+	var (
+		requests *prometheus.Sample
+		count    *prometheus.Sample
+	)
+	for _, family := range httpRequestsTotal.Collect() {
+		for _, sample := range family.Samples {
+			if sample.Labels["route"] == "instrumented_route" {
+				requests = sample
+			}
+		}
+	}
+	for _, family := range httpRequestDuration.Collect() {
+		for _, sample := range family.Samples {
+			if sample.Suffix == "_count" && sample.Labels["route"] == "instrumented_route" {
+				count = sample
+			}
+		}
+	}
+
+	if assert.NotNil(t, requests) {
+		assert.Equal(t, "POST", requests.Labels["method"])
+		assert.Equal(t, "201", requests.Labels["code"])
+		assert.Equal(t, float64(1), requests.Value)
+	}
+	if assert.NotNil(t, count) {
+		assert.Equal(t, float64(1), count.Value)
+	}
+}
@@ -17,7 +17,8 @@ type Route struct {
 }
 
 // AddRoutes adds routes to a router instance. If there are middlewares defined
-// for a route, a new negroni app is created and wrapped as a http.Handler
+// for a route, a new negroni app is created and wrapped as a http.Handler.
+// Every handler is instrumented with request metrics labelled by route name
 func AddRoutes(routes []Route, router *mux.Router) {
 	var (
 		handler http.Handler
@@ -43,6 +44,6 @@ func AddRoutes(routes []Route, router *mux.Router) {
 		router.Methods(route.Method).
 			Path(route.Pattern).
 			Name(route.Name).
-			Handler(handler)
+			Handler(instrument(route.Name, handler))
 	}
 }
@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"github.com/RichardKnop/pinglist-api/prometheus"
+)
+
+const (
+	alarmCheckJob   = "alarm_check"
+	partitioningJob = "partitioning"
+)
+
+var (
+	tickLag = prometheus.NewGaugeVec(
+		"pinglist_scheduler_tick_lag_seconds",
+		"Delay between a scheduler tick and the start of its job.",
+		"job",
+	)
+	alarmsDue = prometheus.NewCounterVec(
+		"pinglist_scheduler_alarms_due_total",
+		"Number of alarms returned as due for a check.",
+	)
+	alarmsChecked = prometheus.NewCounterVec(
+		"pinglist_scheduler_alarms_checked_total",
+		"Number of finished alarm checks by result (success, skipped, cancelled or error).",
+		"result",
+	)
+	checkDuration = prometheus.NewHistogramVec(
+		"pinglist_scheduler_check_duration_seconds",
+		"Duration of alarm checks including incident handling.",
+		[]float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	)
+	partitionJobs = prometheus.NewCounterVec(
+		"pinglist_scheduler_partition_jobs_total",
+		"Number of partitioning jobs by result (success or the failed step).",
+		"result",
+	)
+	partitionLastSuccess = prometheus.NewGaugeVec(
+		"pinglist_scheduler_partition_last_success_timestamp_seconds",
+		"Unix time of the last successful partitioning job.",
+	)
+	cleanupJobs = prometheus.NewCounterVec(
+		"pinglist_scheduler_cleanup_jobs_total",
+		"Number of cleanup jobs by result (success or the failed step).",
+		"result",
+	)
+)
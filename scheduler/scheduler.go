@@ -1,102 +1,267 @@
 package scheduler
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/RichardKnop/pinglist-api/alarms"
+	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/RichardKnop/pinglist-api/jobs"
 	"github.com/RichardKnop/pinglist-api/logger"
 	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/RichardKnop/pinglist-api/util"
 )
 
+const (
+	// CheckAlarmJob checks a single claimed alarm
+	CheckAlarmJob = "check_alarm"
+	// PartitionJob partitions metrics tables and rotates old sub tables
+	PartitionJob = "partition_metrics"
+	// CleanupJob purges expired metrics and old finished jobs
+	CleanupJob = "cleanup"
+	// FinishedJobsRetention is for how long done and dead jobs are kept
+	FinishedJobsRetention = 7 * 24 * time.Hour
+	// DefaultCheckInterval is how often due alarms are claimed, in seconds,
+	// when no check interval is configured
+	DefaultCheckInterval = time.Duration(10)
+)
+
+var (
+	// Checks are retried shortly, the alarm gets claimed again after
+	// its interval anyway
+	checkAlarmJobOptions = &jobs.EnqueueOptions{
+		MaxAttempts:       3,
+		VisibilityTimeout: 3 * alarms.AlarmCheckTimeout,
+	}
+	// Maintenance jobs go after checks and alerts
+	maintenanceJobOptions = jobs.EnqueueOptions{
+		Priority:          -10,
+		MaxAttempts:       3,
+		VisibilityTimeout: 10 * time.Minute,
+	}
+)
+
+// checkAlarmPayload is the payload of CheckAlarmJob jobs
+type checkAlarmPayload struct {
+	AlarmID   uint      `json:"alarm_id"`
+	Watermark time.Time `json:"watermark"`
+}
+
 // Scheduler ...
 type Scheduler struct {
+	cnf            *config.Config
 	metricsService metrics.ServiceInterface
 	alarmsService  alarms.ServiceInterface
+	jobsService    jobs.ServiceInterface
+	worker         *jobs.Worker
+	ticks          util.Background
+	stop           chan struct{}
+	stopped        chan struct{}
 }
 
 // New starts a new Scheduler instance
-func New(metricsService metrics.ServiceInterface, alarmsService alarms.ServiceInterface) *Scheduler {
+func New(cnf *config.Config, metricsService metrics.ServiceInterface, alarmsService alarms.ServiceInterface, jobsService jobs.ServiceInterface) *Scheduler {
 	return &Scheduler{
+		cnf:            cnf,
 		metricsService: metricsService,
 		alarmsService:  alarmsService,
+		jobsService:    jobsService,
 	}
 }
 
-// Start periodically runs goroutines to:
-// - watch for scheduled alarms
+// Start periodically enqueues jobs to:
+// - check scheduled alarms
 // - partition alarm_results table & rotate old sub tables
-func (s *Scheduler) Start(alarmsInterval, partitionInterval time.Duration) chan bool {
+// - purge expired metrics and old finished jobs
+// and runs a worker processing them together with queued alerts and
+// baseline refreshes
+func (s *Scheduler) Start(alarmsInterval, partitionInterval time.Duration) {
+	// Fall back to the default when the check interval is not configured
+	if alarmsInterval <= 0 {
+		alarmsInterval = DefaultCheckInterval
+	}
+
+	// Jobs run in a bounded worker pool
+	s.worker = jobs.NewWorker(s.jobsService, s.cnf.Scheduler.Workers)
+	s.worker.Register(CheckAlarmJob, s.checkAlarm)
+	s.worker.Register(PartitionJob, s.partition)
+	s.worker.Register(CleanupJob, s.cleanup)
+	s.worker.Register(alarms.AlertJob, s.alarmsService.SendAlert)
+	s.worker.Register(alarms.BaselineJob, s.alarmsService.RefreshBaseline)
+	s.worker.Register(alarms.StatusPageUpdateJob, s.alarmsService.SendStatusPageIncidentUpdate)
+	s.worker.Register(alarms.StatusPageEmailJob, s.alarmsService.SendStatusPageEmail)
+	s.worker.Start(alarmsInterval * time.Second)
+
 	// Partition / rotate metrics table once initially
-	s.runPartitioningJob()
+	s.enqueueMaintenanceJobs(time.Now(), partitionInterval*time.Second)
 
-	// Stop channel
-	stopped := make(chan bool, 1)
+	// Stop channels
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
 
 	// Tickers
 	alarmsCheckTicker := time.NewTicker(alarmsInterval * time.Second)
 	partitionTicker := time.NewTicker(partitionInterval * time.Second)
 
 	go func() {
+		defer close(s.stopped)
 		for {
 			select {
-			case <-alarmsCheckTicker.C:
-				go s.runAlarmCheckJob()
-			case <-partitionTicker.C:
-				go s.runPartitioningJob()
-			case <-stopped:
+			case tick := <-alarmsCheckTicker.C:
+				s.ticks.Go(func() { s.enqueueAlarmChecks(tick) })
+			case tick := <-partitionTicker.C:
+				s.ticks.Go(func() {
+					s.enqueueMaintenanceJobs(tick, partitionInterval*time.Second)
+				})
+			case <-s.stop:
+				alarmsCheckTicker.Stop()
+				partitionTicker.Stop()
 				return
 			}
 		}
 	}()
+}
 
-	return stopped
+// Stop stops the tickers and waits for running jobs to finish. When the
+// context is done first, running jobs are cancelled and released back to
+// the queue, cancelled checks don't open incidents
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+	<-s.stopped
+
+	// Wait for ticks which may still be enqueueing jobs
+	if err := s.ticks.Wait(ctx); err != nil {
+		logger.ERROR.Printf("Scheduler ticks still running: %s", err)
+	}
+
+	return s.worker.Stop(ctx)
 }
 
-func (s *Scheduler) runAlarmCheckJob() {
+func (s *Scheduler) enqueueAlarmChecks(tick time.Time) {
+	tickLag.Set(time.Since(tick).Seconds(), alarmCheckJob)
+
+	// Create a new time object as a watermark, due alarms get claimed with it
+	// so other schedulers running at the same time skip them. It is truncated
+	// the same way it gets stored so the check job claims with an equal value
+	now := alarms.TruncateWatermark(time.Now())
+
 	// Get alarms to check
-	alarmIDs, err := s.alarmsService.GetAlarmsToCheck(time.Now())
+	alarmIDs, err := s.alarmsService.GetAlarmsToCheck(now)
 	if err != nil {
 		logger.ERROR.Print(err)
 		return
 	}
 
-	// Any alarms to check
-	if len(alarmIDs) < 1 {
-		return
+	alarmsDue.Add(float64(len(alarmIDs)))
+
+	// Queue the checks, alarms which could not be queued
+	// will be claimed again after their interval
+	for _, alarmID := range alarmIDs {
+		payload := &checkAlarmPayload{AlarmID: alarmID, Watermark: now}
+		_, err := s.jobsService.Enqueue(CheckAlarmJob, payload, checkAlarmJobOptions)
+		if err != nil {
+			logger.ERROR.Printf("Alarm #%d not queued: %s", alarmID, err.Error())
+		}
 	}
+}
 
-	// Create a new time object as a watermark
-	now := time.Now()
+// enqueueMaintenanceJobs enqueues partitioning and cleanup jobs once per
+// interval, jobs are keyed by the interval so when several schedulers run
+// only one of them gets to enqueue
+func (s *Scheduler) enqueueMaintenanceJobs(tick time.Time, interval time.Duration) {
+	tickLag.Set(time.Since(tick).Seconds(), partitioningJob)
 
-	// Iterate over alarms and fire check goroutines
-	for i := range alarmIDs {
-		alarmID := alarmIDs[i]
-		go s.checkAlarm(alarmID, now)
+	for _, jobType := range []string{PartitionJob, CleanupJob} {
+		options := maintenanceJobOptions
+		options.Key = fmt.Sprintf("%s:%d", jobType, tick.Truncate(interval).Unix())
+		_, err := s.jobsService.Enqueue(jobType, struct{}{}, &options)
+		if err != nil && err != jobs.ErrDuplicateJob {
+			logger.ERROR.Printf("%s job not queued: %s", jobType, err.Error())
+		}
 	}
 }
 
-func (s *Scheduler) checkAlarm(alarmID uint, watermark time.Time) {
-	if err := s.alarmsService.CheckAlarm(alarmID, watermark); err != nil {
-		logger.ERROR.Printf("Alarm #%d check error: %s", alarmID, err.Error())
-		return
+// checkAlarm is the handler of CheckAlarmJob jobs
+func (s *Scheduler) checkAlarm(ctx context.Context, job *jobs.Job) error {
+	payload := new(checkAlarmPayload)
+	if err := job.DecodePayload(payload); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := s.alarmsService.CheckAlarm(ctx, payload.AlarmID, payload.Watermark)
+	checkDuration.Observe(time.Since(start).Seconds())
+	if err == context.Canceled {
+		alarmsChecked.Inc("cancelled")
+		logger.INFO.Printf("Alarm #%d check cancelled", payload.AlarmID)
+		return err
+	}
+	if err == alarms.ErrCheckAlreadyTriggered {
+		// A previous attempt has already checked the alarm
+		alarmsChecked.Inc("skipped")
+		return nil
+	}
+	if err != nil {
+		alarmsChecked.Inc("error")
+		return err
 	}
-	logger.INFO.Printf("Alarm #%d checked successfully", alarmID)
+	alarmsChecked.Inc("success")
+	logger.INFO.Printf("Alarm #%d checked successfully", payload.AlarmID)
+	return nil
 }
 
-func (s *Scheduler) runPartitioningJob() {
+// partition is the handler of PartitionJob jobs
+func (s *Scheduler) partition(ctx context.Context, job *jobs.Job) error {
 	// Partition the request time metrics table
 	err := s.metricsService.PartitionResponseTime(
 		metrics.ResponseTimeParentTableName,
 		time.Now(),
 	)
 	if err != nil {
-		logger.ERROR.Printf("Partition response time error: %s", err.Error())
-		return
+		partitionJobs.Inc("partition_response_times_error")
+		return fmt.Errorf("Partition response time error: %s", err)
+	}
+
+	// Partition the check results table
+	err = s.metricsService.PartitionResponseTime(
+		metrics.CheckResultParentTableName,
+		time.Now(),
+	)
+	if err != nil {
+		partitionJobs.Inc("partition_check_results_error")
+		return fmt.Errorf("Partition check results error: %s", err)
 	}
 
 	// Rotate old sub tables
 	if err := s.metricsService.RotateSubTables(); err != nil {
-		logger.ERROR.Printf("Rotate sub tables error: %s", err.Error())
-		return
+		partitionJobs.Inc("rotate_error")
+		return fmt.Errorf("Rotate sub tables error: %s", err)
 	}
+
+	partitionJobs.Inc("success")
+	partitionLastSuccess.Set(float64(time.Now().Unix()))
+	return nil
+}
+
+// cleanup is the handler of CleanupJob jobs
+func (s *Scheduler) cleanup(ctx context.Context, job *jobs.Job) error {
+	now := time.Now()
+
+	// Purge metrics older than retention of users' plans
+	if err := s.metricsService.PurgeExpiredResponseTimes(now); err != nil {
+		cleanupJobs.Inc("purge_error")
+		return fmt.Errorf("Purge expired response times error: %s", err)
+	}
+
+	// Purge old done and dead jobs
+	purged, err := s.jobsService.PurgeFinishedJobs(now.Add(-FinishedJobsRetention))
+	if err != nil {
+		cleanupJobs.Inc("purge_jobs_error")
+		return fmt.Errorf("Purge finished jobs error: %s", err)
+	}
+	logger.INFO.Printf("Purged %d finished jobs", purged)
+
+	cleanupJobs.Inc("success")
+	return nil
 }
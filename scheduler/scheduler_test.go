@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/pinglist-api/config"
+	"github.com/RichardKnop/pinglist-api/jobs"
+	"github.com/RichardKnop/pinglist-api/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEnqueueMaintenanceJobs(t *testing.T) {
+	var (
+		jobsServiceMock = new(jobs.ServiceMock)
+		s               = New(config.NewConfig(false, false), nil, nil, jobsServiceMock)
+		tick            = time.Date(2016, time.March, 7, 9, 17, 0, 0, time.UTC)
+	)
+
+	// Jobs are keyed by the start of the interval
+	jobsServiceMock.On(
+		"Enqueue",
+		PartitionJob,
+		mock.Anything,
+		mock.MatchedBy(func(options *jobs.EnqueueOptions) bool {
+			return options.Key == "partition_metrics:1457341800"
+		}),
+	).Return(new(jobs.Job), nil)
+
+	// Another scheduler has enqueued the cleanup already
+	jobsServiceMock.On(
+		"Enqueue",
+		CleanupJob,
+		mock.Anything,
+		mock.MatchedBy(func(options *jobs.EnqueueOptions) bool {
+			return options.Key == "cleanup:1457341800"
+		}),
+	).Return(nil, jobs.ErrDuplicateJob)
+
+	s.enqueueMaintenanceJobs(tick, 10*time.Minute)
+
+	jobsServiceMock.AssertExpectations(t)
+}
+
+func TestCleanup(t *testing.T) {
+	var (
+		metricsServiceMock = new(metrics.ServiceMock)
+		jobsServiceMock    = new(jobs.ServiceMock)
+		s                  = New(config.NewConfig(false, false), metricsServiceMock, nil, jobsServiceMock)
+	)
+
+	metricsServiceMock.On("PurgeExpiredResponseTimes", mock.AnythingOfType("time.Time")).
+		Return(nil).Once()
+	jobsServiceMock.On(
+		"PurgeFinishedJobs",
+		mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) >= FinishedJobsRetention
+		}),
+	).Return(int64(3), nil).Once()
+	assert.NoError(t, s.cleanup(context.Background(), new(jobs.Job)))
+
+	// A failed step fails the job so it gets retried
+	metricsServiceMock.On("PurgeExpiredResponseTimes", mock.AnythingOfType("time.Time")).
+		Return(errors.New("boom")).Once()
+	assert.Error(t, s.cleanup(context.Background(), new(jobs.Job)))
+
+	metricsServiceMock.AssertExpectations(t)
+	jobsServiceMock.AssertExpectations(t)
+}
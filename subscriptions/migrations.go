@@ -14,6 +14,10 @@ func MigrateAll(db *gorm.DB) error {
 		return err
 	}
 
+	if err := migrate0002(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -115,3 +119,50 @@ func migrate0001(db *gorm.DB) error {
 
 	return nil
 }
+
+// Migrate0002 adds metrics retention to plans
+func migrate0002(db *gorm.DB) error {
+	migrationName := "subscriptions_add_metrics_retention"
+
+	migration := new(migrations.Migration)
+	found := !db.Where("name = ?", migrationName).First(migration).RecordNotFound()
+
+	if found {
+		logger.INFO.Printf("Skipping %s migration", migrationName)
+		return nil
+	}
+
+	logger.INFO.Printf("Running %s migration", migrationName)
+
+	// Add metrics_retention column to subscription_plans table
+	if !db.Dialect().HasColumn("subscription_plans", "metrics_retention") {
+		sql := "ALTER TABLE subscription_plans ADD COLUMN metrics_retention INTEGER NOT NULL DEFAULT 0"
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("Error adding metrics_retention column: %s", err)
+		}
+	}
+
+	// Set retention of existing plans
+	retentions := map[string]uint{
+		"personal":     90,
+		"professional": 180,
+		"startup":      365,
+		"business":     365,
+	}
+	for planID, retention := range retentions {
+		err := db.Model(new(Plan)).Where("plan_id = ?", planID).
+			UpdateColumn("metrics_retention", retention).Error
+		if err != nil {
+			return fmt.Errorf("Error updating metrics retention of %s plan: %s", planID, err)
+		}
+	}
+
+	// Save a record to migrations table,
+	// so we don't rerun this migration again
+	migration.Name = migrationName
+	if err := db.Create(migration).Error; err != nil {
+		return fmt.Errorf("Error saving record to migrations table: %s", err)
+	}
+
+	return nil
+}
@@ -43,6 +43,7 @@ type Plan struct {
 	UnlimitedPushNotifications      bool `sql:"default:false;not null"`
 	MaxPushNotificationsPerInterval sql.NullInt64
 	SlackAlerts                     bool `sql:"default:false;not null"`
+	MetricsRetention                uint `sql:"default:0;not null"` // days, 0 means the free tier retention
 }
 
 // TableName specifies table name
@@ -36,3 +36,15 @@ func (s *Service) FindPlanByPlanID(planID string) (*Plan, error) {
 
 	return plan, nil
 }
+
+// MaxMetricsRetention returns the longest metrics retention of all plans
+// in days, 0 when no plan keeps metrics longer than the free tier
+func (s *Service) MaxMetricsRetention() (uint, error) {
+	var retention uint
+	err := s.db.Model(new(Plan)).Select("COALESCE(MAX(metrics_retention), 0)").
+		Row().Scan(&retention)
+	if err != nil {
+		return 0, err
+	}
+	return retention, nil
+}
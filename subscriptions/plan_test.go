@@ -61,3 +61,13 @@ func (suite *SubscriptionsTestSuite) TestFindPlanByPlanID() {
 		assert.Equal(suite.T(), suite.plans[0].ID, plan.ID)
 	}
 }
+
+func (suite *SubscriptionsTestSuite) TestMaxMetricsRetention() {
+	retention, err := suite.service.MaxMetricsRetention()
+
+	// Error should be nil
+	assert.Nil(suite.T(), err)
+
+	// The longest retention of the fixture plans
+	assert.Equal(suite.T(), uint(365), retention)
+}
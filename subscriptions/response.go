@@ -47,6 +47,7 @@ type PlanResponse struct {
 	UnlimitedPushNotifications      bool   `json:"unlimited_push_notifications"`
 	MaxPushNotificationsPerInterval *uint  `json:"max_push_notifications_per_interval"`
 	SlackAlerts                     bool   `json:"slack_alerts"`
+	MetricsRetention                uint   `json:"metrics_retention"`
 	CreatedAt                       string `json:"created_at"`
 	UpdatedAt                       string `json:"updated_at"`
 }
@@ -164,6 +165,7 @@ func NewPlanResponse(plan *Plan) (*PlanResponse, error) {
 		UnlimitedEmails:            plan.UnlimitedEmails,
 		UnlimitedPushNotifications: plan.UnlimitedPushNotifications,
 		SlackAlerts:                plan.SlackAlerts,
+		MetricsRetention:           plan.MetricsRetention,
 		CreatedAt:                  util.FormatTime(plan.CreatedAt),
 		UpdatedAt:                  util.FormatTime(plan.UpdatedAt),
 	}
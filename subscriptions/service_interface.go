@@ -12,6 +12,7 @@ type ServiceInterface interface {
 	GetAccountsService() accounts.ServiceInterface
 	FindPlanByID(planID uint) (*Plan, error)
 	FindPlanByPlanID(planID string) (*Plan, error)
+	MaxMetricsRetention() (uint, error)
 	FindCustomerByID(customerID uint) (*Customer, error)
 	FindCustomerByUserID(userID uint) (*Customer, error)
 	FindCustomerByCustomerID(customerID string) (*Customer, error)
@@ -20,6 +21,7 @@ type ServiceInterface interface {
 	FindSubscriptionByID(subscriptionID uint) (*Subscription, error)
 	FindSubscriptionBySubscriptionID(subscriptionID string) (*Subscription, error)
 	FindActiveSubscriptionByUserID(userID uint) (*Subscription, error)
+	FindActiveMetricsRetentions(userIDs []uint) (map[uint]uint, error)
 
 	// Needed for the newRoutes to be able to register handlers
 	listPlansHandler(w http.ResponseWriter, r *http.Request)
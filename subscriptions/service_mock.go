@@ -72,6 +72,27 @@ func (_m *ServiceMock) FindPlanByPlanID(planID string) (*Plan, error) {
 	return r0, r1
 }
 
+// MaxMetricsRetention ...
+func (_m *ServiceMock) MaxMetricsRetention() (uint, error) {
+	ret := _m.Called()
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func() uint); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindCustomerByID ...
 func (_m *ServiceMock) FindCustomerByID(customerID uint) (*Customer, error) {
 	ret := _m.Called(customerID)
@@ -256,6 +277,29 @@ func (_m *ServiceMock) FindActiveSubscriptionByUserID(userID uint) (*Subscriptio
 	return r0, r1
 }
 
+// FindActiveMetricsRetentions ...
+func (_m *ServiceMock) FindActiveMetricsRetentions(userIDs []uint) (map[uint]uint, error) {
+	ret := _m.Called(userIDs)
+
+	var r0 map[uint]uint
+	if rf, ok := ret.Get(0).(func([]uint) map[uint]uint); ok {
+		r0 = rf(userIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]uint) error); ok {
+		r1 = rf(userIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 func (_m *ServiceMock) listPlansHandler(w http.ResponseWriter, r *http.Request) {
 	_m.Called(w, r)
 }
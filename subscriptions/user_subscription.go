@@ -28,6 +28,38 @@ func (s *Service) FindActiveSubscriptionByUserID(userID uint) (*Subscription, er
 	return subscription, nil
 }
 
+// FindActiveMetricsRetentions returns metrics retention of plans of active
+// subscriptions of the users in a single query, users without an active
+// subscription are left out
+func (s *Service) FindActiveMetricsRetentions(userIDs []uint) (map[uint]uint, error) {
+	retentions := make(map[uint]uint)
+	if len(userIDs) == 0 {
+		return retentions, nil
+	}
+
+	rows, err := s.db.Model(new(Subscription)).
+		Select("subscription_customers.user_id, subscription_plans.metrics_retention").
+		Joins("inner join subscription_customers on subscription_customers.id = subscription_subscriptions.customer_id").
+		Joins("inner join subscription_plans on subscription_plans.id = subscription_subscriptions.plan_id").
+		Where("subscription_customers.user_id IN (?) AND subscription_subscriptions.ended_at IS NULL", userIDs).
+		Order("subscription_subscriptions.id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// The latest subscription wins the same way as in FindActiveSubscriptionByUserID
+	for rows.Next() {
+		var userID, retention uint
+		if err := rows.Scan(&userID, &retention); err != nil {
+			return nil, err
+		}
+		retentions[userID] = retention
+	}
+
+	return retentions, nil
+}
+
 // calculateTrialPeriodDuration calculates duration of a trial period
 // If the user has been subscribed to the same plan before, the trial period
 // gets decreased by the time already spent in the trialing mode
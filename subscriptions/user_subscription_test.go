@@ -98,6 +98,49 @@ func (suite *SubscriptionsTestSuite) TestFindActiveSubscriptionByUserID() {
 	}
 }
 
+func (suite *SubscriptionsTestSuite) TestFindActiveMetricsRetentions() {
+	var (
+		end   = time.Now()
+		start = end.Add(-30 * 24 * time.Hour)
+	)
+
+	// Insert a test customer with an active subscription
+	testCustomer := NewCustomer(suite.users[1], "new_customer_id")
+	err := suite.db.Create(testCustomer).Error
+	assert.NoError(suite.T(), err, "Failed to insert a test customer")
+	testSubscription := NewSubscription(
+		testCustomer,
+		suite.plans[0],
+		"new_subscription_id",
+		&start, // started at
+		nil,    // cancelled at
+		nil,    // ended at
+		&start, // period start
+		&end,   // period end
+		&start, // trial start
+		&end,   // trial end
+		"trialing",
+	)
+	err = suite.db.Create(testSubscription).Error
+	assert.NoError(suite.T(), err, "Failed to insert a test subscription")
+
+	// Users without an active subscription are left out
+	retentions, err := suite.service.FindActiveMetricsRetentions([]uint{suite.users[1].ID, 12345})
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), map[uint]uint{
+			suite.users[1].ID: suite.plans[0].MetricsRetention,
+		}, retentions)
+	}
+
+	// Ended subscriptions are not active
+	err = suite.db.Model(testSubscription).UpdateColumn("ended_at", end).Error
+	assert.NoError(suite.T(), err, "Failed to update the test subscription")
+	retentions, err = suite.service.FindActiveMetricsRetentions([]uint{suite.users[1].ID})
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), 0, len(retentions))
+	}
+}
+
 func (suite *SubscriptionsTestSuite) TestCalculateTrialPeriodDuration() {
 	var (
 		trialPeriodDuration time.Duration
@@ -12,6 +12,7 @@ type ServiceInterface interface {
 	GetAccountsService() accounts.ServiceInterface
 	FindTeamByID(teamID uint) (*Team, error)
 	FindTeamByMemberID(memberID uint) (*Team, error)
+	FindTeamOwnerIDsByMemberIDs(memberIDs []uint) (map[uint]uint, error)
 
 	// Needed for the newRoutes to be able to register handlers
 	createTeamHandler(w http.ResponseWriter, r *http.Request)
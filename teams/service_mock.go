@@ -72,6 +72,29 @@ func (_m *ServiceMock) FindTeamByMemberID(memberID uint) (*Team, error) {
 	return r0, r1
 }
 
+// FindTeamOwnerIDsByMemberIDs ...
+func (_m *ServiceMock) FindTeamOwnerIDsByMemberIDs(memberIDs []uint) (map[uint]uint, error) {
+	ret := _m.Called(memberIDs)
+
+	var r0 map[uint]uint
+	if rf, ok := ret.Get(0).(func([]uint) map[uint]uint); ok {
+		r0 = rf(memberIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]uint) error); ok {
+		r1 = rf(memberIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 func (_m *ServiceMock) createTeamHandler(w http.ResponseWriter, r *http.Request) {
 	_m.Called(w, r)
 }
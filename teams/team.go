@@ -87,6 +87,38 @@ func (s *Service) FindTeamByMemberID(memberID uint) (*Team, error) {
 	return team, nil
 }
 
+// FindTeamOwnerIDsByMemberIDs returns IDs of owners of teams the users are
+// members of in a single query, users who are not team members are left out
+func (s *Service) FindTeamOwnerIDsByMemberIDs(memberIDs []uint) (map[uint]uint, error) {
+	ownerIDs := make(map[uint]uint)
+	if len(memberIDs) == 0 {
+		return ownerIDs, nil
+	}
+
+	rows, err := s.db.Model(new(Team)).
+		Select("team_team_members.user_id, team_teams.owner_id").
+		Joins("inner join team_team_members on team_team_members.team_id = team_teams.id").
+		Where("team_team_members.user_id IN (?)", memberIDs).
+		Order("team_teams.id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// The first team wins the same way as in FindTeamByMemberID
+	for rows.Next() {
+		var memberID, ownerID uint
+		if err := rows.Scan(&memberID, &ownerID); err != nil {
+			return nil, err
+		}
+		if _, ok := ownerIDs[memberID]; !ok {
+			ownerIDs[memberID] = ownerID
+		}
+	}
+
+	return ownerIDs, nil
+}
+
 // createTeam creates a new team
 func (s *Service) createTeam(owner *accounts.User, teamRequest *TeamRequest) (*Team, error) {
 	maxTeams, maxMembersPerTeam := s.getMaxTeamLimits(owner)
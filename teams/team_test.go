@@ -71,6 +71,20 @@ func (suite *TeamsTestSuite) TestFindTeamByMemberID() {
 	}
 }
 
+func (suite *TeamsTestSuite) TestFindTeamOwnerIDsByMemberIDs() {
+	// Insert a test team member
+	err := suite.db.Model(&suite.teams[0]).Association("Members").Append(suite.users[1]).Error
+	assert.NoError(suite.T(), err, "Inserting test data failed")
+
+	// Users who are not team members are left out
+	ownerIDs, err := suite.service.FindTeamOwnerIDsByMemberIDs([]uint{suite.users[1].ID, 12345})
+	if assert.NoError(suite.T(), err) {
+		assert.Equal(suite.T(), map[uint]uint{
+			suite.users[1].ID: suite.teams[0].Owner.ID,
+		}, ownerIDs)
+	}
+}
+
 func (suite *TeamsTestSuite) TestPaginatedTeamsCount() {
 	var (
 		count int
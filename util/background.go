@@ -0,0 +1,37 @@
+package util
+
+import (
+	"context"
+	"sync"
+)
+
+// Background runs functions in goroutines and keeps track of them so they
+// can be waited for, e.g. emails sent after a request has already returned
+type Background struct {
+	wg sync.WaitGroup
+}
+
+// Go runs the function in a new goroutine
+func (b *Background) Go(f func()) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		f()
+	}()
+}
+
+// Wait blocks until all running functions return or the context is done
+func (b *Background) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
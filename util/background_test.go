@@ -0,0 +1,39 @@
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackground(t *testing.T) {
+	var (
+		background Background
+		unblock    = make(chan struct{})
+		finished   = make(chan struct{})
+	)
+
+	// Nothing running
+	assert.NoError(t, background.Wait(context.Background()))
+
+	background.Go(func() {
+		<-unblock
+		close(finished)
+	})
+
+	// Waiting gives up when the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, background.Wait(ctx))
+
+	// Or returns once the function has finished
+	close(unblock)
+	assert.NoError(t, background.Wait(context.Background()))
+	select {
+	case <-finished:
+	default:
+		t.Error("Wait returned before the function finished")
+	}
+}